@@ -0,0 +1,234 @@
+// Package client - Go client of the greenbem HTTP service
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/capytaine/capytaine/go-capytaine/green_functions/server"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Client calls the API of a greenbem server. The matrices of evaluations are transferred in the binary format.
+type Client struct {
+	// BaseURL is the URL of the server, such as http://localhost:8080
+	BaseURL string
+	// HTTPClient sends the requests, http.DefaultClient when nil
+	HTTPClient *http.Client
+	// Precision of the transferred matrices, complex128 when zero. Complex64 halves the size of the responses.
+	Precision server.Precision
+	// BlockRows is the number of rows the server sends at once, all of them when zero
+	BlockRows int
+	// MaxMatrixBytes limits the memory of each received matrix, whose shape is read from the response, with no
+	// limit when zero
+	MaxMatrixBytes int64
+}
+
+// New creates a client of the server at baseURL
+func New(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Error is an error response of the server
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("server responded %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Evaluation holds the matrices of an evaluation with the headers describing them
+type Evaluation struct {
+	S, K             *mat.CDense
+	SHeader, KHeader server.MatrixHeader
+}
+
+// Evaluate computes the S and K matrices of a Green function
+func (c *Client) Evaluate(ctx context.Context, req *server.EvaluateRequest) (*Evaluation, error) {
+	precision := c.Precision
+	if precision == 0 {
+		precision = server.Complex128
+	}
+	params := map[string]string{"precision": precision.String()}
+	if c.BlockRows > 0 {
+		params["block-rows"] = strconv.Itoa(c.BlockRows)
+	}
+
+	resp, err := c.post(ctx, "/v1/evaluate", req, mime.FormatMediaType(server.MatrixMediaType, params))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != server.MatrixMediaType {
+		return nil, fmt.Errorf("unexpected content type %q", resp.Header.Get("Content-Type"))
+	}
+
+	body := bufio.NewReaderSize(resp.Body, 64<<10)
+	e := &Evaluation{}
+	if e.SHeader, e.S, err = server.ReadMatrix(body, c.MaxMatrixBytes); err != nil {
+		return nil, fmt.Errorf("decoding S: %w", err)
+	}
+	if e.KHeader, e.K, err = server.ReadMatrix(body, c.MaxMatrixBytes); err != nil {
+		return nil, fmt.Errorf("decoding K: %w", err)
+	}
+	if e.SHeader.Name != 'S' || e.KHeader.Name != 'K' {
+		return nil, fmt.Errorf("expected the matrices S and K, got %c and %c", e.SHeader.Name, e.KHeader.Name)
+	}
+	return e, nil
+}
+
+// Solve computes the hydrodynamic coefficients of a rigid body
+func (c *Client) Solve(ctx context.Context, req *server.SolveRequest) (*server.SolveResponse, error) {
+	resp, err := c.post(ctx, "/v1/solve", req, "application/json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var result server.SolveResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decoding the response: %w", err)
+	}
+	return &result, nil
+}
+
+// SolveStream solves the frequencies of a request on the streaming endpoint and calls handle with each event
+// as it arrives. An error returned by handle stops the sweep, whose cancellation reaches the server when
+// the connection is closed. The error of a sweep that timed out is returned after its done event is handled.
+func (c *Client) SolveStream(ctx context.Context, req *server.SolveRequest, handle func(*server.SweepEvent) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	resp, err := c.post(ctx, "/v1/solve/stream", req, server.NDJSONMediaType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Events are decoded one after the other, as the server flushes them
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var event server.SweepEvent
+		if err := dec.Decode(&event); err != nil {
+			if err == io.EOF {
+				return errors.New("stream ended before the done event")
+			}
+			return fmt.Errorf("decoding the stream: %w", err)
+		}
+		if err := handle(&event); err != nil {
+			return err
+		}
+		if event.Type == "done" {
+			if event.Error != "" {
+				return errors.New(event.Error)
+			}
+			return nil
+		}
+	}
+}
+
+// SubmitJob queues a solve request as an asynchronous job
+func (c *Client) SubmitJob(ctx context.Context, req *server.SolveRequest) (*server.Job, error) {
+	var job server.Job
+	if err := c.call(ctx, http.MethodPost, "/v1/jobs", req, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Job returns the status and progress of a job
+func (c *Client) Job(ctx context.Context, id string) (*server.Job, error) {
+	var job server.Job
+	if err := c.call(ctx, http.MethodGet, "/v1/jobs/"+url.PathEscape(id), nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// JobResult returns the result of a succeeded job. The server responds 409 while the job is not done.
+func (c *Client) JobResult(ctx context.Context, id string) (*server.SolveResponse, error) {
+	var result server.SolveResponse
+	if err := c.call(ctx, http.MethodGet, "/v1/jobs/"+url.PathEscape(id)+"/result", nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CancelJob cancels a queued job, or asks a running job to stop
+func (c *Client) CancelJob(ctx context.Context, id string) (*server.Job, error) {
+	var job server.Job
+	if err := c.call(ctx, http.MethodPost, "/v1/jobs/"+url.PathEscape(id)+"/cancel", nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// call sends a request and decodes its JSON response into v
+func (c *Client) call(ctx context.Context, method, path string, req interface{}, v interface{}) error {
+	resp, err := c.do(ctx, method, path, req, "application/json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decoding the response: %w", err)
+	}
+	return nil
+}
+
+// post sends a JSON request and returns the response when its status is successful
+func (c *Client) post(ctx context.Context, path string, req interface{}, accept string) (*http.Response, error) {
+	return c.do(ctx, http.MethodPost, path, req, accept)
+}
+
+// do sends a request with a JSON body, or none when req is nil, and returns the response when its status is successful
+func (c *Client) do(ctx context.Context, method, path string, req interface{}, accept string) (*http.Response, error) {
+	var body io.Reader
+	if req != nil {
+		data, err := json.Marshal(req)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	httpReq.Header.Set("Accept", accept)
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		var e server.ErrorResponse
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		if json.Unmarshal(data, &e) != nil || e.Error == "" {
+			e.Error = strings.TrimSpace(string(data))
+		}
+		return nil, &Error{StatusCode: resp.StatusCode, Message: e.Error}
+	}
+	return resp, nil
+}
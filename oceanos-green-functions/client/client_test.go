@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/cmplx"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/capytaine/capytaine/go-capytaine/green_functions/green_functions"
+	"github.com/capytaine/capytaine/go-capytaine/green_functions/server"
+)
+
+func testServer(t *testing.T) (*httptest.Server, *green_functions.Mesh, server.MeshData) {
+	t.Helper()
+	mesh, err := green_functions.NewHemisphereMesh(1, 2, 6)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data := server.MeshData{Vertices: mesh.Vertices}
+	for _, f := range mesh.Faces {
+		data.Faces = append(data.Faces, []int{f[0], f[1], f[2], f[3]})
+	}
+	s := server.New(server.DefaultConfig())
+	ts := httptest.NewServer(s)
+	t.Cleanup(func() {
+		ts.Close()
+		s.Close()
+	})
+	return ts, mesh, data
+}
+
+func TestClient_Evaluate(t *testing.T) {
+	ts, mesh, data := testServer(t)
+	k := 1.5
+
+	expectedS, expectedK, err := green_functions.NewDefaultDelhommeau().Evaluate(mesh, mesh, 0, math.Inf(1), complex(k, 0), true, true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	n := mesh.GetNbFaces()
+
+	for _, tc := range []struct {
+		precision server.Precision
+		blockRows int
+		tolerance float64
+	}{
+		{0, 0, 0},
+		{server.Complex128, 5, 0},
+		{server.Complex64, 2, 1e-6},
+	} {
+		c := New(ts.URL + "/")
+		c.Precision = tc.precision
+		c.BlockRows = tc.blockRows
+		e, err := c.Evaluate(context.Background(), &server.EvaluateRequest{Mesh: data, Wavenumber: &k})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if rows, cols := e.S.Dims(); rows != n || cols != n {
+			t.Fatalf("Expected S of shape (%d, %d), got (%d, %d)", n, n, rows, cols)
+		}
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				if cmplx.Abs(e.S.At(i, j)-expectedS.At(i, j)) > tc.tolerance*cmplx.Abs(expectedS.At(i, j)) ||
+					cmplx.Abs(e.K.At(i, j)-expectedK.At(i, j)) > tc.tolerance*cmplx.Abs(expectedK.At(i, j)) {
+					t.Fatalf("%v: matrices differ from the library at (%d, %d)", tc.precision, i, j)
+				}
+			}
+		}
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	ts, _, data := testServer(t)
+	c := New(ts.URL)
+
+	_, err := c.Evaluate(context.Background(), &server.EvaluateRequest{Mesh: data})
+	var e *Error
+	if !errors.As(err, &e) || e.StatusCode != http.StatusBadRequest || e.Message != "wavenumber or omega is required" {
+		t.Errorf("Expected the error of the server, got %v", err)
+	}
+
+	k := 1.0
+	limited := New(ts.URL)
+	limited.MaxMatrixBytes = 100
+	if _, err := limited.Evaluate(context.Background(), &server.EvaluateRequest{Mesh: data, Wavenumber: &k}); err == nil {
+		t.Error("Expected error for matrices exceeding the limit")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.Evaluate(ctx, &server.EvaluateRequest{Mesh: data, Wavenumber: &k}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestClient_Solve(t *testing.T) {
+	ts, _, data := testServer(t)
+	resp, err := New(ts.URL).Solve(context.Background(), &server.SolveRequest{Mesh: data, Frequencies: []float64{1, 2}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(resp.DOFs) != 6 || len(resp.Results) != 2 || resp.Results[1].Omega != 2 {
+		t.Errorf("Expected 6 DOFs and 2 results, got %v and %d results", resp.DOFs, len(resp.Results))
+	}
+}
+
+func TestClient_SolveStream(t *testing.T) {
+	ts, _, data := testServer(t)
+	c := New(ts.URL)
+	req := &server.SolveRequest{Mesh: data, Frequencies: []float64{0.5, 1, 2, 4}}
+
+	results := map[int]float64{}
+	var last *server.SweepEvent
+	err := c.SolveStream(context.Background(), req, func(event *server.SweepEvent) error {
+		if event.Type == "result" {
+			results[*event.Index] = event.Result.Omega
+		}
+		last = event
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 4 || results[3] != 4 || last.Type != "done" || last.Completed != 4 {
+		t.Errorf("Expected the 4 results and the done event, got %v and %+v", results, last)
+	}
+
+	stop := errors.New("enough")
+	n := 0
+	err = c.SolveStream(context.Background(), req, func(event *server.SweepEvent) error {
+		n++
+		if event.Type == "result" {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || n != 2 {
+		t.Errorf("Expected the sweep to stop at the first result, got %v after %d events", err, n)
+	}
+}
+
+func TestClient_Jobs(t *testing.T) {
+	ts, _, data := testServer(t)
+	c := New(ts.URL)
+	ctx := context.Background()
+
+	job, err := c.SubmitJob(ctx, &server.SolveRequest{Mesh: data, Frequencies: []float64{1, 2}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for !job.Finished() {
+		time.Sleep(10 * time.Millisecond)
+		if job, err = c.Job(ctx, job.ID); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if job.Status != server.JobSucceeded || job.Completed != 2 {
+		t.Fatalf("Expected a succeeded job, got %+v", job)
+	}
+	result, err := c.JobResult(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.Results) != 2 || len(result.DOFs) != 6 {
+		t.Errorf("Expected 2 results with 6 DOFs, got %+v", result)
+	}
+
+	_, err = c.CancelJob(ctx, job.ID)
+	var e *Error
+	if !errors.As(err, &e) || e.StatusCode != http.StatusConflict {
+		t.Errorf("Expected status 409 when cancelling a finished job, got %v", err)
+	}
+}
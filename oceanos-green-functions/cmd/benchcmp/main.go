@@ -0,0 +1,220 @@
+// Command benchcmp compares two sets of `go test -bench` runs and fails on performance regressions.
+//
+// Usage:
+//
+//	go test -bench Evaluate -benchmem -count 10 ./green_functions > new.txt
+//	benchcmp -threshold 5 -threshold-for 'Evaluate=3' results.txt new.txt
+//
+// Runs of the same benchmark, as given by -count, are compared with the Mann-Whitney U test. A metric regresses
+// when it gets worse by more than the threshold of its benchmark and the difference is significant at the -alpha
+// level, so that several runs on each side are needed to detect a regression: benchcmp refuses to compare fewer
+// than -min-runs runs, or runs too few for any difference to be significant at the -alpha level.
+// The exit status is 0 without regression, 1 with regressions and 2 for invalid inputs.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/capytaine/capytaine/go-capytaine/green_functions/internal/benchfmt"
+)
+
+// thresholdRule is a regression threshold for the benchmarks whose name matches a pattern
+type thresholdRule struct {
+	pattern   *regexp.Regexp
+	threshold float64
+}
+
+// thresholdRules implements flag.Value for the repeatable -threshold-for flag
+type thresholdRules []thresholdRule
+
+func (r *thresholdRules) String() string {
+	rules := make([]string, len(*r))
+	for i, rule := range *r {
+		rules[i] = fmt.Sprintf("%s=%g", rule.pattern, rule.threshold)
+	}
+	return strings.Join(rules, ",")
+}
+
+func (r *thresholdRules) Set(value string) error {
+	i := strings.LastIndexByte(value, '=')
+	if i < 0 {
+		return errors.New("expected pattern=percent")
+	}
+	pattern, err := regexp.Compile(value[:i])
+	if err != nil {
+		return err
+	}
+	threshold, err := strconv.ParseFloat(value[i+1:], 64)
+	if err != nil || threshold < 0 {
+		return fmt.Errorf("invalid threshold %q", value[i+1:])
+	}
+	*r = append(*r, thresholdRule{pattern, threshold})
+	return nil
+}
+
+// threshold returns the threshold in percent of a benchmark: the last matching rule, or the default
+func (r thresholdRules) threshold(name string, defaultThreshold float64) float64 {
+	threshold := defaultThreshold
+	for _, rule := range r {
+		if rule.pattern.MatchString(name) {
+			threshold = rule.threshold
+		}
+	}
+	return threshold
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command and returns the exit status
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("benchcmp", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: benchcmp [flags] old.txt new.txt")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+	var rules thresholdRules
+	threshold := fs.Float64("threshold", 5, "regression threshold in `percent`")
+	fs.Var(&rules, "threshold-for", "regression threshold of the benchmarks matching a regular expression, as `pattern=percent`; "+
+		"may be repeated, the last matching rule wins")
+	alpha := fs.Float64("alpha", 0.05, "significance `level` of the Mann-Whitney U test, 1 flags any change above the threshold")
+	minRuns := fs.Int("min-runs", 5, "smallest accepted `number` of runs of a benchmark in each file, as given by go test -count")
+	unitList := fs.String("units", "ns/op,B/op,allocs/op", "comma separated `units` checked for regressions")
+	filter := fs.String("filter", "", "only compare the benchmarks matching this regular `expression`")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+	var match *regexp.Regexp
+	if *filter != "" {
+		var err error
+		if match, err = regexp.Compile(*filter); err != nil {
+			fmt.Fprintf(stderr, "benchcmp: -filter: %v\n", err)
+			return 2
+		}
+	}
+	units := map[string]bool{}
+	for _, unit := range strings.Split(*unitList, ",") {
+		if unit = strings.TrimSpace(unit); unit != "" {
+			units[unit] = true
+		}
+	}
+
+	old, err := readResults(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "benchcmp: %v\n", err)
+		return 2
+	}
+	candidate, err := readResults(fs.Arg(1))
+	if err != nil {
+		fmt.Fprintf(stderr, "benchcmp: %v\n", err)
+		return 2
+	}
+
+	var comparisons []benchfmt.Comparison
+	for _, c := range benchfmt.Compare(old, candidate) {
+		if match == nil || match.MatchString(c.Name) {
+			comparisons = append(comparisons, c)
+		}
+	}
+	if len(comparisons) == 0 {
+		fmt.Fprintln(stderr, "benchcmp: no benchmark in common")
+		return 2
+	}
+
+	// Too few runs would let every regression pass the significance test
+	insufficient := 0
+	for _, c := range comparisons {
+		if !units[c.Unit] {
+			continue
+		}
+		switch {
+		case c.Old.N < *minRuns || c.New.N < *minRuns:
+			fmt.Fprintf(stderr, "benchcmp: %s %s: %d+%d runs, at least %d are required in each file\n",
+				c.Name, c.Unit, c.Old.N, c.New.N, *minRuns)
+		case !c.Detectable(*alpha):
+			fmt.Fprintf(stderr, "benchcmp: %s %s: %d+%d runs cannot be significant at the level %g, the smallest p-value is %.3f\n",
+				c.Name, c.Unit, c.Old.N, c.New.N, *alpha, benchfmt.MinPValue(c.Old.N, c.New.N))
+		default:
+			continue
+		}
+		insufficient++
+	}
+	if insufficient > 0 {
+		fmt.Fprintf(stderr, "benchcmp: run the benchmarks with -count %d or more in both files\n",
+			max(*minRuns, benchfmt.MinRuns(*alpha)))
+		return 2
+	}
+
+	regressions := 0
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "benchmark\tunit\told\tnew\tdelta\tp\t")
+	for _, c := range comparisons {
+		verdict := ""
+		switch {
+		case !units[c.Unit]:
+		case c.Regression(rules.threshold(c.Name, *threshold)/100, *alpha):
+			verdict = "REGRESSION"
+			regressions++
+		case c.Significant(*alpha) && c.Worsening() < 0:
+			verdict = "improvement"
+		}
+
+		delta := "~"
+		if c.Significant(*alpha) {
+			delta = fmt.Sprintf("%+.2f%%", 100*c.Delta)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\tp=%.3f n=%d+%d\t%s\n", c.Name, c.Unit,
+			formatSummary(c.Old), formatSummary(c.New), delta, c.PValue, c.Old.N, c.New.N, verdict)
+	}
+	tw.Flush()
+
+	if regressions > 0 {
+		fmt.Fprintf(stdout, "\n%d regressions\n", regressions)
+		return 1
+	}
+	return 0
+}
+
+// formatSummary formats the mean and the coefficient of variation of the runs
+func formatSummary(s benchfmt.Summary) string {
+	if s.N < 2 || s.Mean == 0 {
+		return strconv.FormatFloat(s.Mean, 'g', 4, 64)
+	}
+	return fmt.Sprintf("%s ±%.0f%%", strconv.FormatFloat(s.Mean, 'g', 4, 64), 100*s.StdDev/math.Abs(s.Mean))
+}
+
+func readResults(path string) ([]benchfmt.Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	results, err := benchfmt.Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("%s: no benchmark results", path)
+	}
+	return results, nil
+}
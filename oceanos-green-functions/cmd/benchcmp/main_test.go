@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeRuns writes -count style runs of an Evaluate and a Sweep benchmark
+func writeRuns(t *testing.T, name string, evaluate, sweep []float64) string {
+	t.Helper()
+	var b strings.Builder
+	fmt.Fprintln(&b, "goos: linux")
+	fmt.Fprintln(&b, "pkg: github.com/capytaine/capytaine/go-capytaine/green_functions/green_functions")
+	for i := range evaluate {
+		fmt.Fprintf(&b, "BenchmarkDelhommeau_Evaluate_Small-8\t1000000\t%g ns/op\t256 B/op\t4 allocs/op\n", evaluate[i])
+		fmt.Fprintf(&b, "BenchmarkSolver_Sweep-8\t10\t%g ns/op\t4096 B/op\t12 allocs/op\n", sweep[i])
+	}
+	fmt.Fprintln(&b, "PASS")
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return path
+}
+
+func TestRun(t *testing.T) {
+	old := writeRuns(t, "old.txt", []float64{100, 101, 99, 98, 102}, []float64{1000, 1010, 990, 980, 1020})
+	// Evaluate is 8% slower, Sweep 3% slower
+	candidate := writeRuns(t, "new.txt", []float64{108, 109, 107, 106, 110}, []float64{1030, 1040, 1020, 1010, 1050})
+
+	for _, tc := range []struct {
+		name        string
+		args        []string
+		status      int
+		regressions []string
+	}{
+		{"default threshold", nil, 1, []string{"Delhommeau_Evaluate_Small"}},
+		{"larger threshold", []string{"-threshold", "10"}, 0, nil},
+		{"per benchmark threshold", []string{"-threshold", "10", "-threshold-for", "Sweep=2"}, 1, []string{"Solver_Sweep"}},
+		{"filter", []string{"-filter", "Sweep"}, 0, nil},
+		{"units", []string{"-units", "B/op"}, 0, nil},
+	} {
+		var stdout, stderr bytes.Buffer
+		status := run(append(tc.args, old, candidate), &stdout, &stderr)
+		if status != tc.status {
+			t.Errorf("%s: expected status %d, got %d: %s%s", tc.name, tc.status, status, stdout.String(), stderr.String())
+		}
+		var regressions []string
+		for _, line := range strings.Split(stdout.String(), "\n") {
+			if strings.Contains(line, "REGRESSION") {
+				regressions = append(regressions, strings.Fields(line)[0])
+			}
+		}
+		if strings.Join(regressions, ",") != strings.Join(tc.regressions, ",") {
+			t.Errorf("%s: expected regressions %v, got %v:\n%s", tc.name, tc.regressions, regressions, stdout.String())
+		}
+	}
+
+	// Single runs cannot show a significant regression: they are refused, unless the significance test is disabled
+	single := writeRuns(t, "single.txt", []float64{100}, []float64{1000})
+	slower := writeRuns(t, "slower.txt", []float64{200}, []float64{1000})
+	var stdout, stderr bytes.Buffer
+	if status := run([]string{single, candidate}, &stdout, &stderr); status != 2 || !strings.Contains(stderr.String(), "-count 5") {
+		t.Errorf("Expected status 2 for a single run baseline, got %d: %s", status, stderr.String())
+	}
+	stderr.Reset()
+	if status := run([]string{"-min-runs", "1", single, candidate}, &stdout, &stderr); status != 2 || !strings.Contains(stderr.String(), "smallest p-value is 0.333") {
+		t.Errorf("Expected status 2 for runs that cannot be significant, got %d: %s", status, stderr.String())
+	}
+	if status := run([]string{"-alpha", "0.001", old, candidate}, &stdout, &stderr); status != 2 {
+		t.Errorf("Expected status 2 for 5 runs at the level 0.001, got %d", status)
+	}
+	if status := run([]string{"-alpha", "1", "-min-runs", "1", single, slower}, &stdout, &stderr); status != 1 {
+		t.Errorf("Expected status 1 without significance test, got %d:\n%s", status, stdout.String())
+	}
+
+	if status := run([]string{old}, &stdout, &stderr); status != 2 {
+		t.Errorf("Expected status 2 with a single file, got %d", status)
+	}
+	if status := run([]string{old, filepath.Join(t.TempDir(), "missing.txt")}, &stdout, &stderr); status != 2 {
+		t.Errorf("Expected status 2 for a missing file, got %d", status)
+	}
+	if status := run([]string{"-threshold-for", "Sweep", old, candidate}, &stdout, &stderr); status != 2 {
+		t.Errorf("Expected status 2 for an invalid rule, got %d", status)
+	}
+}
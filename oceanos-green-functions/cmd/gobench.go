@@ -0,0 +1,70 @@
+// Package main - Performance metrics from the output of go test -bench
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package main
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/capytaine/capytaine/go-capytaine/green_functions/internal/benchfmt"
+)
+
+// unitMetricName converts a benchmark unit into a metric name: ns/op gives ns_per_op and B/op gives bytes_per_op
+func unitMetricName(unit string) string {
+	if unit == "B/op" {
+		return "bytes_per_op"
+	}
+	unit = strings.ReplaceAll(unit, "/", "_per_")
+	return strings.ToLower(strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return r
+		}
+		return '_'
+	}, unit))
+}
+
+// benchmarkSamples converts benchmark results into samples labelled by benchmark, GOMAXPROCS and configuration.
+// The repeated runs of a benchmark, as with -count, are averaged.
+func benchmarkSamples(results []benchfmt.Result) []metricSample {
+	type key struct {
+		name   string
+		labels string
+	}
+	var samples []metricSample
+	index := map[key]int{}
+	runs := map[key]float64{}
+
+	add := func(name, help string, value float64, labels []metricLabel) {
+		var b strings.Builder
+		for _, l := range labels {
+			b.WriteString(l.name + "=" + l.value + "\x00")
+		}
+		k := key{name, b.String()}
+		if i, ok := index[k]; ok {
+			runs[k]++
+			samples[i].value += (value - samples[i].value) / runs[k]
+			return
+		}
+		index[k] = len(samples)
+		runs[k] = 1
+		samples = append(samples, metricSample{name: name, help: help, value: value, labels: labels})
+	}
+
+	for _, r := range results {
+		labels := []metricLabel{{"benchmark", r.Name}, {"procs", strconv.Itoa(r.Procs)}}
+		for _, name := range []string{"pkg", "goos", "goarch", "cpu"} {
+			if value, ok := r.Config[name]; ok {
+				labels = append(labels, metricLabel{name, value})
+			}
+		}
+
+		add("benchmark.iterations", "Number of iterations of the benchmark", float64(r.Iterations), labels)
+		for _, m := range r.Metrics {
+			add("benchmark."+unitMetricName(m.Unit), "Benchmark metric in "+m.Unit, m.Value, labels)
+		}
+	}
+	return samples
+}
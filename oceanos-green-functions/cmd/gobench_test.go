@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/capytaine/capytaine/go-capytaine/green_functions/internal/benchfmt"
+)
+
+const goBenchOutput = `goos: windows
+goarch: amd64
+pkg: github.com/capytaine/capytaine/go-capytaine/green_functions
+cpu: 12th Gen Intel(R) Core(TM) i5-12450HX
+BenchmarkDelhommeau_Evaluate_Small-12                	 8042275	       139.5 ns/op	     256 B/op	       4 allocs/op
+BenchmarkFinGreen3D_InfiniteVsFiniteDepth/InfiniteDepth-12         	40520553	        29.82 ns/op	       0 B/op	       0 allocs/op
+BenchmarkDelhommeau_Evaluate_Small-12                	 8000000	       140.5 ns/op	     256 B/op	       4 allocs/op
+BenchmarkSolver_Sweep
+BenchmarkSolver_Sweep-12    	      10	 104857600 ns/op	        12.50 frequencies/s
+--- BENCH: BenchmarkSolver_Sweep-12
+    solver_test.go:42: mesh with 128 faces
+PASS
+ok  	github.com/capytaine/capytaine/go-capytaine/green_functions	35.195s
+`
+
+func TestBenchmarkSamples(t *testing.T) {
+	results, err := benchfmt.Parse(strings.NewReader(goBenchOutput))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	samples := benchmarkSamples(results)
+
+	// The two runs of Delhommeau_Evaluate_Small are averaged
+	found := false
+	for _, s := range samples {
+		if s.name == "benchmark.ns_per_op" && s.labels[0].value == "Delhommeau_Evaluate_Small" {
+			found = true
+			if math.Abs(s.value-140) > 1e-12 {
+				t.Errorf("Expected the mean of the runs 140, got %v", s.value)
+			}
+		}
+		if s.name == "benchmark.frequencies_per_s" && s.value != 12.5 {
+			t.Errorf("Expected 12.5 frequencies/s, got %v", s.value)
+		}
+	}
+	if !found {
+		t.Error("Expected a ns/op sample for Delhommeau_Evaluate_Small")
+	}
+	// Iterations, ns/op, B/op and allocs/op for two benchmarks, iterations, ns/op and frequencies/s for the sweep
+	if len(samples) != 11 {
+		t.Errorf("Expected 11 samples, got %d", len(samples))
+	}
+}
+
+func TestWriteExposition(t *testing.T) {
+	samples := []metricSample{
+		{name: "benchmark.ns_per_op", help: "Benchmark metric in ns/op", value: 139.5,
+			labels: []metricLabel{{"benchmark", "Delhommeau_Evaluate_Small"}, {"cpu", `Intel "i5"`}}},
+		{name: "benchmark.ns_per_op", help: "Benchmark metric in ns/op", value: 29.82,
+			labels: []metricLabel{{"benchmark", "FinGreen3D/InfiniteDepth"}}},
+		{name: "requests", help: "Number of requests", value: 25},
+	}
+
+	var buf bytes.Buffer
+	if err := writeExposition(&buf, openMetrics, "greenfunction", []metricLabel{{"env", "ci"}}, samples); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := `# HELP greenfunction_benchmark_ns_per_op Benchmark metric in ns/op
+# TYPE greenfunction_benchmark_ns_per_op gauge
+greenfunction_benchmark_ns_per_op{env="ci",benchmark="Delhommeau_Evaluate_Small",cpu="Intel \"i5\""} 139.5
+greenfunction_benchmark_ns_per_op{env="ci",benchmark="FinGreen3D/InfiniteDepth"} 29.82
+# HELP greenfunction_requests Number of requests
+# TYPE greenfunction_requests gauge
+greenfunction_requests{env="ci"} 25
+# EOF
+`
+	if buf.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, buf.String())
+	}
+
+	buf.Reset()
+	writeExposition(&buf, prometheusText, "", nil, samples[2:])
+	if buf.String() != "# HELP requests Number of requests\n# TYPE requests gauge\nrequests 25\n" {
+		t.Errorf("Unexpected Prometheus text %q", buf.String())
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/capytaine/capytaine/go-capytaine/green_functions/green_functions"
+)
+
+// greenFunctionOptions is a repeatable key=value flag of Green function options
+type greenFunctionOptions map[string]interface{}
+
+func (o greenFunctionOptions) String() string {
+	var options []string
+	for key, value := range o {
+		options = append(options, fmt.Sprintf("%s=%v", key, value))
+	}
+	return strings.Join(options, ",")
+}
+
+// Set records an option, as a number when the value parses as one
+func (o greenFunctionOptions) Set(s string) error {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value, got %q", s)
+	}
+	if x, err := strconv.ParseFloat(value, 64); err == nil {
+		o[key] = x
+	} else {
+		o[key] = value
+	}
+	return nil
+}
+
+// greenFunctionFlags are the flags selecting and configuring the Green function of the commands
+type greenFunctionFlags struct {
+	name    string
+	config  string
+	options greenFunctionOptions
+}
+
+func addGreenFunctionFlags(fs *flag.FlagSet) *greenFunctionFlags {
+	f := &greenFunctionFlags{options: greenFunctionOptions{}}
+	fs.StringVar(&f.name, "gf", "", fmt.Sprintf("Green function: %s; not case sensitive, delhommeau by default",
+		strings.Join(green_functions.GreenFunctionNames(), ", ")))
+	fs.StringVar(&f.config, "gf-config", "", "JSON or YAML `file` of Green function settings, such as the green_function_settings of a results.nc")
+	fs.Var(f.options, "gf-option", "Green function option `key=value`, e.g. tabulation_nr=328; may be repeated and overrides -gf-config")
+	return f
+}
+
+// newGreenFunction creates the Green function of the flags, checking that it supports the water depths.
+// -gf takes precedence over the name in the -gf-config file.
+func (f *greenFunctionFlags) newGreenFunction(waterDepths []float64) (green_functions.AbstractGreenFunction, error) {
+	name := "delhommeau"
+	options := map[string]interface{}{}
+	if f.config != "" {
+		settings, err := green_functions.LoadSettings(f.config)
+		if err != nil {
+			return nil, err
+		}
+		if n, ok := settings[green_functions.SettingsKey].(string); ok {
+			name = n
+		}
+		for key, value := range settings {
+			options[key] = value
+		}
+	}
+	if f.name != "" {
+		name = f.name
+	}
+	for key, value := range f.options {
+		options[key] = value
+	}
+	return green_functions.NewGreenFunction(name, options, waterDepths...)
+}
@@ -0,0 +1,515 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/capytaine/capytaine/go-capytaine/green_functions/green_functions"
+	"github.com/capytaine/capytaine/go-capytaine/green_functions/internal/statsd"
+	"github.com/capytaine/capytaine/go-capytaine/green_functions/server"
+	vegeta "github.com/tsenart/vegeta/v12/lib"
+)
+
+// loadtestOptions are the flags of the loadtest command
+type loadtestOptions struct {
+	url           string
+	local         bool
+	rate          int
+	duration      time.Duration
+	timeout       time.Duration
+	maxWorkers    uint64
+	greenFunction string
+	faces         []int
+	wavenumbers   []float64
+	waterDepth    *float64
+	solveFraction float64
+	seed          int64
+
+	targetsPath string
+	resultsPath string
+	reportPath  string
+	statsdAddr  string
+	prefix      string
+	tags        []string
+}
+
+func parseLoadtestFlags(args []string, stderr io.Writer) (*loadtestOptions, error) {
+	fs := flag.NewFlagSet("loadtest", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: greenbem loadtest [-url URL | -local] [flags]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Sends evaluate and solve requests on hemisphere meshes of several sizes at several wavenumbers,")
+		fmt.Fprintln(stderr, "then writes the latency percentiles, success rate and throughput of each endpoint as JSON.")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	opts := &loadtestOptions{}
+	var faces, wavenumbers, depth, tags string
+	fs.StringVar(&opts.url, "url", "http://localhost:8080", "base `URL` of the greenbem server")
+	fs.BoolVar(&opts.local, "local", false, "start a server on a loopback port for the duration of the test instead of using -url")
+	fs.IntVar(&opts.rate, "rate", 10, "requests per second")
+	fs.DurationVar(&opts.duration, "duration", 30*time.Second, "duration of the attack")
+	fs.DurationVar(&opts.timeout, "timeout", time.Minute, "time limit of a request")
+	fs.Uint64Var(&opts.maxWorkers, "max-workers", 0, "largest number of requests in flight, unlimited when 0")
+	fs.StringVar(&opts.greenFunction, "gf", "delhommeau", "Green function: "+strings.Join(green_functions.GreenFunctionNames(), ", ")+"; not case sensitive")
+	fs.StringVar(&faces, "faces", "16,64,256", "`list` of approximate numbers of faces of the hemisphere meshes")
+	fs.StringVar(&wavenumbers, "wavenumbers", "0.5,1,2,4", "`list` of wavenumbers in rad/m")
+	fs.StringVar(&depth, "depth", "inf", "water depth in meters, inf for deep water")
+	fs.Float64Var(&opts.solveFraction, "solve-fraction", 0.1, "fraction of the requests that are solves, the others are evaluations")
+	fs.Int64Var(&opts.seed, "seed", 1, "seed of the random choice of the requests")
+	fs.StringVar(&opts.targetsPath, "write-targets", "", "write the requests to `file` as vegeta JSON targets instead of attacking")
+	fs.StringVar(&opts.resultsPath, "results", "", "also write the vegeta results to `file` in the gob encoding")
+	fs.StringVar(&opts.reportPath, "report", "-", "`file` where the JSON report is written, - for stdout")
+	fs.StringVar(&opts.statsdAddr, "statsd", "", "also send the report to the StatsD server at `address`")
+	fs.StringVar(&opts.prefix, "prefix", "greenfunction.loadtest", "prefix of the StatsD metric names")
+	fs.StringVar(&tags, "tags", "", "comma separated `key:value` tags added to the StatsD metrics")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		}
+		return nil, usageError{err}
+	}
+	if fs.NArg() > 0 {
+		return nil, usageError{fmt.Errorf("unexpected argument %q", fs.Arg(0))}
+	}
+	if opts.rate <= 0 {
+		return nil, usageError{fmt.Errorf("-rate must be positive, got %d", opts.rate)}
+	}
+	if opts.duration <= 0 {
+		return nil, usageError{fmt.Errorf("-duration must be positive, got %s", opts.duration)}
+	}
+	if !(opts.solveFraction >= 0 && opts.solveFraction <= 1) {
+		return nil, usageError{fmt.Errorf("-solve-fraction must be between 0 and 1, got %g", opts.solveFraction)}
+	}
+
+	values, err := parseList(faces)
+	if err != nil {
+		return nil, usageError{fmt.Errorf("-faces: %w", err)}
+	}
+	for _, v := range values {
+		if v != math.Trunc(v) || v < 3 {
+			return nil, usageError{fmt.Errorf("-faces: expected integers of at least 3, got %g", v)}
+		}
+		opts.faces = append(opts.faces, int(v))
+	}
+	if opts.wavenumbers, err = parseList(wavenumbers); err != nil {
+		return nil, usageError{fmt.Errorf("-wavenumbers: %w", err)}
+	}
+	for _, k := range opts.wavenumbers {
+		if !(k > 0) {
+			return nil, usageError{fmt.Errorf("-wavenumbers: expected positive wavenumbers, got %g", k)}
+		}
+	}
+	if len(opts.faces) == 0 || len(opts.wavenumbers) == 0 {
+		return nil, usageError{errors.New("-faces and -wavenumbers need at least one value")}
+	}
+	d, err := parseList(depth)
+	if err != nil || len(d) != 1 || !(d[0] > 0) {
+		return nil, usageError{fmt.Errorf("-depth: expected a positive water depth, got %q", depth)}
+	}
+	if !math.IsInf(d[0], 1) {
+		opts.waterDepth = &d[0]
+	}
+	if opts.tags, err = statsd.ParseTags(tags); err != nil {
+		return nil, usageError{fmt.Errorf("-tags: %w", err)}
+	}
+	return opts, nil
+}
+
+// runLoadtest implements the loadtest command
+func runLoadtest(args []string, stdout, stderr io.Writer) error {
+	opts, err := parseLoadtestFlags(args, stderr)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	baseURL := opts.url
+	if opts.local && opts.targetsPath == "" {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return err
+		}
+		serverCtx, stopServer := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			done <- serve(serverCtx, listener, &serveOptions{config: server.DefaultConfig(), shutdownTimeout: opts.timeout, quiet: true}, io.Discard)
+		}()
+		defer func() {
+			stopServer()
+			<-done
+		}()
+		baseURL = "http://" + listener.Addr().String()
+	}
+
+	evaluate, solve, err := loadtestTargets(baseURL, opts)
+	if err != nil {
+		return err
+	}
+	targeter := mixedTargeter(evaluate, solve, opts.solveFraction, opts.seed)
+
+	if opts.targetsPath != "" {
+		n := opts.rate * int(math.Ceil(opts.duration.Seconds()))
+		if err := writeTargets(opts.targetsPath, targeter, n); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Wrote %d targets to %s\n", n, opts.targetsPath)
+		return nil
+	}
+
+	fmt.Fprintf(stderr, "Attacking %s at %d requests/s for %s: %d evaluate and %d solve targets\n",
+		baseURL, opts.rate, opts.duration, len(evaluate), len(solve))
+	report, err := attack(ctx, targeter, opts)
+	if err != nil {
+		return err
+	}
+	report.URL = baseURL
+
+	if err := writeLoadtestReport(opts.reportPath, stdout, report); err != nil {
+		return err
+	}
+	fmt.Fprintf(stderr, "%d requests, %.1f%% success, p50 %.1f ms, p95 %.1f ms, p99 %.1f ms, %.2f successful requests/s\n",
+		report.Total.Requests, report.Total.SuccessRate, report.Total.LatencyMS.P50, report.Total.LatencyMS.P95,
+		report.Total.LatencyMS.P99, report.Total.Throughput)
+
+	if opts.statsdAddr != "" {
+		conn, err := net.Dial("udp", opts.statsdAddr)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		client := statsd.NewClient(conn, opts.prefix, opts.tags, statsd.DefaultMaxPacketSize)
+		if err := sendLoadtestReport(client, report); err != nil {
+			return err
+		}
+		fmt.Fprintf(stderr, "Sent %d metrics in %d packets to StatsD at %s\n", client.Metrics(), client.Packets(), opts.statsdAddr)
+	}
+	return nil
+}
+
+// loadtestTargets builds the evaluate and solve requests on hemispheres of each size at each wavenumber.
+// Solves are at a single wavenumber, for head and beam seas.
+func loadtestTargets(baseURL string, opts *loadtestOptions) (evaluate, solve []vegeta.Target, err error) {
+	base, err := url.Parse(baseURL)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, nil, usageError{fmt.Errorf("invalid URL %q", baseURL)}
+	}
+	header := http.Header{"Content-Type": []string{"application/json"}}
+	target := func(endpoint string, body interface{}) (vegeta.Target, error) {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return vegeta.Target{}, err
+		}
+		u := *base
+		u.Path = path.Join(u.Path, "/v1", endpoint)
+		return vegeta.Target{Method: http.MethodPost, URL: u.String(), Body: data, Header: header}, nil
+	}
+
+	for _, faces := range opts.faces {
+		mesh, err := hemisphereMeshData(faces)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, k := range opts.wavenumbers {
+			k := k
+			if opts.solveFraction < 1 {
+				t, err := target("evaluate", server.EvaluateRequest{
+					GreenFunction: opts.greenFunction,
+					Mesh:          mesh,
+					WaterDepth:    opts.waterDepth,
+					Wavenumber:    &k,
+				})
+				if err != nil {
+					return nil, nil, err
+				}
+				evaluate = append(evaluate, t)
+			}
+			if opts.solveFraction > 0 {
+				t, err := target("solve", server.SolveRequest{
+					GreenFunction:  opts.greenFunction,
+					Mesh:           mesh,
+					Frequencies:    []float64{k},
+					FrequencyType:  string(green_functions.Wavenumber),
+					WaveDirections: []float64{0, math.Pi / 2},
+					WaterDepth:     opts.waterDepth,
+				})
+				if err != nil {
+					return nil, nil, err
+				}
+				solve = append(solve, t)
+			}
+		}
+	}
+	return evaluate, solve, nil
+}
+
+// hemisphereMeshData meshes a hemisphere of unit radius with about the given number of faces,
+// twice as many panels in azimuth as in elevation
+func hemisphereMeshData(faces int) (server.MeshData, error) {
+	nTheta := int(math.Max(1, math.Round(math.Sqrt(float64(faces)/2))))
+	nPhi := int(math.Max(3, math.Round(float64(faces)/float64(nTheta))))
+	mesh, err := green_functions.NewHemisphereMesh(1, nTheta, nPhi)
+	if err != nil {
+		return server.MeshData{}, err
+	}
+	data := server.MeshData{Vertices: mesh.Vertices, Faces: make([][]int, len(mesh.Faces))}
+	for i, f := range mesh.Faces {
+		data.Faces[i] = []int{f[0], f[1], f[2], f[3]}
+	}
+	return data, nil
+}
+
+// mixedTargeter draws a solve target with probability solveFraction and an evaluate target otherwise
+func mixedTargeter(evaluate, solve []vegeta.Target, solveFraction float64, seed int64) vegeta.Targeter {
+	var mu sync.Mutex
+	rng := rand.New(rand.NewSource(seed))
+	return func(t *vegeta.Target) error {
+		if t == nil {
+			return vegeta.ErrNilTarget
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		targets := evaluate
+		if len(evaluate) == 0 || len(solve) > 0 && rng.Float64() < solveFraction {
+			targets = solve
+		}
+		if len(targets) == 0 {
+			return vegeta.ErrNoTargets
+		}
+		*t = targets[rng.Intn(len(targets))]
+		return nil
+	}
+}
+
+// writeTargets writes n targets in the JSON format read by `vegeta attack -format json`
+func writeTargets(path string, targeter vegeta.Targeter, n int) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	enc := vegeta.NewJSONTargetEncoder(f)
+	for i := 0; i < n; i++ {
+		var t vegeta.Target
+		if err := targeter(&t); err != nil {
+			f.Close()
+			return err
+		}
+		if err := enc.Encode(&t); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// loadtestReport summarizes an attack, for all the requests and for each endpoint
+type loadtestReport struct {
+	URL             string                    `json:"url"`
+	Rate            int                       `json:"rate"`
+	DurationSeconds float64                   `json:"duration_seconds"`
+	Total           endpointReport            `json:"total"`
+	Endpoints       map[string]endpointReport `json:"endpoints"`
+}
+
+// endpointReport has the success rate in percent, rates in requests per second and latencies in milliseconds
+type endpointReport struct {
+	Requests    uint64         `json:"requests"`
+	SuccessRate float64        `json:"success_rate"`
+	Rate        float64        `json:"rate"`
+	Throughput  float64        `json:"throughput"`
+	LatencyMS   latencyReport  `json:"latency_ms"`
+	StatusCodes map[string]int `json:"status_codes"`
+	Errors      []string       `json:"errors,omitempty"`
+}
+
+type latencyReport struct {
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+// attack sends the requests until the end of the duration or the cancellation of the context
+func attack(ctx context.Context, targeter vegeta.Targeter, opts *loadtestOptions) (*loadtestReport, error) {
+	attackOptions := []func(*vegeta.Attacker){vegeta.Timeout(opts.timeout)}
+	if opts.maxWorkers > 0 {
+		attackOptions = append(attackOptions, vegeta.MaxWorkers(opts.maxWorkers))
+	}
+	attacker := vegeta.NewAttacker(attackOptions...)
+
+	var enc vegeta.Encoder
+	if opts.resultsPath != "" {
+		f, err := os.Create(opts.resultsPath)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		enc = vegeta.NewEncoder(f)
+	}
+
+	var total vegeta.Metrics
+	endpoints := map[string]*vegeta.Metrics{}
+	rate := vegeta.Rate{Freq: opts.rate, Per: time.Second}
+	results := attacker.Attack(targeter, rate, opts.duration, "greenbem loadtest")
+	stopped := ctx.Done()
+	for {
+		select {
+		case <-stopped:
+			// The results of the requests in flight are still collected
+			attacker.Stop()
+			stopped = nil
+			continue
+		case res, ok := <-results:
+			if !ok {
+				return newLoadtestReport(opts, &total, endpoints), nil
+			}
+			total.Add(res)
+			name := endpointName(res.URL)
+			if endpoints[name] == nil {
+				endpoints[name] = &vegeta.Metrics{}
+			}
+			endpoints[name].Add(res)
+			if enc != nil {
+				if err := enc.Encode(res); err != nil {
+					attacker.Stop()
+					return nil, err
+				}
+			}
+		}
+	}
+}
+
+// endpointName is the last element of the path of a request URL
+func endpointName(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Path == "" {
+		return "unknown"
+	}
+	return path.Base(u.Path)
+}
+
+func newLoadtestReport(opts *loadtestOptions, total *vegeta.Metrics, endpoints map[string]*vegeta.Metrics) *loadtestReport {
+	report := &loadtestReport{
+		Rate:            opts.rate,
+		DurationSeconds: opts.duration.Seconds(),
+		Total:           newEndpointReport(total),
+		Endpoints:       map[string]endpointReport{},
+	}
+	for name, m := range endpoints {
+		report.Endpoints[name] = newEndpointReport(m)
+	}
+	return report
+}
+
+func newEndpointReport(m *vegeta.Metrics) endpointReport {
+	m.Close()
+	milliseconds := func(d time.Duration) float64 {
+		return d.Seconds() * 1000
+	}
+	return endpointReport{
+		Requests:    m.Requests,
+		SuccessRate: m.Success * 100,
+		Rate:        m.Rate,
+		Throughput:  m.Throughput,
+		LatencyMS: latencyReport{
+			Mean: milliseconds(m.Latencies.Mean),
+			P50:  milliseconds(m.Latencies.P50),
+			P90:  milliseconds(m.Latencies.P90),
+			P95:  milliseconds(m.Latencies.P95),
+			P99:  milliseconds(m.Latencies.P99),
+			Max:  milliseconds(m.Latencies.Max),
+		},
+		StatusCodes: m.StatusCodes,
+		Errors:      m.Errors,
+	}
+}
+
+func writeLoadtestReport(path string, stdout io.Writer, report *loadtestReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if path == "-" {
+		_, err := stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// sendLoadtestReport sends the report as gauges tagged with the endpoint, "all" for the totals
+func sendLoadtestReport(c *statsd.Client, report *loadtestReport) error {
+	names := make([]string, 0, len(report.Endpoints))
+	for name := range report.Endpoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	send := func(name string, r endpointReport) error {
+		tag := statsd.Tag("endpoint", name)
+		gauges := []struct {
+			name  string
+			value float64
+		}{
+			{"requests", float64(r.Requests)},
+			{"success_rate", r.SuccessRate},
+			{"rate", r.Rate},
+			{"throughput", r.Throughput},
+			{"latency_mean", r.LatencyMS.Mean},
+			{"latency_p50", r.LatencyMS.P50},
+			{"latency_p90", r.LatencyMS.P90},
+			{"latency_p95", r.LatencyMS.P95},
+			{"latency_p99", r.LatencyMS.P99},
+			{"latency_max", r.LatencyMS.Max},
+		}
+		for _, g := range gauges {
+			if err := c.Gauge(g.name, g.value, tag); err != nil {
+				return err
+			}
+		}
+		codes := make([]string, 0, len(r.StatusCodes))
+		for code := range r.StatusCodes {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		for _, code := range codes {
+			if err := c.Gauge("status_codes", float64(r.StatusCodes[code]), tag, statsd.Tag("code", code)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := send("all", report.Total); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := send(name, report.Endpoints[name]); err != nil {
+			return err
+		}
+	}
+	return c.Flush()
+}
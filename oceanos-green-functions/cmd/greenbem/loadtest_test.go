@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/capytaine/capytaine/go-capytaine/green_functions/server"
+	vegeta "github.com/tsenart/vegeta/v12/lib"
+)
+
+func TestLoadtestTargets(t *testing.T) {
+	opts, err := parseLoadtestFlags([]string{"-faces", "16,64", "-wavenumbers", "1,2,4", "-depth", "20"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	evaluate, solve, err := loadtestTargets("http://localhost:8080/api", opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(evaluate) != 6 || len(solve) != 6 {
+		t.Fatalf("Expected 6 evaluate and 6 solve targets, got %d and %d", len(evaluate), len(solve))
+	}
+	if evaluate[0].URL != "http://localhost:8080/api/v1/evaluate" || solve[0].URL != "http://localhost:8080/api/v1/solve" {
+		t.Errorf("Unexpected URLs %q and %q", evaluate[0].URL, solve[0].URL)
+	}
+
+	var req server.EvaluateRequest
+	if err := json.Unmarshal(evaluate[4].Body, &req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// 64 faces are approached by 6 panels in elevation and 11 in azimuth
+	if len(req.Mesh.Faces) != 66 || *req.Wavenumber != 2 || *req.WaterDepth != 20 {
+		t.Errorf("Expected 66 faces at wavenumber 2 in 20 m, got %d faces at %g in %g m",
+			len(req.Mesh.Faces), *req.Wavenumber, *req.WaterDepth)
+	}
+	var solveReq server.SolveRequest
+	if err := json.Unmarshal(solve[0].Body, &solveReq); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(solveReq.Mesh.Faces) != 15 || solveReq.FrequencyType != "wavenumber" || len(solveReq.WaveDirections) != 2 {
+		t.Errorf("Unexpected solve request with %d faces, frequency type %q and directions %v",
+			len(solveReq.Mesh.Faces), solveReq.FrequencyType, solveReq.WaveDirections)
+	}
+}
+
+func TestMixedTargeter(t *testing.T) {
+	evaluate := []vegeta.Target{{URL: "http://x/v1/evaluate"}}
+	solve := []vegeta.Target{{URL: "http://x/v1/solve"}}
+	targeter := mixedTargeter(evaluate, solve, 0.25, 1)
+
+	solves := 0
+	for i := 0; i < 4000; i++ {
+		var target vegeta.Target
+		if err := targeter(&target); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if strings.HasSuffix(target.URL, "/solve") {
+			solves++
+		}
+	}
+	if solves < 900 || solves > 1100 {
+		t.Errorf("Expected about 1000 solves, got %d", solves)
+	}
+
+	var target vegeta.Target
+	if err := mixedTargeter(evaluate, nil, 1, 1)(&target); err != nil || target.URL != evaluate[0].URL {
+		t.Errorf("Expected the evaluate target without solve targets, got %q (%v)", target.URL, err)
+	}
+}
+
+func TestRunLoadtest(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Cannot listen on UDP: %v", err)
+	}
+	defer conn.Close()
+
+	dir := t.TempDir()
+	reportPath := filepath.Join(dir, "report.json")
+	resultsPath := filepath.Join(dir, "results.bin")
+	var stdout, stderr bytes.Buffer
+	status := run([]string{"loadtest", "-local", "-rate", "20", "-duration", "1s", "-faces", "8", "-wavenumbers", "1,2",
+		"-solve-fraction", "0.5", "-report", reportPath, "-results", resultsPath,
+		"-statsd", conn.LocalAddr().String(), "-tags", "env:test"}, &stdout, &stderr)
+	if status != 0 {
+		t.Fatalf("Expected status 0, got %d: %s", status, stderr.String())
+	}
+
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var report loadtestReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if report.Total.Requests != 20 || report.Total.SuccessRate != 100 {
+		t.Errorf("Expected 20 successful requests, got %d at %g%%: %v", report.Total.Requests, report.Total.SuccessRate, report.Total.Errors)
+	}
+	evaluate, solve := report.Endpoints["evaluate"], report.Endpoints["solve"]
+	if evaluate.Requests == 0 || solve.Requests == 0 || evaluate.Requests+solve.Requests != 20 {
+		t.Errorf("Expected the requests split between evaluate and solve, got %+v", report.Endpoints)
+	}
+	if report.Total.LatencyMS.P99 < report.Total.LatencyMS.P50 || report.Total.LatencyMS.P50 <= 0 {
+		t.Errorf("Unexpected latencies %+v", report.Total.LatencyMS)
+	}
+
+	f, err := os.Open(resultsPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer f.Close()
+	var res vegeta.Result
+	if err := vegeta.NewDecoder(f).Decode(&res); err != nil || res.Code != 200 {
+		t.Errorf("Expected a successful result in the results file, got %d (%v)", res.Code, err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 65536)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if packet := string(buf[:n]); !strings.Contains(packet, "greenfunction.loadtest.requests:20|g|#env:test,endpoint:all") {
+		t.Errorf("Expected the number of requests in %q", packet)
+	}
+}
+
+func TestRunLoadtest_WriteTargets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "targets.json")
+	var stdout, stderr bytes.Buffer
+	status := run([]string{"loadtest", "-write-targets", path, "-rate", "5", "-duration", "2s", "-faces", "8"}, &stdout, &stderr)
+	if status != 0 {
+		t.Fatalf("Expected status 0, got %d: %s", status, stderr.String())
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer f.Close()
+	targets, err := vegeta.ReadAllTargets(vegeta.NewJSONTargeter(f, nil, nil))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(targets) != 10 || targets[0].Method != "POST" || targets[0].Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Expected 10 POST targets, got %d", len(targets))
+	}
+
+	for _, args := range [][]string{{"-rate", "0"}, {"-faces", "2"}, {"-solve-fraction", "2"}, {"-depth", "-1"}} {
+		if status := run(append([]string{"loadtest"}, args...), &stdout, &stderr); status != 2 {
+			t.Errorf("%v: expected status 2, got %d", args, status)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Command greenbem computes the hydrodynamic coefficients of floating bodies with the boundary element solver
+// of the green_functions package.
+//
+// Usage:
+//
+//	greenbem solve -mesh hull.gdf -frequencies 0.5:2:16 -headings 0,90 -output results
+//	greenbem nemoh path/to/case
+//	greenbem serve -addr localhost:8080
+//	greenbem loadtest -url http://localhost:8080 -rate 20 -duration 1m -statsd localhost:8125
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// usageError is returned for invalid command lines, which exit with status 2 instead of 1
+type usageError struct {
+	err error
+}
+
+func (e usageError) Error() string {
+	return e.err.Error()
+}
+
+func (e usageError) Unwrap() error {
+	return e.err
+}
+
+// commands maps the subcommands to their implementations
+var commands = map[string]func(args []string, stdout, stderr io.Writer) error{
+	"solve":    runSolve,
+	"nemoh":    runNemoh,
+	"serve":    runServe,
+	"loadtest": runLoadtest,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes a subcommand and returns the exit status
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "help" {
+		printUsage(stderr)
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+
+	command, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "greenbem: unknown command %q\n", args[0])
+		printUsage(stderr)
+		return 2
+	}
+
+	err := command(args[1:], stdout, stderr)
+	var usage usageError
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.As(err, &usage):
+		fmt.Fprintf(stderr, "greenbem %s: %v\n", args[0], err)
+		return 2
+	default:
+		fmt.Fprintf(stderr, "greenbem %s: %v\n", args[0], err)
+		return 1
+	}
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: greenbem <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	fmt.Fprintln(w, "  solve    compute added mass, radiation damping and excitation forces from a mesh file")
+	fmt.Fprintln(w, "  nemoh    solve a Nemoh case folder and write the .tec files of Nemoh")
+	fmt.Fprintln(w, "  serve    serve Green function evaluations and solves over HTTP")
+	fmt.Fprintln(w, "  loadtest send evaluate and solve requests to a server and report latencies and throughput")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'greenbem <command> -h' for the flags of a command.")
+}
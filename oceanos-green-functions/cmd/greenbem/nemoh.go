@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/capytaine/capytaine/go-capytaine/green_functions/green_functions"
+)
+
+// runNemoh implements the nemoh command: it solves a Nemoh case folder and writes the .tec files of Nemoh
+// in its results directory
+func runNemoh(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("nemoh", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: greenbem nemoh [flags] [CASE_DIR]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Solves the case described by CASE_DIR/Nemoh.cal, the current directory by default,")
+		fmt.Fprintln(stderr, "and writes the RadiationCoefficients, ExcitationForce, DiffractionForce and FKForce .tec files")
+		fmt.Fprintln(stderr, "in CASE_DIR/results.")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+	greenFunction := addGreenFunctionFlags(fs)
+	workers := fs.Int("workers", 0, "number of frequencies solved in parallel, defaults to the number of CPUs")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return usageError{err}
+	}
+	if fs.NArg() > 1 {
+		return usageError{fmt.Errorf("unexpected argument %q", fs.Arg(1))}
+	}
+	dir := "."
+	if fs.NArg() == 1 {
+		dir = fs.Arg(0)
+	}
+
+	cal, err := green_functions.LoadNemohCal(filepath.Join(dir, "Nemoh.cal"))
+	if err != nil {
+		return err
+	}
+	solver, body, sweep, err := cal.Setup(dir, nil)
+	if err != nil {
+		return err
+	}
+	if solver.GreenFunction, err = greenFunction.newGreenFunction(sweep.WaterDepths); err != nil {
+		return usageError{err}
+	}
+	sweep.Workers = *workers
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	fmt.Fprintf(stderr, "%s: %d faces, %s, %d frequencies, %d headings\n",
+		body.Name, body.Mesh.GetNbFaces(), solver.GreenFunction, len(sweep.Frequencies), len(sweep.WaveDirections))
+
+	stream, err := solver.Sweep(ctx, body, sweep)
+	if err != nil {
+		return err
+	}
+	results := make([]*green_functions.FrequencyResult, len(sweep.Frequencies))
+	start := time.Now()
+	done := 0
+	for r := range stream {
+		if r.Err != nil {
+			cancel()
+			for range stream {
+				// Wait for the workers to stop
+			}
+			return fmt.Errorf("%s %g: %w", sweep.FrequencyType, sweep.Frequencies[r.FrequencyIndex], r.Err)
+		}
+		results[r.FrequencyIndex] = r.Result
+		done++
+		fmt.Fprintf(stderr, "[%d/%d] omega=%.4f rad/s (%s elapsed)\n",
+			done, len(results), r.Result.Omega, time.Since(start).Round(time.Millisecond))
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	output := filepath.Join(dir, "results")
+	if err := green_functions.ExportNemoh(output, body, results); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Wrote %s, %s, %s and %s in %s\n", green_functions.NemohRadiationCoefficientsFile,
+		green_functions.NemohExcitationForceFile, green_functions.NemohDiffractionForceFile,
+		green_functions.NemohFroudeKrylovForceFile, output)
+	return nil
+}
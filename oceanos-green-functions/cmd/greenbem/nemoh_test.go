@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/capytaine/capytaine/go-capytaine/green_functions/green_functions"
+)
+
+const testNemohCal = `--- Environment ---
+1000.0				! RHO
+9.81				! G
+0.				! DEPTH
+0.	0.			! XEFF YEFF
+--- Description of floating bodies ---
+1				! Number of bodies
+--- Body 1 ---
+hemisphere.dat		! Name of mesh file
+%d	%d			! Number of points and number of panels
+1				! Number of degrees of freedom
+1 0. 0. 1. 0. 0. 0.		! Heave
+1				! Number of resulting generalised forces
+1 0. 0. 1. 0. 0. 0.		! Force in z direction
+0				! Number of lines of additional information
+--- Load cases to be solved ---
+2	1.	2.		! Number of wave frequencies, Min, and Max (rad/s)
+1	0.	0.		! Number of wave directions, Min and Max (degrees)
+`
+
+func TestRunNemoh(t *testing.T) {
+	mesh, err := green_functions.NewHemisphereMesh(1, 3, 8)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var b strings.Builder
+	fmt.Fprintln(&b, "2 0")
+	for i, v := range mesh.Vertices {
+		fmt.Fprintf(&b, "%d %.17g %.17g %.17g\n", i+1, v[0], v[1], v[2])
+	}
+	fmt.Fprintln(&b, "0 0. 0. 0.")
+	for _, face := range mesh.Faces {
+		fmt.Fprintf(&b, "%d %d %d %d\n", face[0]+1, face[1]+1, face[2]+1, face[3]+1)
+	}
+	fmt.Fprintln(&b, "0 0 0 0")
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "hemisphere.dat"), []byte(b.String()), 0o644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cal := fmt.Sprintf(testNemohCal, len(mesh.Vertices), mesh.GetNbFaces())
+	if err := os.WriteFile(filepath.Join(dir, "Nemoh.cal"), []byte(cal), 0o644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if status := run([]string{"nemoh", "-workers", "2", dir}, &stdout, &stderr); status != 0 {
+		t.Fatalf("Expected status 0, got %d: %s", status, stderr.String())
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "results", green_functions.NemohRadiationCoefficientsFile))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Header, variables and a zone of two frequencies
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 5 {
+		t.Errorf("Expected 5 lines, got %q", lines)
+	}
+
+	if status := run([]string{"nemoh", t.TempDir()}, &stdout, &stderr); status != 1 {
+		t.Errorf("Expected status 1 without Nemoh.cal, got %d", status)
+	}
+	if status := run([]string{"nemoh", "-gf", "fingreen3d", dir}, &stdout, &stderr); status != 2 {
+		t.Errorf("Expected status 2 for fingreen3d in deep water, got %d", status)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/capytaine/capytaine/go-capytaine/green_functions/server"
+)
+
+// serveOptions are the flags of the serve command
+type serveOptions struct {
+	addr            string
+	config          server.Config
+	shutdownTimeout time.Duration
+	quiet           bool
+}
+
+func parseServeFlags(args []string, stderr io.Writer) (*serveOptions, error) {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: greenbem serve [flags]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Endpoints: GET /healthz, GET /readyz, POST /v1/evaluate, POST /v1/solve, POST /v1/solve/stream, /v1/jobs")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	opts := &serveOptions{config: server.DefaultConfig()}
+	fs.StringVar(&opts.addr, "addr", "localhost:8080", "listen `address`")
+	fs.Int64Var(&opts.config.MaxRequestBytes, "max-request-bytes", opts.config.MaxRequestBytes, "largest accepted request body in `bytes`")
+	fs.IntVar(&opts.config.MaxFaces, "max-faces", opts.config.MaxFaces, "largest accepted number of mesh faces")
+	fs.Int64Var(&opts.config.MaxMatrixBytes, "max-matrix-bytes", opts.config.MaxMatrixBytes, "largest memory in `bytes` of the S and K matrices of an evaluation or of a frequency of a solve")
+	fs.IntVar(&opts.config.MaxComputations, "max-computations", opts.config.MaxComputations, "largest number of evaluations, solves and streaming sweeps computed at the same time")
+	fs.IntVar(&opts.config.MaxFrequencies, "max-frequencies", opts.config.MaxFrequencies, "largest accepted number of frequencies of a solve")
+	fs.DurationVar(&opts.config.RequestTimeout, "timeout", opts.config.RequestTimeout, "computation time limit of a request")
+	fs.DurationVar(&opts.config.StreamTimeout, "stream-timeout", opts.config.StreamTimeout, "time limit of a streaming sweep, unlimited when 0")
+	fs.DurationVar(&opts.config.ProgressInterval, "progress-interval", opts.config.ProgressInterval, "period of the progress events of the streaming sweeps")
+	fs.IntVar(&opts.config.Workers, "workers", 0, "frequencies of a solve computed in parallel, defaults to the number of CPUs")
+	fs.StringVar(&opts.config.JobDir, "job-dir", "", "`directory` where the jobs and their results are saved to survive a restart")
+	fs.IntVar(&opts.config.JobWorkers, "job-workers", opts.config.JobWorkers, "number of jobs run at the same time")
+	fs.IntVar(&opts.config.MaxQueuedJobs, "max-queued-jobs", opts.config.MaxQueuedJobs, "largest number of jobs waiting for a worker")
+	fs.DurationVar(&opts.config.JobTTL, "job-ttl", opts.config.JobTTL, "time a finished job and its result are kept")
+	fs.DurationVar(&opts.shutdownTimeout, "shutdown-timeout", 30*time.Second, "time given to the requests in progress on shutdown")
+	fs.BoolVar(&opts.quiet, "quiet", false, "do not log the requests")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		}
+		return nil, usageError{err}
+	}
+	if fs.NArg() > 0 {
+		return nil, usageError{fmt.Errorf("unexpected argument %q", fs.Arg(0))}
+	}
+	return opts, nil
+}
+
+// runServe implements the serve command, which runs until interrupted
+func runServe(args []string, stdout, stderr io.Writer) error {
+	opts, err := parseServeFlags(args, stderr)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	listener, err := net.Listen("tcp", opts.addr)
+	if err != nil {
+		return err
+	}
+	return serve(ctx, listener, opts, stderr)
+}
+
+// serve serves the API on the listener until the context is done, then drains the requests in progress
+func serve(ctx context.Context, listener net.Listener, opts *serveOptions, stderr io.Writer) error {
+	logger := log.New(stderr, "", log.LstdFlags)
+	config := opts.config
+	if !opts.quiet {
+		config.Logger = logger
+	}
+	if config.JobDir != "" {
+		if err := os.MkdirAll(config.JobDir, 0o755); err != nil {
+			return err
+		}
+	}
+	handler := server.New(config)
+	// Running jobs are saved as queued, to be run again at the next start
+	defer handler.Close()
+
+	// The write timeout leaves time to encode the response of a request that used all its computation time
+	srv := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       time.Minute,
+		WriteTimeout:      config.RequestTimeout + time.Minute,
+		IdleTimeout:       2 * time.Minute,
+		ErrorLog:          logger,
+	}
+	if config.RequestTimeout <= 0 {
+		srv.WriteTimeout = 0
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.Serve(listener)
+	}()
+	logger.Printf("serving on http://%s", listener.Addr())
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Printf("shutting down")
+	handler.Drain()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), opts.shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutdown: %w", err)
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
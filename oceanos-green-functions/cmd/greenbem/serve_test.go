@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestServe(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Cannot listen on TCP: %v", err)
+	}
+	opts, err := parseServeFlags([]string{"-timeout", "10s", "-shutdown-timeout", "5s"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var logs bytes.Buffer
+	done := make(chan error, 1)
+	go func() {
+		done <- serve(ctx, listener, opts, &logs)
+	}()
+
+	resp, err := http.Get("http://" + listener.Addr().String() + "/healthz")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected a clean shutdown, got %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Server did not shut down")
+	}
+	if !bytes.Contains(logs.Bytes(), []byte("GET /healthz 200")) {
+		t.Errorf("Expected the request in the log, got %q", logs.String())
+	}
+}
+
+func TestParseServeFlags(t *testing.T) {
+	opts, err := parseServeFlags([]string{"-addr", ":9090", "-max-faces", "100", "-quiet"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if opts.addr != ":9090" || opts.config.MaxFaces != 100 || !opts.quiet || opts.config.MaxRequestBytes != 8<<20 {
+		t.Errorf("Unexpected options %+v", opts)
+	}
+	if _, err := parseServeFlags([]string{"extra"}, &bytes.Buffer{}); err == nil {
+		t.Error("Expected error for an unexpected argument")
+	}
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/capytaine/capytaine/go-capytaine/green_functions/green_functions"
+)
+
+// Names of the files written by the solve command in the output directory
+const (
+	addedMassFile        = "added_mass.csv"
+	radiationDampingFile = "radiation_damping.csv"
+	excitationForceFile  = "excitation_force.csv"
+	datasetFile          = "results.nc"
+)
+
+// solveOptions are the flags of the solve command
+type solveOptions struct {
+	mesh          string
+	greenFunction *greenFunctionFlags
+	frequencies   []float64
+	frequencyType green_functions.FrequencyType
+	headings      []float64
+	waterDepths   []float64
+	output        string
+	rho           float64
+	center        [3]float64
+	workers       int
+	wamit         bool
+	ulen          float64
+}
+
+func parseSolveFlags(args []string, stderr io.Writer) (*solveOptions, error) {
+	fs := flag.NewFlagSet("solve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: greenbem solve -mesh FILE -frequencies LIST -output DIR [flags]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Lists are comma separated values or start:stop:count ranges, e.g. 0.1,0.2 or 0.5:2:16.")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	opts := &solveOptions{}
+	var frequencies, headings, depths, center, frequencyType string
+	fs.StringVar(&opts.mesh, "mesh", "", "mesh `file` in the GDF (.gdf) or Nemoh (.dat) format")
+	opts.greenFunction = addGreenFunctionFlags(fs)
+	fs.StringVar(&frequencies, "frequencies", "", "`list` of frequencies")
+	fs.StringVar(&frequencyType, "frequency-type", string(green_functions.AngularFrequency),
+		"meaning of the frequencies: omega (rad/s), period (s) or wavenumber (rad/m)")
+	fs.StringVar(&headings, "headings", "0", "`list` of wave directions in degrees")
+	fs.StringVar(&depths, "depth", "inf", "`list` of water depths in meters, inf for deep water")
+	fs.StringVar(&opts.output, "output", "", "`directory` where the coefficient tables and the NetCDF datasets are written")
+	fs.Float64Var(&opts.rho, "rho", green_functions.WaterDensity, "water density in kg/m^3")
+	fs.StringVar(&center, "center", "0,0,0", "rotation center `x,y,z` of the body")
+	fs.IntVar(&opts.workers, "workers", 0, "number of frequencies solved in parallel, defaults to the number of CPUs")
+	fs.BoolVar(&opts.wamit, "wamit", false, "also write the WAMIT .1 and .3 files of the body, for a single water depth")
+	fs.Float64Var(&opts.ulen, "ulen", 1, "length scale ULEN of the WAMIT files in meters")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		}
+		return nil, usageError{err}
+	}
+	if fs.NArg() > 0 {
+		return nil, usageError{fmt.Errorf("unexpected argument %q", fs.Arg(0))}
+	}
+	if opts.mesh == "" {
+		return nil, usageError{errors.New("-mesh is required")}
+	}
+	if opts.output == "" {
+		return nil, usageError{errors.New("-output is required")}
+	}
+
+	var err error
+	if opts.frequencies, err = parseList(frequencies); err != nil {
+		return nil, usageError{fmt.Errorf("-frequencies: %w", err)}
+	}
+	if len(opts.frequencies) == 0 {
+		return nil, usageError{errors.New("-frequencies is required")}
+	}
+	switch t := green_functions.FrequencyType(frequencyType); t {
+	case green_functions.AngularFrequency, green_functions.WavePeriod, green_functions.Wavenumber:
+		opts.frequencyType = t
+	default:
+		return nil, usageError{fmt.Errorf("-frequency-type: unknown frequency type %q", frequencyType)}
+	}
+	if opts.headings, err = parseList(headings); err != nil {
+		return nil, usageError{fmt.Errorf("-headings: %w", err)}
+	}
+	if opts.waterDepths, err = parseList(depths); err != nil {
+		return nil, usageError{fmt.Errorf("-depth: %w", err)}
+	}
+	c, err := parseList(center)
+	if err != nil || len(c) != 3 {
+		return nil, usageError{fmt.Errorf("-center: expected three coordinates, got %q", center)}
+	}
+	copy(opts.center[:], c)
+	if opts.wamit && len(opts.waterDepths) != 1 {
+		return nil, usageError{errors.New("-wamit needs a single water depth")}
+	}
+	if opts.ulen <= 0 {
+		return nil, usageError{fmt.Errorf("-ulen must be positive, got %g", opts.ulen)}
+	}
+
+	return opts, nil
+}
+
+// parseList parses comma separated numbers and start:stop:count ranges of evenly spaced numbers
+func parseList(s string) ([]float64, error) {
+	var values []float64
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.Split(item, ":")
+		switch len(parts) {
+		case 1:
+			x, err := strconv.ParseFloat(item, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q", item)
+			}
+			values = append(values, x)
+		case 3:
+			start, err1 := strconv.ParseFloat(parts[0], 64)
+			stop, err2 := strconv.ParseFloat(parts[1], 64)
+			count, err3 := strconv.Atoi(parts[2])
+			if err1 != nil || err2 != nil || err3 != nil || count < 1 {
+				return nil, fmt.Errorf("invalid range %q, expected start:stop:count", item)
+			}
+			if count == 1 {
+				values = append(values, start)
+				continue
+			}
+			for i := 0; i < count; i++ {
+				values = append(values, start+(stop-start)*float64(i)/float64(count-1))
+			}
+		default:
+			return nil, fmt.Errorf("invalid range %q, expected start:stop:count", item)
+		}
+	}
+	return values, nil
+}
+
+// runSolve implements the solve command
+func runSolve(args []string, stdout, stderr io.Writer) error {
+	opts, err := parseSolveFlags(args, stderr)
+	if err != nil {
+		return err
+	}
+	gf, err := opts.greenFunction.newGreenFunction(opts.waterDepths)
+	if err != nil {
+		return usageError{err}
+	}
+
+	mesh, err := green_functions.LoadMesh(opts.mesh)
+	if err != nil {
+		return err
+	}
+	body := green_functions.NewRigidBody(strings.TrimSuffix(filepath.Base(opts.mesh), filepath.Ext(opts.mesh)), mesh, opts.center)
+
+	solver := green_functions.NewBEMSolver(gf)
+	solver.Rho = opts.rho
+
+	directions := make([]float64, len(opts.headings))
+	for i, heading := range opts.headings {
+		directions[i] = heading * math.Pi / 180
+	}
+	sweep := green_functions.Sweep{
+		Frequencies:    opts.frequencies,
+		FrequencyType:  opts.frequencyType,
+		WaveDirections: directions,
+		WaterDepths:    opts.waterDepths,
+		Workers:        opts.workers,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	fmt.Fprintf(stderr, "%s: %d faces, %s, %d frequencies, %d headings, %d water depths\n",
+		opts.mesh, mesh.GetNbFaces(), gf, len(opts.frequencies), len(opts.headings), len(opts.waterDepths))
+
+	stream, err := solver.Sweep(ctx, body, sweep)
+	if err != nil {
+		return err
+	}
+
+	results := make([][]*green_functions.FrequencyResult, len(opts.waterDepths))
+	for d := range results {
+		results[d] = make([]*green_functions.FrequencyResult, len(opts.frequencies))
+	}
+	total := len(opts.waterDepths) * len(opts.frequencies)
+	start := time.Now()
+	done := 0
+	for r := range stream {
+		if r.Err != nil {
+			cancel()
+			for range stream {
+				// Wait for the workers to stop
+			}
+			return fmt.Errorf("%s %g, water depth %g: %w", opts.frequencyType,
+				opts.frequencies[r.FrequencyIndex], opts.waterDepths[r.DepthIndex], r.Err)
+		}
+		results[r.DepthIndex][r.FrequencyIndex] = r.Result
+		done++
+		fmt.Fprintf(stderr, "[%d/%d] omega=%.4f rad/s, water depth %g m (%s elapsed)\n",
+			done, total, r.Result.Omega, r.Result.WaterDepth, time.Since(start).Round(time.Millisecond))
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := writeResults(opts.output, body, opts.headings, results); err != nil {
+		return err
+	}
+	datasets, err := writeDatasets(opts.output, body, gf, results)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Wrote %s, %s, %s and %s in %s\n", addedMassFile, radiationDampingFile, excitationForceFile,
+		strings.Join(datasets, ", "), opts.output)
+
+	if opts.wamit {
+		conventions := green_functions.WAMITConventions{Length: opts.ulen, Rho: opts.rho, Gravity: green_functions.Gravity}
+		paths, err := green_functions.ExportWAMIT(filepath.Join(opts.output, body.Name), body, results[0], conventions)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Wrote %s\n", strings.Join(paths, ", "))
+	}
+	return nil
+}
+
+// writeResults writes the added mass, radiation damping and excitation force tables in the output directory
+func writeResults(dir string, body *green_functions.FloatingBody, headings []float64, results [][]*green_functions.FrequencyResult) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	var addedMass, damping, excitation [][]string
+	format := func(x float64) string {
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	}
+	for _, byFrequency := range results {
+		for _, r := range byFrequency {
+			depth, omega, period := format(r.WaterDepth), format(r.Omega), format(2*math.Pi/r.Omega)
+			// The rows of the solver matrices are the influenced DOFs
+			for j, radiating := range body.DOFs {
+				for i, influenced := range body.DOFs {
+					addedMass = append(addedMass, []string{depth, omega, period, radiating.Name, influenced.Name,
+						format(r.AddedMass.At(i, j))})
+					damping = append(damping, []string{depth, omega, period, radiating.Name, influenced.Name,
+						format(r.RadiationDamping.At(i, j))})
+				}
+			}
+			for d, heading := range headings {
+				for j, influenced := range body.DOFs {
+					f := r.ExcitationForce.At(d, j)
+					excitation = append(excitation, []string{depth, omega, period, format(heading), influenced.Name,
+						format(real(f)), format(imag(f)), format(cmplx.Abs(f)), format(cmplx.Phase(f) * 180 / math.Pi)})
+				}
+			}
+		}
+	}
+
+	coefficientHeader := []string{"water_depth", "omega", "period", "radiating_dof", "influenced_dof", "value"}
+	if err := writeCSV(filepath.Join(dir, addedMassFile), coefficientHeader, addedMass); err != nil {
+		return err
+	}
+	if err := writeCSV(filepath.Join(dir, radiationDampingFile), coefficientHeader, damping); err != nil {
+		return err
+	}
+	return writeCSV(filepath.Join(dir, excitationForceFile),
+		[]string{"water_depth", "omega", "period", "wave_direction_deg", "influenced_dof", "real", "imag", "abs", "phase_deg"},
+		excitation)
+}
+
+// writeDatasets writes the results of each water depth as a NetCDF dataset readable by xarray, and returns the file names.
+// The file of a single water depth is results.nc, otherwise the water depth is added to the name, as in results_depth_10.nc.
+func writeDatasets(dir string, body *green_functions.FloatingBody, gf green_functions.AbstractGreenFunction,
+	results [][]*green_functions.FrequencyResult) ([]string, error) {
+	var names []string
+	for _, byFrequency := range results {
+		name := datasetFile
+		if len(results) > 1 {
+			name = fmt.Sprintf("results_depth_%g.nc", byFrequency[0].WaterDepth)
+		}
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		if err := green_functions.WriteDataset(f, body, byFrequency, gf); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if err := f.Close(); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func writeCSV(path string, header []string, rows [][]string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	w.Write(header)
+	w.WriteAll(rows)
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
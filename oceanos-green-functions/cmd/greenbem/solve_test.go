@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+
+	"github.com/capytaine/capytaine/go-capytaine/green_functions/green_functions"
+	"github.com/capytaine/capytaine/go-capytaine/green_functions/internal/netcdf"
+)
+
+// writeHemisphereGDF writes a coarse hemisphere mesh in the GDF format and returns its path
+func writeHemisphereGDF(t *testing.T) string {
+	t.Helper()
+	mesh, err := green_functions.NewHemisphereMesh(1, 3, 8)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintln(&b, "hemisphere")
+	fmt.Fprintln(&b, "1.0 9.81")
+	fmt.Fprintln(&b, "0 0")
+	fmt.Fprintln(&b, mesh.GetNbFaces())
+	for _, face := range mesh.Faces {
+		for _, v := range face {
+			p := mesh.Vertices[v]
+			fmt.Fprintf(&b, "%.17g %.17g %.17g\n", p[0], p[1], p[2])
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "hemisphere.gdf")
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return path
+}
+
+func readCSV(t *testing.T, path string) [][]string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return records
+}
+
+func TestParseList(t *testing.T) {
+	values, err := parseList("0.5, 1,inf,2:3:3")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []float64{0.5, 1, math.Inf(1), 2, 2.5, 3}
+	if len(values) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, values)
+	}
+	for i := range expected {
+		if values[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, values)
+		}
+	}
+
+	for _, invalid := range []string{"a", "1:2", "1:2:0", "1:2:x"} {
+		if _, err := parseList(invalid); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+}
+
+func TestRunSolve(t *testing.T) {
+	mesh := writeHemisphereGDF(t)
+	output := filepath.Join(t.TempDir(), "results")
+
+	var stdout, stderr bytes.Buffer
+	status := run([]string{"solve", "-mesh", mesh, "-frequencies", "1,2", "-headings", "0,90", "-output", output, "-workers", "2",
+		"-wamit", "-ulen", "2"}, &stdout, &stderr)
+	if status != 0 {
+		t.Fatalf("Expected status 0, got %d: %s", status, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "[2/2]") {
+		t.Errorf("Expected progress output, got %q", stderr.String())
+	}
+
+	// Header and 36 coefficients per frequency
+	for _, name := range []string{addedMassFile, radiationDampingFile} {
+		records := readCSV(t, filepath.Join(output, name))
+		if len(records) != 1+2*36 {
+			t.Errorf("%s: expected %d records, got %d", name, 1+2*36, len(records))
+		}
+	}
+	records := readCSV(t, filepath.Join(output, excitationForceFile))
+	if len(records) != 1+2*2*6 {
+		t.Errorf("%s: expected %d records, got %d", excitationForceFile, 1+2*2*6, len(records))
+	}
+
+	dataset, err := os.Open(filepath.Join(output, datasetFile))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer dataset.Close()
+	f, err := netcdf.Read(dataset)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if omega := f.Dimension("omega"); omega == nil || omega.Length != 2 || f.Variable("excitation_force") == nil {
+		t.Errorf("Expected a dataset with the excitation force at 2 frequencies, got %+v", f.Dimensions)
+	}
+
+	wamit, err := os.Open(filepath.Join(output, "hemisphere.1"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer wamit.Close()
+	radiation, err := green_functions.ReadWAMIT1(wamit, green_functions.WAMITConventions{Length: 2, Rho: green_functions.WaterDensity, Gravity: green_functions.Gravity})
+	if err != nil || len(radiation) != 2*36 {
+		t.Errorf("Expected the radiation coefficients of 2 frequencies in hemisphere.1, got %d (%v)", len(radiation), err)
+	}
+
+	// Heave added mass of the hemisphere is positive
+	for _, record := range readCSV(t, filepath.Join(output, addedMassFile))[1:] {
+		if record[3] == "Heave" && record[4] == "Heave" && strings.HasPrefix(record[5], "-") {
+			t.Errorf("Expected a positive heave added mass, got %s", record[5])
+		}
+	}
+}
+
+func TestWriteResults(t *testing.T) {
+	mesh, err := green_functions.NewHemisphereMesh(1, 3, 8)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	body := green_functions.NewRigidBody("hemisphere", mesh, [3]float64{})
+	result := &green_functions.FrequencyResult{
+		Omega:            1,
+		WaterDepth:       math.Inf(1),
+		AddedMass:        mat.NewDense(6, 6, nil),
+		RadiationDamping: mat.NewDense(6, 6, nil),
+		ExcitationForce:  mat.NewCDense(1, 6, nil),
+	}
+	// The rows of the solver matrices are the influenced DOFs: the force in surge due to a motion in pitch
+	result.AddedMass.Set(0, 4, 11)
+	result.AddedMass.Set(4, 0, 13)
+	result.RadiationDamping.Set(0, 4, 17)
+	result.RadiationDamping.Set(4, 0, 19)
+
+	dir := t.TempDir()
+	if err := writeResults(dir, body, []float64{0}, [][]*green_functions.FrequencyResult{{result}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for name, expected := range map[string]string{addedMassFile: "11", radiationDampingFile: "17"} {
+		found := false
+		for _, record := range readCSV(t, filepath.Join(dir, name))[1:] {
+			if record[3] == "Pitch" && record[4] == "Surge" {
+				found = true
+				if record[5] != expected {
+					t.Errorf("%s: expected %s for the radiating pitch and the influenced surge, got %s", name, expected, record[5])
+				}
+			}
+		}
+		if !found {
+			t.Errorf("%s: expected a record for the radiating pitch and the influenced surge", name)
+		}
+	}
+}
+
+func TestRunSolve_GreenFunctionConfig(t *testing.T) {
+	mesh := writeHemisphereGDF(t)
+	output := filepath.Join(t.TempDir(), "results")
+	config := filepath.Join(t.TempDir(), "gf.yaml")
+	if err := os.WriteFile(config, []byte("green_function: Delhommeau\ntabulation_nr: 328\ngf_singularities: low_freq\n"), 0o644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	status := run([]string{"solve", "-mesh", mesh, "-frequencies", "1", "-output", output,
+		"-gf-config", config, "-gf-option", "gf_singularities=high_freq"}, &stdout, &stderr)
+	if status != 0 {
+		t.Fatalf("Expected status 0, got %d: %s", status, stderr.String())
+	}
+
+	// The dataset records the settings of the Green function of the run
+	dataset, err := os.Open(filepath.Join(output, datasetFile))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer dataset.Close()
+	gf, err := green_functions.ReadDatasetGreenFunction(dataset)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	settings, _ := green_functions.SettingsOf(gf)
+	if settings["tabulation_nr"] != 328 || fmt.Sprint(settings["gf_singularities"]) != "high_freq" {
+		t.Errorf("Expected the settings of the configuration file and options, got %v", settings)
+	}
+}
+
+func TestRunSolve_FinGreen3D(t *testing.T) {
+	mesh := writeHemisphereGDF(t)
+
+	// FinGreen3D and Delhommeau compute the same finite depth coefficients
+	coefficients := map[string]map[string]float64{}
+	for _, gf := range []string{"fingreen3d", "delhommeau"} {
+		output := filepath.Join(t.TempDir(), gf)
+		var stdout, stderr bytes.Buffer
+		status := run([]string{"solve", "-mesh", mesh, "-frequencies", "1", "-depth", "3", "-output", output, "-gf", gf}, &stdout, &stderr)
+		if status != 0 {
+			t.Fatalf("%s: expected status 0, got %d: %s", gf, status, stderr.String())
+		}
+		coefficients[gf] = map[string]float64{}
+		for _, name := range []string{addedMassFile, radiationDampingFile} {
+			for _, record := range readCSV(t, filepath.Join(output, name))[1:] {
+				if record[3] == record[4] {
+					value, err := strconv.ParseFloat(record[5], 64)
+					if err != nil {
+						t.Fatalf("Unexpected error: %v", err)
+					}
+					coefficients[gf][name+" "+record[3]] = value
+				}
+			}
+		}
+	}
+	for key, expected := range coefficients["delhommeau"] {
+		if value := coefficients["fingreen3d"][key]; math.Abs(value-expected) > 1e-3*math.Abs(expected)+1e-6 {
+			t.Errorf("%s: expected %v, got %v", key, expected, value)
+		}
+	}
+}
+
+func TestRunSolve_UnimplementedGreenFunction(t *testing.T) {
+	mesh := writeHemisphereGDF(t)
+
+	// The evaluation of HAMS is not implemented, its tables would be all zero
+	for _, args := range [][]string{{"-gf", "hams"}} {
+		output := t.TempDir()
+		var stdout, stderr bytes.Buffer
+		status := run(append([]string{"solve", "-mesh", mesh, "-frequencies", "1", "-output", output}, args...), &stdout, &stderr)
+		if status == 0 || !strings.Contains(stderr.String(), "not implemented") {
+			t.Errorf("%v: expected a non-zero status and a not implemented error, got %d: %s", args, status, stderr.String())
+		}
+		if entries, _ := os.ReadDir(output); len(entries) != 0 {
+			t.Errorf("%v: expected no output, got %d files", args, len(entries))
+		}
+	}
+}
+
+func TestRunSolve_Errors(t *testing.T) {
+	mesh := writeHemisphereGDF(t)
+	output := t.TempDir()
+
+	for _, tc := range []struct {
+		name   string
+		args   []string
+		status int
+	}{
+		{"no command", nil, 2},
+		{"unknown command", []string{"mesh"}, 2},
+		{"missing mesh", []string{"solve", "-frequencies", "1", "-output", output}, 2},
+		{"missing frequencies", []string{"solve", "-mesh", mesh, "-output", output}, 2},
+		{"unknown green function", []string{"solve", "-mesh", mesh, "-frequencies", "1", "-output", output, "-gf", "rankine"}, 2},
+		{"unknown green function option", []string{"solve", "-mesh", mesh, "-frequencies", "1", "-output", output, "-gf-option", "depth=10"}, 2},
+		{"invalid green function option", []string{"solve", "-mesh", mesh, "-frequencies", "1", "-output", output, "-gf-option", "depth"}, 2},
+		{"missing green function config", []string{"solve", "-mesh", mesh, "-frequencies", "1", "-output", output, "-gf-config", mesh + ".yaml"}, 2},
+		{"fingreen3d in deep water", []string{"solve", "-mesh", mesh, "-frequencies", "1", "-output", output, "-gf", "fingreen3d"}, 2},
+		{"unreadable mesh", []string{"solve", "-mesh", mesh + ".dat", "-frequencies", "1", "-output", output}, 1},
+		{"wamit with several depths", []string{"solve", "-mesh", mesh, "-frequencies", "1", "-output", output, "-wamit", "-depth", "10,inf"}, 2},
+		{"invalid frequency", []string{"solve", "-mesh", mesh, "-frequencies", "-1", "-output", output}, 1},
+	} {
+		var stdout, stderr bytes.Buffer
+		if status := run(tc.args, &stdout, &stderr); status != tc.status {
+			t.Errorf("%s: expected status %d, got %d: %s", tc.name, tc.status, status, stderr.String())
+		}
+	}
+}
@@ -0,0 +1,187 @@
+// Package main - Samples of the performance metrics and their StatsD and Prometheus outputs
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/capytaine/capytaine/go-capytaine/green_functions/internal/statsd"
+	vegeta "github.com/tsenart/vegeta/v12/lib"
+)
+
+// metricLabel is a label of a sample, sent to StatsD as a name:value tag
+type metricLabel struct {
+	name, value string
+}
+
+// metricSample is the value of a gauge. Names use dots as separators, which become underscores for Prometheus.
+type metricSample struct {
+	name   string
+	help   string
+	value  float64
+	labels []metricLabel
+}
+
+// vegetaSamples summarizes an attack: success rate in percent, rates in requests per second,
+// latencies in milliseconds and mean sizes in bytes
+func vegetaSamples(m *vegeta.Metrics) []metricSample {
+	milliseconds := func(d time.Duration) float64 {
+		return d.Seconds() * 1000
+	}
+	samples := []metricSample{
+		{name: "requests", help: "Number of requests", value: float64(m.Requests)},
+		{name: "success_rate", help: "Percentage of successful requests", value: m.Success * 100},
+		{name: "rate", help: "Requests sent per second", value: m.Rate},
+		{name: "throughput", help: "Successful requests per second", value: m.Throughput},
+		{name: "error_types", help: "Number of distinct errors", value: float64(len(m.Errors))},
+		{name: "latency_mean", help: "Mean latency in milliseconds", value: milliseconds(m.Latencies.Mean)},
+		{name: "latency_p50", help: "Median latency in milliseconds", value: milliseconds(m.Latencies.P50)},
+		{name: "latency_p90", help: "90th percentile latency in milliseconds", value: milliseconds(m.Latencies.P90)},
+		{name: "latency_p95", help: "95th percentile latency in milliseconds", value: milliseconds(m.Latencies.P95)},
+		{name: "latency_p99", help: "99th percentile latency in milliseconds", value: milliseconds(m.Latencies.P99)},
+		{name: "latency_max", help: "Maximum latency in milliseconds", value: milliseconds(m.Latencies.Max)},
+		{name: "bytes_in_mean", help: "Mean size of the responses in bytes", value: m.BytesIn.Mean},
+		{name: "bytes_out_mean", help: "Mean size of the requests in bytes", value: m.BytesOut.Mean},
+	}
+
+	codes := make([]string, 0, len(m.StatusCodes))
+	for code := range m.StatusCodes {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		samples = append(samples, metricSample{
+			name:   "status_codes",
+			help:   "Number of responses by status code",
+			value:  float64(m.StatusCodes[code]),
+			labels: []metricLabel{{"code", code}},
+		})
+	}
+	return samples
+}
+
+// sendSamples sends the samples as StatsD gauges, with their labels as tags
+func sendSamples(c *statsd.Client, samples []metricSample) error {
+	for _, s := range samples {
+		tags := make([]string, len(s.labels))
+		for i, l := range s.labels {
+			tags[i] = statsd.Tag(l.name, l.value)
+		}
+		if err := c.Gauge(s.name, s.value, tags...); err != nil {
+			return err
+		}
+	}
+	return c.Flush()
+}
+
+// exposition is a text format read by Prometheus
+type exposition string
+
+const (
+	prometheusText exposition = "prometheus"
+	openMetrics    exposition = "openmetrics"
+)
+
+// writeExposition writes the samples as gauges in the Prometheus text format or in OpenMetrics,
+// prefixing the metric names and adding the constant labels to every sample
+func writeExposition(w io.Writer, format exposition, prefix string, constLabels []metricLabel, samples []metricSample) error {
+	bw := bufio.NewWriter(w)
+
+	// Samples of a metric are written together, after its HELP and TYPE lines
+	var names []string
+	byName := map[string][]metricSample{}
+	for _, s := range samples {
+		if _, ok := byName[s.name]; !ok {
+			names = append(names, s.name)
+		}
+		byName[s.name] = append(byName[s.name], s)
+	}
+
+	for _, name := range names {
+		metric := prometheusName(prefix, name)
+		fmt.Fprintf(bw, "# HELP %s %s\n", metric, escapeHelp(byName[name][0].help))
+		fmt.Fprintf(bw, "# TYPE %s gauge\n", metric)
+		for _, s := range byName[name] {
+			bw.WriteString(metric)
+			labels := append(append([]metricLabel(nil), constLabels...), s.labels...)
+			if len(labels) > 0 {
+				bw.WriteByte('{')
+				for i, l := range labels {
+					if i > 0 {
+						bw.WriteByte(',')
+					}
+					fmt.Fprintf(bw, "%s=\"%s\"", prometheusName("", l.name), escapeLabelValue(l.value))
+				}
+				bw.WriteByte('}')
+			}
+			bw.WriteByte(' ')
+			bw.WriteString(strconv.FormatFloat(s.value, 'g', -1, 64))
+			bw.WriteByte('\n')
+		}
+	}
+	if format == openMetrics {
+		bw.WriteString("# EOF\n")
+	}
+	return bw.Flush()
+}
+
+// prometheusName joins the prefix and the name with an underscore and replaces the invalid characters
+func prometheusName(prefix, name string) string {
+	if prefix != "" {
+		name = strings.TrimSuffix(prefix, ".") + "_" + name
+	}
+	name = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(value)
+}
+
+// writeTextfile writes an exposition to stdout for "-", or atomically replaces the file so that
+// the textfile collector of the node exporter never reads a partial file
+func writeTextfile(path string, stdout io.Writer, write func(io.Writer) error) error {
+	if path == "-" {
+		return write(stdout)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -1,39 +1,219 @@
+// Command send_to_statsd sends performance metrics to StatsD, or writes them for Prometheus.
+//
+// Usage:
+//
+//	vegeta attack -targets targets.txt -duration 30s | send_to_statsd -addr statsd:8125 -tags env:ci
+//	send_to_statsd -prefix greenfunction results.bin more_results.json
+//	go test -bench . ./green_functions | send_to_statsd -input gobench -tags branch:main
+//	send_to_statsd -input gobench -output prometheus -textfile /var/lib/node_exporter/greenfunction.prom results.txt
+//
+// The inputs are read from the files given as arguments, or from stdin when there are none or for "-".
+// With -input vegeta, the gob, JSON and CSV result encodings of vegeta are detected automatically and
+// all the results are summarized together. With -input gobench, every `go test -bench` result line gives
+// gauges tagged with the benchmark name, GOMAXPROCS and the goos, goarch, pkg and cpu configuration.
 package main
 
 import (
-	"bytes"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"io"
 	"net"
+	"os"
+	"strings"
 
+	"github.com/capytaine/capytaine/go-capytaine/green_functions/internal/benchfmt"
+	"github.com/capytaine/capytaine/go-capytaine/green_functions/internal/statsd"
 	vegeta "github.com/tsenart/vegeta/v12/lib"
 )
 
 func main() {
-	// Contoh: hasil benchmark dari file atau stdin
-	data := []byte(`PUT_BENCHMARK_RESULTS_HERE`)
-	dec := vegeta.NewDecoder(bytes.NewReader(data))
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
 
-	var m vegeta.Metrics
-	for {
-		var r vegeta.Result
-		if err := dec.Decode(&r); err != nil {
-			break
+// run executes the command and returns the exit status
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("send_to_statsd", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: send_to_statsd [flags] [file ...]")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+	input := fs.String("input", "vegeta", "input `format`: vegeta results or gobench output of go test -bench")
+	output := fs.String("output", "statsd", "output `format`: statsd, prometheus or openmetrics")
+	addr := fs.String("addr", "127.0.0.1:8125", "StatsD `address`")
+	textfile := fs.String("textfile", "-", "`file` written by the prometheus and openmetrics outputs, - for stdout")
+	prefix := fs.String("prefix", "greenfunction", "metric name `prefix`")
+	tagList := fs.String("tags", "", "comma separated `key:value` tags added to every metric")
+	maxPacketSize := fs.Int("max-packet-size", statsd.DefaultMaxPacketSize,
+		"maximum size in `bytes` of the UDP packets batching the metrics, 0 sends one metric per packet")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	tags, err := statsd.ParseTags(*tagList)
+	if err != nil {
+		fmt.Fprintf(stderr, "send_to_statsd: -tags: %v\n", err)
+		return 2
+	}
+	switch *output {
+	case "statsd", string(prometheusText), string(openMetrics):
+	default:
+		fmt.Fprintf(stderr, "send_to_statsd: -output: unknown format %q\n", *output)
+		return 2
+	}
+
+	inputs := fs.Args()
+	if len(inputs) == 0 {
+		inputs = []string{"-"}
+	}
+
+	var samples []metricSample
+	switch *input {
+	case "vegeta":
+		samples, err = readVegetaInputs(inputs, stdin, stderr)
+	case "gobench":
+		samples, err = readGoBenchInputs(inputs, stdin, stderr)
+	default:
+		fmt.Fprintf(stderr, "send_to_statsd: -input: unknown format %q\n", *input)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "send_to_statsd: %v\n", err)
+		return 1
+	}
+
+	if *output != "statsd" {
+		labels := make([]metricLabel, len(tags))
+		for i, tag := range tags {
+			name, value, ok := strings.Cut(tag, ":")
+			if !ok {
+				fmt.Fprintf(stderr, "send_to_statsd: -tags: %q has no value, expected key:value\n", tag)
+				return 2
+			}
+			labels[i] = metricLabel{name, value}
+		}
+		err := writeTextfile(*textfile, stdout, func(w io.Writer) error {
+			return writeExposition(w, exposition(*output), *prefix, labels, samples)
+		})
+		if err != nil {
+			fmt.Fprintf(stderr, "send_to_statsd: %v\n", err)
+			return 1
 		}
-		m.Add(&r)
+		if *textfile != "-" {
+			fmt.Fprintf(stdout, "Wrote %d samples to %s\n", len(samples), *textfile)
+		}
+		return 0
 	}
 
-	// Hubungkan ke StatsD
-	conn, err := net.Dial("udp", "127.0.0.1:8125")
+	conn, err := net.Dial("udp", *addr)
 	if err != nil {
-		log.Fatal(err)
+		fmt.Fprintf(stderr, "send_to_statsd: %v\n", err)
+		return 1
 	}
 	defer conn.Close()
 
-	// Contoh kirim success rate
-	fmt.Fprintf(conn, "greenfunction.success_rate:%.2f|g\n", m.Success*100)
-	fmt.Fprintf(conn, "greenfunction.throughput:%.2f|g\n", m.Throughput)
-	fmt.Fprintf(conn, "greenfunction.latency_mean:%.2f|g\n", m.Latencies.Mean.Seconds()*1000) // ms
+	client := statsd.NewClient(conn, *prefix, tags, *maxPacketSize)
+	if err := sendSamples(client, samples); err != nil {
+		fmt.Fprintf(stderr, "send_to_statsd: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "Sent %d metrics in %d packets to StatsD at %s\n", client.Metrics(), client.Packets(), *addr)
+	return 0
+}
+
+func displayName(input string) string {
+	if input == "-" {
+		return "stdin"
+	}
+	return input
+}
+
+// openInput opens a file, or returns stdin for "-"
+func openInput(input string, stdin io.Reader) (io.ReadCloser, error) {
+	if input == "-" {
+		return io.NopCloser(stdin), nil
+	}
+	return os.Open(input)
+}
+
+// readVegetaInputs summarizes the results of all the inputs
+func readVegetaInputs(inputs []string, stdin io.Reader, stderr io.Writer) ([]metricSample, error) {
+	var m vegeta.Metrics
+	for _, input := range inputs {
+		n, err := decodeInput(input, stdin, &m)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(stderr, "%s: %d results\n", displayName(input), n)
+	}
+	m.Close()
+	if m.Requests == 0 {
+		return nil, errors.New("no results to send")
+	}
+	return vegetaSamples(&m), nil
+}
+
+// readGoBenchInputs parses the benchmark results of all the inputs
+func readGoBenchInputs(inputs []string, stdin io.Reader, stderr io.Writer) ([]metricSample, error) {
+	var results []benchfmt.Result
+	for _, input := range inputs {
+		r, err := openInput(input, stdin)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := benchfmt.Parse(r)
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", displayName(input), err)
+		}
+		fmt.Fprintf(stderr, "%s: %d benchmark results\n", displayName(input), len(parsed))
+		results = append(results, parsed...)
+	}
+	if len(results) == 0 {
+		return nil, errors.New("no benchmark results to send")
+	}
+	return benchmarkSamples(results), nil
+}
+
+// decodeInput adds the results of a file, or of stdin for "-", to the metrics and returns the number of results
+func decodeInput(input string, stdin io.Reader, m *vegeta.Metrics) (int, error) {
+	r, err := openInput(input, stdin)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	n, err := decodeResults(r, m)
+	if err != nil {
+		return n, fmt.Errorf("%s: %w", displayName(input), err)
+	}
+	return n, nil
+}
 
-	fmt.Println("Metrics sent to StatsD")
+// decodeResults adds all the results of a vegeta result stream to the metrics.
+// Unlike the end of the stream, a decoding error is reported with the position of the faulty result.
+func decodeResults(r io.Reader, m *vegeta.Metrics) (int, error) {
+	dec := vegeta.DecoderFor(r)
+	if dec == nil {
+		return 0, errors.New("empty input or unknown result encoding, expected vegeta gob, JSON or CSV results")
+	}
+
+	n := 0
+	for {
+		var result vegeta.Result
+		err := dec.Decode(&result)
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, fmt.Errorf("result %d: %w", n+1, err)
+		}
+		m.Add(&result)
+		n++
+	}
 }
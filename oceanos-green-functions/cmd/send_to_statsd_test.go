@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	vegeta "github.com/tsenart/vegeta/v12/lib"
+)
+
+// encodeResults encodes n successful results with the given encoder factory
+func encodeResults(t *testing.T, n int, newEncoder func(w *bytes.Buffer) vegeta.Encoder) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	enc := newEncoder(&buf)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		r := vegeta.Result{
+			Code:      200,
+			Timestamp: start.Add(time.Duration(i) * 10 * time.Millisecond),
+			Latency:   time.Duration(i+1) * time.Millisecond,
+			Method:    "POST",
+			URL:       "http://localhost:8080/solve",
+		}
+		if err := enc.Encode(&r); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeResults(t *testing.T) {
+	for name, newEncoder := range map[string]func(w *bytes.Buffer) vegeta.Encoder{
+		"gob":  func(w *bytes.Buffer) vegeta.Encoder { return vegeta.NewEncoder(w) },
+		"json": func(w *bytes.Buffer) vegeta.Encoder { return vegeta.NewJSONEncoder(w) },
+		"csv":  func(w *bytes.Buffer) vegeta.Encoder { return vegeta.NewCSVEncoder(w) },
+	} {
+		var m vegeta.Metrics
+		n, err := decodeResults(bytes.NewReader(encodeResults(t, 10, newEncoder)), &m)
+		if err != nil || n != 10 {
+			t.Errorf("%s: expected 10 results, got %d (%v)", name, n, err)
+		}
+	}
+
+	// A corrupted result is reported instead of ending the stream silently
+	data := encodeResults(t, 3, func(w *bytes.Buffer) vegeta.Encoder { return vegeta.NewJSONEncoder(w) })
+	data = append(data, []byte("{\"code\": \"not a number\"}\n")...)
+	var m vegeta.Metrics
+	n, err := decodeResults(bytes.NewReader(data), &m)
+	if err == nil || n != 3 || !strings.Contains(err.Error(), "result 4") {
+		t.Errorf("Expected an error at result 4, got %d results and %v", n, err)
+	}
+
+	if _, err := decodeResults(strings.NewReader(""), &m); err == nil {
+		t.Error("Expected error for empty input")
+	}
+}
+
+func TestRun(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Cannot listen on UDP: %v", err)
+	}
+	defer conn.Close()
+
+	path := filepath.Join(t.TempDir(), "results.json")
+	data := encodeResults(t, 20, func(w *bytes.Buffer) vegeta.Encoder { return vegeta.NewJSONEncoder(w) })
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	stdin := bytes.NewReader(encodeResults(t, 5, func(w *bytes.Buffer) vegeta.Encoder { return vegeta.NewEncoder(w) }))
+	status := run([]string{"-addr", conn.LocalAddr().String(), "-tags", "env:test", path, "-"}, stdin, &stdout, &stderr)
+	if status != 0 {
+		t.Fatalf("Expected status 0, got %d: %s", status, stderr.String())
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 65536)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	packet := string(buf[:n])
+	if !strings.Contains(packet, "greenfunction.requests:25|g|#env:test") {
+		t.Errorf("Expected the number of requests in %q", packet)
+	}
+	if !strings.Contains(packet, "greenfunction.success_rate:100|g|#env:test") {
+		t.Errorf("Expected the success rate in %q", packet)
+	}
+
+	if status := run([]string{filepath.Join(t.TempDir(), "missing.json")}, nil, &stdout, &stderr); status != 1 {
+		t.Errorf("Expected status 1 for a missing file, got %d", status)
+	}
+	if status := run([]string{"-max-packet-size", "x"}, nil, &stdout, &stderr); status != 2 {
+		t.Errorf("Expected status 2 for an invalid flag, got %d", status)
+	}
+}
+
+func TestRun_GoBench(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Cannot listen on UDP: %v", err)
+	}
+	defer conn.Close()
+
+	var stdout, stderr bytes.Buffer
+	status := run([]string{"-input", "gobench", "-addr", conn.LocalAddr().String()}, strings.NewReader(goBenchOutput), &stdout, &stderr)
+	if status != 0 {
+		t.Fatalf("Expected status 0, got %d: %s", status, stderr.String())
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 65536)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "greenfunction.benchmark.ns_per_op:140|g|#benchmark:Delhommeau_Evaluate_Small,procs:12," +
+		"pkg:github.com/capytaine/capytaine/go-capytaine/green_functions,goos:windows,goarch:amd64,cpu:12th_Gen_Intel(R)_Core(TM)_i5-12450HX"
+	if !strings.Contains(string(buf[:n]), expected) {
+		t.Errorf("Expected %q in %q", expected, buf[:n])
+	}
+
+	path := filepath.Join(t.TempDir(), "greenfunction.prom")
+	status = run([]string{"-input", "gobench", "-output", "prometheus", "-textfile", path, "-tags", "branch:main"},
+		strings.NewReader(goBenchOutput), &stdout, &stderr)
+	if status != 0 {
+		t.Fatalf("Expected status 0, got %d: %s", status, stderr.String())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(string(data), `greenfunction_benchmark_allocs_per_op{branch="main",benchmark="Delhommeau_Evaluate_Small",procs="12",`) {
+		t.Errorf("Unexpected textfile:\n%s", data)
+	}
+
+	if status := run([]string{"-input", "gobench", "-output", "prometheus"}, strings.NewReader("PASS\n"), &stdout, &stderr); status != 1 {
+		t.Errorf("Expected status 1 without benchmark results, got %d", status)
+	}
+	if status := run([]string{"-output", "graphite"}, nil, &stdout, &stderr); status != 2 {
+		t.Errorf("Expected status 2 for an unknown output, got %d", status)
+	}
+}
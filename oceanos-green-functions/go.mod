@@ -7,6 +7,7 @@ toolchain go1.24.6
 require (
 	github.com/tsenart/vegeta/v12 v12.12.0
 	gonum.org/v1/gonum v0.16.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -7,8 +7,12 @@ package green_functions
 import (
 	"errors"
 	"gonum.org/v1/gonum/mat"
+	"math"
 )
 
+// nearFieldRatio is the distance, in panel radii, below which the Rankine terms are integrated exactly
+const nearFieldRatio = 7.0
+
 // GreenFunctionEvaluationError represents errors during Green function evaluation
 type GreenFunctionEvaluationError struct {
 	Message string
@@ -33,6 +37,15 @@ type MeshLike interface {
 	GetNbFaces() int
 }
 
+// PanelMesh is a MeshLike that also exposes the geometry of its panels,
+// allowing the Rankine terms to be integrated exactly on neighbouring panels
+type PanelMesh interface {
+	MeshLike
+	GetFacesAreas() []float64
+	GetFacesRadii() []float64
+	GetFaceVertices(i int) [][3]float64
+}
+
 // AbstractGreenFunction defines the interface for Green function implementations
 type AbstractGreenFunction interface {
 	// Evaluate computes the Green function between two meshes
@@ -46,6 +59,21 @@ type AbstractGreenFunction interface {
 	SetFloatingPointPrecision(precision FloatingPointPrecision)
 }
 
+// SplitGreenFunction is implemented by Green functions whose Rankine terms do not depend on the wavenumber.
+// For a finite positive wavenumber, Evaluate is the sum of EvaluateRankine and EvaluateWave,
+// so that the Rankine terms can be computed once for a range of frequencies.
+type SplitGreenFunction interface {
+	AbstractGreenFunction
+
+	// EvaluateRankine computes the Rankine and reflected Rankine terms, which only depend on the geometry
+	EvaluateRankine(mesh1, mesh2 interface{}, freeSurface float64, waterDepth float64,
+		adjointDoubleLayer bool, earlyDotProduct bool) (*mat.CDense, *mat.CDense, error)
+
+	// EvaluateWave computes the remaining wave terms for a finite positive wavenumber
+	EvaluateWave(mesh1, mesh2 interface{}, freeSurface float64, waterDepth float64,
+		wavenumber complex128, adjointDoubleLayer bool, earlyDotProduct bool) (*mat.CDense, *mat.CDense, error)
+}
+
 // BaseGreenFunction provides common functionality for Green function implementations
 type BaseGreenFunction struct {
 	FloatingPointPrecision FloatingPointPrecision
@@ -129,3 +157,165 @@ func (bgf *BaseGreenFunction) initMatrices(rows, cols int, earlyDotProduct bool)
 
 	return S, K, nil
 }
+
+// rankineImage is an image of the Rankine source multiplied by weight: the mirror image of the source
+// with respect to the horizontal plane z = plane, or with translated, the source moved vertically by shift
+type rankineImage struct {
+	plane      float64
+	weight     float64
+	translated bool
+	shift      float64
+}
+
+// waveTerm computes the wave part of a Green function between a point x and a source point ξ,
+// together with its gradients with respect to x and to ξ
+type waveTerm func(x, xi [3]float64) (complex128, [3]complex128, [3]complex128)
+
+// greenFunctionTerms decomposes a Green function as
+//
+//	G(x, ξ) = -1/(4π) [ 1/|x - ξ| + Σ sign/|x - mirror(ξ)| + wave(x, ξ) ]
+//
+// The waveImages are integrated like the images but depend on the wavenumber, so they belong to the wave part.
+// With waveOnly, the direct Rankine term is left out, so that the wave term can be added to Rankine terms computed once.
+type greenFunctionTerms struct {
+	images     []rankineImage
+	waveImages []rankineImage
+	wave       waveTerm
+	waveOnly   bool
+}
+
+// fillMatrices integrates the Green function terms over the faces of mesh2.
+// The Rankine terms are integrated exactly on the neighbouring faces when mesh2 is a PanelMesh,
+// the other terms are approximated with a one-point quadrature at the face centers.
+// Meshes without panel geometry are treated as point sources of unit weight, without self-interaction.
+func (bgf *BaseGreenFunction) fillMatrices(S, K *mat.CDense, colocationPoints, earlyDotProductNormals *mat.Dense,
+	mesh2 MeshLike, terms greenFunctionTerms, adjointDoubleLayer bool, earlyDotProduct bool) {
+
+	rows, _ := colocationPoints.Dims()
+	cols := mesh2.GetNbFaces()
+	centers := mesh2.GetFacesCenters()
+	normals := mesh2.GetFacesNormals()
+
+	panels, isPanelMesh := mesh2.(PanelMesh)
+	var areas, radii []float64
+	if isPanelMesh {
+		areas = panels.GetFacesAreas()
+		radii = panels.GetFacesRadii()
+	}
+
+	for j := 0; j < cols; j++ {
+		xi := rowVector(centers, j)
+		normal := rowVector(normals, j)
+
+		area := 1.0
+		var vertices [][3]float64
+		if isPanelMesh {
+			area = areas[j]
+			vertices = panels.GetFaceVertices(j)
+		}
+
+		// rankine integrates 1/|y - ξ| over the face, with its gradient with respect to y
+		rankine := func(y [3]float64) (float64, [3]float64) {
+			d := sub3(y, xi)
+			r := norm3(d)
+			if isPanelMesh && r < nearFieldRatio*radii[j] {
+				return rankinePanelIntegral(y, vertices, normal)
+			}
+			if r == 0 {
+				return 0, [3]float64{}
+			}
+			return area / r, scale3(d, -area/(r*r*r))
+		}
+
+		for i := 0; i < rows; i++ {
+			x := rowVector(colocationPoints, i)
+
+			var g complex128
+			var gradX, gradXi [3]complex128
+			if !terms.waveOnly {
+				value, gradient := rankine(x)
+				g = complex(value, 0)
+				gradX = realToComplex3(gradient)
+				gradXi = realToComplex3(scale3(gradient, -1))
+			}
+
+			addImage := func(image rankineImage) {
+				if image.translated {
+					value, gradient := rankine([3]float64{x[0], x[1], x[2] - image.shift})
+					g += complex(image.weight*value, 0)
+					gradX = addComplex3(gradX, realToComplex3(scale3(gradient, image.weight)))
+					gradXi = addComplex3(gradXi, realToComplex3(scale3(gradient, -image.weight)))
+					return
+				}
+				mirrored := [3]float64{x[0], x[1], 2*image.plane - x[2]}
+				value, gradient := rankine(mirrored)
+				g += complex(image.weight*value, 0)
+				gradX = addComplex3(gradX, realToComplex3(scale3([3]float64{gradient[0], gradient[1], -gradient[2]}, image.weight)))
+				gradXi = addComplex3(gradXi, realToComplex3(scale3(gradient, -image.weight)))
+			}
+			for _, image := range terms.images {
+				addImage(image)
+			}
+			for _, image := range terms.waveImages {
+				addImage(image)
+			}
+
+			if terms.wave != nil {
+				w, wX, wXi := terms.wave(x, xi)
+				a := complex(area, 0)
+				g += a * w
+				for c := 0; c < 3; c++ {
+					gradX[c] += a * wX[c]
+					gradXi[c] += a * wXi[c]
+				}
+			}
+
+			coef := complex(-1/(4*math.Pi), 0)
+			S.Set(i, j, bgf.round(coef*g))
+
+			kGradient := gradXi
+			if adjointDoubleLayer {
+				kGradient = gradX
+			}
+
+			if earlyDotProduct {
+				var n [3]float64
+				if adjointDoubleLayer {
+					n = rowVector(earlyDotProductNormals, i)
+				} else {
+					n = rowVector(earlyDotProductNormals, j)
+				}
+				var k complex128
+				for c := 0; c < 3; c++ {
+					k += kGradient[c] * complex(n[c], 0)
+				}
+				K.Set(i, j, bgf.round(coef*k))
+			} else {
+				for c := 0; c < 3; c++ {
+					K.Set(i, 3*j+c, bgf.round(coef*kGradient[c]))
+				}
+			}
+		}
+	}
+}
+
+// round truncates a value to the floating point precision of the Green function
+func (bgf *BaseGreenFunction) round(value complex128) complex128 {
+	if bgf.FloatingPointPrecision == Float32 {
+		return complex128(complex64(value))
+	}
+	return value
+}
+
+func rowVector(m *mat.Dense, i int) [3]float64 {
+	row := m.RawRowView(i)
+	return [3]float64{row[0], row[1], row[2]}
+}
+
+func realToComplex3(a [3]float64) [3]complex128 {
+	return [3]complex128{complex(a[0], 0), complex(a[1], 0), complex(a[2], 0)}
+}
+
+func addComplex3(a, b [3]complex128) [3]complex128 {
+	return [3]complex128{a[0] + b[0], a[1] + b[1], a[2] + b[2]}
+}
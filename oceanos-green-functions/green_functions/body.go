@@ -0,0 +1,282 @@
+// Package green_functions - Floating bodies and rigid body degrees of freedom
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package green_functions
+
+import (
+	"errors"
+	"gonum.org/v1/gonum/mat"
+)
+
+// WaterDensity is the default density of water in kg/m^3
+const WaterDensity = 1000.0
+
+// DOF represents a rigid body degree of freedom: a translation along Axis,
+// or a rotation around Axis passing through the rotation center of the body
+type DOF struct {
+	Name     string
+	Axis     [3]float64
+	Rotation bool
+}
+
+// RigidBodyDOFs returns the six degrees of freedom of a rigid body
+func RigidBodyDOFs() []DOF {
+	return []DOF{
+		{Name: "Surge", Axis: [3]float64{1, 0, 0}},
+		{Name: "Sway", Axis: [3]float64{0, 1, 0}},
+		{Name: "Heave", Axis: [3]float64{0, 0, 1}},
+		{Name: "Roll", Axis: [3]float64{1, 0, 0}, Rotation: true},
+		{Name: "Pitch", Axis: [3]float64{0, 1, 0}, Rotation: true},
+		{Name: "Yaw", Axis: [3]float64{0, 0, 1}, Rotation: true},
+	}
+}
+
+// Motion returns the displacement of a point for a unit amplitude of the degree of freedom
+func (dof DOF) Motion(point, rotationCenter [3]float64) [3]float64 {
+	if dof.Rotation {
+		return cross3(dof.Axis, sub3(point, rotationCenter))
+	}
+	return dof.Axis
+}
+
+// generalizedMotion returns the components of the degree of freedom on the six rigid body motions
+func (dof DOF) generalizedMotion() [6]float64 {
+	var g [6]float64
+	offset := 0
+	if dof.Rotation {
+		offset = 3
+	}
+	copy(g[offset:offset+3], dof.Axis[:])
+	return g
+}
+
+// FloatingBody is a rigid body described by the mesh of its wetted surface
+type FloatingBody struct {
+	Name           string
+	Mesh           *Mesh
+	DOFs           []DOF
+	RotationCenter [3]float64
+	CenterOfMass   [3]float64
+	Mass           float64
+
+	// InertiaMatrix and HydrostaticStiffness are (nDOFs, nDOFs) matrices, required to compute motions
+	InertiaMatrix        *mat.Dense
+	HydrostaticStiffness *mat.Dense
+}
+
+// NewRigidBody creates a floating body with the six rigid body degrees of freedom
+func NewRigidBody(name string, mesh *Mesh, rotationCenter [3]float64) *FloatingBody {
+	return &FloatingBody{
+		Name:           name,
+		Mesh:           mesh,
+		DOFs:           RigidBodyDOFs(),
+		RotationCenter: rotationCenter,
+		CenterOfMass:   rotationCenter,
+	}
+}
+
+// NbDOFs returns the number of degrees of freedom of the body
+func (b *FloatingBody) NbDOFs() int {
+	return len(b.DOFs)
+}
+
+// DOFIndex returns the index of the degree of freedom with the given name, or -1
+func (b *FloatingBody) DOFIndex(name string) int {
+	for i, dof := range b.DOFs {
+		if dof.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// dofNormalVelocities returns, for each degree of freedom, the normal velocity of each face
+// for a unit velocity of the degree of freedom
+func (b *FloatingBody) dofNormalVelocities() [][]float64 {
+	centers := b.Mesh.GetFacesCenters()
+	normals := b.Mesh.GetFacesNormals()
+	nFaces := b.Mesh.GetNbFaces()
+
+	velocities := make([][]float64, len(b.DOFs))
+	for d, dof := range b.DOFs {
+		velocities[d] = make([]float64, nFaces)
+		for i := 0; i < nFaces; i++ {
+			motion := dof.Motion(rowVector(centers, i), b.RotationCenter)
+			velocities[d][i] = dot3(motion, rowVector(normals, i))
+		}
+	}
+	return velocities
+}
+
+// rigidMotion returns the complex translation and rotation amplitudes of the body
+// for the given complex amplitudes of its degrees of freedom
+func (b *FloatingBody) rigidMotion(amplitudes []complex128) ([3]complex128, [3]complex128) {
+	var translation, rotation [3]complex128
+	for j, dof := range b.DOFs {
+		g := dof.generalizedMotion()
+		for c := 0; c < 3; c++ {
+			translation[c] += complex(g[c], 0) * amplitudes[j]
+			rotation[c] += complex(g[3+c], 0) * amplitudes[j]
+		}
+	}
+	return translation, rotation
+}
+
+// projectOnDOFs restricts a (6, 6) rigid body matrix to the degrees of freedom of the body
+func (b *FloatingBody) projectOnDOFs(rigid *mat.Dense) *mat.Dense {
+	n := len(b.DOFs)
+	projected := mat.NewDense(n, n, nil)
+	for i, dofI := range b.DOFs {
+		gi := dofI.generalizedMotion()
+		for j, dofJ := range b.DOFs {
+			gj := dofJ.generalizedMotion()
+			var v float64
+			for p := 0; p < 6; p++ {
+				for q := 0; q < 6; q++ {
+					v += gi[p] * rigid.At(p, q) * gj[q]
+				}
+			}
+			projected.Set(i, j, v)
+		}
+	}
+	return projected
+}
+
+// Hydrostatics holds the hydrostatic properties of a floating body
+type Hydrostatics struct {
+	DisplacedVolume   float64
+	CenterOfBuoyancy  [3]float64
+	WaterplaneArea    float64
+	WaterplaneCenter  [2]float64
+	StiffnessMatrix   *mat.Dense
+	DisplacedMass     float64
+	WaterplaneInertia [3]float64 // ∫x² dA, ∫y² dA and ∫xy dA relative to the rotation center
+}
+
+// hullIntegral computes ∫ f n_z dS over the wetted surface. By the divergence theorem, with the
+// free surface at z = 0, it equals the volume integral of ∂f/∂z over the displaced volume, or
+// minus the waterplane integral of f when f does not depend on z.
+func (b *FloatingBody) hullIntegral(f func(p [3]float64) float64) float64 {
+	centers := b.Mesh.GetFacesCenters()
+	normals := b.Mesh.GetFacesNormals()
+	areas := b.Mesh.GetFacesAreas()
+	var sum float64
+	for i := range areas {
+		sum += f(rowVector(centers, i)) * normals.At(i, 2) * areas[i]
+	}
+	return sum
+}
+
+// ComputeHydrostatics computes the displaced volume, the center of buoyancy and the linear hydrostatic
+// stiffness matrix of the body around its rotation center, with the free surface at z = 0.
+// The gravity terms use the Mass and CenterOfMass of the body.
+func (b *FloatingBody) ComputeHydrostatics(rho float64) (*Hydrostatics, error) {
+	c := b.RotationCenter
+
+	volume := b.hullIntegral(func(p [3]float64) float64 { return p[2] })
+	if volume <= 0 {
+		return nil, errors.New("body has no displaced volume, check that the normals point into the fluid")
+	}
+	buoyancy := [3]float64{
+		b.hullIntegral(func(p [3]float64) float64 { return p[0] * p[2] }) / volume,
+		b.hullIntegral(func(p [3]float64) float64 { return p[1] * p[2] }) / volume,
+		b.hullIntegral(func(p [3]float64) float64 { return p[2] * p[2] / 2 }) / volume,
+	}
+
+	// Waterplane integrals relative to the rotation center
+	area := -b.hullIntegral(func(p [3]float64) float64 { return 1 })
+	sx := -b.hullIntegral(func(p [3]float64) float64 { return p[0] - c[0] })
+	sy := -b.hullIntegral(func(p [3]float64) float64 { return p[1] - c[1] })
+	ixx := -b.hullIntegral(func(p [3]float64) float64 { return (p[0] - c[0]) * (p[0] - c[0]) })
+	iyy := -b.hullIntegral(func(p [3]float64) float64 { return (p[1] - c[1]) * (p[1] - c[1]) })
+	ixy := -b.hullIntegral(func(p [3]float64) float64 { return (p[0] - c[0]) * (p[1] - c[1]) })
+
+	rg := rho * Gravity
+	mg := b.Mass * Gravity
+	xb, yb, zb := buoyancy[0]-c[0], buoyancy[1]-c[1], buoyancy[2]-c[2]
+	xg, yg, zg := b.CenterOfMass[0]-c[0], b.CenterOfMass[1]-c[1], b.CenterOfMass[2]-c[2]
+
+	stiffness := mat.NewDense(6, 6, nil)
+	stiffness.Set(2, 2, rg*area)
+	stiffness.Set(2, 3, rg*sy)
+	stiffness.Set(3, 2, rg*sy)
+	stiffness.Set(2, 4, -rg*sx)
+	stiffness.Set(4, 2, -rg*sx)
+	stiffness.Set(3, 3, rg*(iyy+volume*zb)-mg*zg)
+	stiffness.Set(4, 4, rg*(ixx+volume*zb)-mg*zg)
+	stiffness.Set(3, 4, -rg*ixy)
+	stiffness.Set(4, 3, -rg*ixy)
+	stiffness.Set(3, 5, -rg*volume*xb+mg*xg)
+	stiffness.Set(4, 5, -rg*volume*yb+mg*yg)
+
+	return &Hydrostatics{
+		DisplacedVolume:   volume,
+		CenterOfBuoyancy:  buoyancy,
+		WaterplaneArea:    area,
+		WaterplaneCenter:  [2]float64{c[0] + sx/area, c[1] + sy/area},
+		StiffnessMatrix:   b.projectOnDOFs(stiffness),
+		DisplacedMass:     rho * volume,
+		WaterplaneInertia: [3]float64{ixx, iyy, ixy},
+	}, nil
+}
+
+// ComputeRigidBodyInertia computes the mass, center of mass and inertia matrix of a body of uniform density
+// filling the displaced volume, so that the body is in equilibrium. The inertia matrix is relative to the rotation center.
+func (b *FloatingBody) ComputeRigidBodyInertia(rho float64) (float64, [3]float64, *mat.Dense, error) {
+	c := b.RotationCenter
+
+	volume := b.hullIntegral(func(p [3]float64) float64 { return p[2] })
+	if volume <= 0 {
+		return 0, [3]float64{}, nil, errors.New("body has no displaced volume, check that the normals point into the fluid")
+	}
+	mass := rho * volume
+	centerOfMass := [3]float64{
+		b.hullIntegral(func(p [3]float64) float64 { return p[0] * p[2] }) / volume,
+		b.hullIntegral(func(p [3]float64) float64 { return p[1] * p[2] }) / volume,
+		b.hullIntegral(func(p [3]float64) float64 { return p[2] * p[2] / 2 }) / volume,
+	}
+
+	// Volume integrals of the second moments, from primitives in z vanishing at z = 0
+	xx := b.hullIntegral(func(p [3]float64) float64 { return (p[0] - c[0]) * (p[0] - c[0]) * p[2] })
+	yy := b.hullIntegral(func(p [3]float64) float64 { return (p[1] - c[1]) * (p[1] - c[1]) * p[2] })
+	zz := b.hullIntegral(func(p [3]float64) float64 {
+		return ((p[2]-c[2])*(p[2]-c[2])*(p[2]-c[2]) + c[2]*c[2]*c[2]) / 3
+	})
+	xy := b.hullIntegral(func(p [3]float64) float64 { return (p[0] - c[0]) * (p[1] - c[1]) * p[2] })
+	xz := b.hullIntegral(func(p [3]float64) float64 {
+		return (p[0] - c[0]) * ((p[2]-c[2])*(p[2]-c[2]) - c[2]*c[2]) / 2
+	})
+	yz := b.hullIntegral(func(p [3]float64) float64 {
+		return (p[1] - c[1]) * ((p[2]-c[2])*(p[2]-c[2]) - c[2]*c[2]) / 2
+	})
+
+	g := sub3(centerOfMass, c)
+	inertia := mat.NewDense(6, 6, nil)
+	for i := 0; i < 3; i++ {
+		inertia.Set(i, i, mass)
+	}
+	// Coupling between translations and rotations: -m [g]x and m [g]x
+	skew := [3][3]float64{
+		{0, -g[2], g[1]},
+		{g[2], 0, -g[0]},
+		{-g[1], g[0], 0},
+	}
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			inertia.Set(i, 3+j, -mass*skew[i][j])
+			inertia.Set(3+i, j, mass*skew[i][j])
+		}
+	}
+	inertia.Set(3, 3, rho*(yy+zz))
+	inertia.Set(4, 4, rho*(xx+zz))
+	inertia.Set(5, 5, rho*(xx+yy))
+	inertia.Set(3, 4, -rho*xy)
+	inertia.Set(4, 3, -rho*xy)
+	inertia.Set(3, 5, -rho*xz)
+	inertia.Set(5, 3, -rho*xz)
+	inertia.Set(4, 5, -rho*yz)
+	inertia.Set(5, 4, -rho*yz)
+
+	return mass, centerOfMass, b.projectOnDOFs(inertia), nil
+}
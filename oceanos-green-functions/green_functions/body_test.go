@@ -0,0 +1,104 @@
+package green_functions
+
+import (
+	"math"
+	"testing"
+)
+
+// newTestHemisphere creates a freely floating hemisphere of unit radius in equilibrium
+func newTestHemisphere(t testing.TB, nTheta, nPhi int) *FloatingBody {
+	mesh, err := NewHemisphereMesh(1, nTheta, nPhi)
+	if err != nil {
+		t.Fatalf("Failed to create mesh: %v", err)
+	}
+	body := NewRigidBody("hemisphere", mesh, [3]float64{0, 0, 0})
+
+	mass, centerOfMass, inertia, err := body.ComputeRigidBodyInertia(WaterDensity)
+	if err != nil {
+		t.Fatalf("Failed to compute inertia: %v", err)
+	}
+	body.Mass, body.CenterOfMass, body.InertiaMatrix = mass, centerOfMass, inertia
+
+	hydrostatics, err := body.ComputeHydrostatics(WaterDensity)
+	if err != nil {
+		t.Fatalf("Failed to compute hydrostatics: %v", err)
+	}
+	body.HydrostaticStiffness = hydrostatics.StiffnessMatrix
+	return body
+}
+
+func TestNewRigidBody(t *testing.T) {
+	mesh, _ := NewHemisphereMesh(1, 4, 8)
+	body := NewRigidBody("hemisphere", mesh, [3]float64{0, 0, -0.5})
+
+	if body.NbDOFs() != 6 {
+		t.Errorf("Expected 6 degrees of freedom, got %d", body.NbDOFs())
+	}
+	if body.DOFIndex("Heave") != 2 || body.DOFIndex("Yaw") != 5 || body.DOFIndex("Unknown") != -1 {
+		t.Error("Unexpected degrees of freedom ordering")
+	}
+
+	motion := body.DOFs[4].Motion([3]float64{1, 0, -0.5}, body.RotationCenter)
+	if norm3(sub3(motion, [3]float64{0, 0, -1})) > 1e-12 {
+		t.Errorf("Expected pitch motion (0, 0, -1), got %v", motion)
+	}
+}
+
+func TestFloatingBody_ComputeHydrostatics(t *testing.T) {
+	body := newTestHemisphere(t, 10, 24)
+	hydrostatics, err := body.ComputeHydrostatics(WaterDensity)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	volume := 2 * math.Pi / 3
+	if math.Abs(hydrostatics.DisplacedVolume-volume) > 0.03*volume {
+		t.Errorf("Expected volume close to %v, got %v", volume, hydrostatics.DisplacedVolume)
+	}
+	if math.Abs(hydrostatics.CenterOfBuoyancy[2]+3.0/8) > 0.01 {
+		t.Errorf("Expected center of buoyancy at z=-3/8, got %v", hydrostatics.CenterOfBuoyancy[2])
+	}
+
+	c33 := WaterDensity * Gravity * math.Pi
+	if math.Abs(hydrostatics.StiffnessMatrix.At(2, 2)-c33) > 0.03*c33 {
+		t.Errorf("Expected heave stiffness close to %v, got %v", c33, hydrostatics.StiffnessMatrix.At(2, 2))
+	}
+
+	// A homogeneous hemisphere has a metacentric height of R/8 - 3R/8 + 3R/8 > 0
+	if hydrostatics.StiffnessMatrix.At(3, 3) <= 0 || hydrostatics.StiffnessMatrix.At(4, 4) <= 0 {
+		t.Error("Expected positive roll and pitch stiffness")
+	}
+	if math.Abs(hydrostatics.StiffnessMatrix.At(0, 0)) > 1e-9 || math.Abs(hydrostatics.StiffnessMatrix.At(5, 5)) > 1e-9 {
+		t.Error("Expected no stiffness in surge and yaw")
+	}
+}
+
+func TestFloatingBody_ComputeRigidBodyInertia(t *testing.T) {
+	mesh, _ := NewHemisphereMesh(1, 10, 24)
+	body := NewRigidBody("hemisphere", mesh, [3]float64{0, 0, 0})
+
+	mass, centerOfMass, inertia, err := body.ComputeRigidBodyInertia(WaterDensity)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if math.Abs(mass-WaterDensity*2*math.Pi/3) > 0.03*mass {
+		t.Errorf("Expected mass close to %v, got %v", WaterDensity*2*math.Pi/3, mass)
+	}
+	if math.Abs(centerOfMass[2]+3.0/8) > 0.01 {
+		t.Errorf("Expected center of mass at z=-3/8, got %v", centerOfMass[2])
+	}
+
+	// Moment of inertia of a solid hemisphere around a diameter of its flat face: 2/5 m R²
+	expected := 0.4 * mass
+	for _, i := range []int{3, 4, 5} {
+		if math.Abs(inertia.At(i, i)-expected) > 0.03*expected {
+			t.Errorf("Expected moment of inertia %d close to %v, got %v", i, expected, inertia.At(i, i))
+		}
+	}
+
+	// Coupling between surge and pitch through the center of mass below the rotation center
+	if math.Abs(inertia.At(0, 4)-mass*centerOfMass[2]) > 1e-9*mass {
+		t.Errorf("Expected surge-pitch coupling %v, got %v", mass*centerOfMass[2], inertia.At(0, 4))
+	}
+}
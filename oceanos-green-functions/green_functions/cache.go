@@ -0,0 +1,231 @@
+// Package green_functions - Cache of the frequency independent Rankine terms
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package green_functions
+
+import (
+	"container/list"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"gonum.org/v1/gonum/mat"
+	"hash/fnv"
+	"math"
+	"sync"
+)
+
+// MeshFingerprint computes a hash of the geometry of a mesh, or of a (n, 3) array of points.
+// Two meshes with the same fingerprint give the same Green function matrices.
+func MeshFingerprint(mesh interface{}) (uint64, error) {
+	h := fnv.New64a()
+	buffer := make([]byte, 8)
+	writeFloat := func(x float64) {
+		binary.LittleEndian.PutUint64(buffer, math.Float64bits(x))
+		h.Write(buffer)
+	}
+	writeMatrix := func(m *mat.Dense) {
+		rows, cols := m.Dims()
+		binary.LittleEndian.PutUint64(buffer, uint64(rows))
+		h.Write(buffer)
+		for i := 0; i < rows; i++ {
+			for j := 0; j < cols; j++ {
+				writeFloat(m.At(i, j))
+			}
+		}
+	}
+
+	switch m := mesh.(type) {
+	case MeshLike:
+		writeMatrix(m.GetFacesCenters())
+		writeMatrix(m.GetFacesNormals())
+		if panels, ok := m.(PanelMesh); ok {
+			for i, area := range panels.GetFacesAreas() {
+				writeFloat(area)
+				for _, vertex := range panels.GetFaceVertices(i) {
+					for _, x := range vertex {
+						writeFloat(x)
+					}
+				}
+			}
+		}
+	case *mat.Dense:
+		h.Write([]byte("points"))
+		writeMatrix(m)
+	default:
+		return 0, errors.New("unrecognized mesh type for fingerprint")
+	}
+	return h.Sum64(), nil
+}
+
+// DefaultRankineCacheEntries is the number of entries of a RankineCache whose MaxEntries is zero
+const DefaultRankineCacheEntries = 16
+
+// greenFunctionIdentity identifies the Green functions of the same settings and floating point precision,
+// which share their cache entries
+type greenFunctionIdentity struct {
+	settings  string
+	precision FloatingPointPrecision
+}
+
+// rankineCacheKey identifies the Rankine terms of a Green function between two meshes
+type rankineCacheKey struct {
+	greenFunction      interface{}
+	mesh1, mesh2       uint64
+	freeSurface        float64
+	waterDepth         float64
+	adjointDoubleLayer bool
+	earlyDotProduct    bool
+}
+
+// rankineCacheEntry holds Rankine terms computed once, even when requested concurrently
+type rankineCacheEntry struct {
+	key     rankineCacheKey
+	element *list.Element
+	once    sync.Once
+	S, K    *mat.CDense
+	err     error
+}
+
+// RankineCache stores the frequency independent Rankine terms of S and K, keyed by the fingerprints of the meshes,
+// so that evaluations at many wavenumbers only compute the wave terms. It is safe for concurrent use.
+type RankineCache struct {
+	// MaxEntries is the largest number of entries, the least recently used entries being removed beyond it;
+	// DefaultRankineCacheEntries when zero, unlimited when negative
+	MaxEntries int
+
+	mu      sync.Mutex
+	entries map[rankineCacheKey]*rankineCacheEntry
+	// recent lists the entries from the most to the least recently used
+	recent *list.List
+	hits   int
+	misses int
+}
+
+// NewRankineCache creates an empty cache of DefaultRankineCacheEntries entries
+func NewRankineCache() *RankineCache {
+	return &RankineCache{entries: make(map[rankineCacheKey]*rankineCacheEntry), recent: list.New()}
+}
+
+// Evaluate computes the Green function matrices like greenFunction.Evaluate. For a finite positive wavenumber and a
+// Green function implementing SplitGreenFunction, the Rankine terms are taken from the cache, or computed and stored.
+// The returned matrices are not shared with the cache and can be modified.
+func (c *RankineCache) Evaluate(greenFunction AbstractGreenFunction, mesh1, mesh2 interface{}, freeSurface float64, waterDepth float64,
+	wavenumber complex128, adjointDoubleLayer bool, earlyDotProduct bool) (*mat.CDense, *mat.CDense, error) {
+
+	split, ok := greenFunction.(SplitGreenFunction)
+	k := real(wavenumber)
+	if !ok || k <= 0 || math.IsInf(k, 1) {
+		return greenFunction.Evaluate(mesh1, mesh2, freeSurface, waterDepth, wavenumber, adjointDoubleLayer, earlyDotProduct)
+	}
+
+	entry, err := c.entry(split, mesh1, mesh2, freeSurface, waterDepth, adjointDoubleLayer, earlyDotProduct)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	S, K, err := split.EvaluateWave(mesh1, mesh2, freeSurface, waterDepth, wavenumber, adjointDoubleLayer, earlyDotProduct)
+	if err != nil {
+		return nil, nil, err
+	}
+	addComplexMatrix(S, entry.S)
+	addComplexMatrix(K, entry.K)
+	return S, K, nil
+}
+
+// entry returns the cached Rankine terms, computing them on the first request
+func (c *RankineCache) entry(greenFunction SplitGreenFunction, mesh1, mesh2 interface{}, freeSurface float64, waterDepth float64,
+	adjointDoubleLayer bool, earlyDotProduct bool) (*rankineCacheEntry, error) {
+
+	fingerprint1, err := MeshFingerprint(mesh1)
+	if err != nil {
+		return nil, err
+	}
+	fingerprint2, err := MeshFingerprint(mesh2)
+	if err != nil {
+		return nil, err
+	}
+
+	key := rankineCacheKey{
+		greenFunction:      cacheIdentity(greenFunction),
+		mesh1:              fingerprint1,
+		mesh2:              fingerprint2,
+		freeSurface:        freeSurface,
+		waterDepth:         waterDepth,
+		adjointDoubleLayer: adjointDoubleLayer,
+		earlyDotProduct:    earlyDotProduct,
+	}
+
+	c.mu.Lock()
+	entry, found := c.entries[key]
+	if found {
+		c.hits++
+		c.recent.MoveToFront(entry.element)
+	} else {
+		c.misses++
+		entry = &rankineCacheEntry{key: key}
+		entry.element = c.recent.PushFront(entry)
+		c.entries[key] = entry
+		c.evict()
+	}
+	c.mu.Unlock()
+
+	entry.once.Do(func() {
+		entry.S, entry.K, entry.err = greenFunction.EvaluateRankine(mesh1, mesh2, freeSurface, waterDepth, adjointDoubleLayer, earlyDotProduct)
+	})
+	if entry.err != nil {
+		return nil, entry.err
+	}
+	return entry, nil
+}
+
+// evict removes the least recently used entries beyond MaxEntries. An entry being computed is removed from the
+// cache but remains valid for the evaluations that requested it.
+func (c *RankineCache) evict() {
+	maxEntries := c.MaxEntries
+	if maxEntries == 0 {
+		maxEntries = DefaultRankineCacheEntries
+	}
+	for maxEntries > 0 && c.recent.Len() > maxEntries {
+		oldest := c.recent.Remove(c.recent.Back()).(*rankineCacheEntry)
+		delete(c.entries, oldest.key)
+	}
+}
+
+// cacheIdentity identifies a Green function by its current settings and floating point precision, which may have
+// changed since its creation, so that Green functions configured alike share their cache entries. Green functions
+// without settings are identified by themselves.
+func cacheIdentity(greenFunction SplitGreenFunction) interface{} {
+	configurable, ok := greenFunction.(ConfigurableGreenFunction)
+	if !ok {
+		return greenFunction
+	}
+	settings, err := json.Marshal(configurable.Settings())
+	if err != nil {
+		return greenFunction
+	}
+	return greenFunctionIdentity{settings: string(settings), precision: greenFunction.GetFloatingPointPrecision()}
+}
+
+// Len returns the number of cached entries
+func (c *RankineCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Stats returns the number of evaluations that found their Rankine terms in the cache, and the number that did not
+func (c *RankineCache) Stats() (hits, misses int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// Clear removes all the entries of the cache
+func (c *RankineCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[rankineCacheKey]*rankineCacheEntry)
+	c.recent.Init()
+	c.hits, c.misses = 0, 0
+}
@@ -0,0 +1,201 @@
+package green_functions
+
+import (
+	"context"
+	"gonum.org/v1/gonum/mat"
+	"math"
+	"math/cmplx"
+	"sync"
+	"testing"
+)
+
+func TestMeshFingerprint(t *testing.T) {
+	mesh1, err := NewHemisphereMesh(1, 4, 8)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	mesh2, _ := NewHemisphereMesh(1, 4, 8)
+	mesh3, _ := NewHemisphereMesh(1.1, 4, 8)
+
+	f1, err := MeshFingerprint(mesh1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if f2, _ := MeshFingerprint(mesh2); f1 != f2 {
+		t.Error("Identical meshes have different fingerprints")
+	}
+	if f3, _ := MeshFingerprint(mesh3); f1 == f3 {
+		t.Error("Scaled mesh has the same fingerprint")
+	}
+
+	points := mat.DenseCopyOf(mesh1.GetFacesCenters())
+	fp, err := MeshFingerprint(points)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if fp == f1 {
+		t.Error("Points and mesh have the same fingerprint")
+	}
+
+	if _, err := MeshFingerprint("mesh"); err == nil {
+		t.Error("Expected error for invalid mesh type")
+	}
+}
+
+func TestRankineCache_Evaluate(t *testing.T) {
+	mesh, err := NewHemisphereMesh(1, 3, 6)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	gf := NewDefaultDelhommeau()
+	cache := NewRankineCache()
+
+	for _, k := range []float64{0.5, 1, 2} {
+		for _, adjoint := range []bool{true, false} {
+			S, K, err := cache.Evaluate(gf, mesh, mesh, 0, math.Inf(1), complex(k, 0), adjoint, !adjoint)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			expectedS, expectedK, _ := gf.Evaluate(mesh, mesh, 0, math.Inf(1), complex(k, 0), adjoint, !adjoint)
+			if !mat.CEqualApprox(S, expectedS, 1e-12) || !mat.CEqualApprox(K, expectedK, 1e-12) {
+				t.Errorf("Cached matrices differ from a full evaluation at k=%v", k)
+			}
+		}
+	}
+
+	hits, misses := cache.Stats()
+	if misses != 2 || hits != 4 || cache.Len() != 2 {
+		t.Errorf("Expected 2 entries with 4 hits, got %d entries, %d hits and %d misses", cache.Len(), hits, misses)
+	}
+
+	// Zero and infinite wavenumbers are evaluated without the cache
+	S, _, err := cache.Evaluate(gf, mesh, mesh, 0, math.Inf(1), 0, true, true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expectedS, _, _ := gf.Evaluate(mesh, mesh, 0, math.Inf(1), 0, true, true)
+	if cmplx.Abs(S.At(0, 0)-expectedS.At(0, 0)) > 1e-12 {
+		t.Error("Zero wavenumber evaluation differs from a full evaluation")
+	}
+	if cache.Len() != 2 {
+		t.Errorf("Zero wavenumber evaluation should not be cached")
+	}
+
+	cache.Clear()
+	if hits, misses := cache.Stats(); cache.Len() != 0 || hits != 0 || misses != 0 {
+		t.Error("Cache was not cleared")
+	}
+}
+
+func TestRankineCache_Concurrent(t *testing.T) {
+	mesh, err := NewHemisphereMesh(1, 3, 6)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	gf := NewDefaultDelhommeau()
+	cache := NewRankineCache()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(k float64) {
+			defer wg.Done()
+			if _, _, err := cache.Evaluate(gf, mesh, mesh, 0, math.Inf(1), complex(k, 0), true, true); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}(0.5 + 0.1*float64(i))
+	}
+	wg.Wait()
+
+	if hits, misses := cache.Stats(); misses != 1 || hits != 7 {
+		t.Errorf("Expected 1 miss and 7 hits, got %d and %d", misses, hits)
+	}
+}
+
+func TestRankineCache_Key(t *testing.T) {
+	mesh, err := NewHemisphereMesh(1, 3, 6)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cache := NewRankineCache()
+	evaluate := func(gf AbstractGreenFunction) *mat.CDense {
+		S, _, err := cache.Evaluate(gf, mesh, mesh, 0, math.Inf(1), 1, true, true)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return S
+	}
+
+	// Green functions of the same settings share their entries
+	evaluate(NewDefaultDelhommeau())
+	evaluate(NewDefaultDelhommeau())
+	if hits, misses := cache.Stats(); hits != 1 || misses != 1 {
+		t.Errorf("Expected 1 hit and 1 miss, got %d and %d", hits, misses)
+	}
+
+	// Changing the precision after the creation of the Green function changes its entry
+	gf := NewDefaultDelhommeau()
+	gf.SetFloatingPointPrecision(Float32)
+	S := evaluate(gf)
+	if hits, misses := cache.Stats(); hits != 1 || misses != 2 {
+		t.Errorf("Expected a new entry for the float32 precision, got %d hits and %d misses", hits, misses)
+	}
+	expectedS, _, _ := gf.Evaluate(mesh, mesh, 0, math.Inf(1), 1, true, true)
+	if !mat.CEqualApprox(S, expectedS, 1e-6) {
+		t.Error("Cached matrices differ from a full evaluation in float32 precision")
+	}
+}
+
+func TestRankineCache_Eviction(t *testing.T) {
+	cache := NewRankineCache()
+	cache.MaxEntries = 2
+	gf := NewDefaultDelhommeau()
+	var meshes []*Mesh
+	for _, radius := range []float64{1, 2, 3} {
+		mesh, err := NewHemisphereMesh(radius, 2, 4)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		meshes = append(meshes, mesh)
+	}
+	evaluate := func(mesh *Mesh) {
+		if _, _, err := cache.Evaluate(gf, mesh, mesh, 0, math.Inf(1), 1, true, true); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	// The first mesh is used again before the third one, which evicts the least recently used second mesh
+	evaluate(meshes[0])
+	evaluate(meshes[1])
+	evaluate(meshes[0])
+	evaluate(meshes[2])
+	if cache.Len() != 2 {
+		t.Errorf("Expected 2 entries, got %d", cache.Len())
+	}
+	evaluate(meshes[0])
+	evaluate(meshes[1])
+	if hits, misses := cache.Stats(); hits != 2 || misses != 4 {
+		t.Errorf("Expected 2 hits and 4 misses, got %d and %d", hits, misses)
+	}
+}
+
+func TestBEMSolver_SweepRankineCache(t *testing.T) {
+	body := newTestHemisphere(t, 3, 6)
+	solver := NewBEMSolver(NewDefaultDelhommeau())
+	solver.Cache = NewRankineCache()
+
+	frequencies := make([]float64, 100)
+	for i := range frequencies {
+		frequencies[i] = 0.1 + 0.05*float64(i)
+	}
+	sweep := Sweep{Frequencies: frequencies, FrequencyType: Wavenumber}
+
+	if _, err := solver.SolveSweep(context.Background(), body, sweep); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The near-field singular integrals are computed once for the whole sweep
+	if hits, misses := solver.Cache.Stats(); misses != 1 || hits != len(frequencies)-1 {
+		t.Errorf("Expected 1 miss and %d hits, got %d and %d", len(frequencies)-1, misses, hits)
+	}
+}
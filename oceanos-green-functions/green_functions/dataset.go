@@ -0,0 +1,246 @@
+// Package green_functions - Export of the solver results as a NetCDF dataset
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package green_functions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/capytaine/capytaine/go-capytaine/green_functions/internal/netcdf"
+	"gonum.org/v1/gonum/mat"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+)
+
+// datasetCoordinates lists the non-index coordinates of the data variables,
+// which xarray attaches to the variables when opening the dataset
+const datasetCoordinates = "period wavenumber wavelength water_depth rho g"
+
+// settingsAttribute is the global attribute holding the settings of the Green function as JSON,
+// from which ReadDatasetGreenFunction recreates the Green function
+const settingsAttribute = "green_function_settings"
+
+// attributeValue converts a setting to a value of a NetCDF attribute, which are strings, integers or floats
+func attributeValue(value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int32(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int32(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Bool:
+		if v.Bool() {
+			return int32(1)
+		}
+		return int32(0)
+	}
+	return fmt.Sprint(value)
+}
+
+// denseValues returns the values of a real matrix in row major order
+func denseValues(m mat.Matrix) []float64 {
+	rows, cols := m.Dims()
+	values := make([]float64, 0, rows*cols)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			values = append(values, m.At(i, j))
+		}
+	}
+	return values
+}
+
+// complexValues appends the values of a complex matrix in row major order, with the real and imaginary parts
+// along a last dimension of length 2
+func complexValues(values []float64, m *mat.CDense) []float64 {
+	rows, cols := m.Dims()
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			z := m.At(i, j)
+			values = append(values, real(z), imag(z))
+		}
+	}
+	return values
+}
+
+// assembleDataset lays out the results of a frequency sweep with the dimensions of the Capytaine datasets:
+// omega, wave_direction, radiating_dof and influenced_dof. NetCDF has no complex type, so complex
+// variables have a last complex dimension holding the real and imaginary parts.
+// The RAO, the inertia matrix and the hydrostatic stiffness are only included when the body has them.
+func assembleDataset(body *FloatingBody, results []*FrequencyResult, gf AbstractGreenFunction) (*netcdf.File, error) {
+	if len(results) == 0 {
+		return nil, errors.New("no result to export")
+	}
+	for i, r := range results {
+		if r == nil {
+			return nil, fmt.Errorf("missing result %d", i)
+		}
+	}
+	first := results[0]
+	for _, r := range results[1:] {
+		if r.WaterDepth != first.WaterDepth || r.Rho != first.Rho || !reflect.DeepEqual(r.WaveDirections, first.WaveDirections) {
+			return nil, errors.New("results must share the water depth, the water density and the wave directions")
+		}
+	}
+	// The omega coordinate is sorted, which xarray expects to select frequencies
+	results = append([]*FrequencyResult(nil), results...)
+	sort.Slice(results, func(i, j int) bool { return results[i].Omega < results[j].Omega })
+
+	n := body.NbDOFs()
+	nOmega := len(results)
+	nDirections := len(first.WaveDirections)
+	dofs := make([]string, n)
+	for i, dof := range body.DOFs {
+		dofs[i] = dof.Name
+	}
+
+	f := &netcdf.File{}
+	units := func(u string) netcdf.Attribute { return netcdf.Attribute{Name: "units", Value: u} }
+	longName := func(s string) netcdf.Attribute { return netcdf.Attribute{Name: "long_name", Value: s} }
+	coordinates := netcdf.Attribute{Name: "coordinates", Value: datasetCoordinates}
+
+	f.AddDimension("omega", nOmega)
+	omega := make([]float64, nOmega)
+	period := make([]float64, nOmega)
+	wavenumber := make([]float64, nOmega)
+	wavelength := make([]float64, nOmega)
+	for i, r := range results {
+		omega[i] = r.Omega
+		period[i] = 2 * math.Pi / r.Omega
+		wavenumber[i] = r.Wavenumber
+		wavelength[i] = 2 * math.Pi / r.Wavenumber
+	}
+	f.AddVariable("omega", []string{"omega"}, omega, longName("Angular frequency"), units("rad/s"))
+	f.AddVariable("period", []string{"omega"}, period, longName("Period"), units("s"))
+	f.AddVariable("wavenumber", []string{"omega"}, wavenumber, longName("Angular wavenumber"), units("rad/m"))
+	f.AddVariable("wavelength", []string{"omega"}, wavelength, longName("Wave length"), units("m"))
+	if err := f.AddStrings("radiating_dof", "radiating_dof", dofs, longName("Radiating DOF")); err != nil {
+		return nil, err
+	}
+	if err := f.AddStrings("influenced_dof", "influenced_dof", dofs, longName("Influenced DOF")); err != nil {
+		return nil, err
+	}
+	if err := f.AddStrings("complex", "complex", []string{"re", "im"}); err != nil {
+		return nil, err
+	}
+	f.AddVariable("water_depth", nil, first.WaterDepth, longName("Water depth"), units("m"))
+	f.AddVariable("rho", nil, first.Rho, longName("Fluid density"), units("kg/m3"))
+	f.AddVariable("g", nil, Gravity, longName("Gravity acceleration"), units("m/s2"))
+
+	// The rows of the solver matrices are the influenced DOFs, transposed to the order of Capytaine
+	var addedMass, damping []float64
+	for _, r := range results {
+		addedMass = append(addedMass, denseValues(r.AddedMass.T())...)
+		damping = append(damping, denseValues(r.RadiationDamping.T())...)
+	}
+	radiationDims := []string{"omega", "radiating_dof", "influenced_dof"}
+	f.AddVariable("added_mass", radiationDims, addedMass, longName("Added mass"), coordinates)
+	f.AddVariable("radiation_damping", radiationDims, damping, longName("Radiation damping"), coordinates)
+
+	if body.InertiaMatrix != nil {
+		f.AddVariable("inertia_matrix", []string{"influenced_dof", "radiating_dof"}, denseValues(body.InertiaMatrix),
+			longName("Inertia matrix"))
+	}
+	if body.HydrostaticStiffness != nil {
+		f.AddVariable("hydrostatic_stiffness", []string{"influenced_dof", "radiating_dof"}, denseValues(body.HydrostaticStiffness),
+			longName("Hydrostatic stiffness"))
+	}
+
+	// Without wave direction, there is no diffraction problem and the dimension is left out
+	if nDirections > 0 {
+		f.AddDimension("wave_direction", nDirections)
+		f.AddVariable("wave_direction", []string{"wave_direction"}, first.WaveDirections,
+			longName("Wave direction"), units("rad"))
+
+		var froudeKrylov, diffraction, excitation, rao []float64
+		withRAO := body.InertiaMatrix != nil && body.HydrostaticStiffness != nil
+		for _, r := range results {
+			froudeKrylov = complexValues(froudeKrylov, r.FroudeKrylovForce)
+			diffraction = complexValues(diffraction, r.DiffractionForce)
+			excitation = complexValues(excitation, r.ExcitationForce)
+			if withRAO {
+				motions, err := r.ComputeRAO(body)
+				if err != nil {
+					return nil, fmt.Errorf("omega %g: %w", r.Omega, err)
+				}
+				rao = complexValues(rao, motions)
+			}
+		}
+		forceDims := []string{"omega", "wave_direction", "influenced_dof", "complex"}
+		f.AddVariable("Froude_Krylov_force", forceDims, froudeKrylov, longName("Froude Krylov force"), coordinates)
+		f.AddVariable("diffraction_force", forceDims, diffraction, longName("Diffraction force"), coordinates)
+		f.AddVariable("excitation_force", forceDims, excitation, longName("Excitation force"), coordinates)
+		if withRAO {
+			f.AddVariable("RAO", []string{"omega", "wave_direction", "radiating_dof", "complex"}, rao,
+				longName("Response amplitude operator"), coordinates)
+		}
+	}
+
+	f.AddAttribute("body_name", body.Name)
+	if body.Mesh != nil {
+		f.AddAttribute("nb_faces", int32(body.Mesh.GetNbFaces()))
+	}
+	if gf != nil {
+		settings, _ := SettingsOf(gf)
+		names := make([]string, 0, len(settings))
+		for name := range settings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			f.AddAttribute(name, attributeValue(settings[name]))
+		}
+		if _, ok := settings[SettingsKey]; !ok {
+			f.AddAttribute(SettingsKey, fmt.Sprint(gf))
+		}
+		if settings != nil {
+			data, err := json.Marshal(settings)
+			if err != nil {
+				return nil, err
+			}
+			f.AddAttribute(settingsAttribute, string(data))
+		}
+	}
+	return f, nil
+}
+
+// WriteDataset writes the results of a frequency sweep of the body in the NetCDF classic format,
+// laid out like the datasets of Capytaine so that xarray.open_dataset reads it without any conversion.
+// The results must share the water depth and the wave directions. The settings of the Green function
+// are recorded as global attributes, and as JSON in the green_function_settings attribute read by
+// ReadDatasetGreenFunction; gf may be nil.
+//
+// Complex variables, such as excitation_force, have a last complex dimension of coordinates re and im,
+// which cpt.io.xarray.merge_complex_values turns back into complex values in Python.
+func WriteDataset(w io.Writer, body *FloatingBody, results []*FrequencyResult, gf AbstractGreenFunction) error {
+	f, err := assembleDataset(body, results, gf)
+	if err != nil {
+		return err
+	}
+	return f.Write(w)
+}
+
+// ReadDatasetGreenFunction recreates the Green function that produced a dataset written by WriteDataset,
+// from the settings recorded in its green_function_settings attribute
+func ReadDatasetGreenFunction(r io.Reader) (AbstractGreenFunction, error) {
+	f, err := netcdf.Read(r)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := f.Attribute(settingsAttribute)
+	if !ok {
+		return nil, fmt.Errorf("dataset has no %s attribute", settingsAttribute)
+	}
+	data, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("%s attribute is not a string", settingsAttribute)
+	}
+	return UnmarshalSettingsJSON([]byte(data))
+}
@@ -0,0 +1,148 @@
+package green_functions
+
+import (
+	"bytes"
+	"github.com/capytaine/capytaine/go-capytaine/green_functions/internal/netcdf"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestWriteDataset(t *testing.T) {
+	body := newTestHemisphere(t, 4, 8)
+	gf := NewDefaultDelhommeau()
+	solver := NewBEMSolver(gf)
+	directions := []float64{0, math.Pi / 2}
+
+	// Results are written by increasing frequency
+	var results []*FrequencyResult
+	for _, omega := range []float64{2, 1} {
+		result, err := solver.SolveFrequency(body, omega, math.Inf(1), directions)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		results = append(results, result)
+	}
+	// Surge force due to pitch motion, distinct from the pitch moment due to surge motion
+	results[0].AddedMass.Set(0, 4, 11)
+	results[0].AddedMass.Set(4, 0, 13)
+	results[0].RadiationDamping.Set(0, 4, 17)
+	results[0].RadiationDamping.Set(4, 0, 19)
+
+	var buf bytes.Buffer
+	if err := WriteDataset(&buf, body, results, gf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	f, err := netcdf.Read(&buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for name, length := range map[string]int{"omega": 2, "wave_direction": 2, "radiating_dof": 6, "influenced_dof": 6, "complex": 2} {
+		if d := f.Dimension(name); d == nil || d.Length != length {
+			t.Errorf("Expected dimension %s of length %d, got %+v", name, length, d)
+		}
+	}
+	if omega := f.Variable("omega").Value; !reflect.DeepEqual(omega, []float64{1, 2}) {
+		t.Errorf("Expected sorted frequencies, got %v", omega)
+	}
+	if dofs := netcdf.Strings(f.Variable("radiating_dof").Value.(string), 6); !reflect.DeepEqual(dofs, []string{"Surge", "Sway", "Heave", "Roll", "Pitch", "Yaw"}) {
+		t.Errorf("Expected the DOF names, got %q", dofs)
+	}
+
+	// Heave added mass at omega = 2, the second frequency
+	addedMass := f.Variable("added_mass")
+	if !reflect.DeepEqual(addedMass.Dims, []string{"omega", "radiating_dof", "influenced_dof"}) {
+		t.Errorf("Unexpected added mass dimensions %v", addedMass.Dims)
+	}
+	if a33 := addedMass.Value.([]float64)[36+2*6+2]; a33 != results[0].AddedMass.At(2, 2) {
+		t.Errorf("Expected heave added mass %v, got %v", results[0].AddedMass.At(2, 2), a33)
+	}
+	// radiating_dof is Pitch and influenced_dof Surge
+	if a51 := addedMass.Value.([]float64)[36+4*6+0]; a51 != 11 {
+		t.Errorf("Expected the surge added mass due to pitch 11, got %v", a51)
+	}
+	if b51 := f.Variable("radiation_damping").Value.([]float64)[36+4*6+0]; b51 != 17 {
+		t.Errorf("Expected the surge damping due to pitch 17, got %v", b51)
+	}
+
+	// Sway excitation in beam waves at omega = 1
+	excitation := f.Variable("excitation_force")
+	if !reflect.DeepEqual(excitation.Dims, []string{"omega", "wave_direction", "influenced_dof", "complex"}) {
+		t.Errorf("Unexpected excitation force dimensions %v", excitation.Dims)
+	}
+	expected := results[1].ExcitationForce.At(1, 1)
+	values := excitation.Value.([]float64)[2*(6+1):]
+	if values[0] != real(expected) || values[1] != imag(expected) {
+		t.Errorf("Expected sway excitation %v, got %v", expected, values[:2])
+	}
+
+	if rao := f.Variable("RAO"); rao == nil || len(rao.Value.([]float64)) != 2*2*6*2 {
+		t.Errorf("Expected the RAO of a body with inertia and stiffness, got %+v", rao)
+	}
+	if depth := f.Variable("water_depth").Value.([]float64); !math.IsInf(depth[0], 1) {
+		t.Errorf("Expected an infinite water depth, got %v", depth)
+	}
+	if name, _ := f.Attribute("green_function"); name != "Delhommeau" {
+		t.Errorf("Expected the Green function name, got %v", name)
+	}
+	if nr, _ := f.Attribute("tabulation_nr"); nr != int32(gf.parameters.TabulationNr) {
+		t.Errorf("Expected the tabulation settings, got %v", nr)
+	}
+	if shape, _ := f.Attribute("tabulation_grid_shape"); shape != string(gf.parameters.TabulationGridShape) {
+		t.Errorf("Expected the tabulation grid shape, got %v", shape)
+	}
+
+	// Without inertia, there is no RAO
+	body.InertiaMatrix = nil
+	buf.Reset()
+	if err := WriteDataset(&buf, body, results, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if f, err = netcdf.Read(&buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if f.Variable("RAO") != nil || f.Variable("hydrostatic_stiffness") == nil {
+		t.Error("Expected the hydrostatic stiffness without the RAO")
+	}
+
+	mixed := []*FrequencyResult{results[0], {Omega: 3, WaterDepth: 10, WaveDirections: directions}}
+	if err := WriteDataset(&buf, body, mixed, gf); err == nil {
+		t.Error("Expected error for results in different water depths")
+	}
+	if err := WriteDataset(&buf, body, nil, gf); err == nil {
+		t.Error("Expected error without results")
+	}
+}
+
+func TestReadDatasetGreenFunction(t *testing.T) {
+	body := newTestHemisphere(t, 4, 8)
+	params := DefaultDelhommeauParameters()
+	params.TabulationNr = 328
+	params.GfSingularities = HighFreq
+	gf := NewDelhommeau(params)
+	result, err := NewBEMSolver(gf).SolveFrequency(body, 1, math.Inf(1), []float64{0})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteDataset(&buf, body, []*FrequencyResult{result}, gf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	read, err := ReadDatasetGreenFunction(&buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(read.(*Delhommeau).parameters, params) {
+		t.Errorf("Expected %+v, got %+v", params, read.(*Delhommeau).parameters)
+	}
+
+	buf.Reset()
+	if err := WriteDataset(&buf, body, []*FrequencyResult{result}, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := ReadDatasetGreenFunction(&buf); err == nil {
+		t.Error("Expected error for a dataset without Green function settings")
+	}
+}
@@ -8,8 +8,13 @@ import (
 	"fmt"
 	"gonum.org/v1/gonum/mat"
 	"hash/fnv"
+	"math"
+	"os"
 	"path/filepath"
+	"reflect"
+	"runtime"
 	"sort"
+	"sync"
 )
 
 // TabulationGridShape represents different grid shapes for tabulation
@@ -86,9 +91,14 @@ type Delhommeau struct {
 	dispersionRelationRoots  []complex128
 	exportableSettings       map[string]interface{}
 	hash                     uint64
+
+	// pronyDecompositions caches the finite depth Prony decompositions by dimensionless wavenumber
+	pronyDecompositions sync.Map
 }
 
-// NewDelhommeau creates a new Delhommeau Green function with specified parameters
+// NewDelhommeau creates a new Delhommeau Green function with specified parameters.
+// The wave integrals are integrated at each evaluation, unless TabulationCacheDir is set: they are then interpolated
+// in a tabulation saved in that directory, loaded or created on the first evaluation.
 func NewDelhommeau(params DelhommeauParameters) *Delhommeau {
 	d := &Delhommeau{
 		BaseGreenFunction:       NewBaseGreenFunction(),
@@ -141,13 +151,6 @@ func NewDelhommeau(params DelhommeauParameters) *Delhommeau {
 
 	d.hash = d.computeHash()
 
-	// Initialize tabulation
-	if params.TabulationCacheDir == "" {
-		d.createTabulation()
-	} else {
-		d.createOrLoadTabulation()
-	}
-
 	return d
 }
 
@@ -172,11 +175,27 @@ func (d *Delhommeau) computeHash() uint64 {
 	return h.Sum64()
 }
 
+// Settings returns a copy of the parameters of the Delhommeau configuration, keyed as in Capytaine.
+// The tabulation cache directory is not part of them.
+func (d *Delhommeau) Settings() map[string]interface{} {
+	return copySettings(d.exportableSettings)
+}
+
 // Hash returns the hash of the Delhommeau configuration
 func (d *Delhommeau) Hash() uint64 {
 	return d.hash
 }
 
+// SetFloatingPointPrecision sets the floating point precision, and updates the settings and the hash accordingly
+func (d *Delhommeau) SetFloatingPointPrecision(precision FloatingPointPrecision) {
+	d.BaseGreenFunction.SetFloatingPointPrecision(precision)
+	d.parameters.FloatingPointPrecision = precision
+	if d.exportableSettings != nil {
+		d.exportableSettings["floating_point_precision"] = precision
+		d.hash = d.computeHash()
+	}
+}
+
 // String returns a string representation showing only non-default values
 func (d *Delhommeau) String() string {
 	defaults := DefaultDelhommeauParameters()
@@ -219,28 +238,215 @@ func (d *Delhommeau) String() string {
 	return fmt.Sprintf("Delhommeau(%s)", fmt.Sprintf("%v", nonDefaults))
 }
 
-// createTabulation creates the tabulation for Green function computation
-func (d *Delhommeau) createTabulation() error {
-	// TODO: Implement tabulation creation
-	// This would involve creating lookup tables for efficient Green function evaluation
-	return nil
+// delhommeauTabulationComponents are the tabulated integrals of delhommeauIntegrals and negativePoleIntegrals
+var delhommeauTabulationComponents = []string{"delhommeau", "delhommeau_dr", "negative_pole", "negative_pole_dr"}
+
+// tabulationSingularityRadius is the distance to the origin below which the dimensionless integrals are computed
+// directly, as their logarithmic singularity is not interpolated accurately
+const tabulationSingularityRadius = 1.0
+
+// sharedTabulation is a tabulation loaded or created once for all the Delhommeau Green functions
+// with the same tabulation cache file
+type sharedTabulation struct {
+	once sync.Once
+	tc   *TabulationCache
+	err  error
+}
+
+// sharedTabulations holds the *sharedTabulation by tabulation cache file
+var sharedTabulations sync.Map
+
+// tabulationCacheFile returns the file of the tabulation in TabulationCacheDir, named after the hash of the settings
+func (d *Delhommeau) tabulationCacheFile() string {
+	return filepath.Join(d.parameters.TabulationCacheDir, fmt.Sprintf("tabulation_%d.cache", d.Hash()))
+}
+
+// tabulation returns the tabulation of the dimensionless wave integrals, loaded from TabulationCacheDir or created
+// on the first call, and shared by the Delhommeau Green functions with the same settings
+func (d *Delhommeau) tabulation() (*TabulationCache, error) {
+	cacheFile := d.tabulationCacheFile()
+	value, _ := sharedTabulations.LoadOrStore(cacheFile, &sharedTabulation{})
+	shared := value.(*sharedTabulation)
+	shared.once.Do(func() {
+		shared.tc, shared.err = d.createOrLoadTabulation()
+		if shared.err != nil {
+			// Tried again on the next evaluation
+			sharedTabulations.CompareAndDelete(cacheFile, shared)
+			return
+		}
+		// The integrals are computed directly outside of the tabulated range
+		shared.tc.AsymptoticComponents = func(x, z float64) ([]complex128, []complex128, []complex128) {
+			integral, integralDr := delhommeauIntegrals(x, z)
+			l, lr := negativePoleIntegrals(x, z)
+			return []complex128{complex(integral, 0), complex(integralDr, 0), complex(l, 0), complex(lr, 0)}, nil, nil
+		}
+	})
+	return shared.tc, shared.err
+}
+
+// createTabulation tabulates the integrals of delhommeauIntegrals and negativePoleIntegrals on the grid of
+// the parameters, rounded to the floating point precision
+func (d *Delhommeau) createTabulation() (*TabulationCache, error) {
+	p := d.parameters
+	r, z, err := NewTabulationGrid(p.TabulationGridShape, p.TabulationNr, p.TabulationRmax, p.TabulationNz, p.TabulationZmin)
+	if err != nil {
+		return nil, err
+	}
+	precision := d.GetFloatingPointPrecision()
+	tc, err := NewTabulationCacheWithComponents(r, z, precision, delhommeauTabulationComponents...)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make(chan int)
+	go func() {
+		defer close(rows)
+		for j := range z {
+			rows <- j
+		}
+	}()
+
+	var wg sync.WaitGroup
+	workers := runtime.NumCPU()
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for j := range rows {
+				for i, x := range r {
+					if x == 0 && z[j] == 0 {
+						// Logarithmic singularity, within tabulationSingularityRadius
+						continue
+					}
+					integral, integralDr := delhommeauIntegrals(x, z[j])
+					l, lr := negativePoleIntegrals(x, z[j])
+					for c, v := range [...]float64{integral, integralDr, l, lr} {
+						if precision == Float32 {
+							v = float64(float32(v))
+						}
+						tc.Components[c][j][i] = complex(v, 0)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	tc.Method = CubicInterpolation
+	tc.IsValid = true
+	return tc, nil
+}
+
+// createOrLoadTabulation loads the tabulation saved in TabulationCacheDir for the current settings,
+// or creates it and saves it there
+func (d *Delhommeau) createOrLoadTabulation() (*TabulationCache, error) {
+	cacheFile := d.tabulationCacheFile()
+	if tc, err := LoadTabulationCache(cacheFile); err == nil && d.isTabulation(tc) {
+		return tc, nil
+	}
+
+	tc, err := d.createTabulation()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(d.parameters.TabulationCacheDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create the tabulation cache directory: %w", err)
+	}
+	// Saved under a temporary name and renamed, so that other processes never load a partial file
+	temporary := fmt.Sprintf("%s.%d.tmp", cacheFile, os.Getpid())
+	if err := tc.Save(temporary); err != nil {
+		os.Remove(temporary)
+		return nil, fmt.Errorf("failed to save the tabulation: %w", err)
+	}
+	if err := os.Rename(temporary, cacheFile); err != nil {
+		os.Remove(temporary)
+		return nil, fmt.Errorf("failed to save the tabulation: %w", err)
+	}
+	return tc, nil
+}
+
+// isTabulation checks that a loaded tabulation has the grid, precision and components of the parameters
+func (d *Delhommeau) isTabulation(tc *TabulationCache) bool {
+	p := d.parameters
+	r, z, err := NewTabulationGrid(p.TabulationGridShape, p.TabulationNr, p.TabulationRmax, p.TabulationNz, p.TabulationZmin)
+	return err == nil && tc.IsValid && tc.Precision == d.GetFloatingPointPrecision() &&
+		reflect.DeepEqual(tc.RRange, r) && reflect.DeepEqual(tc.ZRange, z) &&
+		reflect.DeepEqual(tc.ComponentNames, delhommeauTabulationComponents)
 }
 
-// createOrLoadTabulation creates or loads tabulation from cache
-func (d *Delhommeau) createOrLoadTabulation() error {
-	// TODO: Implement tabulation loading/creation with caching
-	// Check if tabulation exists in cache directory, load if exists, create if not
-	if d.parameters.TabulationCacheDir != "" {
-		cacheFile := filepath.Join(d.parameters.TabulationCacheDir, fmt.Sprintf("tabulation_%d.cache", d.hash))
-		_ = cacheFile // TODO: Implement actual caching logic
+// waveIntegrals returns the dimensionless integrals of the wave terms. With a TabulationCacheDir, they are
+// interpolated in the tabulation but close to their singularity at the origin.
+func (d *Delhommeau) waveIntegrals() (waveIntegrals, error) {
+	if d.parameters.TabulationCacheDir == "" {
+		return directWaveIntegrals, nil
 	}
-	return d.createTabulation()
+	tc, err := d.tabulation()
+	if err != nil {
+		return waveIntegrals{}, &GreenFunctionEvaluationError{fmt.Sprintf("failed to tabulate the wave integrals: %v", err)}
+	}
+
+	// interpolate returns the components c and c+1 of the tabulation, or of direct
+	interpolate := func(x, z float64, c int, direct func(x, z float64) (float64, float64)) (float64, float64) {
+		if math.Hypot(x, z) < tabulationSingularityRadius {
+			return direct(x, z)
+		}
+		values, _, _, err := tc.InterpolateComponents(x, z)
+		if err != nil {
+			// Not expected from a valid tabulation
+			return direct(x, z)
+		}
+		return real(values[c]), real(values[c+1])
+	}
+	return waveIntegrals{
+		delhommeau: func(x, z float64) (float64, float64) {
+			return interpolate(x, z, 0, delhommeauIntegrals)
+		},
+		negativePole: func(x, z float64) (float64, float64) {
+			return interpolate(x, z, 2, negativePoleIntegrals)
+		},
+	}, nil
 }
 
 // Evaluate computes the Green function between two meshes using Delhommeau method
 func (d *Delhommeau) Evaluate(mesh1, mesh2 interface{}, freeSurface float64, waterDepth float64,
 	wavenumber complex128, adjointDoubleLayer bool, earlyDotProduct bool) (*mat.CDense, *mat.CDense, error) {
 
+	terms, err := d.greenFunctionTerms(freeSurface, waterDepth, wavenumber)
+	if err != nil {
+		return nil, nil, err
+	}
+	return d.evaluateTerms(mesh1, mesh2, terms, adjointDoubleLayer, earlyDotProduct)
+}
+
+// EvaluateRankine computes the Rankine terms of the Green function for a finite positive wavenumber,
+// which do not depend on the value of the wavenumber
+func (d *Delhommeau) EvaluateRankine(mesh1, mesh2 interface{}, freeSurface float64, waterDepth float64,
+	adjointDoubleLayer bool, earlyDotProduct bool) (*mat.CDense, *mat.CDense, error) {
+
+	terms, err := d.greenFunctionTerms(freeSurface, waterDepth, 1)
+	if err != nil {
+		return nil, nil, err
+	}
+	terms.wave = nil
+	terms.waveImages = nil
+	return d.evaluateTerms(mesh1, mesh2, terms, adjointDoubleLayer, earlyDotProduct)
+}
+
+// EvaluateWave computes the wave terms of the Green function for a finite positive wavenumber
+func (d *Delhommeau) EvaluateWave(mesh1, mesh2 interface{}, freeSurface float64, waterDepth float64,
+	wavenumber complex128, adjointDoubleLayer bool, earlyDotProduct bool) (*mat.CDense, *mat.CDense, error) {
+
+	terms, err := d.greenFunctionTerms(freeSurface, waterDepth, wavenumber)
+	if err != nil {
+		return nil, nil, err
+	}
+	return d.evaluateTerms(mesh1, mesh2, greenFunctionTerms{waveImages: terms.waveImages, wave: terms.wave, waveOnly: true}, adjointDoubleLayer, earlyDotProduct)
+}
+
+// evaluateTerms integrates the given terms of the Green function
+func (d *Delhommeau) evaluateTerms(mesh1, mesh2 interface{}, terms greenFunctionTerms,
+	adjointDoubleLayer bool, earlyDotProduct bool) (*mat.CDense, *mat.CDense, error) {
+
 	// Get collocation points and normals
 	colocationPoints, earlyDotProductNormals, err := d.getColocationPointsAndNormals(mesh1, mesh2, adjointDoubleLayer)
 	if err != nil {
@@ -255,11 +461,11 @@ func (d *Delhommeau) Evaluate(mesh1, mesh2 interface{}, freeSurface float64, wat
 		rows, _ = pointArray.Dims()
 	}
 
-	if meshLike2, ok := mesh2.(MeshLike); ok {
-		cols = meshLike2.GetNbFaces()
-	} else {
+	meshLike2, ok := mesh2.(MeshLike)
+	if !ok {
 		return nil, nil, &GreenFunctionEvaluationError{"mesh2 must implement MeshLike interface"}
 	}
+	cols = meshLike2.GetNbFaces()
 
 	// Initialize matrices
 	S, K, err := d.initMatrices(rows, cols, earlyDotProduct)
@@ -267,22 +473,108 @@ func (d *Delhommeau) Evaluate(mesh1, mesh2 interface{}, freeSurface float64, wat
 		return nil, nil, err
 	}
 
-	// TODO: Implement actual Green function computation
-	// This would involve:
-	// 1. Computing distances between collocation points and mesh elements
-	// 2. Evaluating Green function using tabulated values or direct computation
-	// 3. Handling free surface effects and finite depth corrections
-	// 4. Applying singularity treatments
-
-	_ = colocationPoints
-	_ = earlyDotProductNormals
-	_ = freeSurface
-	_ = waterDepth
-	_ = wavenumber
+	d.fillMatrices(S, K, colocationPoints, earlyDotProductNormals, meshLike2, terms, adjointDoubleLayer, earlyDotProduct)
 
 	return S, K, nil
 }
 
+// greenFunctionTerms decomposes the Green function into Rankine terms and a wave term.
+// The imaginary part of the wavenumber is not supported by the wave term and is ignored.
+func (d *Delhommeau) greenFunctionTerms(freeSurface, waterDepth float64, wavenumber complex128) (greenFunctionTerms, error) {
+	if math.IsInf(freeSurface, 1) {
+		// No free surface: Rankine source in an unbounded fluid
+		return greenFunctionTerms{}, nil
+	}
+
+	k := real(wavenumber)
+	if !math.IsInf(waterDepth, 1) {
+		return d.finiteDepthGreenFunctionTerms(freeSurface, waterDepth, k)
+	}
+
+	switch {
+	case k == 0:
+		// Low frequency limit: the free surface acts as a rigid wall
+		return greenFunctionTerms{images: []rankineImage{{plane: freeSurface, weight: 1}}}, nil
+	case math.IsInf(k, 1):
+		// High frequency limit: the free surface acts as a zero potential surface
+		return greenFunctionTerms{images: []rankineImage{{plane: freeSurface, weight: -1}}}, nil
+	}
+
+	// low_freq_with_rankine_part is handled as low_freq: the Rankine-like part of the wave term
+	// is not integrated separately
+	highFrequency := d.gfSingularitiesIndex == 0
+	sign := 1.0
+	if highFrequency {
+		sign = -1.0
+	}
+
+	integrals, err := d.waveIntegrals()
+	if err != nil {
+		return greenFunctionTerms{}, err
+	}
+	return greenFunctionTerms{
+		images: []rankineImage{{plane: freeSurface, weight: sign}},
+		wave:   infiniteDepthWaveTerm(k, freeSurface, highFrequency, integrals),
+	}, nil
+}
+
+// finiteDepthGreenFunctionTerms decomposes the finite depth Green function, see finiteDepthWaveTerm.
+// The images of the Prony decomposition depend on the wavenumber: with the newer method, they are integrated
+// exactly on the neighbouring faces like the other images, while the legacy method computes all the images
+// but the one with respect to the free surface in the wave term with a one-point quadrature.
+func (d *Delhommeau) finiteDepthGreenFunctionTerms(freeSurface, waterDepth, k float64) (greenFunctionTerms, error) {
+	if !(waterDepth > 0) {
+		return greenFunctionTerms{}, &GreenFunctionEvaluationError{fmt.Sprintf("invalid water depth %g", waterDepth)}
+	}
+	if k == 0 {
+		return greenFunctionTerms{}, &GreenFunctionEvaluationError{"zero wavenumber is only implemented for infinite depth"}
+	}
+	if math.IsInf(k, 1) {
+		// High frequency limit: the free surface acts as a zero potential surface above a rigid sea bottom
+		return greenFunctionTerms{images: infiniteFrequencyImages(waterDepth, freeSurface)}, nil
+	}
+
+	prony, err := d.FiniteDepthPronyDecomposition(k * waterDepth)
+	if err != nil {
+		return greenFunctionTerms{}, &GreenFunctionEvaluationError{err.Error()}
+	}
+
+	highFrequency := d.gfSingularitiesIndex == 0
+	sign := 1.0
+	if highFrequency {
+		sign = -1.0
+	}
+	legacy := d.finiteDepthMethodIndex == 0
+	integrals, err := d.waveIntegrals()
+	if err != nil {
+		return greenFunctionTerms{}, err
+	}
+
+	terms := greenFunctionTerms{
+		images: []rankineImage{{plane: freeSurface, weight: sign}},
+		wave:   finiteDepthWaveTerm(k, waterDepth, freeSurface, prony, legacy, highFrequency, integrals),
+	}
+	if !legacy {
+		terms.images = append(terms.images, finiteDepthImages(waterDepth, freeSurface)...)
+		terms.waveImages = pronyImages(waterDepth, freeSurface, prony)
+	}
+	return terms, nil
+}
+
+// FiniteDepthPronyDecomposition returns the Prony decomposition of the finite depth kernel for the dimensionless
+// wavenumber kh, computed with the method of the parameters and cached for the next evaluations
+func (d *Delhommeau) FiniteDepthPronyDecomposition(dimensionlessWavenumber float64) (*PronyDecomposition, error) {
+	if cached, ok := d.pronyDecompositions.Load(dimensionlessWavenumber); ok {
+		return cached.(*PronyDecomposition), nil
+	}
+	prony, err := FiniteDepthPronyDecomposition(dimensionlessWavenumber, d.parameters.FiniteDepthPronyDecompositionMethod)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := d.pronyDecompositions.LoadOrStore(dimensionlessWavenumber, prony)
+	return actual.(*PronyDecomposition), nil
+}
+
 // GetParameters returns the current parameters
 func (d *Delhommeau) GetParameters() DelhommeauParameters {
 	return d.parameters
@@ -1,7 +1,10 @@
 package green_functions
 
 import (
+	"gonum.org/v1/gonum/mat"
 	"math"
+	"math/cmplx"
+	"os"
 	"reflect"
 	"testing"
 )
@@ -193,6 +196,218 @@ func TestDelhommeau_Evaluate(t *testing.T) {
 	}
 }
 
+func TestDelhommeau_FiniteDepth(t *testing.T) {
+	depth := 2.0
+	points := [][3]float64{{0.6, -0.4, -1.2}, {2, -1, -0.2}, {6, -1.8, -0.6}, {0.4, -0.1, -0.1}}
+
+	for _, method := range []FiniteDepthMethod{LegacyMethod, NewerMethod} {
+		params := DefaultDelhommeauParameters()
+		params.FiniteDepthMethod = method
+		d := NewDelhommeau(params)
+
+		for _, kh := range []float64{0.1, 0.5, 1, 2, 5, 10} {
+			k := kh / depth
+			reference := NewFinGreen3D(depth)
+
+			for _, p := range points {
+				// Point sources of the mock mesh have a unit weight
+				field := mat.NewDense(1, 3, []float64{0, 0, p[1]})
+				source := NewMockMesh([][]float64{{p[0], 0, p[2]}}, [][]float64{{0, 0, 1}})
+				S, _, err := d.Evaluate(field, source, 0, depth, complex(k, 0), true, true)
+				if err != nil {
+					t.Fatalf("%s, kh=%v: unexpected error: %v", method, kh, err)
+				}
+				g := -4 * math.Pi * S.At(0, 0)
+
+				SRef, _, err := reference.Evaluate(field, source, 0, depth, complex(k, 0), true, true)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				expected := -4 * math.Pi * SRef.At(0, 0)
+				// Compared to the Rankine term
+				r := math.Hypot(p[0], p[1]-p[2])
+				if cmplx.Abs(g-expected) > 1e-2/r {
+					t.Errorf("%s, kh=%v, (R, z, ζ)=%v: expected %v, got %v", method, kh, p, expected, g)
+				}
+			}
+		}
+	}
+}
+
+func TestDelhommeau_FiniteDepthSplit(t *testing.T) {
+	mesh, err := NewHemisphereMesh(1, 4, 8)
+	if err != nil {
+		t.Fatalf("Failed to create mesh: %v", err)
+	}
+
+	for _, method := range []FiniteDepthMethod{LegacyMethod, NewerMethod} {
+		params := DefaultDelhommeauParameters()
+		params.FiniteDepthMethod = method
+		d := NewDelhommeau(params)
+
+		S, K, err := d.Evaluate(mesh, mesh, 0, 3, 0.8, false, false)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", method, err)
+		}
+		SRankine, KRankine, err := d.EvaluateRankine(mesh, mesh, 0, 3, false, false)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", method, err)
+		}
+		SWave, KWave, err := d.EvaluateWave(mesh, mesh, 0, 3, 0.8, false, false)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", method, err)
+		}
+		addComplexMatrix(SRankine, SWave)
+		addComplexMatrix(KRankine, KWave)
+		if !mat.CEqualApprox(S, SRankine, 1e-12) || !mat.CEqualApprox(K, KRankine, 1e-12) {
+			t.Errorf("%s: Rankine and wave terms do not add up to the Green function", method)
+		}
+	}
+
+	d := NewDefaultDelhommeau()
+	if _, _, err := d.Evaluate(mesh, mesh, 0, 3, 0, false, false); err == nil {
+		t.Error("Expected error for a zero wavenumber in finite depth")
+	}
+}
+
+func TestDelhommeau_InfiniteWavenumberFiniteDepth(t *testing.T) {
+	mesh, err := NewHemisphereMesh(1, 3, 6)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	d := NewDefaultDelhommeau()
+	points := mat.NewDense(3, 3, []float64{
+		0.5, 0.2, 0, // on the free surface
+		0.3, -0.1, -2, // on the sea bottom
+		2, 0, -1,
+	})
+	S, K, err := d.Evaluate(points, mesh, 0, 2, complex(math.Inf(1), 0), true, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The potential vanishes on the free surface and its vertical derivative on the sea bottom
+	_, cols := S.Dims()
+	for j := 0; j < cols; j++ {
+		if s := cmplx.Abs(S.At(0, j)); s > 1e-6 {
+			t.Errorf("Expected a zero potential on the free surface, got %v for face %d", s, j)
+		}
+		if dz := cmplx.Abs(K.At(1, 3*j+2)); dz > 1e-6 {
+			t.Errorf("Expected no flux through the sea bottom, got %v for face %d", dz, j)
+		}
+	}
+
+	// The sea bottom has a negligible influence in deep water
+	SDeep, _, err := d.Evaluate(points, mesh, 0, math.Inf(1), complex(math.Inf(1), 0), false, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	SFar, _, err := d.Evaluate(points, mesh, 0, 1000, complex(math.Inf(1), 0), false, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !mat.CEqualApprox(SFar, SDeep, 1e-4) {
+		t.Error("Expected the infinite wavenumber Green function in very deep water to match the infinite depth one")
+	}
+}
+
+func TestDelhommeau_TabulationCacheDir(t *testing.T) {
+	params := DefaultDelhommeauParameters()
+	params.TabulationNr = 100
+	params.TabulationNz = 60
+	direct := NewDelhommeau(params)
+	params.TabulationCacheDir = t.TempDir()
+	tabulated := NewDelhommeau(params)
+	cacheFile := tabulated.tabulationCacheFile()
+	defer sharedTabulations.Delete(cacheFile)
+
+	mesh, err := NewHemisphereMesh(1, 4, 8)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, depth := range []float64{math.Inf(1), 3} {
+		expectedS, expectedK, err := direct.EvaluateWave(mesh, mesh, 0, depth, 1.5, true, true)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		S, K, err := tabulated.EvaluateWave(mesh, mesh, 0, depth, 1.5, true, true)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for _, m := range [][2]*mat.CDense{{S, expectedS}, {K, expectedK}} {
+			rows, cols := m[0].Dims()
+			var difference, scale float64
+			for i := 0; i < rows; i++ {
+				for j := 0; j < cols; j++ {
+					difference = math.Max(difference, cmplx.Abs(m[0].At(i, j)-m[1].At(i, j)))
+					scale = math.Max(scale, cmplx.Abs(m[1].At(i, j)))
+				}
+			}
+			if difference > 1e-3*scale {
+				t.Errorf("Depth %v: expected the tabulated wave terms within 1e-3 of the integrated ones, got a difference of %v for %v", depth, difference, scale)
+			}
+		}
+	}
+
+	if _, err := os.Stat(cacheFile); err != nil {
+		t.Fatalf("Expected the tabulation to be saved: %v", err)
+	}
+
+	// The saved tabulation is loaded instead of being created again
+	saved, err := LoadTabulationCache(cacheFile)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	saved.Components[0][30][50] = 42
+	if err := saved.Save(cacheFile); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	sharedTabulations.Delete(cacheFile)
+	tc, err := NewDelhommeau(params).tabulation()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tc.Components[0][30][50] != 42 {
+		t.Error("Expected the saved tabulation to be loaded")
+	}
+
+	// A saved tabulation of another grid is replaced
+	other := NewTabulationCache([]float64{0, 1}, []float64{-1, 0}, Float64)
+	other.IsValid = true
+	if err := other.Save(cacheFile); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	sharedTabulations.Delete(cacheFile)
+	if tc, err = NewDelhommeau(params).tabulation(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(tc.RRange) != params.TabulationNr || len(tc.ZRange) != params.TabulationNz {
+		t.Errorf("Expected a tabulation of %dx%d nodes, got %dx%d", params.TabulationNz, params.TabulationNr, len(tc.ZRange), len(tc.RRange))
+	}
+	if saved, err := LoadTabulationCache(cacheFile); err != nil || len(saved.RRange) != params.TabulationNr {
+		t.Errorf("Expected the new tabulation to be saved (%v)", err)
+	}
+}
+
+func TestDelhommeau_SetFloatingPointPrecision(t *testing.T) {
+	d := NewDefaultDelhommeau()
+	d.SetFloatingPointPrecision(Float32)
+
+	params := DefaultDelhommeauParameters()
+	params.FloatingPointPrecision = Float32
+	expected := NewDelhommeau(params)
+
+	if d.GetParameters() != expected.GetParameters() {
+		t.Errorf("Expected parameters %+v, got %+v", expected.GetParameters(), d.GetParameters())
+	}
+	if !reflect.DeepEqual(d.Settings(), expected.Settings()) {
+		t.Errorf("Expected settings %v, got %v", expected.Settings(), d.Settings())
+	}
+	if d.Hash() != expected.Hash() || d.Hash() == NewDefaultDelhommeau().Hash() {
+		t.Error("Expected the hash to follow the precision")
+	}
+}
+
 func TestDelhommeau_GetParameters(t *testing.T) {
 	params := DefaultDelhommeauParameters()
 	params.TabulationNr = 500
@@ -0,0 +1,150 @@
+// Package green_functions - Mean second order drift forces
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package green_functions
+
+import (
+	"errors"
+	"fmt"
+	"gonum.org/v1/gonum/mat"
+	"math"
+	"math/cmplx"
+)
+
+// DefaultNbTheta is the default number of directions used to integrate the Kochin function
+const DefaultNbTheta = 180
+
+// MeanDriftForce is the mean second order horizontal force and yaw moment
+// exerted by regular waves of unit amplitude on a body
+type MeanDriftForce struct {
+	Omega         float64
+	WaveDirection float64
+	Surge         float64
+	Sway          float64
+	Yaw           float64
+}
+
+// FarFieldDriftForce computes the mean drift force with Maruo's far-field formulation, from the Kochin function
+// of the total disturbance of the body in regular waves of unit amplitude and direction waveDirection.
+// The yaw moment is given around the vertical axis through the origin.
+//
+//	F = -2n [ ρg a²/4 ∫ |H|² e_θ dθ + ρg a sqrt(π/2k) Re(H(β)) e_β ]
+//	M = -2n [ ρg a²/(4k) ∫ Im(H' H*) dθ + ρg a/k sqrt(π/2k) Im(H'(β)) ]
+//
+// The integrals over θ are computed with the trapezoidal rule on nbTheta directions,
+// which converges quickly for periodic functions.
+func FarFieldDriftForce(kochin *KochinFunction, omega, waveDirection, rho float64, nbTheta int) MeanDriftForce {
+	if nbTheta <= 0 {
+		nbTheta = DefaultNbTheta
+	}
+
+	k := kochin.Wavenumber
+	n2 := groupVelocityRatio(k, kochin.WaterDepth)
+	a := kochin.farFieldAmplitude(omega)
+	rg := rho * Gravity
+
+	dTheta := 2 * math.Pi / float64(nbTheta)
+	var ix, iy, iz float64
+	for i := 0; i < nbTheta; i++ {
+		theta := float64(i) * dTheta
+		h, dh := kochin.evaluate(theta)
+		h2 := real(h)*real(h) + imag(h)*imag(h)
+		ix += h2 * math.Cos(theta) * dTheta
+		iy += h2 * math.Sin(theta) * dTheta
+		iz += imag(dh*cmplx.Conj(h)) * dTheta
+	}
+
+	hBeta, dhBeta := kochin.evaluate(waveDirection)
+	cross := rg * a * math.Sqrt(math.Pi/(2*k))
+
+	return MeanDriftForce{
+		Omega:         omega,
+		WaveDirection: waveDirection,
+		Surge:         -n2 * (rg*a*a/4*ix + cross*real(hBeta)*math.Cos(waveDirection)),
+		Sway:          -n2 * (rg*a*a/4*iy + cross*real(hBeta)*math.Sin(waveDirection)),
+		Yaw:           -n2 * (rg*a*a/(4*k)*iz + cross/k*imag(dhBeta)),
+	}
+}
+
+// FarFieldDriftForces computes the far-field mean drift forces on a body for each wave direction of a result.
+// motions holds the complex motion amplitudes as a (nDirections, nDOFs) matrix, as returned by ComputeRAO.
+// With nil motions, the drift forces on the fixed body are computed.
+func FarFieldDriftForces(body *FloatingBody, result *FrequencyResult, motions *mat.CDense, nbTheta int) ([]MeanDriftForce, error) {
+	nDirections := len(result.WaveDirections)
+	if motions != nil {
+		rows, cols := motions.Dims()
+		if rows != nDirections || cols != body.NbDOFs() {
+			return nil, fmt.Errorf("motions must be a (%d, %d) matrix", nDirections, body.NbDOFs())
+		}
+	}
+	if len(result.Diffraction) != nDirections {
+		return nil, errors.New("result has no diffraction solution for each wave direction")
+	}
+
+	forces := make([]MeanDriftForce, nDirections)
+	for d, beta := range result.WaveDirections {
+		var xi []complex128
+		if motions != nil {
+			xi = make([]complex128, body.NbDOFs())
+			for j := range xi {
+				xi[j] = motions.At(d, j)
+			}
+		}
+
+		kochin, err := NewKochinFunction(body.Mesh, result.TotalSources(d, xi), result.Wavenumber, result.WaterDepth)
+		if err != nil {
+			return nil, err
+		}
+		forces[d] = FarFieldDriftForce(kochin, result.Omega, beta, result.Rho, nbTheta)
+	}
+	return forces, nil
+}
+
+// QTFDiagonal holds mean drift forces in waves of unit amplitude over a range of frequencies and wave directions.
+// They are the diagonal of the difference frequency quadratic transfer function.
+type QTFDiagonal struct {
+	Omegas         []float64
+	WaveDirections []float64
+
+	// Surge, Sway and Yaw are (nOmegas, nDirections) matrices
+	Surge *mat.Dense
+	Sway  *mat.Dense
+	Yaw   *mat.Dense
+}
+
+// NewQTFDiagonal gathers mean drift forces computed at several frequencies, one slice per frequency,
+// each slice holding the forces for the same wave directions
+func NewQTFDiagonal(forces [][]MeanDriftForce) (*QTFDiagonal, error) {
+	if len(forces) == 0 || len(forces[0]) == 0 {
+		return nil, errors.New("no drift forces to gather")
+	}
+
+	nOmegas, nDirections := len(forces), len(forces[0])
+	qtf := &QTFDiagonal{
+		Omegas:         make([]float64, nOmegas),
+		WaveDirections: make([]float64, nDirections),
+		Surge:          mat.NewDense(nOmegas, nDirections, nil),
+		Sway:           mat.NewDense(nOmegas, nDirections, nil),
+		Yaw:            mat.NewDense(nOmegas, nDirections, nil),
+	}
+	for d, force := range forces[0] {
+		qtf.WaveDirections[d] = force.WaveDirection
+	}
+
+	for i, atOmega := range forces {
+		if len(atOmega) != nDirections {
+			return nil, fmt.Errorf("expected %d wave directions at frequency index %d, got %d", nDirections, i, len(atOmega))
+		}
+		qtf.Omegas[i] = atOmega[0].Omega
+		for d, force := range atOmega {
+			if force.Omega != qtf.Omegas[i] || force.WaveDirection != qtf.WaveDirections[d] {
+				return nil, fmt.Errorf("inconsistent frequency or wave direction at index (%d, %d)", i, d)
+			}
+			qtf.Surge.Set(i, d, force.Surge)
+			qtf.Sway.Set(i, d, force.Sway)
+			qtf.Yaw.Set(i, d, force.Yaw)
+		}
+	}
+	return qtf, nil
+}
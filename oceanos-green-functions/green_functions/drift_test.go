@@ -0,0 +1,221 @@
+package green_functions
+
+import (
+	"gonum.org/v1/gonum/mat"
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+// energyDriftForce computes the mean drift force from the energy conservation form of Maruo's formula,
+//
+//	F = ρg/4 2n ∫ |a H|² (e_β - e_θ) dθ
+//
+// which holds for bodies that do not absorb energy
+func energyDriftForce(kochin *KochinFunction, omega, waveDirection float64, nbTheta int) (float64, float64) {
+	a := kochin.farFieldAmplitude(omega)
+	n2 := groupVelocityRatio(kochin.Wavenumber, kochin.WaterDepth)
+	var fx, fy float64
+	for i := 0; i < nbTheta; i++ {
+		theta := 2 * math.Pi * float64(i) / float64(nbTheta)
+		h := kochin.Evaluate(theta)
+		h2 := a * a * (real(h)*real(h) + imag(h)*imag(h))
+		fx += h2 * (math.Cos(waveDirection) - math.Cos(theta))
+		fy += h2 * (math.Sin(waveDirection) - math.Sin(theta))
+	}
+	coef := WaterDensity * Gravity / 4 * n2 * 2 * math.Pi / float64(nbTheta)
+	return coef * fx, coef * fy
+}
+
+func TestFarFieldDriftForces_Hemisphere(t *testing.T) {
+	body := newTestHemisphere(t, 8, 16)
+	solver := NewBEMSolver(NewDefaultDelhommeau())
+	directions := []float64{0, math.Pi / 4}
+
+	for _, kr := range []float64{0.5, 1.5} {
+		omega := math.Sqrt(kr * Gravity)
+		result, err := solver.SolveFrequency(body, omega, math.Inf(1), directions)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		rao, err := result.ComputeRAO(body)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		for _, floating := range []bool{false, true} {
+			motions := rao
+			if !floating {
+				motions = nil
+			}
+			forces, err := FarFieldDriftForces(body, result, motions, 0)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(forces) != len(directions) {
+				t.Fatalf("Expected %d drift forces, got %d", len(directions), len(forces))
+			}
+
+			for d, force := range forces {
+				var xi []complex128
+				if floating {
+					xi = make([]complex128, body.NbDOFs())
+					for j := range xi {
+						xi[j] = rao.At(d, j)
+					}
+				}
+				kochin, _ := NewKochinFunction(body.Mesh, result.TotalSources(d, xi), result.Wavenumber, result.WaterDepth)
+				fx, fy := energyDriftForce(kochin, omega, directions[d], DefaultNbTheta)
+
+				scale := WaterDensity * Gravity
+				if math.Abs(force.Surge-fx) > 1e-3*scale || math.Abs(force.Sway-fy) > 1e-3*scale {
+					t.Errorf("kR=%v floating=%v β=%v: drift force (%v, %v) does not match energy balance (%v, %v)",
+						kr, floating, directions[d], force.Surge, force.Sway, fx, fy)
+				}
+
+				// The drift force is aligned with the waves and the yaw moment vanishes by symmetry
+				if force.Surge <= 0 {
+					t.Errorf("kR=%v floating=%v: expected positive drift force, got %v", kr, floating, force.Surge)
+				}
+				if math.Abs(force.Surge*math.Sin(directions[d])-force.Sway*math.Cos(directions[d])) > 1e-6*scale {
+					t.Errorf("kR=%v floating=%v: drift force is not aligned with the waves", kr, floating)
+				}
+				if math.Abs(force.Yaw) > 1e-6*scale {
+					t.Errorf("kR=%v floating=%v: expected zero yaw moment, got %v", kr, floating, force.Yaw)
+				}
+			}
+		}
+	}
+}
+
+// The surge drift coefficient F/(ρgA²R) of the hemisphere in head waves of amplitude A is checked against its
+// limits over kR. Long waves are not scattered by the fixed body and carry the floating one, so that the drift force
+// vanishes. Short waves are reflected by the vertical wall at the waterline, where the drift coefficient of a
+// circular waterline tends to 2/3 (Faltinsen 1990, Sea Loads on Ships and Offshore Structures, chapter 5); the
+// hemisphere reaches it slowly, hence the 0.06 tolerance at kR = 6 and 8, which also covers the 288 panels.
+func TestFarFieldDriftForce_HemisphereSurgeCoefficients(t *testing.T) {
+	body := newTestHemisphere(t, 12, 24)
+	solver := NewBEMSolver(NewDefaultDelhommeau())
+
+	for _, tc := range []struct {
+		kr        float64
+		fixed     float64
+		floating  float64
+		tolerance float64
+	}{
+		{0.2, 0, 0, 0.02},
+		{6, 2. / 3, 2. / 3, 0.06},
+		{8, 2. / 3, 2. / 3, 0.06},
+	} {
+		omega := math.Sqrt(tc.kr * Gravity)
+		result, err := solver.SolveFrequency(body, omega, math.Inf(1), []float64{0})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		rao, err := result.ComputeRAO(body)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		for _, motions := range []struct {
+			name     string
+			rao      *mat.CDense
+			expected float64
+		}{
+			{"fixed", nil, tc.fixed},
+			{"floating", rao, tc.floating},
+		} {
+			forces, err := FarFieldDriftForces(body, result, motions.rao, 0)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			coefficient := forces[0].Surge / (result.Rho * Gravity)
+			if math.Abs(coefficient-motions.expected) > tc.tolerance {
+				t.Errorf("kR=%v %s: expected a surge drift coefficient of %.3f ± %v, got %.4f",
+					tc.kr, motions.name, motions.expected, tc.tolerance, coefficient)
+			}
+		}
+	}
+}
+
+func TestFarFieldDriftForce_YawMomentAroundShiftedOrigin(t *testing.T) {
+	body := newTestHemisphere(t, 8, 16)
+	solver := NewBEMSolver(NewDefaultDelhommeau())
+	omega := math.Sqrt(Gravity)
+	beta := math.Pi / 3
+
+	result, err := solver.SolveFrequency(body, omega, math.Inf(1), []float64{beta})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	kochin, _ := NewKochinFunction(body.Mesh, result.Diffraction[0].Sources, result.Wavenumber, result.WaterDepth)
+	force := FarFieldDriftForce(kochin, omega, beta, WaterDensity, 0)
+
+	// Translating the body by d multiplies its sources by the phase of the incident wave at d.
+	// The moment around the origin is then the moment around the body plus d × F.
+	k := result.Wavenumber
+	dx, dy := 1.5, -0.7
+	phase := cmplx.Exp(complex(0, k*(dx*math.Cos(beta)+dy*math.Sin(beta))))
+	shifted := &KochinFunction{Wavenumber: k, WaterDepth: kochin.WaterDepth}
+	for i := range kochin.weights {
+		shifted.x = append(shifted.x, kochin.x[i]+dx)
+		shifted.y = append(shifted.y, kochin.y[i]+dy)
+		shifted.weights = append(shifted.weights, kochin.weights[i]*phase)
+	}
+	shiftedForce := FarFieldDriftForce(shifted, omega, beta, WaterDensity, 0)
+
+	if math.Abs(shiftedForce.Surge-force.Surge) > 1e-6*math.Abs(force.Surge) {
+		t.Errorf("Drift force changed with translation: %v vs %v", shiftedForce.Surge, force.Surge)
+	}
+	expected := force.Yaw + dx*force.Sway - dy*force.Surge
+	if math.Abs(shiftedForce.Yaw-expected) > 1e-6*math.Abs(expected) {
+		t.Errorf("Yaw moment %v, expected %v", shiftedForce.Yaw, expected)
+	}
+}
+
+// The waves radiated by an axisymmetric body oscillating in surge and heave in still water carry a mean horizontal
+// momentum flux, opposite to the drift force on the body. With a radiated power per direction P3/(2π) in heave and
+// P1/π cos²θ in surge, where P_j = ω²B_jj/2 for unit motions, the drift force oscillates with the phase φ between
+// the motions with an amplitude √2 (k/ω) √(P1 P3) = kω √(B11 B33 / 2), which only depends on damping coefficients
+// such as those tabulated by Hulme for the hemisphere. They come here from the pressure on the body, independently
+// of the Kochin function.
+func TestFarFieldDriftForce_HemisphereRadiationRecoil(t *testing.T) {
+	body := newTestHemisphere(t, 8, 16)
+	solver := NewBEMSolver(NewDefaultDelhommeau())
+
+	for _, kr := range []float64{0.5, 1, 1.5} {
+		omega := math.Sqrt(kr * Gravity)
+		result, err := solver.SolveFrequency(body, omega, math.Inf(1), []float64{0})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		// drift returns the drift force of the waves radiated by the surge and heave motions
+		drift := func(surge, heave complex128) float64 {
+			sigma := make([]complex128, len(result.Radiation[0].Sources))
+			for i := range sigma {
+				sigma[i] = complex(0, -omega) * (surge*result.Radiation[0].Sources[i] + heave*result.Radiation[2].Sources[i])
+			}
+			kochin, err := NewKochinFunction(body.Mesh, sigma, result.Wavenumber, result.WaterDepth)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			return FarFieldDriftForce(kochin, omega, 0, result.Rho, 0).Surge
+		}
+
+		// The terms of FarFieldDriftForce linear in the Kochin function, from the incident waves, cancel out
+		interaction := func(phi float64) float64 {
+			surge := cmplx.Exp(complex(0, phi))
+			return drift(surge, 1) - drift(surge, 0) - drift(0, 1)
+		}
+		amplitude := math.Hypot(interaction(0), interaction(math.Pi/2))
+
+		k := result.Wavenumber
+		expected := k * omega * math.Sqrt(result.RadiationDamping.At(0, 0)*result.RadiationDamping.At(2, 2)/2)
+		// On 128 panels, the radiated power from the Kochin function exceeds ω²B/2 by up to 6%,
+		// decreasing with the size of the panels
+		if math.Abs(amplitude-expected) > 0.06*expected {
+			t.Errorf("kR=%v: expected a recoil drift force amplitude of %v, got %v", kr, expected, amplitude)
+		}
+	}
+}
@@ -11,6 +11,13 @@ import (
 	"math/cmplx"
 )
 
+// finGreen3DSeriesTerms is the smallest number of evanescent modes of the eigenfunction expansion
+const finGreen3DSeriesTerms = 200
+
+// finGreen3DSmallestDistance is the product k0 R_min of the wavenumber by the horizontal distance below which
+// the expansion is not evaluated, see computeWavePart. It sets the number of evanescent modes in deep water.
+const finGreen3DSmallestDistance = 0.01
+
 // FinGreen3D implements finite depth Green function computation
 // Based on the Fortran implementation by Yingyi Liu (2013)
 type FinGreen3D struct {
@@ -35,6 +42,11 @@ func NewFinGreen3D(waterDepth float64) *FinGreen3D {
 	return fg
 }
 
+// Settings returns a copy of the parameters of the FinGreen3D Green function
+func (fg *FinGreen3D) Settings() map[string]interface{} {
+	return copySettings(fg.exportableSettings)
+}
+
 // String returns a string representation of the FinGreen3D Green function
 func (fg *FinGreen3D) String() string {
 	return "FinGreen3D(water_depth=" + fmt.Sprintf("%.2f", fg.waterDepth) + ")"
@@ -56,31 +68,55 @@ func (fg *FinGreen3D) SetWaveNumber(wavenumber complex128) error {
 	return nil
 }
 
-// computeDispersionRoots computes the roots of the dispersion relation
+// computeDispersionRoots computes the roots of the dispersion relation.
+// The first root is the wavenumber k0 of the propagating waves, with ν = k0 tanh(k0 h). In finite depth,
+// it is followed by the imaginary roots i k_n of the evanescent modes, where k_n tan(k_n h) = -ν
+// and (n - 1/2)π < k_n h < nπ. The number of evanescent modes grows with k0 h, see finGreen3DSmallestDistance.
 func (fg *FinGreen3D) computeDispersionRoots(wavenumber complex128) ([]complex128, error) {
-	// TODO: Implement proper dispersion relation solver
-	// This involves solving: V = k*tanh(k*h) where V = omega^2/g
-
-	// For now, return a simple approximation
 	k0 := wavenumber
 	roots := []complex128{k0}
+	if math.IsInf(fg.waterDepth, 1) {
+		return roots, nil
+	}
 
-	// Add imaginary roots for finite depth case
-	if !math.IsInf(fg.waterDepth, 1) {
-		// Add additional roots for finite depth
-		for n := 1; n <= 10; n++ {
-			kn := complex(0, float64(n)*math.Pi/fg.waterDepth)
-			roots = append(roots, kn)
+	h := fg.waterDepth
+	K := real(k0) * h * math.Tanh(real(k0)*h)
+	nbModes := int(math.Max(finGreen3DSeriesTerms, math.Ceil(2*real(k0)*h/(math.Pi*finGreen3DSmallestDistance))))
+	for n := 1; n <= nbModes; n++ {
+		// Bisection on y sin(y) + K cos(y), which has no poles and changes sign on the interval
+		f := func(y float64) float64 { return y*math.Sin(y) + K*math.Cos(y) }
+		a, b := (float64(n)-0.5)*math.Pi, float64(n)*math.Pi
+		fa := f(a)
+		for iter := 0; iter < 100 && b-a > 1e-15*b; iter++ {
+			m := (a + b) / 2
+			if fm := f(m); (fm < 0) == (fa < 0) {
+				a, fa = m, fm
+			} else {
+				b = m
+			}
 		}
+		roots = append(roots, complex(0, (a+b)/(2*h)))
 	}
 
 	return roots, nil
 }
 
-// Evaluate computes the Green function using FinGreen3D method
+// Evaluate computes the Green function with its eigenfunction expansion. The Rankine source and its images of
+// greenFunctionTerms are integrated exactly on the neighbouring faces, the wave part with a one-point quadrature.
+// Only finite depths and positive wavenumbers are supported.
 func (fg *FinGreen3D) Evaluate(mesh1, mesh2 interface{}, freeSurface float64, waterDepth float64,
 	wavenumber complex128, adjointDoubleLayer bool, earlyDotProduct bool) (*mat.CDense, *mat.CDense, error) {
 
+	if !(waterDepth > 0) || math.IsInf(waterDepth, 1) {
+		return nil, nil, &GreenFunctionEvaluationError{fmt.Sprintf("FinGreen3D requires a finite water depth, got %g", waterDepth)}
+	}
+	if math.IsInf(freeSurface, 0) {
+		return nil, nil, &GreenFunctionEvaluationError{"FinGreen3D requires a free surface"}
+	}
+	if k := real(wavenumber); !(k > 0) || math.IsInf(k, 1) {
+		return nil, nil, &GreenFunctionEvaluationError{fmt.Sprintf("FinGreen3D requires a positive and finite wavenumber, got %g", k)}
+	}
+
 	// Update water depth and wave number if changed
 	if waterDepth != fg.waterDepth {
 		fg.waterDepth = waterDepth
@@ -98,38 +134,168 @@ func (fg *FinGreen3D) Evaluate(mesh1, mesh2 interface{}, freeSurface float64, wa
 	}
 
 	// Determine matrix dimensions
-	var rows, cols int
+	var rows int
 	if meshLike1, ok := mesh1.(MeshLike); ok {
 		rows = meshLike1.GetNbFaces()
 	} else if pointArray, ok := mesh1.(*mat.Dense); ok {
 		rows, _ = pointArray.Dims()
 	}
 
-	if meshLike2, ok := mesh2.(MeshLike); ok {
-		cols = meshLike2.GetNbFaces()
-	} else {
+	meshLike2, ok := mesh2.(MeshLike)
+	if !ok {
 		return nil, nil, &GreenFunctionEvaluationError{"mesh2 must implement MeshLike interface"}
 	}
 
 	// Initialize matrices
-	S, K, err := fg.initMatrices(rows, cols, earlyDotProduct)
+	S, K, err := fg.initMatrices(rows, meshLike2.GetNbFaces(), earlyDotProduct)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	// TODO: Implement actual FinGreen3D computation
-	// This would involve:
-	// 1. Computing the Rankine part: 1/r + 1/r'
-	// 2. Computing the wave part Gw using series expansion
-	// 3. Handling singularities properly
-
-	_ = colocationPoints
-	_ = earlyDotProductNormals
-	_ = freeSurface
+	fg.fillMatrices(S, K, colocationPoints, earlyDotProductNormals, meshLike2, fg.greenFunctionTerms(freeSurface), adjointDoubleLayer, earlyDotProduct)
 
 	return S, K, nil
 }
 
+// greenFunctionTerms decomposes the eigenfunction expansion into the Rankine source, its images with respect to
+// the free surface and the sea bottom, integrated exactly on the neighbouring faces, and the remaining wave term
+func (fg *FinGreen3D) greenFunctionTerms(freeSurface float64) greenFunctionTerms {
+	images := []rankineImage{{plane: freeSurface, weight: 1}}
+	images = append(images, finiteDepthImages(fg.waterDepth, freeSurface)...)
+
+	return greenFunctionTerms{
+		images: images,
+		wave: func(x, xi [3]float64) (complex128, [3]complex128, [3]complex128) {
+			dx, dy := x[0]-xi[0], x[1]-xi[1]
+			rr := math.Hypot(dx, dy)
+			term := fg.computeWavePart(rr, x[2]-freeSurface, xi[2]-freeSurface)
+
+			var gradX, gradXi [3]complex128
+			if rr > 0 {
+				gradX[0] = term.dR * complex(dx/rr, 0)
+				gradX[1] = term.dR * complex(dy/rr, 0)
+				gradXi[0], gradXi[1] = -gradX[0], -gradX[1]
+			}
+			gradX[2] = term.dzf
+			gradXi[2] = term.dzp
+			return term.value, gradX, gradXi
+		},
+	}
+}
+
+// expansionTerm is a term of the eigenfunction expansion with its derivatives with respect to the horizontal
+// distance R and to the vertical coordinates zf and zp of the field point and of the source point
+type expansionTerm struct {
+	value, dR, dzf, dzp complex128
+}
+
+// add adds the term other multiplied by weight
+func (t *expansionTerm) add(other expansionTerm, weight float64) {
+	w := complex(weight, 0)
+	t.value += w * other.value
+	t.dR += w * other.dR
+	t.dzf += w * other.dzf
+	t.dzp += w * other.dzp
+}
+
+// rankineTerm computes 1/ρ with ρ² = R² + Z², for Z = zf + zpSign zp + shift
+func rankineTerm(rr, zf, zp, zpSign, shift float64) expansionTerm {
+	z := zf + zpSign*zp + shift
+	rho := math.Hypot(rr, z)
+	rho3 := rho * rho * rho
+	return expansionTerm{
+		value: complex(1/rho, 0),
+		dR:    complex(-rr/rho3, 0),
+		dzf:   complex(-z/rho3, 0),
+		dzp:   complex(-zpSign*z/rho3, 0),
+	}
+}
+
+// finGreen3DSmallDistance is the horizontal distance, relative to the water depth, below which the evanescent
+// modes are summed with the Rankine images of their large order asymptotics
+const finGreen3DSmallDistance = 0.25
+
+// finGreen3DImageOrders is the number of periods of the Rankine images of the small distance expansion
+const finGreen3DImageOrders = 50
+
+// computeWavePart computes the eigenfunction expansion without the Rankine source and the images of
+// greenFunctionTerms, at the horizontal distance R of the depths zf and zp below the free surface.
+//
+// For R larger than finGreen3DSmallDistance h, the expansion converges exponentially and the images are subtracted
+// from it. For smaller R, the series of the evanescent modes is written as
+//
+//	Σ_n [a_n cos k_n(zf+h) cos k_n(zp+h) K0(k_n R) - 4/h cos m_n(zf+h) cos m_n(zp+h) K0(m_n R)] + 4/h Σ_n ...
+//
+// with m_n = nπ/h, where the second series is summed in closed form as the Rankine images of the source with
+// respect to the free surface and to the sea bottom of a channel of depth h (Gradshteyn and Ryzhik, 8.526):
+//
+//	4/h Σ_n cos m_n(zf+h) cos m_n(zp+h) K0(m_n R) = Σ_l [1/ρ(zf-zp+2lh) + 1/ρ(zf+zp+2h+2lh) - 1/(|l|h)] + 2/h (γ + ln(R/4h))
+//
+// the term in 1/|l| being left out for l = 0. The logarithmic singularities at R = 0 cancel, and the remaining
+// series converges down to the distance R_min = 2h/(Nπ) of the last evanescent mode N. Below R_min,
+// the expansion is evaluated at R_min, the wave part being an even function of R.
+func (fg *FinGreen3D) computeWavePart(rr, zf, zp float64) expansionTerm {
+	h := fg.waterDepth
+	var part expansionTerm
+
+	if rr >= finGreen3DSmallDistance*h {
+		for i, root := range fg.dispersionRoots {
+			term := fg.computeWaveTermDerivatives(rr, zf, zp, root, i == 0)
+			part.add(term, 1)
+			if i > 0 && cmplx.Abs(term.value) < 1e-14*cmplx.Abs(part.value) {
+				break
+			}
+		}
+		// The Rankine source and the images of greenFunctionTerms
+		for _, image := range []struct{ sign, shift float64 }{
+			{-1, 0}, {-1, 2 * h}, {-1, -2 * h}, {1, 0}, {1, 2 * h}, {1, 4 * h},
+		} {
+			part.add(rankineTerm(rr, zf, zp, image.sign, image.shift), -1)
+		}
+		return part
+	}
+
+	nbModes := len(fg.dispersionRoots) - 1
+	r := math.Max(rr, 2*h/(float64(nbModes)*math.Pi))
+
+	part.add(fg.computeWaveTermDerivatives(r, zf, zp, fg.dispersionRoots[0], true), 1)
+	for n := 1; n <= nbModes; n++ {
+		part.add(fg.computeWaveTermDerivatives(r, zf, zp, fg.dispersionRoots[n], false), 1)
+		part.add(evanescentMode(r, zf, zp, h, float64(n)*math.Pi/h, 4/h), -1)
+	}
+
+	// The images of the closed form, except the Rankine source and the images of greenFunctionTerms
+	part.add(expansionTerm{value: complex(2/h*(eulerGamma+math.Log(r/(4*h))), 0), dR: complex(2/(h*r), 0)}, 1)
+	part.value -= complex(2/h, 0)
+	for l := 2; l <= finGreen3DImageOrders; l++ {
+		shift := 2 * float64(l) * h
+		for _, sign := range []float64{-1, 1} {
+			center := 0.0
+			if sign > 0 {
+				center = 2 * h
+			}
+			part.add(rankineTerm(r, zf, zp, sign, center+shift), 1)
+			part.add(rankineTerm(r, zf, zp, sign, center-shift), 1)
+		}
+		part.value -= complex(2/(float64(l)*h), 0)
+	}
+
+	// Remainder of the images, each pair of orders ±l adding up to (2Z² - R²)/(2lh)³ + O(1/l⁵)
+	remainder := 1 / (2 * math.Pow(float64(finGreen3DImageOrders)+0.5, 2)) / (8 * h * h * h)
+	for _, z := range [][2]float64{{zf - zp, -1}, {zf + zp + 2*h, 1}} {
+		part.add(expansionTerm{
+			value: complex((2*z[0]*z[0]-r*r)*remainder, 0),
+			dR:    complex(-2*r*remainder, 0),
+			dzf:   complex(4*z[0]*remainder, 0),
+			dzp:   complex(4*z[1]*z[0]*remainder, 0),
+		}, 1)
+	}
+
+	// The wave part is even in R
+	part.dR *= complex(rr/r, 0)
+	return part
+}
+
 // computeGreenFunction3D computes the 3D finite depth Green function
 func (fg *FinGreen3D) computeGreenFunction3D(rr, zf, zp float64) (complex128, error) {
 	// Input parameters:
@@ -171,72 +337,99 @@ func (fg *FinGreen3D) computeInfiniteDepthGF(rr, zf, zp float64) (complex128, er
 	return complex(rankine, 0) + wavePart, nil
 }
 
-// computeFiniteDepthGF computes finite depth Green function
+// computeFiniteDepthGF computes the finite depth Green function with its eigenfunction expansion (Newman, 1985)
+//
+//	G = -2π k0 cosh k0(zf+h) cosh k0(zp+h) / (k0 h + sinh(k0 h) cosh(k0 h)) [Y0(k0 R) - i J0(k0 R)]
+//	    + 4 Σ_n (k_n² + ν²) / ((k_n² + ν²) h - ν) cos k_n(zf+h) cos k_n(zp+h) K0(k_n R)
+//
+// normalized as 1/r near the source. The series converges exponentially for R > 0, but slowly when R is small
+// compared to the water depth.
 func (fg *FinGreen3D) computeFiniteDepthGF(rr, zf, zp float64) (complex128, error) {
-	// Rankine parts
-	r := math.Sqrt(rr*rr + (zf-zp)*(zf-zp))
-	rPrime := math.Sqrt(rr*rr + (zf+zp)*(zf+zp))
-
-	rankine := 1.0/r + 1.0/rPrime
-
-	// Wave part using dispersion roots
-	var wavePart complex128 = 0
+	if rr <= 0 {
+		return 0, fmt.Errorf("the eigenfunction expansion requires a positive horizontal distance, got %g", rr)
+	}
 
+	var g complex128
 	for i, root := range fg.dispersionRoots {
-		if i == 0 {
-			// First root (propagating mode)
-			k := root
-			if real(k) > 0 {
-				term := fg.computeWaveTerm(rr, zf, zp, k, true)
-				wavePart += term
-			}
-		} else {
-			// Higher order roots (evanescent modes)
-			kn := root
-			term := fg.computeWaveTerm(rr, zf, zp, kn, false)
-			wavePart += term
+		term := fg.computeWaveTerm(rr, zf, zp, root, i == 0)
+		g += term
+		if i > 0 && cmplx.Abs(term) < 1e-14*cmplx.Abs(g) {
+			break
 		}
 	}
 
-	return complex(rankine, 0) + wavePart, nil
+	return g, nil
 }
 
-// computeWaveTerm computes individual wave terms
+// computeWaveTerm computes the term of the eigenfunction expansion of a root of the dispersion relation,
+// the propagating root k0 or an imaginary root i k_n of an evanescent mode
 func (fg *FinGreen3D) computeWaveTerm(rr, zf, zp float64, k complex128, isPropagating bool) complex128 {
-	h := fg.waterDepth
-
-	// Vertical functions
-	var phi1, phi2 complex128
+	return fg.computeWaveTermDerivatives(rr, zf, zp, k, isPropagating).value
+}
 
+// computeWaveTermDerivatives computes the term of computeWaveTerm with its derivatives
+func (fg *FinGreen3D) computeWaveTermDerivatives(rr, zf, zp float64, k complex128, isPropagating bool) expansionTerm {
+	h := fg.waterDepth
 	if isPropagating {
-		// Propagating mode
-		coshKZ1 := cmplx.Cosh(k * complex(zf+h, 0))
-		coshKZ2 := cmplx.Cosh(k * complex(zp+h, 0))
-		coshKH := cmplx.Cosh(k * complex(h, 0))
+		k0 := real(k)
+		m0 := k0 * h
+		// cosh k0(zf+h) cosh k0(zp+h) / (m0 + sinh(m0) cosh(m0)), multiplied by 4 exp(-2 m0) to avoid overflows
+		e1, e2 := math.Exp(k0*(zf+zp)), math.Exp(k0*(zf-zp-2*h))
+		e3, e4 := math.Exp(k0*(zp-zf-2*h)), math.Exp(-k0*(zf+zp+4*h))
+		denominator := 1 - math.Exp(-4*m0) + 4*m0*math.Exp(-2*m0)
+		coefficient := -2 * math.Pi * k0 / denominator
+		x := k0 * rr
+		radial := complex(math.Y0(x), -math.J0(x))
+		return expansionTerm{
+			value: complex(coefficient*(e1+e2+e3+e4), 0) * radial,
+			dR:    complex(coefficient*(e1+e2+e3+e4)*k0, 0) * complex(-math.Y1(x), math.J1(x)),
+			dzf:   complex(coefficient*k0*(e1+e2-e3-e4), 0) * radial,
+			dzp:   complex(coefficient*k0*(e1-e2+e3-e4), 0) * radial,
+		}
+	}
 
-		phi1 = coshKZ1 / coshKH
-		phi2 = coshKZ2 / coshKH
-	} else {
-		// Evanescent mode
-		cosKZ1 := cmplx.Cos(k * complex(zf+h, 0))
-		cosKZ2 := cmplx.Cos(k * complex(zp+h, 0))
-		cosKH := cmplx.Cos(k * complex(h, 0))
+	kn := imag(k)
+	nu := real(fg.waveNumber) * math.Tanh(real(fg.waveNumber)*h)
+	return evanescentMode(rr, zf, zp, h, kn, 4*(kn*kn+nu*nu)/((kn*kn+nu*nu)*h-nu))
+}
 
-		phi1 = cosKZ1 / cosKH
-		phi2 = cosKZ2 / cosKH
+// evanescentMode computes coefficient cos k(zf+h) cos k(zp+h) K0(k R) with its derivatives
+func evanescentMode(rr, zf, zp, h, k, coefficient float64) expansionTerm {
+	cf, sf := math.Cos(k*(zf+h)), math.Sin(k*(zf+h))
+	cp, sp := math.Cos(k*(zp+h)), math.Sin(k*(zp+h))
+	k0 := besselK0(k * rr)
+	return expansionTerm{
+		value: complex(coefficient*cf*cp*k0, 0),
+		dR:    complex(-coefficient*cf*cp*k*besselK1(k*rr), 0),
+		dzf:   complex(-coefficient*k*sf*cp*k0, 0),
+		dzp:   complex(-coefficient*k*cf*sp*k0, 0),
 	}
+}
 
-	// Horizontal function (simplified Bessel function approximation)
-	kr := k * complex(rr, 0)
-	var horizontalFunc complex128
-
-	if cmplx.Abs(kr) < 0.1 {
-		// Small argument approximation
-		horizontalFunc = complex(1, 0)
-	} else {
-		// Large argument approximation
-		horizontalFunc = cmplx.Exp(1i*kr) / cmplx.Sqrt(kr)
+// besselK0 computes the modified Bessel function of the second kind K0(x) for x > 0,
+// with the polynomial approximations of Abramowitz and Stegun (9.8.1, 9.8.5 and 9.8.6)
+func besselK0(x float64) float64 {
+	if x <= 2 {
+		t := x / 3.75
+		t2 := t * t
+		i0 := 1 + t2*(3.5156229+t2*(3.0899424+t2*(1.2067492+t2*(0.2659732+t2*(0.0360768+t2*0.0045813)))))
+		y := x * x / 4
+		return -math.Log(x/2)*i0 + (-0.57721566 + y*(0.42278420+y*(0.23069756+y*(0.03488590+y*(0.00262698+y*(0.00010750+y*0.00000740))))))
 	}
+	y := 2 / x
+	return math.Exp(-x) / math.Sqrt(x) * (1.25331414 + y*(-0.07832358+y*(0.02189568+y*(-0.01062446+y*(0.00587872+y*(-0.00251540+y*0.00053208))))))
+}
 
-	return phi1 * phi2 * horizontalFunc
+// besselK1 computes the modified Bessel function of the second kind K1(x) for x > 0,
+// with the polynomial approximations of Abramowitz and Stegun (9.8.3, 9.8.7 and 9.8.8)
+func besselK1(x float64) float64 {
+	if x <= 2 {
+		t := x / 3.75
+		t2 := t * t
+		i1 := x * (0.5 + t2*(0.87890594+t2*(0.51498869+t2*(0.15084934+t2*(0.02658733+t2*(0.00301532+t2*0.00032411))))))
+		y := x * x / 4
+		return math.Log(x/2)*i1 + (1+y*(0.15443144+y*(-0.67278579+y*(-0.18156897+y*(-0.01919402+y*(-0.00110404+y*-0.00004686))))))/x
+	}
+	y := 2 / x
+	return math.Exp(-x) / math.Sqrt(x) * (1.25331414 + y*(0.23498619+y*(-0.03655620+y*(0.01504268+y*(-0.00780353+y*(0.00325614+y*-0.00068245))))))
 }
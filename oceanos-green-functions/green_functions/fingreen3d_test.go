@@ -1,7 +1,9 @@
 package green_functions
 
 import (
+	"gonum.org/v1/gonum/mat"
 	"math"
+	"math/cmplx"
 	"testing"
 )
 
@@ -89,6 +91,26 @@ func TestFinGreen3D_ComputeDispersionRoots_InfiniteDepth(t *testing.T) {
 	}
 }
 
+func TestFinGreen3D_EvanescentRoots(t *testing.T) {
+	depth, k0 := 4.0, 0.7
+	fg := NewFinGreen3D(depth)
+	roots, err := fg.computeDispersionRoots(complex(k0, 0))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	nu := k0 * math.Tanh(k0*depth)
+	for n, root := range roots[1:] {
+		kn := imag(root)
+		if residual := kn*math.Tan(kn*depth) + nu; math.Abs(residual) > 1e-8 {
+			t.Errorf("Root %d: k_n tan(k_n h) + ν = %v", n+1, residual)
+		}
+		if lower, upper := (float64(n)+0.5)*math.Pi/depth, float64(n+1)*math.Pi/depth; kn <= lower || kn >= upper {
+			t.Errorf("Root %d: %v is not in (%v, %v)", n+1, kn, lower, upper)
+		}
+	}
+}
+
 func TestFinGreen3D_Evaluate(t *testing.T) {
 	fg := NewFinGreen3D(20.0)
 
@@ -142,6 +164,113 @@ func TestFinGreen3D_Evaluate_WaterDepthChange(t *testing.T) {
 	}
 }
 
+func TestFinGreen3D_Evaluate_Errors(t *testing.T) {
+	fg := NewFinGreen3D(10.0)
+	mesh := NewMockMesh([][]float64{{0, 0, -1}}, [][]float64{{0, 0, 1}})
+
+	for _, tc := range []struct {
+		name        string
+		freeSurface float64
+		waterDepth  float64
+		wavenumber  complex128
+	}{
+		{"infinite depth", 0, math.Inf(1), 1},
+		{"no free surface", math.Inf(1), 10, 1},
+		{"zero wavenumber", 0, 10, 0},
+		{"infinite wavenumber", 0, 10, complex(math.Inf(1), 0)},
+	} {
+		if _, _, err := fg.Evaluate(mesh, mesh, tc.freeSurface, tc.waterDepth, tc.wavenumber, true, true); err == nil {
+			t.Errorf("%s: expected error", tc.name)
+		}
+	}
+}
+
+func TestFinGreen3D_WavePart(t *testing.T) {
+	depth := 2.0
+	fg := NewFinGreen3D(depth)
+	for _, kh := range []float64{0.1, 1, 5} {
+		if err := fg.SetWaveNumber(complex(kh/depth, 0)); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for _, z := range [][2]float64{{-0.3, -0.5}, {-1.2, -0.4}, {-0.1, -0.1}, {-1.9, -1.7}} {
+			// The two summations of the evanescent modes agree where they meet
+			rr := finGreen3DSmallDistance * depth
+			far := fg.computeWavePart(rr*(1+1e-9), z[0], z[1])
+			near := fg.computeWavePart(rr*(1-1e-9), z[0], z[1])
+			if cmplx.Abs(far.value-near.value) > 1e-5*cmplx.Abs(far.value) {
+				t.Errorf("kh=%v, z=%v: expected %v, got %v", kh, z, far.value, near.value)
+			}
+
+			// The derivatives are those of the value
+			for _, rr := range []float64{0.1, 1} {
+				term := fg.computeWavePart(rr, z[0], z[1])
+				h := 1e-6
+				for _, d := range []struct {
+					name     string
+					analytic complex128
+					plus     expansionTerm
+					minus    expansionTerm
+				}{
+					{"dR", term.dR, fg.computeWavePart(rr+h, z[0], z[1]), fg.computeWavePart(rr-h, z[0], z[1])},
+					{"dzf", term.dzf, fg.computeWavePart(rr, z[0]+h, z[1]), fg.computeWavePart(rr, z[0]-h, z[1])},
+					{"dzp", term.dzp, fg.computeWavePart(rr, z[0], z[1]+h), fg.computeWavePart(rr, z[0], z[1]-h)},
+				} {
+					numeric := (d.plus.value - d.minus.value) / complex(2*h, 0)
+					if cmplx.Abs(numeric-d.analytic) > 1e-5*(1+cmplx.Abs(numeric)) {
+						t.Errorf("kh=%v, R=%v, z=%v: expected %s=%v, got %v", kh, rr, z, d.name, numeric, d.analytic)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestFinGreen3D_EvaluateAgainstDelhommeau(t *testing.T) {
+	mesh, err := NewHemisphereMesh(1, 4, 8)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	params := DefaultDelhommeauParameters()
+	params.FiniteDepthMethod = NewerMethod
+	d := NewDelhommeau(params)
+
+	for _, depth := range []float64{1.5, 3} {
+		for _, k := range []float64{0.3, 1, 3} {
+			S, K, err := NewFinGreen3D(depth).Evaluate(mesh, mesh, 0, depth, complex(k, 0), false, true)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			SRef, KRef, err := d.Evaluate(mesh, mesh, 0, depth, complex(k, 0), false, true)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			// Relative to the largest coefficients, the self-interactions of the faces
+			if e := maxAbsDifference(S, SRef); e > 2e-3*maxAbs(SRef) {
+				t.Errorf("depth=%v, k=%v: S differs from Delhommeau by %v", depth, k, e)
+			}
+			if e := maxAbsDifference(K, KRef); e > 5e-2*maxAbs(KRef) {
+				t.Errorf("depth=%v, k=%v: K differs from Delhommeau by %v", depth, k, e)
+			}
+		}
+	}
+}
+
+func maxAbsDifference(a, b *mat.CDense) float64 {
+	var m float64
+	for i, v := range a.RawCMatrix().Data {
+		m = math.Max(m, cmplx.Abs(v-b.RawCMatrix().Data[i]))
+	}
+	return m
+}
+
+func maxAbs(a *mat.CDense) float64 {
+	var m float64
+	for _, v := range a.RawCMatrix().Data {
+		m = math.Max(m, cmplx.Abs(v))
+	}
+	return m
+}
+
 func TestFinGreen3D_ComputeGreenFunction3D_InfiniteDepth(t *testing.T) {
 	fg := NewFinGreen3D(math.Inf(1))
 	fg.SetWaveNumber(complex(1.0, 0))
@@ -12,6 +12,6 @@ func BenchmarkGreenFunctions(b *testing.B) {
 
 	b.ResetTimer() // reset timer sebelum loop benchmark
 	for i := 0; i < b.N; i++ {
-		_ = green_functions(x, y, z) // ganti dengan nama fungsi sebenarnya
+		_ = ComputeDistance([3]float64{x, y, z}, [3]float64{})
 	}
 }
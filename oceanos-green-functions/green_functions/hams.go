@@ -29,12 +29,18 @@ func NewLiangWuNoblesseGF() *LiangWuNoblesseGF {
 	return lwn
 }
 
+// Settings returns a copy of the parameters of the LiangWuNoblesse Green function
+func (lwn *LiangWuNoblesseGF) Settings() map[string]interface{} {
+	return copySettings(lwn.exportableSettings)
+}
+
 // String returns a string representation of the LiangWuNoblesse Green function
 func (lwn *LiangWuNoblesseGF) String() string {
 	return "LiangWuNoblesseGF()"
 }
 
-// Evaluate computes the Green function using the LiangWuNoblesse method
+// Evaluate computes the Green function using the LiangWuNoblesse method. It is not implemented yet and returns
+// zero matrices for every wavenumber: the zero and infinite wavenumber limits are served by Delhommeau.
 func (lwn *LiangWuNoblesseGF) Evaluate(mesh1, mesh2 interface{}, freeSurface float64, waterDepth float64,
 	wavenumber complex128, adjointDoubleLayer bool, earlyDotProduct bool) (*mat.CDense, *mat.CDense, error) {
 
@@ -69,14 +75,6 @@ func (lwn *LiangWuNoblesseGF) Evaluate(mesh1, mesh2 interface{}, freeSurface flo
 		return nil, nil, err
 	}
 
-	// Determine singularity handling based on wavenumber
-	var gfSingularitiesIndex int
-	if math.IsInf(real(wavenumber), 1) {
-		gfSingularitiesIndex = 0 // high_freq
-	} else {
-		gfSingularitiesIndex = 1 // low_freq
-	}
-
 	// TODO: Implement actual LiangWuNoblesse Green function computation
 	// This would involve:
 	// 1. Computing the Rankine and reflected Rankine terms
@@ -85,7 +83,6 @@ func (lwn *LiangWuNoblesseGF) Evaluate(mesh1, mesh2 interface{}, freeSurface flo
 
 	_ = colocationPoints
 	_ = earlyDotProductNormals
-	_ = gfSingularitiesIndex
 
 	return S, K, nil
 }
@@ -108,6 +105,11 @@ func NewHAMS() *HAMS {
 	return hams
 }
 
+// Settings returns a copy of the parameters of the HAMS Green function
+func (h *HAMS) Settings() map[string]interface{} {
+	return copySettings(h.exportableSettings)
+}
+
 // String returns a string representation of the HAMS Green function
 func (h *HAMS) String() string {
 	return "HAMS()"
@@ -0,0 +1,554 @@
+// Package green_functions - Integrals shared by the Green function implementations
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package green_functions
+
+import (
+	"math"
+	"math/cmplx"
+	"sync"
+)
+
+// eulerGamma is the Euler-Mascheroni constant
+const eulerGamma = 0.57721566490153286061
+
+// asymptoticDelhommeauLimit is the dimensionless horizontal distance above which
+// the wave integrals are computed with their large distance expansion
+const asymptoticDelhommeauLimit = 100.0
+
+// expE1 computes exp(z)*E1(z), where E1 is the exponential integral on its principal branch.
+// On the negative real axis, the value on the upper side of the branch cut is returned.
+func expE1(z complex128) complex128 {
+	az := cmplx.Abs(z)
+
+	switch {
+	case az == 0:
+		return cmplx.Inf()
+
+	case az >= 20:
+		// Asymptotic expansion, truncated before its terms start growing
+		var sum complex128
+		term := 1 / z
+		for n := 1; n <= 60; n++ {
+			sum += term
+			next := term * complex(-float64(n), 0) / z
+			if cmplx.Abs(next) >= cmplx.Abs(term) || cmplx.Abs(next) < 1e-17*cmplx.Abs(sum) {
+				break
+			}
+			term = next
+		}
+		return sum
+
+	case real(z) >= 0 && az > 1.5:
+		// Continued fraction, evaluated with the modified Lentz algorithm
+		const tiny = 1e-300
+		f := z + 1
+		c := f
+		var d complex128
+		for n := 1; n <= 500; n++ {
+			a := complex(-float64(n*n), 0)
+			b := z + complex(float64(2*n+1), 0)
+			d = b + a*d
+			if d == 0 {
+				d = tiny
+			}
+			c = b + a/c
+			if c == 0 {
+				c = tiny
+			}
+			d = 1 / d
+			delta := c * d
+			f *= delta
+			if cmplx.Abs(delta-1) < 1e-16 {
+				break
+			}
+		}
+		return 1 / f
+
+	default:
+		// Power series
+		var sum complex128
+		term := complex(1, 0)
+		for n := 1; n <= 300; n++ {
+			term *= -z / complex(float64(n), 0)
+			contribution := term / complex(float64(n), 0)
+			sum += contribution
+			if cmplx.Abs(contribution) < 1e-17*cmplx.Abs(sum) {
+				break
+			}
+		}
+		e1 := complex(-eulerGamma, 0) - cmplx.Log(z) - sum
+		return cmplx.Exp(z) * e1
+	}
+}
+
+// gaussLegendreRule holds the nodes and weights of a Gauss-Legendre rule on [-1, 1]
+type gaussLegendreRule struct {
+	nodes   []float64
+	weights []float64
+}
+
+var gaussLegendreCache sync.Map
+
+// gaussLegendre returns the n-points Gauss-Legendre rule on [-1, 1]
+func gaussLegendre(n int) *gaussLegendreRule {
+	if rule, ok := gaussLegendreCache.Load(n); ok {
+		return rule.(*gaussLegendreRule)
+	}
+
+	rule := &gaussLegendreRule{
+		nodes:   make([]float64, n),
+		weights: make([]float64, n),
+	}
+	for i := 0; i < (n+1)/2; i++ {
+		// Initial guess from the asymptotic position of the roots, refined by Newton iterations
+		x := math.Cos(math.Pi * (float64(i) + 0.75) / (float64(n) + 0.5))
+		var dp float64
+		for iter := 0; iter < 100; iter++ {
+			p0, p1 := 1.0, x
+			for k := 2; k <= n; k++ {
+				p0, p1 = p1, ((2*float64(k)-1)*x*p1-(float64(k)-1)*p0)/float64(k)
+			}
+			dp = float64(n) * (x*p1 - p0) / (x*x - 1)
+			dx := p1 / dp
+			x -= dx
+			if math.Abs(dx) < 1e-15 {
+				break
+			}
+		}
+		w := 2 / ((1 - x*x) * dp * dp)
+		rule.nodes[i], rule.nodes[n-1-i] = -x, x
+		rule.weights[i], rule.weights[n-1-i] = w, w
+	}
+
+	actual, _ := gaussLegendreCache.LoadOrStore(n, rule)
+	return actual.(*gaussLegendreRule)
+}
+
+// delhommeauIntegrals computes the dimensionless wave integrals of the infinite depth Green function
+//
+//	d  = PV ∫_0^∞ exp(t z) J0(t x) / (t - 1) dt
+//	dr = PV ∫_0^∞ exp(t z) J1(t x) / (t - 1) dt
+//
+// for x >= 0 and z <= 0, using Delhommeau's representation as an integral over θ of exp(ζ)(E1(ζ) + iπ)
+// with ζ = z + i x cos(θ).
+func delhommeauIntegrals(x, z float64) (float64, float64) {
+	if x > asymptoticDelhommeauLimit {
+		return asymptoticDelhommeauIntegrals(x, z)
+	}
+
+	rule := gaussLegendre(24 + 2*int(math.Ceil(x)))
+
+	// The integrand is even in θ, only [0, π/2] is integrated
+	var d, dr float64
+	for i, u := range rule.nodes {
+		theta := math.Pi / 4 * (u + 1)
+		cosTheta := math.Cos(theta)
+		zeta := complex(z, x*cosTheta)
+		f := expE1(zeta) + complex(0, math.Pi)*cmplx.Exp(zeta)
+		d += rule.weights[i] * real(f)
+		dr += rule.weights[i] * cosTheta * imag(f)
+	}
+	// (2/π) * (π/4) from the change of variable
+	return d / 2, dr / 2
+}
+
+// asymptoticDelhommeauIntegrals computes the integrals of delhommeauIntegrals for large x
+func asymptoticDelhommeauIntegrals(x, z float64) (float64, float64) {
+	az := math.Abs(z)
+	rho := math.Hypot(x, z)
+	rho3 := rho * rho * rho
+	rho5 := rho3 * rho * rho
+	rho7 := rho5 * rho * rho
+	ez := math.Exp(z)
+
+	d := -math.Pi*ez*math.Y0(x) - 1/rho - az/rho3 - (2*z*z-x*x)/rho5
+
+	dddx := math.Pi*ez*math.Y1(x) + x/rho3 + 3*az*x/rho5 + 2*x/rho5 + 5*x*(2*z*z-x*x)/rho7
+	dr := -dddx - (1-az/rho)/x
+
+	return d, dr
+}
+
+// waveIntegrals evaluates the dimensionless integrals of delhommeauIntegrals and negativePoleIntegrals
+type waveIntegrals struct {
+	delhommeau   func(x, z float64) (float64, float64)
+	negativePole func(x, z float64) (float64, float64)
+}
+
+// directWaveIntegrals computes the dimensionless integrals by quadrature at each evaluation
+var directWaveIntegrals = waveIntegrals{delhommeau: delhommeauIntegrals, negativePole: negativePoleIntegrals}
+
+// infiniteDepthWavePart computes the wave part of the infinite depth Green function
+//
+//	W(R, Z) = 2k [ PV ∫_0^∞ exp(μ Z) J0(μ R) / (μ - k) dμ + iπ exp(k Z) J0(k R) ]
+//
+// and its derivatives with respect to R and Z, where R is the horizontal distance
+// and Z the sum of the depths of the two points below the free surface.
+func infiniteDepthWavePart(k, r, z float64, integrals waveIntegrals) (complex128, complex128, complex128) {
+	if k == 0 {
+		return 0, 0, 0
+	}
+	if z > 0 {
+		z = 0
+	}
+	if r == 0 && z == 0 {
+		// Logarithmic singularity on the free surface, left out as the Rankine self-terms
+		return 0, 0, 0
+	}
+
+	x, zd := k*r, k*z
+	d, dr := integrals.delhommeau(x, zd)
+	ez := math.Exp(zd)
+
+	w := complex(2*k, 0) * complex(d, math.Pi*ez*math.J0(x))
+
+	r1 := math.Hypot(r, z)
+	dwdz := complex(2*k/r1, 0) + complex(k, 0)*w
+	dwdr := complex(2*k, 0) * complex(-r/(r1*(r1+math.Abs(z)))-k*dr, -math.Pi*k*ez*math.J1(x))
+
+	return w, dwdr, dwdz
+}
+
+// infiniteDepthWaveTerm returns the wave term of the infinite depth Green function for a free surface at z = freeSurface.
+// With highFrequency, the wave term also contains 2/r1, as the reflected Rankine term is then subtracted instead of added.
+func infiniteDepthWaveTerm(k, freeSurface float64, highFrequency bool, integrals waveIntegrals) waveTerm {
+	return func(x, xi [3]float64) (complex128, [3]complex128, [3]complex128) {
+		dx, dy := x[0]-xi[0], x[1]-xi[1]
+		r := math.Hypot(dx, dy)
+		z := (x[2] - freeSurface) + (xi[2] - freeSurface)
+
+		w, dwdr, dwdz := infiniteDepthWavePart(k, r, z, integrals)
+		if highFrequency {
+			r1 := math.Hypot(r, z)
+			if r1 > 0 {
+				r13 := r1 * r1 * r1
+				w += complex(2/r1, 0)
+				dwdr += complex(-2*r/r13, 0)
+				dwdz += complex(-2*z/r13, 0)
+			}
+		}
+
+		var ex, ey float64
+		if r > 0 {
+			ex, ey = dx/r, dy/r
+		}
+		dwdx := dwdr * complex(ex, 0)
+		dwdy := dwdr * complex(ey, 0)
+		return w, [3]complex128{dwdx, dwdy, dwdz}, [3]complex128{-dwdx, -dwdy, dwdz}
+	}
+}
+
+// negativePoleIntegrals computes the dimensionless integrals of the finite depth Green function
+//
+//	l  = ∫_0^∞ exp(t z) J0(t x) / (t + 1) dt
+//	lr = ∫_0^∞ exp(t z) J1(t x) / (t + 1) dt
+//
+// for x >= 0 and z <= 0, as integrals over θ of exp(-ζ) E1(-ζ) with ζ = z + i x cos(θ).
+func negativePoleIntegrals(x, z float64) (float64, float64) {
+	if x > asymptoticDelhommeauLimit {
+		return asymptoticNegativePoleIntegrals(x, z)
+	}
+
+	rule := gaussLegendre(24 + 2*int(math.Ceil(x)))
+
+	var l, lr float64
+	for i, u := range rule.nodes {
+		theta := math.Pi / 4 * (u + 1)
+		cosTheta := math.Cos(theta)
+		f := expE1(complex(-z, -x*cosTheta))
+		l += rule.weights[i] * real(f)
+		lr += rule.weights[i] * cosTheta * imag(f)
+	}
+	return l / 2, lr / 2
+}
+
+// asymptoticNegativePoleIntegrals computes the integrals of negativePoleIntegrals for large x, from the expansion
+// of 1/(t + 1) at t = 0 and ∫ t^n exp(t z) J0(t x) dt = n! P_n(|z|/ρ) / ρ^(n+1)
+func asymptoticNegativePoleIntegrals(x, z float64) (float64, float64) {
+	az := math.Abs(z)
+	rho := math.Hypot(x, z)
+	c := az / rho
+
+	// Legendre polynomials P_n(c) and derivatives P'_(n+1)(c)
+	const order = 6
+	var p, dp [order + 2]float64
+	p[0], p[1] = 1, c
+	dp[0], dp[1] = 0, 1
+	for n := 1; n <= order; n++ {
+		p[n+1] = ((2*float64(n)+1)*c*p[n] - float64(n)*p[n-1]) / float64(n+1)
+		dp[n+1] = dp[n-1] + (2*float64(n)+1)*p[n]
+	}
+
+	var l, dldx float64
+	factorial := 1.0
+	power := 1 / rho
+	for n := 0; n < order; n++ {
+		if n > 0 {
+			factorial *= -float64(n)
+		}
+		l += factorial * p[n] * power
+		dldx -= factorial * x * dp[n+1] * power / (rho * rho)
+		power /= rho
+	}
+
+	lr := dldx + x/(rho*(rho+az))
+	return l, lr
+}
+
+// negativePolePart computes the integral of the finite depth Green function at the negative pole of its kernel
+//
+//	L(R, Z) = ∫_0^∞ exp(μ Z) J0(μ R) / (μ + k) dμ
+//
+// and its derivatives with respect to R and Z.
+func negativePolePart(k, r, z float64, integrals waveIntegrals) (float64, float64, float64) {
+	if z > 0 {
+		z = 0
+	}
+	if r == 0 && z == 0 {
+		// Logarithmic singularity on the free surface, left out as the Rankine self-terms
+		return 0, 0, 0
+	}
+
+	x, zd := k*r, k*z
+	l, lr := integrals.negativePole(x, zd)
+	rho := math.Hypot(r, z)
+
+	dldr := k*lr - r/(rho*(rho+math.Abs(z)))
+	dldz := 1/rho - k*l
+	return l, dldr, dldz
+}
+
+// finiteDepthWaveTerm returns the wave term of the finite depth Green function for a free surface at
+// z = freeSurface and a sea bottom at z = freeSurface - waterDepth. With the image depths below the free surface
+//
+//	Z1 = z + ζ,  Z2 = z - ζ - 2h,  Z3 = ζ - z - 2h,  Z4 = -(z + ζ + 4h)
+//
+// the Green function is 1/r + 1/r2 + Σ_i 1/ρ_i plus the wave term
+//
+//	Σ_i [ r0/(4kh) W(R, Z_i) + r1/(2h) L(R, Z_i) + Σ_m a_m/2 / √(R² + (Z_i + λ_m h)²) ]
+//
+// where r2 is the distance to the image with respect to the sea bottom, ρ_i = √(R² + Z_i²), W is the wave part
+// of the infinite depth Green function, L the integral at the negative pole and the a_m exp(λ_m x) the Prony decomposition
+// of finiteDepthKernel. The Rankine terms of the images Z1 are handled by the caller. With legacy, the other Rankine-like
+// terms are also included in the wave term, and with highFrequency, the wave term also contains 2/ρ_1 as in infiniteDepthWaveTerm.
+func finiteDepthWaveTerm(k, waterDepth, freeSurface float64, prony *PronyDecomposition, legacy, highFrequency bool, integrals waveIntegrals) waveTerm {
+	h := waterDepth
+	r0, r1 := finiteDepthPoleResidues(k * h)
+	propagating := complex(r0/(4*k*h), 0)
+	evanescent := r1 / (2 * h)
+
+	return func(x, xi [3]float64) (complex128, [3]complex128, [3]complex128) {
+		dx, dy := x[0]-xi[0], x[1]-xi[1]
+		r := math.Hypot(dx, dy)
+		z, zeta := x[2]-freeSurface, xi[2]-freeSurface
+
+		var w, dwdr, dwdz, dwdzeta complex128
+
+		// point adds weight/√(R² + Z²), where Z varies as sz z + sZeta ζ
+		point := func(weight, Z, sz, sZeta float64) {
+			rho := math.Hypot(r, Z)
+			if rho == 0 {
+				return
+			}
+			rho3 := rho * rho * rho
+			w += complex(weight/rho, 0)
+			dwdr += complex(-weight*r/rho3, 0)
+			dwdz += complex(-weight*sz*Z/rho3, 0)
+			dwdzeta += complex(-weight*sZeta*Z/rho3, 0)
+		}
+
+		images := [4]struct{ Z, sz, sZeta float64 }{
+			{z + zeta, 1, 1},
+			{z - zeta - 2*h, 1, -1},
+			{zeta - z - 2*h, -1, 1},
+			{-(z + zeta + 4*h), -1, -1},
+		}
+		for i, image := range images {
+			wi, wr, wz := infiniteDepthWavePart(k, r, image.Z, integrals)
+			l, lr, lz := negativePolePart(k, r, image.Z, integrals)
+			w += propagating*wi + complex(evanescent*l, 0)
+			dwdr += propagating*wr + complex(evanescent*lr, 0)
+			dwdz += complex(image.sz, 0) * (propagating*wz + complex(evanescent*lz, 0))
+			dwdzeta += complex(image.sZeta, 0) * (propagating*wz + complex(evanescent*lz, 0))
+
+			if legacy {
+				for m, a := range prony.Coefficients {
+					point(real(a)/2, image.Z+real(prony.Exponents[m])*h, image.sz, image.sZeta)
+				}
+				if i > 0 {
+					point(1, image.Z, image.sz, image.sZeta)
+				}
+			}
+			if i == 0 && highFrequency {
+				point(2, image.Z, image.sz, image.sZeta)
+			}
+		}
+		if legacy {
+			point(1, z+zeta+2*h, 1, 1)
+		}
+
+		var ex, ey float64
+		if r > 0 {
+			ex, ey = dx/r, dy/r
+		}
+		dwdx := dwdr * complex(ex, 0)
+		dwdy := dwdr * complex(ey, 0)
+		return w, [3]complex128{dwdx, dwdy, dwdz}, [3]complex128{-dwdx, -dwdy, dwdzeta}
+	}
+}
+
+// finiteDepthImages returns the Rankine images of the finite depth Green function other than the image
+// with respect to the free surface: 1/r2 and the 1/ρ_i of the images Z2, Z3 and Z4 of finiteDepthWaveTerm
+func finiteDepthImages(waterDepth, freeSurface float64) []rankineImage {
+	h := waterDepth
+	return []rankineImage{
+		{plane: freeSurface - h, weight: 1},
+		{translated: true, shift: 2 * h, weight: 1},
+		{translated: true, shift: -2 * h, weight: 1},
+		{plane: freeSurface - 2*h, weight: 1},
+	}
+}
+
+// infiniteFrequencyImageOrders is the number of periods of the series of infiniteFrequencyImages
+const infiniteFrequencyImageOrders = 32
+
+// infiniteFrequencyImages returns the Rankine images of the finite depth Green function in the limit of an
+// infinite wavenumber, where the potential vanishes on the free surface and the sea bottom has no flux.
+// The mirror images with respect to the free surface (weight -1) and to the sea bottom (weight +1) generate
+// the translations of the source by 2mh of weight (-1)^m and its mirror images with respect to the planes
+// z = freeSurface + mh of weight -(-1)^m. The alternating series is truncated at |m| = infiniteFrequencyImageOrders,
+// the last terms having half weight to average the last two partial sums.
+func infiniteFrequencyImages(waterDepth, freeSurface float64) []rankineImage {
+	h := waterDepth
+	images := []rankineImage{{plane: freeSurface, weight: -1}}
+	for m := 1; m <= infiniteFrequencyImageOrders; m++ {
+		weight := 1.0
+		if m%2 == 1 {
+			weight = -1
+		}
+		if m == infiniteFrequencyImageOrders {
+			weight /= 2
+		}
+		shift := 2 * float64(m) * h
+		images = append(images,
+			rankineImage{translated: true, shift: shift, weight: weight},
+			rankineImage{translated: true, shift: -shift, weight: weight},
+			rankineImage{plane: freeSurface + float64(m)*h, weight: -weight},
+			rankineImage{plane: freeSurface - float64(m)*h, weight: -weight},
+		)
+	}
+	return images
+}
+
+// pronyImages returns the images of the Prony decomposition in the finite depth Green function,
+// of weights a_m/2 at the depths Z_i + λ_m h of finiteDepthWaveTerm
+func pronyImages(waterDepth, freeSurface float64, prony *PronyDecomposition) []rankineImage {
+	h := waterDepth
+	var images []rankineImage
+	for m, a := range prony.Coefficients {
+		weight := real(a) / 2
+		lambdaH := real(prony.Exponents[m]) * h
+		images = append(images,
+			rankineImage{plane: freeSurface - lambdaH/2, weight: weight},
+			rankineImage{translated: true, shift: 2*h - lambdaH, weight: weight},
+			rankineImage{translated: true, shift: -2*h + lambdaH, weight: weight},
+			rankineImage{plane: freeSurface - 2*h + lambdaH/2, weight: weight},
+		)
+	}
+	return images
+}
+
+// rankinePanelIntegral computes the integral of 1/|x - ξ| for ξ on a flat polygonal panel
+// and its gradient with respect to x, following Hess and Smith.
+// The vertices are ordered counterclockwise around the normal.
+func rankinePanelIntegral(x [3]float64, vertices [][3]float64, normal [3]float64) (float64, [3]float64) {
+	nv := len(vertices)
+	h := dot3(sub3(x, vertices[0]), normal)
+	if math.Abs(h) < 1e-12*norm3(sub3(vertices[1], vertices[0])) {
+		// The point is in the plane of the panel, up to round-off errors
+		h = 0
+	}
+
+	var potential float64
+	var gradient [3]float64
+
+	for k := 0; k < nv; k++ {
+		a, b := vertices[k], vertices[(k+1)%nv]
+		edge := sub3(b, a)
+		length := norm3(edge)
+		if length < 1e-14 {
+			continue
+		}
+		tangent := scale3(edge, 1/length)
+		outward := cross3(tangent, normal)
+
+		ra := norm3(sub3(a, x))
+		rb := norm3(sub3(b, x))
+		if ra+rb-length <= 1e-12*length {
+			// The point lies on the edge, where the logarithm is singular
+			continue
+		}
+		logTerm := math.Log((ra + rb + length) / (ra + rb - length))
+
+		potential += dot3(sub3(a, x), outward) * logTerm
+		gradient = sub3(gradient, scale3(outward, logTerm))
+	}
+
+	if h == 0 {
+		// In the plane of the panel, the principal value of the normal derivative vanishes
+		return potential, gradient
+	}
+
+	omega := math.Abs(polygonSolidAngle(x, vertices))
+	potential -= math.Abs(h) * omega
+	gradient = sub3(gradient, scale3(normal, math.Copysign(omega, h)))
+
+	return potential, gradient
+}
+
+// polygonSolidAngle computes the signed solid angle subtended by a flat polygon seen from x,
+// summing the Van Oosterom and Strackee formula over a fan of triangles
+func polygonSolidAngle(x [3]float64, vertices [][3]float64) float64 {
+	var omega float64
+	r0 := sub3(vertices[0], x)
+	n0 := norm3(r0)
+	for k := 1; k+1 < len(vertices); k++ {
+		r1 := sub3(vertices[k], x)
+		r2 := sub3(vertices[k+1], x)
+		n1, n2 := norm3(r1), norm3(r2)
+		numerator := dot3(r0, cross3(r1, r2))
+		denominator := n0*n1*n2 + dot3(r0, r1)*n2 + dot3(r0, r2)*n1 + dot3(r1, r2)*n0
+		omega += 2 * math.Atan2(numerator, denominator)
+	}
+	return omega
+}
+
+func sub3(a, b [3]float64) [3]float64 {
+	return [3]float64{a[0] - b[0], a[1] - b[1], a[2] - b[2]}
+}
+
+func add3(a, b [3]float64) [3]float64 {
+	return [3]float64{a[0] + b[0], a[1] + b[1], a[2] + b[2]}
+}
+
+func scale3(a [3]float64, s float64) [3]float64 {
+	return [3]float64{s * a[0], s * a[1], s * a[2]}
+}
+
+func dot3(a, b [3]float64) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+func cross3(a, b [3]float64) [3]float64 {
+	return [3]float64{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}
+}
+
+func norm3(a [3]float64) float64 {
+	return math.Sqrt(dot3(a, a))
+}
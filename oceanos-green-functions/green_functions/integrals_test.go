@@ -0,0 +1,163 @@
+package green_functions
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestExpE1(t *testing.T) {
+	// Reference values of E1 from Abramowitz and Stegun
+	testCases := []struct {
+		z        complex128
+		expected complex128
+	}{
+		{complex(1, 0), complex(0.21938393439552029, 0)},
+		{complex(0.1, 0), complex(1.8229239584193906, 0)},
+		{complex(5, 0), complex(0.0011482955912753257, 0)},
+		{complex(10, 0), complex(4.156968929685324e-06, 0)},
+	}
+
+	for _, tc := range testCases {
+		result := expE1(tc.z) * cmplx.Exp(-tc.z)
+		if cmplx.Abs(result-tc.expected) > 1e-10*cmplx.Abs(tc.expected) {
+			t.Errorf("E1(%v) = %v, expected %v", tc.z, result, tc.expected)
+		}
+	}
+}
+
+func TestExpE1_BranchContinuity(t *testing.T) {
+	// The three evaluation methods must agree at the boundaries of their domains
+	for _, z := range []complex128{complex(1.5, 0.01), complex(0.01, 1.51), complex(19.99, 0.5), complex(-19.99, 0.5)} {
+		inner := expE1(z)
+		outer := expE1(z * complex(1+1e-9, 0))
+		if cmplx.Abs(inner-outer) > 1e-7*cmplx.Abs(inner) {
+			t.Errorf("expE1 is discontinuous near %v: %v vs %v", z, inner, outer)
+		}
+	}
+}
+
+func TestGaussLegendre(t *testing.T) {
+	rule := gaussLegendre(10)
+
+	// A 10 points rule integrates exactly polynomials up to degree 19
+	var integral float64
+	for i, x := range rule.nodes {
+		integral += rule.weights[i] * (math.Pow(x, 18) + x)
+	}
+	if math.Abs(integral-2.0/19) > 1e-14 {
+		t.Errorf("Expected %v, got %v", 2.0/19, integral)
+	}
+}
+
+func TestDelhommeauIntegrals_AsymptoticContinuity(t *testing.T) {
+	for _, z := range []float64{-0.1, -1, -5} {
+		dNear, drNear := delhommeauIntegrals(asymptoticDelhommeauLimit, z)
+		dFar, drFar := delhommeauIntegrals(math.Nextafter(asymptoticDelhommeauLimit, math.Inf(1)), z)
+		if math.Abs(dNear-dFar) > 1e-8 || math.Abs(drNear-drFar) > 1e-8 {
+			t.Errorf("Integrals are discontinuous at z=%v: (%v, %v) vs (%v, %v)", z, dNear, drNear, dFar, drFar)
+		}
+	}
+}
+
+func TestInfiniteDepthWavePart_Derivatives(t *testing.T) {
+	k, r, z := 1.3, 0.7, -0.4
+	w, dwdr, dwdz := infiniteDepthWavePart(k, r, z, directWaveIntegrals)
+
+	h := 1e-6
+	wr, _, _ := infiniteDepthWavePart(k, r+h, z, directWaveIntegrals)
+	wz, _, _ := infiniteDepthWavePart(k, r, z+h, directWaveIntegrals)
+
+	if cmplx.Abs((wr-w)/complex(h, 0)-dwdr) > 1e-4 {
+		t.Errorf("dW/dR = %v, finite difference gives %v", dwdr, (wr-w)/complex(h, 0))
+	}
+	if cmplx.Abs((wz-w)/complex(h, 0)-dwdz) > 1e-4 {
+		t.Errorf("dW/dZ = %v, finite difference gives %v", dwdz, (wz-w)/complex(h, 0))
+	}
+}
+
+func TestNegativePoleIntegrals(t *testing.T) {
+	for _, point := range [][2]float64{{0.5, -0.3}, {3, -1}, {0.01, -0.05}, {150, -2}} {
+		x, z := point[0], point[1]
+		l, lr := negativePoleIntegrals(x, z)
+
+		// Midpoint rule, truncated where exp(t z) is negligible
+		var expectedL, expectedLr float64
+		step := 1e-4
+		for tt := step / 2; tt < 40/math.Abs(z); tt += step {
+			e := math.Exp(tt*z) / (tt + 1) * step
+			expectedL += e * math.J0(tt*x)
+			expectedLr += e * math.J1(tt*x)
+		}
+		if math.Abs(l-expectedL) > 1e-7 || math.Abs(lr-expectedLr) > 1e-7 {
+			t.Errorf("x=%v, z=%v: expected (%v, %v), got (%v, %v)", x, z, expectedL, expectedLr, l, lr)
+		}
+	}
+
+	for _, z := range []float64{-0.1, -1, -5} {
+		lNear, lrNear := negativePoleIntegrals(asymptoticDelhommeauLimit, z)
+		lFar, lrFar := negativePoleIntegrals(math.Nextafter(asymptoticDelhommeauLimit, math.Inf(1)), z)
+		if math.Abs(lNear-lFar) > 1e-8 || math.Abs(lrNear-lrFar) > 1e-8 {
+			t.Errorf("Integrals are discontinuous at z=%v: (%v, %v) vs (%v, %v)", z, lNear, lrNear, lFar, lrFar)
+		}
+	}
+}
+
+func TestFiniteDepthWaveTerm_Derivatives(t *testing.T) {
+	prony, err := FiniteDepthPronyDecomposition(1.5, PythonMethod)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	x, xi := [3]float64{0.2, -0.1, -0.3}, [3]float64{0.7, 0.4, -0.8}
+
+	for _, legacy := range []bool{false, true} {
+		wave := finiteDepthWaveTerm(1.5, 2, 0.5, prony, legacy, legacy, directWaveIntegrals)
+		w, gradX, gradXi := wave(x, xi)
+
+		h := 1e-6
+		for c := 0; c < 3; c++ {
+			xh, xih := x, xi
+			xh[c] += h
+			xih[c] += h
+			wx, _, _ := wave(xh, xi)
+			wxi, _, _ := wave(x, xih)
+			if cmplx.Abs((wx-w)/complex(h, 0)-gradX[c]) > 1e-4 {
+				t.Errorf("legacy=%v: dW/dx%d = %v, finite difference gives %v", legacy, c, gradX[c], (wx-w)/complex(h, 0))
+			}
+			if cmplx.Abs((wxi-w)/complex(h, 0)-gradXi[c]) > 1e-4 {
+				t.Errorf("legacy=%v: dW/dξ%d = %v, finite difference gives %v", legacy, c, gradXi[c], (wxi-w)/complex(h, 0))
+			}
+		}
+	}
+}
+
+func TestRankinePanelIntegral(t *testing.T) {
+	vertices := [][3]float64{{-0.5, -0.5, 0}, {0.5, -0.5, 0}, {0.5, 0.5, 0}, {-0.5, 0.5, 0}}
+	normal := [3]float64{0, 0, 1}
+
+	// Far from the panel, the integral tends to area/r
+	x := [3]float64{30, 20, 10}
+	value, gradient := rankinePanelIntegral(x, vertices, normal)
+	r := norm3(x)
+	if math.Abs(value-1/r) > 1e-4/r {
+		t.Errorf("Expected %v far from the panel, got %v", 1/r, value)
+	}
+	if math.Abs(gradient[0]+x[0]/(r*r*r)) > 1e-3/(r*r) {
+		t.Errorf("Expected gradient %v far from the panel, got %v", -x[0]/(r*r*r), gradient[0])
+	}
+
+	// At the center of a unit square, the integral is 4 ln(1 + sqrt(2))
+	value, gradient = rankinePanelIntegral([3]float64{0, 0, 0}, vertices, normal)
+	if math.Abs(value-4*math.Log(1+math.Sqrt2)) > 1e-12 {
+		t.Errorf("Expected %v at the center of the panel, got %v", 4*math.Log(1+math.Sqrt2), value)
+	}
+	if norm3(gradient) > 1e-12 {
+		t.Errorf("Expected zero gradient at the center of the panel, got %v", gradient)
+	}
+
+	// Just above the center, the normal derivative tends to -2π
+	_, gradient = rankinePanelIntegral([3]float64{0, 0, 1e-9}, vertices, normal)
+	if math.Abs(gradient[2]+2*math.Pi) > 1e-6 {
+		t.Errorf("Expected normal derivative -2π above the panel, got %v", gradient[2])
+	}
+}
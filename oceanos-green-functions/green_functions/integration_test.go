@@ -1,6 +1,7 @@
 package green_functions
 
 import (
+	"gonum.org/v1/gonum/mat"
 	"math"
 	"testing"
 )
@@ -65,8 +66,7 @@ func TestIntegration_FiniteVsInfiniteDepthLimits(t *testing.T) {
 	wavenumber := complex(0.5, 0) // Relatively low frequency
 
 	// Infinite depth reference
-	fgInf := NewFinGreen3D(math.Inf(1))
-	SInf, _, err := fgInf.Evaluate(mesh, mesh, freeSurface, math.Inf(1), wavenumber, true, true)
+	SInf, _, err := NewDefaultDelhommeau().Evaluate(mesh, mesh, freeSurface, math.Inf(1), wavenumber, true, true)
 	if err != nil {
 		t.Fatalf("Infinite depth evaluation failed: %v", err)
 	}
@@ -90,9 +90,8 @@ func TestIntegration_FiniteVsInfiniteDepthLimits(t *testing.T) {
 		}
 
 		// For very deep water, finite depth should approach infinite depth
-		if depth > 100.0 {
-			// This is where we would compare actual values if computation was implemented
-			t.Logf("Depth %f: matrices computed successfully", depth)
+		if depth > 100.0 && !mat.CEqualApprox(SInf, SFin, 1e-4) {
+			t.Errorf("Depth %f: expected %v, got %v", depth, SInf.RawCMatrix().Data, SFin.RawCMatrix().Data)
 		}
 	}
 }
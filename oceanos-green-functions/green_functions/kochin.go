@@ -0,0 +1,97 @@
+// Package green_functions - Kochin function of a source distribution
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package green_functions
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+)
+
+// KochinFunction is the far-field amplitude of the waves radiated by a source distribution
+//
+//	H(θ) = 1/(4π) ∫ σ(ξ) Z(ζ) exp(-ik(ξ cos θ + η sin θ)) dS
+//
+// where Z is the vertical profile of the propagating mode, normalized to 1 at the free surface
+type KochinFunction struct {
+	Wavenumber float64
+	WaterDepth float64
+
+	// Per face: horizontal position and source strength weighted by area and vertical profile
+	x, y    []float64
+	weights []complex128
+}
+
+// NewKochinFunction creates the Kochin function of the sources distributed on the faces of a mesh
+func NewKochinFunction(mesh PanelMesh, sources []complex128, wavenumber, waterDepth float64) (*KochinFunction, error) {
+	nFaces := mesh.GetNbFaces()
+	if len(sources) != nFaces {
+		return nil, errors.New("sources must have one value per face")
+	}
+	if wavenumber <= 0 || math.IsInf(wavenumber, 0) {
+		return nil, errors.New("Kochin function requires a positive finite wavenumber")
+	}
+
+	centers := mesh.GetFacesCenters()
+	areas := mesh.GetFacesAreas()
+	kf := &KochinFunction{
+		Wavenumber: wavenumber,
+		WaterDepth: waterDepth,
+		x:          make([]float64, nFaces),
+		y:          make([]float64, nFaces),
+		weights:    make([]complex128, nFaces),
+	}
+	for i := 0; i < nFaces; i++ {
+		c := rowVector(centers, i)
+		profile, _ := verticalProfile(wavenumber, c[2], waterDepth)
+		kf.x[i], kf.y[i] = c[0], c[1]
+		kf.weights[i] = sources[i] * complex(profile*areas[i]/(4*math.Pi), 0)
+	}
+	return kf, nil
+}
+
+// Evaluate returns the value of the Kochin function in the direction theta
+func (kf *KochinFunction) Evaluate(theta float64) complex128 {
+	h, _ := kf.evaluate(theta)
+	return h
+}
+
+// Derivative returns the derivative of the Kochin function with respect to theta
+func (kf *KochinFunction) Derivative(theta float64) complex128 {
+	_, dh := kf.evaluate(theta)
+	return dh
+}
+
+// evaluate computes the Kochin function and its derivative in one pass over the faces
+func (kf *KochinFunction) evaluate(theta float64) (complex128, complex128) {
+	k := kf.Wavenumber
+	ct, st := math.Cos(theta), math.Sin(theta)
+	var h, dh complex128
+	for i, w := range kf.weights {
+		term := w * cmplx.Exp(complex(0, -k*(kf.x[i]*ct+kf.y[i]*st)))
+		h += term
+		dh += term * complex(0, -k*(-kf.x[i]*st+kf.y[i]*ct))
+	}
+	return h, dh
+}
+
+// groupVelocityRatio returns 2n = 1 + 2kh/sinh(2kh), the ratio between twice the group velocity and the phase velocity
+func groupVelocityRatio(k, waterDepth float64) float64 {
+	if math.IsInf(waterDepth, 1) || 2*k*waterDepth > 700 {
+		return 1
+	}
+	return 1 + 2*k*waterDepth/math.Sinh(2*k*waterDepth)
+}
+
+// farFieldAmplitude returns the factor a such that the far-field elevation of the waves radiated by
+// sources of Kochin function H is a H(θ) exp(i(kρ - π/4)) / sqrt(ρ), for an angular frequency omega
+func (kf *KochinFunction) farFieldAmplitude(omega float64) float64 {
+	k := kf.Wavenumber
+	ck := k
+	if !math.IsInf(kf.WaterDepth, 1) {
+		ck = k / (math.Tanh(k*kf.WaterDepth) * groupVelocityRatio(k, kf.WaterDepth))
+	}
+	return 2 * math.Pi * omega * ck / Gravity * math.Sqrt(2/(math.Pi*k))
+}
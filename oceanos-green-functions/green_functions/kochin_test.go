@@ -0,0 +1,69 @@
+package green_functions
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestKochinFunction_SingleSource(t *testing.T) {
+	vertices := [][3]float64{{1.9, -0.1, -1}, {2.1, -0.1, -1}, {2.1, 0.1, -1}, {1.9, 0.1, -1}}
+	mesh, _ := NewMesh(vertices, [][4]int{{0, 1, 2, 3}})
+	k := 0.5
+
+	kochin, err := NewKochinFunction(mesh, []complex128{complex(1, 0)}, k, math.Inf(1))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, theta := range []float64{0, 1, math.Pi} {
+		expected := complex(0.04*math.Exp(-k)/(4*math.Pi), 0) * cmplx.Exp(complex(0, -2*k*math.Cos(theta)))
+		if cmplx.Abs(kochin.Evaluate(theta)-expected) > 1e-12 {
+			t.Errorf("H(%v) = %v, expected %v", theta, kochin.Evaluate(theta), expected)
+		}
+	}
+
+	h := 1e-6
+	fd := (kochin.Evaluate(1+h) - kochin.Evaluate(1-h)) / complex(2*h, 0)
+	if cmplx.Abs(fd-kochin.Derivative(1)) > 1e-9 {
+		t.Errorf("H'(1) = %v, finite difference gives %v", kochin.Derivative(1), fd)
+	}
+
+	if _, err := NewKochinFunction(mesh, nil, k, math.Inf(1)); err == nil {
+		t.Error("Expected error for missing sources")
+	}
+	if _, err := NewKochinFunction(mesh, []complex128{1}, 0, math.Inf(1)); err == nil {
+		t.Error("Expected error for zero wavenumber")
+	}
+}
+
+func TestKochinFunction_RadiationDamping(t *testing.T) {
+	body := newTestHemisphere(t, 8, 16)
+	solver := NewBEMSolver(NewDefaultDelhommeau())
+	omega := math.Sqrt(0.8 * Gravity)
+
+	result, err := solver.SolveFrequency(body, omega, math.Inf(1), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The energy radiated to the far field balances the work of the damping force:
+	// B = 4π ρ ω k ∫ |H|² dθ in infinite depth
+	for _, dof := range []int{0, 2} {
+		kochin, err := NewKochinFunction(body.Mesh, result.Radiation[dof].Sources, result.Wavenumber, result.WaterDepth)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		n := 90
+		var integral float64
+		for i := 0; i < n; i++ {
+			h := kochin.Evaluate(2 * math.Pi * float64(i) / float64(n))
+			integral += (real(h)*real(h) + imag(h)*imag(h)) * 2 * math.Pi / float64(n)
+		}
+		expected := 4 * math.Pi * WaterDensity * omega * result.Wavenumber * integral
+		if b := result.RadiationDamping.At(dof, dof); math.Abs(b-expected) > 0.08*expected {
+			t.Errorf("Damping %v of DOF %d does not match far-field energy flux %v", b, dof, expected)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Package green_functions - Dense complex linear algebra
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package green_functions
+
+import (
+	"errors"
+	"gonum.org/v1/gonum/mat"
+	"math/cmplx"
+)
+
+// complexLU is the LU factorization with partial pivoting of a square complex matrix
+type complexLU struct {
+	n     int
+	lu    []complex128
+	pivot []int
+}
+
+// factorizeComplex computes the LU factorization of a square complex matrix
+func factorizeComplex(a *mat.CDense) (*complexLU, error) {
+	n, c := a.Dims()
+	if n != c {
+		return nil, errors.New("matrix must be square")
+	}
+
+	f := &complexLU{n: n, lu: make([]complex128, n*n), pivot: make([]int, n)}
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			f.lu[i*n+j] = a.At(i, j)
+		}
+	}
+
+	for k := 0; k < n; k++ {
+		p := k
+		maxAbs := cmplx.Abs(f.lu[k*n+k])
+		for i := k + 1; i < n; i++ {
+			if v := cmplx.Abs(f.lu[i*n+k]); v > maxAbs {
+				p, maxAbs = i, v
+			}
+		}
+		if maxAbs == 0 {
+			return nil, errors.New("matrix is singular")
+		}
+		f.pivot[k] = p
+		if p != k {
+			for j := 0; j < n; j++ {
+				f.lu[k*n+j], f.lu[p*n+j] = f.lu[p*n+j], f.lu[k*n+j]
+			}
+		}
+
+		pivot := f.lu[k*n+k]
+		for i := k + 1; i < n; i++ {
+			l := f.lu[i*n+k] / pivot
+			f.lu[i*n+k] = l
+			if l == 0 {
+				continue
+			}
+			rowI := f.lu[i*n+k+1 : i*n+n]
+			rowK := f.lu[k*n+k+1 : k*n+n]
+			for j := range rowI {
+				rowI[j] -= l * rowK[j]
+			}
+		}
+	}
+	return f, nil
+}
+
+// solve solves A x = b in place
+func (f *complexLU) solve(b []complex128) {
+	n := f.n
+	for k := 0; k < n; k++ {
+		if p := f.pivot[k]; p != k {
+			b[k], b[p] = b[p], b[k]
+		}
+	}
+	for i := 0; i < n; i++ {
+		row := f.lu[i*n : i*n+i]
+		for j, l := range row {
+			b[i] -= l * b[j]
+		}
+	}
+	for i := n - 1; i >= 0; i-- {
+		row := f.lu[i*n+i+1 : i*n+n]
+		for j, u := range row {
+			b[i] -= u * b[i+1+j]
+		}
+		b[i] /= f.lu[i*n+i]
+	}
+}
+
+// complexMatVec computes the product of a complex matrix with a vector
+func complexMatVec(a *mat.CDense, x []complex128) []complex128 {
+	rows, cols := a.Dims()
+	y := make([]complex128, rows)
+	for i := 0; i < rows; i++ {
+		var s complex128
+		for j := 0; j < cols; j++ {
+			s += a.At(i, j) * x[j]
+		}
+		y[i] = s
+	}
+	return y
+}
+
+// addComplexMatrix adds b to a in place, both matrices having the same dimensions
+func addComplexMatrix(a, b *mat.CDense) {
+	rows, cols := a.Dims()
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			a.Set(i, j, a.At(i, j)+b.At(i, j))
+		}
+	}
+}
+
+// solveComplexSystem solves A x = b for a square complex matrix A,
+// with one right-hand side per column of b
+func solveComplexSystem(a *mat.CDense, b *mat.CDense) (*mat.CDense, error) {
+	f, err := factorizeComplex(a)
+	if err != nil {
+		return nil, err
+	}
+	n, m := b.Dims()
+	x := mat.NewCDense(n, m, nil)
+	column := make([]complex128, n)
+	for j := 0; j < m; j++ {
+		for i := 0; i < n; i++ {
+			column[i] = b.At(i, j)
+		}
+		f.solve(column)
+		for i := 0; i < n; i++ {
+			x.Set(i, j, column[i])
+		}
+	}
+	return x, nil
+}
@@ -0,0 +1,205 @@
+// Package green_functions - Panel meshes
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package green_functions
+
+import (
+	"errors"
+	"fmt"
+	"gonum.org/v1/gonum/mat"
+	"math"
+)
+
+// Mesh is a surface mesh made of flat quadrilateral and triangular panels.
+// Triangles are stored as quadrilaterals whose last vertex repeats the third one.
+// Vertices are ordered counterclockwise when seen from the fluid, so that normals point into the fluid.
+type Mesh struct {
+	Vertices [][3]float64
+	Faces    [][4]int
+
+	facesCenters *mat.Dense
+	facesNormals *mat.Dense
+	facesAreas   []float64
+	facesRadii   []float64
+}
+
+// NewMesh creates a new mesh and computes the geometric properties of its faces
+func NewMesh(vertices [][3]float64, faces [][4]int) (*Mesh, error) {
+	if len(faces) == 0 {
+		return nil, errors.New("mesh must have at least one face")
+	}
+
+	nFaces := len(faces)
+	m := &Mesh{
+		Vertices:     vertices,
+		Faces:        faces,
+		facesCenters: mat.NewDense(nFaces, 3, nil),
+		facesNormals: mat.NewDense(nFaces, 3, nil),
+		facesAreas:   make([]float64, nFaces),
+		facesRadii:   make([]float64, nFaces),
+	}
+
+	for i, face := range faces {
+		for _, v := range face {
+			if v < 0 || v >= len(vertices) {
+				return nil, fmt.Errorf("face %d refers to unknown vertex %d", i, v)
+			}
+		}
+
+		v0, v1, v2, v3 := vertices[face[0]], vertices[face[1]], vertices[face[2]], vertices[face[3]]
+
+		// Normal from the cross product of the diagonals, which also gives the area of a flat quadrilateral
+		n := cross3(sub3(v2, v0), sub3(v3, v1))
+		area := norm3(n) / 2
+		if area == 0 {
+			return nil, fmt.Errorf("face %d has zero area", i)
+		}
+		n = scale3(n, 1/(2*area))
+
+		// Center as the area weighted mean of the centers of the two triangles
+		a1 := norm3(cross3(sub3(v1, v0), sub3(v2, v0))) / 2
+		a2 := norm3(cross3(sub3(v2, v0), sub3(v3, v0))) / 2
+		c1 := scale3(add3(add3(v0, v1), v2), 1.0/3)
+		c2 := scale3(add3(add3(v0, v2), v3), 1.0/3)
+		center := scale3(add3(scale3(c1, a1), scale3(c2, a2)), 1/(a1+a2))
+
+		var radius float64
+		for _, v := range face {
+			radius = math.Max(radius, norm3(sub3(vertices[v], center)))
+		}
+
+		m.facesCenters.SetRow(i, center[:])
+		m.facesNormals.SetRow(i, n[:])
+		m.facesAreas[i] = area
+		m.facesRadii[i] = radius
+	}
+
+	return m, nil
+}
+
+// GetFacesCenters returns the centers of the faces as a (nFaces, 3) matrix
+func (m *Mesh) GetFacesCenters() *mat.Dense {
+	return m.facesCenters
+}
+
+// GetFacesNormals returns the unit normals of the faces as a (nFaces, 3) matrix
+func (m *Mesh) GetFacesNormals() *mat.Dense {
+	return m.facesNormals
+}
+
+// GetNbFaces returns the number of faces
+func (m *Mesh) GetNbFaces() int {
+	return len(m.Faces)
+}
+
+// GetFacesAreas returns the areas of the faces
+func (m *Mesh) GetFacesAreas() []float64 {
+	return m.facesAreas
+}
+
+// GetFacesRadii returns the largest distance between the center and the vertices of each face
+func (m *Mesh) GetFacesRadii() []float64 {
+	return m.facesRadii
+}
+
+// GetFaceVertices returns the distinct vertices of a face, projected on the plane of the face
+func (m *Mesh) GetFaceVertices(i int) [][3]float64 {
+	face := m.Faces[i]
+	center := [3]float64{m.facesCenters.At(i, 0), m.facesCenters.At(i, 1), m.facesCenters.At(i, 2)}
+	normal := [3]float64{m.facesNormals.At(i, 0), m.facesNormals.At(i, 1), m.facesNormals.At(i, 2)}
+
+	vertices := make([][3]float64, 0, 4)
+	for k, v := range face {
+		if k > 0 && v == face[k-1] || k == 3 && v == face[0] {
+			continue
+		}
+		p := m.Vertices[v]
+		p = sub3(p, scale3(normal, dot3(sub3(p, center), normal)))
+		vertices = append(vertices, p)
+	}
+	return vertices
+}
+
+// WaterlineSegment is an edge of a face lying on the free surface
+type WaterlineSegment struct {
+	Face  int
+	Start [3]float64
+	End   [3]float64
+}
+
+// Length returns the length of the segment
+func (s WaterlineSegment) Length() float64 {
+	return norm3(sub3(s.End, s.Start))
+}
+
+// Midpoint returns the middle of the segment
+func (s WaterlineSegment) Midpoint() [3]float64 {
+	return scale3(add3(s.Start, s.End), 0.5)
+}
+
+// WaterlineSegments returns the edges of the faces lying on the free surface at z = freeSurface
+func (m *Mesh) WaterlineSegments(freeSurface float64) []WaterlineSegment {
+	var segments []WaterlineSegment
+	for i, face := range m.Faces {
+		tolerance := 1e-6 * m.facesRadii[i]
+		onSurface := func(v int) bool {
+			return math.Abs(m.Vertices[v][2]-freeSurface) <= tolerance
+		}
+		for k := 0; k < 4; k++ {
+			a, b := face[k], face[(k+1)%4]
+			if a == b || !onSurface(a) || !onSurface(b) {
+				continue
+			}
+			segments = append(segments, WaterlineSegment{Face: i, Start: m.Vertices[a], End: m.Vertices[b]})
+		}
+	}
+	return segments
+}
+
+// NewHemisphereMesh creates a mesh of the immersed half of a sphere centered on the free surface,
+// with nTheta panels from the waterline to the bottom and nPhi panels around the vertical axis
+func NewHemisphereMesh(radius float64, nTheta, nPhi int) (*Mesh, error) {
+	if radius <= 0 || nTheta < 1 || nPhi < 3 {
+		return nil, errors.New("hemisphere mesh needs a positive radius, nTheta >= 1 and nPhi >= 3")
+	}
+
+	// Vertex (i, j) is at polar angle i*π/(2 nTheta) from the waterline and azimuth 2πj/nPhi.
+	// The bottom pole is a single vertex.
+	var vertices [][3]float64
+	for i := 0; i < nTheta; i++ {
+		theta := float64(i) * math.Pi / 2 / float64(nTheta)
+		for j := 0; j < nPhi; j++ {
+			phi := 2 * math.Pi * float64(j) / float64(nPhi)
+			vertices = append(vertices, [3]float64{
+				radius * math.Cos(theta) * math.Cos(phi),
+				radius * math.Cos(theta) * math.Sin(phi),
+				-radius * math.Sin(theta),
+			})
+		}
+	}
+	pole := len(vertices)
+	vertices = append(vertices, [3]float64{0, 0, -radius})
+
+	index := func(i, j int) int {
+		if i == nTheta {
+			return pole
+		}
+		return i*nPhi + j%nPhi
+	}
+
+	var faces [][4]int
+	for i := 0; i < nTheta; i++ {
+		for j := 0; j < nPhi; j++ {
+			a, b := index(i, j), index(i+1, j)
+			c, d := index(i+1, j+1), index(i, j+1)
+			if i+1 == nTheta {
+				faces = append(faces, [4]int{a, b, d, d})
+			} else {
+				faces = append(faces, [4]int{a, b, c, d})
+			}
+		}
+	}
+
+	return NewMesh(vertices, faces)
+}
@@ -0,0 +1,123 @@
+package green_functions
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNewMesh(t *testing.T) {
+	vertices := [][3]float64{{0, 0, -1}, {2, 0, -1}, {2, 1, -1}, {0, 1, -1}}
+	mesh, err := NewMesh(vertices, [][4]int{{0, 1, 2, 3}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if mesh.GetNbFaces() != 1 {
+		t.Errorf("Expected 1 face, got %d", mesh.GetNbFaces())
+	}
+	if math.Abs(mesh.GetFacesAreas()[0]-2) > 1e-12 {
+		t.Errorf("Expected area 2, got %v", mesh.GetFacesAreas()[0])
+	}
+	if math.Abs(mesh.GetFacesRadii()[0]-math.Sqrt(1.25)) > 1e-12 {
+		t.Errorf("Expected radius %v, got %v", math.Sqrt(1.25), mesh.GetFacesRadii()[0])
+	}
+
+	center := rowVector(mesh.GetFacesCenters(), 0)
+	if norm3(sub3(center, [3]float64{1, 0.5, -1})) > 1e-12 {
+		t.Errorf("Expected center (1, 0.5, -1), got %v", center)
+	}
+	normal := rowVector(mesh.GetFacesNormals(), 0)
+	if norm3(sub3(normal, [3]float64{0, 0, 1})) > 1e-12 {
+		t.Errorf("Expected normal (0, 0, 1), got %v", normal)
+	}
+}
+
+func TestNewMesh_Triangle(t *testing.T) {
+	vertices := [][3]float64{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}}
+	mesh, err := NewMesh(vertices, [][4]int{{0, 1, 2, 2}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if math.Abs(mesh.GetFacesAreas()[0]-0.5) > 1e-12 {
+		t.Errorf("Expected area 0.5, got %v", mesh.GetFacesAreas()[0])
+	}
+	if len(mesh.GetFaceVertices(0)) != 3 {
+		t.Errorf("Expected 3 distinct vertices, got %d", len(mesh.GetFaceVertices(0)))
+	}
+}
+
+func TestNewMesh_InvalidInput(t *testing.T) {
+	vertices := [][3]float64{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}}
+
+	if _, err := NewMesh(vertices, nil); err == nil {
+		t.Error("Expected error for mesh without faces")
+	}
+	if _, err := NewMesh(vertices, [][4]int{{0, 1, 2, 5}}); err == nil {
+		t.Error("Expected error for unknown vertex")
+	}
+	if _, err := NewMesh(vertices, [][4]int{{0, 0, 0, 0}}); err == nil {
+		t.Error("Expected error for face with zero area")
+	}
+}
+
+func TestNewHemisphereMesh(t *testing.T) {
+	mesh, err := NewHemisphereMesh(2, 8, 16)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if mesh.GetNbFaces() != 8*16 {
+		t.Errorf("Expected %d faces, got %d", 8*16, mesh.GetNbFaces())
+	}
+
+	var area float64
+	for i := 0; i < mesh.GetNbFaces(); i++ {
+		area += mesh.GetFacesAreas()[i]
+		center := rowVector(mesh.GetFacesCenters(), i)
+		normal := rowVector(mesh.GetFacesNormals(), i)
+		if dot3(center, normal) <= 0 {
+			t.Errorf("Normal of face %d does not point into the fluid", i)
+		}
+		if center[2] >= 0 {
+			t.Errorf("Face %d is not immersed", i)
+		}
+	}
+
+	expected := 2 * math.Pi * 4
+	if math.Abs(area-expected) > 0.02*expected {
+		t.Errorf("Expected area close to %v, got %v", expected, area)
+	}
+
+	if _, err := NewHemisphereMesh(-1, 8, 16); err == nil {
+		t.Error("Expected error for negative radius")
+	}
+}
+
+func TestMesh_WaterlineSegments(t *testing.T) {
+	mesh, _ := NewHemisphereMesh(1, 4, 16)
+	segments := mesh.WaterlineSegments(0)
+
+	if len(segments) != 16 {
+		t.Fatalf("Expected 16 waterline segments, got %d", len(segments))
+	}
+
+	var length float64
+	for _, segment := range segments {
+		length += segment.Length()
+		if segment.Start[2] != 0 || segment.End[2] != 0 {
+			t.Errorf("Segment %v is not on the free surface", segment)
+		}
+		if segment.Face >= 16 {
+			t.Errorf("Segment belongs to face %d, which is not on the first row", segment.Face)
+		}
+	}
+	expected := 32 * math.Sin(math.Pi/16)
+	if math.Abs(length-expected) > 1e-12 {
+		t.Errorf("Expected waterline length %v, got %v", expected, length)
+	}
+
+	if len(mesh.WaterlineSegments(-10)) != 0 {
+		t.Error("Expected no waterline below the body")
+	}
+}
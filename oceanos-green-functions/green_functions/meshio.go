@@ -0,0 +1,253 @@
+// Package green_functions - Reading meshes from files
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package green_functions
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// MeshFormat identifies a mesh file format
+type MeshFormat string
+
+const (
+	// GDFFormat is the WAMIT geometric data file format
+	GDFFormat MeshFormat = "gdf"
+	// NemohFormat is the Nemoh mesh format, with a list of vertices followed by a list of panels
+	NemohFormat MeshFormat = "nemoh"
+)
+
+// MeshFormatFromPath guesses the format of a mesh file from its extension
+func MeshFormatFromPath(path string) (MeshFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gdf":
+		return GDFFormat, nil
+	case ".dat", ".nemoh":
+		return NemohFormat, nil
+	}
+	return "", fmt.Errorf("unknown mesh file extension %q", filepath.Ext(path))
+}
+
+// LoadMesh reads a mesh file, whose format is guessed from the extension
+func LoadMesh(path string) (*Mesh, error) {
+	format, err := MeshFormatFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	mesh, err := ReadMesh(f, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return mesh, nil
+}
+
+// ReadMesh reads a mesh in the given format
+func ReadMesh(r io.Reader, format MeshFormat) (*Mesh, error) {
+	switch format {
+	case GDFFormat:
+		return ReadGDF(r)
+	case NemohFormat:
+		return ReadNemohMesh(r)
+	}
+	return nil, fmt.Errorf("unknown mesh format %q", format)
+}
+
+// ReadGDF reads a mesh in the WAMIT GDF format: a title line, ULEN and GRAV, the ISX and ISY symmetry flags,
+// the number of panels and the coordinates of the four vertices of each panel.
+// The panels symmetric with respect to the planes x = 0 (ISX = 1) and y = 0 (ISY = 1) are added to the mesh.
+func ReadGDF(r io.Reader) (*Mesh, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() {
+		return nil, errors.New("gdf: missing title line")
+	}
+
+	// The rest of the file is read as a stream of numbers, since the vertices of a panel may span several lines
+	var tokens []string
+	for scanner.Scan() {
+		tokens = append(tokens, strings.Fields(scanner.Text())...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	numbers := make([]float64, 0, len(tokens))
+	for _, token := range tokens {
+		x, err := strconv.ParseFloat(strings.Replace(strings.Replace(token, "D", "E", 1), "d", "e", 1), 64)
+		if err != nil {
+			return nil, fmt.Errorf("gdf: invalid number %q", token)
+		}
+		numbers = append(numbers, x)
+	}
+	if len(numbers) < 5 {
+		return nil, errors.New("gdf: truncated header")
+	}
+
+	isx, isy := numbers[2] != 0, numbers[3] != 0
+	nPanels := int(numbers[4])
+	if nPanels <= 0 || float64(nPanels) != numbers[4] {
+		return nil, fmt.Errorf("gdf: invalid number of panels %g", numbers[4])
+	}
+	coordinates := numbers[5:]
+	if len(coordinates) < 12*nPanels {
+		return nil, fmt.Errorf("gdf: expected %d panels, found %d", nPanels, len(coordinates)/12)
+	}
+
+	builder := newMeshBuilder()
+	for i := 0; i < nPanels; i++ {
+		var panel [4][3]float64
+		for j := range panel {
+			copy(panel[j][:], coordinates[12*i+3*j:12*i+3*j+3])
+		}
+		builder.addSymmetricPanels(panel, isx, isy)
+	}
+	return builder.mesh()
+}
+
+// ReadNemohMesh reads a mesh in the Nemoh format: a header line "2 ISYM", the vertices as "index x y z" lines
+// ended by a line of zeros, then the panels as four one-based vertex indices ended by a line of zeros.
+// The panels symmetric with respect to the plane y = 0 are added to the mesh when ISYM = 1.
+func ReadNemohMesh(r io.Reader) (*Mesh, error) {
+	scanner := bufio.NewScanner(r)
+	var lines [][]string
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) > 0 {
+			lines = append(lines, fields)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 || len(lines[0]) < 2 {
+		return nil, errors.New("nemoh mesh: missing header line")
+	}
+	isym := lines[0][1] != "0"
+
+	parse := func(line int, fields []string) ([]float64, error) {
+		values := make([]float64, len(fields))
+		for i, field := range fields {
+			x, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, fmt.Errorf("nemoh mesh: line %d: invalid number %q", line+1, field)
+			}
+			values[i] = x
+		}
+		return values, nil
+	}
+
+	var vertices [][3]float64
+	i := 1
+	for ; i < len(lines); i++ {
+		values, err := parse(i, lines[i])
+		if err != nil {
+			return nil, err
+		}
+		if len(values) != 4 {
+			return nil, fmt.Errorf("nemoh mesh: line %d: expected an index and three coordinates", i+1)
+		}
+		if values[0] == 0 {
+			break
+		}
+		vertices = append(vertices, [3]float64{values[1], values[2], values[3]})
+	}
+	if i == len(lines) {
+		return nil, errors.New("nemoh mesh: missing end of the vertices")
+	}
+
+	builder := newMeshBuilder()
+	for i++; i < len(lines); i++ {
+		values, err := parse(i, lines[i])
+		if err != nil {
+			return nil, err
+		}
+		if len(values) != 4 {
+			return nil, fmt.Errorf("nemoh mesh: line %d: expected four vertex indices", i+1)
+		}
+		if values[0] == 0 {
+			return builder.mesh()
+		}
+		var panel [4][3]float64
+		for j, v := range values {
+			index := int(v) - 1
+			if index < 0 || index >= len(vertices) || float64(index+1) != v {
+				return nil, fmt.Errorf("nemoh mesh: line %d: unknown vertex %g", i+1, v)
+			}
+			panel[j] = vertices[index]
+		}
+		builder.addSymmetricPanels(panel, false, isym)
+	}
+	return nil, errors.New("nemoh mesh: missing end of the panels")
+}
+
+// meshBuilder merges the identical vertices of the panels read from a file
+type meshBuilder struct {
+	vertices [][3]float64
+	faces    [][4]int
+	indices  map[[3]float64]int
+}
+
+func newMeshBuilder() *meshBuilder {
+	return &meshBuilder{indices: make(map[[3]float64]int)}
+}
+
+// addPanel adds a panel, storing it as a triangle if two of its vertices coincide
+func (b *meshBuilder) addPanel(panel [4][3]float64) {
+	face := make([]int, 0, 4)
+	for _, p := range panel {
+		index, ok := b.indices[p]
+		if !ok {
+			index = len(b.vertices)
+			b.indices[p] = index
+			b.vertices = append(b.vertices, p)
+		}
+		if len(face) > 0 && (face[len(face)-1] == index || len(face) == 3 && face[0] == index) {
+			continue
+		}
+		face = append(face, index)
+	}
+	for len(face) < 4 {
+		face = append(face, face[len(face)-1])
+	}
+	b.faces = append(b.faces, [4]int{face[0], face[1], face[2], face[3]})
+}
+
+// addSymmetricPanels adds a panel and its images by the planes x = 0 and y = 0.
+// The order of the vertices of the images is reversed to keep the normals pointing into the fluid.
+func (b *meshBuilder) addSymmetricPanels(panel [4][3]float64, xSymmetry, ySymmetry bool) {
+	reflect := func(panel [4][3]float64, axis int) [4][3]float64 {
+		var image [4][3]float64
+		for j := range panel {
+			image[3-j] = panel[j]
+			image[3-j][axis] = -panel[j][axis]
+		}
+		return image
+	}
+
+	b.addPanel(panel)
+	if xSymmetry {
+		b.addPanel(reflect(panel, 0))
+	}
+	if ySymmetry {
+		b.addPanel(reflect(panel, 1))
+		if xSymmetry {
+			b.addPanel(reflect(reflect(panel, 0), 1))
+		}
+	}
+}
+
+func (b *meshBuilder) mesh() (*Mesh, error) {
+	return NewMesh(b.vertices, b.faces)
+}
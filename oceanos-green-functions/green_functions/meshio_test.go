@@ -0,0 +1,160 @@
+package green_functions
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// formatGDF writes the given faces of a mesh in the GDF format
+func formatGDF(mesh *Mesh, faces []int, isx, isy int) string {
+	var b strings.Builder
+	fmt.Fprintln(&b, "test mesh")
+	fmt.Fprintln(&b, "1.0 9.81")
+	fmt.Fprintf(&b, "%d %d\n", isx, isy)
+	fmt.Fprintln(&b, len(faces))
+	for _, i := range faces {
+		for _, v := range mesh.Faces[i] {
+			p := mesh.Vertices[v]
+			fmt.Fprintf(&b, "%.17g %.17g %.17g\n", p[0], p[1], p[2])
+		}
+	}
+	return b.String()
+}
+
+// meshMoments returns the total area and the area weighted sum of the normals and centers of a mesh
+func meshMoments(mesh *Mesh) (float64, [3]float64, [3]float64) {
+	var area float64
+	var normals, centers [3]float64
+	for i, a := range mesh.GetFacesAreas() {
+		area += a
+		normals = add3(normals, scale3(rowVector(mesh.GetFacesNormals(), i), a))
+		centers = add3(centers, scale3(rowVector(mesh.GetFacesCenters(), i), a))
+	}
+	return area, normals, centers
+}
+
+func TestReadGDF(t *testing.T) {
+	hemisphere, err := NewHemisphereMesh(1, 4, 8)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	all := make([]int, hemisphere.GetNbFaces())
+	for i := range all {
+		all[i] = i
+	}
+
+	mesh, err := ReadGDF(strings.NewReader(formatGDF(hemisphere, all, 0, 0)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if mesh.GetNbFaces() != hemisphere.GetNbFaces() || len(mesh.Vertices) != len(hemisphere.Vertices) {
+		t.Fatalf("Expected %d faces and %d vertices, got %d and %d",
+			hemisphere.GetNbFaces(), len(hemisphere.Vertices), mesh.GetNbFaces(), len(mesh.Vertices))
+	}
+	for i := 0; i < mesh.GetNbFaces(); i++ {
+		if math.Abs(mesh.GetFacesAreas()[i]-hemisphere.GetFacesAreas()[i]) > 1e-12 {
+			t.Errorf("Face %d: expected area %v, got %v", i, hemisphere.GetFacesAreas()[i], mesh.GetFacesAreas()[i])
+		}
+		if len(mesh.GetFaceVertices(i)) != len(hemisphere.GetFaceVertices(i)) {
+			t.Errorf("Face %d: expected %d vertices, got %d", i, len(hemisphere.GetFaceVertices(i)), len(mesh.GetFaceVertices(i)))
+		}
+	}
+}
+
+func TestReadGDF_Symmetry(t *testing.T) {
+	hemisphere, err := NewHemisphereMesh(1, 4, 8)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expectedArea, expectedNormals, expectedCenters := meshMoments(hemisphere)
+
+	// Faces of the quarter x > 0, y > 0 are the first two azimuthal sectors of each ring
+	var quarter, half []int
+	for i := 0; i < hemisphere.GetNbFaces(); i++ {
+		if j := i % 8; j < 2 {
+			quarter = append(quarter, i)
+		} else if j < 4 {
+			half = append(half, i)
+		}
+	}
+	half = append(half, quarter...)
+
+	for _, tc := range []struct {
+		name     string
+		faces    []int
+		isx, isy int
+	}{
+		{"ISY", half, 0, 1},
+		{"ISX and ISY", quarter, 1, 1},
+	} {
+		mesh, err := ReadGDF(strings.NewReader(formatGDF(hemisphere, tc.faces, tc.isx, tc.isy)))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if mesh.GetNbFaces() != hemisphere.GetNbFaces() {
+			t.Errorf("%s: expected %d faces, got %d", tc.name, hemisphere.GetNbFaces(), mesh.GetNbFaces())
+		}
+		area, normals, centers := meshMoments(mesh)
+		if math.Abs(area-expectedArea) > 1e-12 {
+			t.Errorf("%s: expected area %v, got %v", tc.name, expectedArea, area)
+		}
+		if norm3(sub3(normals, expectedNormals)) > 1e-12 || norm3(sub3(centers, expectedCenters)) > 1e-12 {
+			t.Errorf("%s: expected moments %v %v, got %v %v", tc.name, expectedNormals, expectedCenters, normals, centers)
+		}
+	}
+}
+
+func TestReadNemohMesh(t *testing.T) {
+	input := `2 1
+1 0.0 0.0 -1.0
+2 1.0 0.0 -1.0
+3 1.0 1.0 -1.0
+4 0.0 1.0 -1.0
+5 0.0 1.0 0.0
+0 0.0 0.0 0.0
+1 4 3 2
+4 5 5 3
+0 0 0 0
+`
+	mesh, err := ReadNemohMesh(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if mesh.GetNbFaces() != 4 {
+		t.Fatalf("Expected 4 faces with the symmetric ones, got %d", mesh.GetNbFaces())
+	}
+	area, normals, _ := meshMoments(mesh)
+	if math.Abs(area-3) > 1e-12 {
+		t.Errorf("Expected area 3, got %v", area)
+	}
+	if norm3(sub3(normals, [3]float64{0, 0, -2})) > 1e-12 {
+		t.Errorf("Expected normals summing to (0, 0, -2), got %v", normals)
+	}
+	if len(mesh.GetFaceVertices(2)) != 3 {
+		t.Errorf("Expected a triangle, got %d vertices", len(mesh.GetFaceVertices(2)))
+	}
+
+	for _, invalid := range []string{
+		"",
+		"2 0\n1 0 0 0\n",
+		"2 0\n1 0 0 0\n0 0 0 0\n1 2 3 4\n0 0 0 0\n",
+		"2 0\n1 0 0 0\n0 0 0 0\n1 1 1 1\n",
+	} {
+		if _, err := ReadNemohMesh(strings.NewReader(invalid)); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+}
+
+func TestMeshFormatFromPath(t *testing.T) {
+	for path, expected := range map[string]MeshFormat{"hull.gdf": GDFFormat, "HULL.GDF": GDFFormat, "mesh/hull.dat": NemohFormat} {
+		if format, err := MeshFormatFromPath(path); err != nil || format != expected {
+			t.Errorf("%s: expected %q, got %q (%v)", path, expected, format, err)
+		}
+	}
+	if _, err := MeshFormatFromPath("hull.stl"); err == nil {
+		t.Error("Expected error for unknown extension")
+	}
+}
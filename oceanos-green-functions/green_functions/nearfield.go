@@ -0,0 +1,239 @@
+// Package green_functions - Near-field mean drift forces by direct pressure integration
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package green_functions
+
+import (
+	"errors"
+	"fmt"
+	"gonum.org/v1/gonum/mat"
+	"math"
+	"math/cmplx"
+)
+
+// DriftForceComponents holds the horizontal components of a mean drift force and its yaw moment around the origin
+type DriftForceComponents struct {
+	Surge float64
+	Sway  float64
+	Yaw   float64
+}
+
+func (c DriftForceComponents) add(other DriftForceComponents) DriftForceComponents {
+	return DriftForceComponents{Surge: c.Surge + other.Surge, Sway: c.Sway + other.Sway, Yaw: c.Yaw + other.Yaw}
+}
+
+// NearFieldDriftForce is the mean drift force computed by direct integration of the second order pressure
+// on the wetted surface (Pinkster), together with the contribution of each term of the integration
+type NearFieldDriftForce struct {
+	MeanDriftForce
+
+	// RelativeWaveElevation is -ρg/4 ∮ |ζ_r|² n dl along the waterline
+	RelativeWaveElevation DriftForceComponents
+	// VelocitySquared is ρ/4 ∫ |∇φ|² n dS on the mean wetted surface
+	VelocitySquared DriftForceComponents
+	// PressureGradient is -1/2 ∫ Re(X* · ∇p) n dS, the first order pressure gradient times the first order motion
+	PressureGradient DriftForceComponents
+	// RotationOfInertiaForces is the first order inertia force rotated by the first order rotation of the body
+	RotationOfInertiaForces DriftForceComponents
+}
+
+// NearFieldDriftForces computes the near-field mean drift forces on a body for each wave direction of a result,
+// by integration of the second order pressure on the mean wetted surface.
+// motions holds the complex motion amplitudes as a (nDirections, nDOFs) matrix, as returned by ComputeRAO.
+// With nil motions, the drift forces on the fixed body are computed. The yaw moment is given around the origin.
+func (s *BEMSolver) NearFieldDriftForces(body *FloatingBody, result *FrequencyResult, motions *mat.CDense) ([]NearFieldDriftForce, error) {
+	nDirections := len(result.WaveDirections)
+	if motions != nil {
+		rows, cols := motions.Dims()
+		if rows != nDirections || cols != body.NbDOFs() {
+			return nil, fmt.Errorf("motions must be a (%d, %d) matrix", nDirections, body.NbDOFs())
+		}
+		if body.InertiaMatrix == nil {
+			return nil, errors.New("body inertia matrix is required to compute the drift forces on a moving body")
+		}
+	}
+	if len(result.Diffraction) != nDirections {
+		return nil, errors.New("result has no diffraction solution for each wave direction")
+	}
+
+	mesh := body.Mesh
+	nFaces := mesh.GetNbFaces()
+	omega, k, depth, rho := result.Omega, complex(result.Wavenumber, 0), result.WaterDepth, result.Rho
+
+	// Velocity on the faces, from the gradient of the single layer potential and the jump of its normal derivative
+	_, gradients, err := s.evaluateGreenFunction(mesh, mesh, 0, depth, k, true, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Potential on the waterline
+	segments := mesh.WaterlineSegments(0)
+	if len(segments) == 0 {
+		return nil, errors.New("mesh has no waterline, near-field drift forces require a surface piercing body")
+	}
+	waterlinePoints := mat.NewDense(len(segments), 3, nil)
+	for w, segment := range segments {
+		midpoint := segment.Midpoint()
+		waterlinePoints.SetRow(w, midpoint[:])
+	}
+	waterlineS, _, err := s.evaluateGreenFunction(waterlinePoints, mesh, 0, depth, k, false, true)
+	if err != nil {
+		return nil, err
+	}
+
+	centers := mesh.GetFacesCenters()
+	normals := mesh.GetFacesNormals()
+	areas := mesh.GetFacesAreas()
+
+	forces := make([]NearFieldDriftForce, nDirections)
+	for d, beta := range result.WaveDirections {
+		var xi []complex128
+		var translation, rotation [3]complex128
+		if motions != nil {
+			xi = make([]complex128, body.NbDOFs())
+			for j := range xi {
+				xi[j] = motions.At(d, j)
+			}
+			translation, rotation = body.rigidMotion(xi)
+		}
+		sigma := result.TotalSources(d, xi)
+
+		// motion returns the complex displacement of a point of the body
+		motion := func(point [3]float64) [3]complex128 {
+			arm := realToComplex3(sub3(point, body.RotationCenter))
+			return addComplex3(translation, complexCross3(rotation, arm))
+		}
+
+		var elevation, velocitySquared, pressureGradient DriftForceComponents
+
+		// Relative wave elevation along the waterline, with the horizontal normal of the hull
+		for w, segment := range segments {
+			point := segment.Midpoint()
+			phi, _ := airyWave(point, omega, result.Wavenumber, depth, beta)
+			for j := 0; j < nFaces; j++ {
+				phi += waterlineS.At(w, j) * sigma[j]
+			}
+			zetaR := complex(0, omega/Gravity)*phi - motion(point)[2]
+
+			n := rowVector(normals, segment.Face)
+			nh := math.Hypot(n[0], n[1])
+			if nh == 0 {
+				continue
+			}
+			weight := -rho * Gravity / 4 * absSquared(zetaR) * segment.Length() / nh
+			elevation = elevation.add(horizontalForce(point, n, weight))
+		}
+
+		for i := 0; i < nFaces; i++ {
+			x := rowVector(centers, i)
+			n := rowVector(normals, i)
+
+			_, velocity := airyWave(x, omega, result.Wavenumber, depth, beta)
+			for c := 0; c < 3; c++ {
+				velocity[c] += complex(0.5*n[c], 0) * sigma[i]
+				for j := 0; j < nFaces; j++ {
+					velocity[c] += gradients.At(i, 3*j+c) * sigma[j]
+				}
+			}
+
+			var v2 float64
+			for c := 0; c < 3; c++ {
+				v2 += absSquared(velocity[c])
+			}
+			velocitySquared = velocitySquared.add(horizontalForce(x, n, rho/4*v2*areas[i]))
+
+			if motions != nil {
+				// The gradient of the first order pressure p = iωρφ
+				displacement := motion(x)
+				var work complex128
+				for c := 0; c < 3; c++ {
+					work += cmplx.Conj(displacement[c]) * complex(0, omega*rho) * velocity[c]
+				}
+				pressureGradient = pressureGradient.add(horizontalForce(x, n, -0.5*real(work)*areas[i]))
+			}
+		}
+
+		var inertia DriftForceComponents
+		if motions != nil {
+			inertia = body.rotationOfInertiaForces(omega, xi, translation, rotation)
+		}
+
+		total := elevation.add(velocitySquared).add(pressureGradient).add(inertia)
+		forces[d] = NearFieldDriftForce{
+			MeanDriftForce: MeanDriftForce{
+				Omega:         omega,
+				WaveDirection: beta,
+				Surge:         total.Surge,
+				Sway:          total.Sway,
+				Yaw:           total.Yaw,
+			},
+			RelativeWaveElevation:   elevation,
+			VelocitySquared:         velocitySquared,
+			PressureGradient:        pressureGradient,
+			RotationOfInertiaForces: inertia,
+		}
+	}
+	return forces, nil
+}
+
+// TotalDriftForces returns the total of each near-field drift force, for instance to build a QTFDiagonal
+func TotalDriftForces(forces []NearFieldDriftForce) []MeanDriftForce {
+	totals := make([]MeanDriftForce, len(forces))
+	for i, force := range forces {
+		totals[i] = force.MeanDriftForce
+	}
+	return totals
+}
+
+// rotationOfInertiaForces computes the second order force due to the rotation of the first order forces with the body.
+// The first order force and moment around the rotation center are given by the equation of motion, G = -ω² M ξ.
+// The moment around the origin also includes the first order force applied at the displaced rotation center.
+func (b *FloatingBody) rotationOfInertiaForces(omega float64, xi []complex128, translation, rotation [3]complex128) DriftForceComponents {
+	n := b.NbDOFs()
+	generalized := make([]complex128, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			generalized[i] += complex(-omega*omega*b.InertiaMatrix.At(i, j), 0) * xi[j]
+		}
+	}
+	force, moment := b.rigidMotion(generalized)
+
+	meanCross := func(a, c [3]complex128) [3]float64 {
+		var conj [3]complex128
+		for i := range c {
+			conj[i] = cmplx.Conj(c[i])
+		}
+		product := complexCross3(a, conj)
+		return [3]float64{real(product[0]) / 2, real(product[1]) / 2, real(product[2]) / 2}
+	}
+
+	rotatedForce := meanCross(rotation, force)
+	rotatedMoment := meanCross(rotation, moment)
+	translatedForce := meanCross(translation, force)
+	leverArm := cross3(b.RotationCenter, rotatedForce)
+
+	return DriftForceComponents{
+		Surge: rotatedForce[0],
+		Sway:  rotatedForce[1],
+		Yaw:   rotatedMoment[2] + translatedForce[2] + leverArm[2],
+	}
+}
+
+// horizontalForce returns the horizontal force and the yaw moment around the origin of a force weight * n applied at x
+func horizontalForce(x, n [3]float64, weight float64) DriftForceComponents {
+	fx, fy := weight*n[0], weight*n[1]
+	return DriftForceComponents{Surge: fx, Sway: fy, Yaw: x[0]*fy - x[1]*fx}
+}
+
+func absSquared(z complex128) float64 {
+	return real(z)*real(z) + imag(z)*imag(z)
+}
+
+func complexCross3(a, b [3]complex128) [3]complex128 {
+	return [3]complex128{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}
+}
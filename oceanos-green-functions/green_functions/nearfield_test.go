@@ -0,0 +1,124 @@
+package green_functions
+
+import (
+	"gonum.org/v1/gonum/mat"
+	"math"
+	"testing"
+)
+
+func TestNearFieldDriftForces_Hemisphere(t *testing.T) {
+	body := newTestHemisphere(t, 8, 16)
+	solver := NewBEMSolver(NewDefaultDelhommeau())
+	directions := []float64{0, math.Pi / 3}
+
+	testCases := []struct {
+		kr        float64
+		floating  bool
+		tolerance float64
+	}{
+		{1.0, false, 0.03},
+		{1.5, false, 0.03},
+		// The pressure integration converges slowly on the moving body, because of cancellations between terms
+		{1.5, true, 0.08},
+	}
+
+	for _, tc := range testCases {
+		omega := math.Sqrt(tc.kr * Gravity)
+		result, err := solver.SolveFrequency(body, omega, math.Inf(1), directions)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		var motions *mat.CDense
+		if tc.floating {
+			motions, err = result.ComputeRAO(body)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		}
+
+		farField, err := FarFieldDriftForces(body, result, motions, 0)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		nearField, err := solver.NearFieldDriftForces(body, result, motions)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		for d := range directions {
+			far, near := farField[d], nearField[d]
+			magnitude := math.Hypot(far.Surge, far.Sway)
+			if math.Hypot(near.Surge-far.Surge, near.Sway-far.Sway) > tc.tolerance*magnitude {
+				t.Errorf("kR=%v floating=%v β=%v: near-field (%v, %v) does not match far-field (%v, %v)",
+					tc.kr, tc.floating, directions[d], near.Surge, near.Sway, far.Surge, far.Sway)
+			}
+
+			sum := near.RelativeWaveElevation.add(near.VelocitySquared).add(near.PressureGradient).add(near.RotationOfInertiaForces)
+			if math.Abs(sum.Surge-near.Surge) > 1e-9*magnitude || math.Abs(sum.Yaw-near.Yaw) > 1e-9*magnitude {
+				t.Errorf("Terms do not sum to the total drift force")
+			}
+			if !tc.floating && (near.PressureGradient != DriftForceComponents{} || near.RotationOfInertiaForces != DriftForceComponents{}) {
+				t.Errorf("Expected no motion terms on the fixed body, got %+v and %+v", near.PressureGradient, near.RotationOfInertiaForces)
+			}
+			if math.Abs(near.Yaw) > 1e-6*magnitude {
+				t.Errorf("Expected zero yaw moment by symmetry, got %v", near.Yaw)
+			}
+		}
+	}
+}
+
+func TestNearFieldDriftForces_YawMomentOfShiftedBody(t *testing.T) {
+	mesh, _ := NewHemisphereMesh(1, 8, 16)
+	shift := [3]float64{1.5, -0.7, 0}
+	vertices := make([][3]float64, len(mesh.Vertices))
+	for i, v := range mesh.Vertices {
+		vertices[i] = add3(v, shift)
+	}
+	shiftedMesh, _ := NewMesh(vertices, mesh.Faces)
+	body := NewRigidBody("shifted hemisphere", shiftedMesh, shift)
+
+	solver := NewBEMSolver(NewDefaultDelhommeau())
+	result, err := solver.SolveFrequency(body, math.Sqrt(Gravity), math.Inf(1), []float64{math.Pi / 3})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	nearField, err := solver.NearFieldDriftForces(body, result, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The moment around the origin of a force applied on the axis of the hemisphere
+	force := nearField[0]
+	expected := shift[0]*force.Sway - shift[1]*force.Surge
+	if math.Abs(force.Yaw-expected) > 1e-6*math.Abs(expected) {
+		t.Errorf("Expected yaw moment %v, got %v", expected, force.Yaw)
+	}
+}
+
+func TestNewQTFDiagonal(t *testing.T) {
+	forces := [][]MeanDriftForce{
+		{{Omega: 1, WaveDirection: 0, Surge: 1}, {Omega: 1, WaveDirection: 1, Surge: 2, Yaw: 3}},
+		{{Omega: 2, WaveDirection: 0, Surge: 4}, {Omega: 2, WaveDirection: 1, Sway: 5}},
+	}
+
+	qtf, err := NewQTFDiagonal(forces)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if qtf.Omegas[1] != 2 || qtf.WaveDirections[1] != 1 {
+		t.Errorf("Unexpected axes %v and %v", qtf.Omegas, qtf.WaveDirections)
+	}
+	if qtf.Surge.At(1, 0) != 4 || qtf.Sway.At(1, 1) != 5 || qtf.Yaw.At(0, 1) != 3 {
+		t.Error("Unexpected QTF values")
+	}
+
+	forces[1][1].WaveDirection = 2
+	if _, err := NewQTFDiagonal(forces); err == nil {
+		t.Error("Expected error for inconsistent wave directions")
+	}
+	if _, err := NewQTFDiagonal(nil); err == nil {
+		t.Error("Expected error for empty forces")
+	}
+}
@@ -0,0 +1,470 @@
+// Package green_functions - Nemoh case files
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package green_functions
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"gonum.org/v1/gonum/mat"
+	"io"
+	"math"
+	"math/cmplx"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Names of the files of the results directory of a Nemoh case
+const (
+	NemohRadiationCoefficientsFile = "RadiationCoefficients.tec"
+	NemohExcitationForceFile       = "ExcitationForce.tec"
+	NemohDiffractionForceFile      = "DiffractionForce.tec"
+	NemohFroudeKrylovForceFile     = "FKForce.tec"
+)
+
+// NemohBody is a body of a Nemoh.cal file
+type NemohBody struct {
+	// MeshFile is relative to the directory of the case
+	MeshFile string
+	NbPoints int
+	NbPanels int
+
+	// DOFs are the degrees of freedom of the body, rotating around RotationCenter
+	DOFs           []DOF
+	RotationCenter [3]float64
+	// Forces are the generalised forces integrated on the body
+	Forces []DOF
+}
+
+// NemohCal is the configuration of a Nemoh case.
+// An infinite water depth is given as 0 in the file.
+type NemohCal struct {
+	Rho        float64
+	Gravity    float64
+	WaterDepth float64
+	// WaveMeasurementPoint is the point where the phase of the incoming waves is zero
+	WaveMeasurementPoint [2]float64
+
+	Bodies []NemohBody
+
+	Frequencies   []float64
+	FrequencyType FrequencyType
+	// WaveDirections are in radians
+	WaveDirections []float64
+}
+
+// nemohCalReader reads the values of a Nemoh.cal file line by line, without the comments after "!"
+// and the section separators starting with "---"
+type nemohCalReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// next returns the fields of the next line of values
+func (r *nemohCalReader) next(what string) ([]string, error) {
+	for r.scanner.Scan() {
+		r.line++
+		text := r.scanner.Text()
+		if strings.HasPrefix(strings.TrimSpace(text), "---") {
+			continue
+		}
+		if i := strings.Index(text, "!"); i >= 0 {
+			text = text[:i]
+		}
+		if fields := strings.Fields(text); len(fields) > 0 {
+			return fields, nil
+		}
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("nemoh.cal: missing %s", what)
+}
+
+// numbers returns the first n numbers of the next line of values
+func (r *nemohCalReader) numbers(what string, n int) ([]float64, error) {
+	fields, err := r.next(what)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) < n {
+		return nil, fmt.Errorf("nemoh.cal: line %d: expected %d values for %s, got %d", r.line, n, what, len(fields))
+	}
+	values := make([]float64, n)
+	for i := range values {
+		if values[i], err = strconv.ParseFloat(strings.Replace(strings.Replace(fields[i], "D", "E", 1), "d", "e", 1), 64); err != nil {
+			return nil, fmt.Errorf("nemoh.cal: line %d: invalid number %q for %s", r.line, fields[i], what)
+		}
+	}
+	return values, nil
+}
+
+// count returns a non negative integer alone on the next line of values
+func (r *nemohCalReader) count(what string) (int, error) {
+	values, err := r.numbers(what, 1)
+	if err != nil {
+		return 0, err
+	}
+	if values[0] < 0 || values[0] != math.Trunc(values[0]) {
+		return 0, fmt.Errorf("nemoh.cal: line %d: invalid %s %g", r.line, what, values[0])
+	}
+	return int(values[0]), nil
+}
+
+// dofs reads n lines "type dx dy dz x y z" of degrees of freedom or generalised forces, with type 1 for a translation
+// along the direction and 2 for a rotation around the axis of that direction passing through the point.
+// It also returns the point of the rotations, which must be the same for all of them.
+func (r *nemohCalReader) dofs(n int, what string) ([]DOF, [3]float64, error) {
+	var dofs []DOF
+	var center [3]float64
+	rotations := 0
+	for i := 0; i < n; i++ {
+		values, err := r.numbers(what, 7)
+		if err != nil {
+			return nil, center, err
+		}
+		dof := DOF{Axis: [3]float64{values[1], values[2], values[3]}}
+		switch values[0] {
+		case 1:
+		case 2:
+			dof.Rotation = true
+			point := [3]float64{values[4], values[5], values[6]}
+			if rotations > 0 && point != center {
+				return nil, center, fmt.Errorf("nemoh.cal: line %d: rotations around different points %v and %v are not supported",
+					r.line, center, point)
+			}
+			center = point
+			rotations++
+		default:
+			return nil, center, fmt.Errorf("nemoh.cal: line %d: invalid type %g of %s, expected 1 or 2", r.line, values[0], what)
+		}
+		dof.Name = nemohDOFName(dof, i)
+		dofs = append(dofs, dof)
+	}
+	return dofs, center, nil
+}
+
+// nemohDOFName returns the name of the rigid body degree of freedom along or around the same axis,
+// or a generic name from the index of the degree of freedom in the body
+func nemohDOFName(dof DOF, i int) string {
+	for _, rigid := range RigidBodyDOFs() {
+		if rigid.Axis == dof.Axis && rigid.Rotation == dof.Rotation {
+			return rigid.Name
+		}
+	}
+	return fmt.Sprintf("DOF %d", i+1)
+}
+
+// nemohRange returns n evenly spaced values between min and max, or min when n is 1
+func nemohRange(n int, min, max float64) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = min
+		if n > 1 {
+			values[i] = min + (max-min)*float64(i)/float64(n-1)
+		}
+	}
+	return values
+}
+
+// ReadNemohCal reads a Nemoh.cal file of Nemoh 2 or Nemoh 3. The frequencies are given as "n min max" in rad/s,
+// or as "type n min max" in Nemoh 3, with type 1 for rad/s, 2 for Hz and 3 for periods in seconds.
+// The post processing section is ignored.
+func ReadNemohCal(r io.Reader) (*NemohCal, error) {
+	cr := &nemohCalReader{scanner: bufio.NewScanner(r)}
+	cal := &NemohCal{}
+
+	environment, err := cr.numbers("fluid density", 1)
+	if err != nil {
+		return nil, err
+	}
+	cal.Rho = environment[0]
+	if environment, err = cr.numbers("gravity", 1); err != nil {
+		return nil, err
+	}
+	cal.Gravity = environment[0]
+	if environment, err = cr.numbers("water depth", 1); err != nil {
+		return nil, err
+	}
+	cal.WaterDepth = environment[0]
+	if environment, err = cr.numbers("wave measurement point", 2); err != nil {
+		return nil, err
+	}
+	copy(cal.WaveMeasurementPoint[:], environment)
+
+	nBodies, err := cr.count("number of bodies")
+	if err != nil {
+		return nil, err
+	}
+	for b := 0; b < nBodies; b++ {
+		var body NemohBody
+		fields, err := cr.next("mesh file")
+		if err != nil {
+			return nil, err
+		}
+		body.MeshFile = fields[0]
+		sizes, err := cr.numbers("number of points and panels", 2)
+		if err != nil {
+			return nil, err
+		}
+		body.NbPoints, body.NbPanels = int(sizes[0]), int(sizes[1])
+
+		n, err := cr.count("number of degrees of freedom")
+		if err != nil {
+			return nil, err
+		}
+		if body.DOFs, body.RotationCenter, err = cr.dofs(n, "degree of freedom"); err != nil {
+			return nil, err
+		}
+		if n, err = cr.count("number of generalised forces"); err != nil {
+			return nil, err
+		}
+		if body.Forces, _, err = cr.dofs(n, "generalised force"); err != nil {
+			return nil, err
+		}
+		if n, err = cr.count("number of lines of additional information"); err != nil {
+			return nil, err
+		}
+		for i := 0; i < n; i++ {
+			if _, err := cr.next("additional information"); err != nil {
+				return nil, err
+			}
+		}
+		cal.Bodies = append(cal.Bodies, body)
+	}
+
+	fields, err := cr.next("wave frequencies")
+	if err != nil {
+		return nil, err
+	}
+	cal.FrequencyType = AngularFrequency
+	var frequencies []float64
+	for _, field := range fields {
+		x, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			break
+		}
+		frequencies = append(frequencies, x)
+	}
+	if len(frequencies) >= 4 {
+		// Nemoh 3: the first value is the type of frequency
+		switch frequencies[0] {
+		case 1:
+		case 2:
+			for i := 2; i < 4; i++ {
+				frequencies[i] *= 2 * math.Pi
+			}
+		case 3:
+			cal.FrequencyType = WavePeriod
+		default:
+			return nil, fmt.Errorf("nemoh.cal: line %d: invalid frequency type %g, expected 1, 2 or 3", cr.line, frequencies[0])
+		}
+		frequencies = frequencies[1:]
+	}
+	if len(frequencies) < 3 || frequencies[0] < 1 || frequencies[0] != math.Trunc(frequencies[0]) {
+		return nil, fmt.Errorf("nemoh.cal: line %d: expected the number of frequencies, min and max", cr.line)
+	}
+	cal.Frequencies = nemohRange(int(frequencies[0]), frequencies[1], frequencies[2])
+
+	directions, err := cr.numbers("wave directions", 3)
+	if err != nil {
+		return nil, err
+	}
+	if directions[0] < 0 || directions[0] != math.Trunc(directions[0]) {
+		return nil, fmt.Errorf("nemoh.cal: line %d: invalid number of wave directions %g", cr.line, directions[0])
+	}
+	cal.WaveDirections = nemohRange(int(directions[0]), directions[1]*math.Pi/180, directions[2]*math.Pi/180)
+	return cal, nil
+}
+
+// LoadNemohCal reads the Nemoh.cal file at the given path
+func LoadNemohCal(path string) (*NemohCal, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cal, err := ReadNemohCal(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cal, nil
+}
+
+// Setup creates the solver, the body and the sweep of a Nemoh case, reading the mesh relative to the directory
+// of the case. The Green function defaults to Delhommeau, the one of Nemoh, when gf is nil.
+// Only cases with a single body are supported, in the gravity of the solver, with the generalised forces
+// of the degrees of freedom.
+func (cal *NemohCal) Setup(dir string, gf AbstractGreenFunction) (*BEMSolver, *FloatingBody, Sweep, error) {
+	if len(cal.Bodies) != 1 {
+		return nil, nil, Sweep{}, fmt.Errorf("only cases with a single body are supported, got %d bodies", len(cal.Bodies))
+	}
+	if math.Abs(cal.Gravity-Gravity) > 1e-9 {
+		return nil, nil, Sweep{}, fmt.Errorf("gravity %g is not supported, the solver uses %g", cal.Gravity, Gravity)
+	}
+	nb := cal.Bodies[0]
+	if len(nb.DOFs) == 0 {
+		return nil, nil, Sweep{}, errors.New("body has no degree of freedom")
+	}
+	if cal.WaveMeasurementPoint != [2]float64{} {
+		return nil, nil, Sweep{}, errors.New("the phases of the solver are relative to a wave measurement point at the origin")
+	}
+	// The solver integrates the pressure on the degrees of freedom themselves
+	if len(nb.Forces) != len(nb.DOFs) {
+		return nil, nil, Sweep{}, errors.New("generalised forces different from the degrees of freedom are not supported")
+	}
+	for i, force := range nb.Forces {
+		if force.Axis != nb.DOFs[i].Axis || force.Rotation != nb.DOFs[i].Rotation {
+			return nil, nil, Sweep{}, errors.New("generalised forces different from the degrees of freedom are not supported")
+		}
+	}
+
+	f, err := os.Open(filepath.Join(dir, nb.MeshFile))
+	if err != nil {
+		return nil, nil, Sweep{}, err
+	}
+	defer f.Close()
+	mesh, err := ReadNemohMesh(f)
+	if err != nil {
+		return nil, nil, Sweep{}, fmt.Errorf("%s: %w", nb.MeshFile, err)
+	}
+	name := strings.TrimSuffix(filepath.Base(nb.MeshFile), filepath.Ext(nb.MeshFile))
+	body := NewRigidBody(name, mesh, nb.RotationCenter)
+	body.DOFs = append([]DOF(nil), nb.DOFs...)
+
+	if gf == nil {
+		gf = NewDefaultDelhommeau()
+	}
+	solver := NewBEMSolver(gf)
+	solver.Rho = cal.Rho
+
+	depth := cal.WaterDepth
+	if depth == 0 {
+		depth = math.Inf(1)
+	}
+	sweep := Sweep{
+		Frequencies:    cal.Frequencies,
+		FrequencyType:  cal.FrequencyType,
+		WaveDirections: cal.WaveDirections,
+		WaterDepths:    []float64{depth},
+	}
+	return solver, body, sweep, nil
+}
+
+// writeTecplotZones writes the variables line and the zones of a Nemoh .tec file, one zone per title,
+// with one line per frequency starting with omega followed by the values of row
+func writeTecplotZones(w io.Writer, variables []string, titles []string, results []*FrequencyResult,
+	row func(zone int, r *FrequencyResult) []float64) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, ` VARIABLES="w (rad/s)"`)
+	for _, v := range variables {
+		fmt.Fprintln(bw, v)
+	}
+	for zone, title := range titles {
+		fmt.Fprintf(bw, "Zone t=%q,I=%6d,F=POINT\n", title, len(results))
+		for _, r := range results {
+			fmt.Fprintf(bw, " %14.7E", r.Omega)
+			for _, x := range row(zone, r) {
+				fmt.Fprintf(bw, " %14.7E", x)
+			}
+			fmt.Fprintln(bw)
+		}
+	}
+	return bw.Flush()
+}
+
+// WriteNemohRadiationCoefficients writes the added mass and radiation damping as the RadiationCoefficients.tec
+// file of Nemoh: one zone per radiating degree of freedom, whose lines are omega followed by the pairs A B
+// of each influenced degree of freedom
+func WriteNemohRadiationCoefficients(w io.Writer, body *FloatingBody, results []*FrequencyResult) error {
+	n := body.NbDOFs()
+	variables := make([]string, n)
+	titles := make([]string, n)
+	for i := 0; i < n; i++ {
+		variables[i] = fmt.Sprintf(`"A%4d%4d" "B%4d%4d"`, 1, i+1, 1, i+1)
+		titles[i] = fmt.Sprintf("Motion of body %4d in DoF %4d", 1, i+1)
+	}
+	// The rows of the solver matrices are the influenced degrees of freedom
+	return writeTecplotZones(w, variables, titles, results, func(i int, r *FrequencyResult) []float64 {
+		values := make([]float64, 0, 2*n)
+		for j := 0; j < n; j++ {
+			values = append(values, r.AddedMass.At(j, i), r.RadiationDamping.At(j, i))
+		}
+		return values
+	})
+}
+
+// writeNemohForce writes a force as a Nemoh .tec file: one zone per wave direction, whose lines are omega
+// followed by the modulus and the phase in radians of the force on each degree of freedom
+func writeNemohForce(w io.Writer, title string, body *FloatingBody, results []*FrequencyResult,
+	force func(r *FrequencyResult) *mat.CDense) error {
+	if len(results) == 0 {
+		return writeTecplotZones(w, nil, nil, nil, nil)
+	}
+	n := body.NbDOFs()
+	variables := make([]string, n)
+	for i := 0; i < n; i++ {
+		variables[i] = fmt.Sprintf(`"abs(F%4d%4d)" "angle(F%4d%4d)"`, 1, i+1, 1, i+1)
+	}
+	directions := results[0].WaveDirections
+	titles := make([]string, len(directions))
+	for d, beta := range directions {
+		titles[d] = fmt.Sprintf("%s - beta = %7.3f deg", title, beta*180/math.Pi)
+	}
+	return writeTecplotZones(w, variables, titles, results, func(d int, r *FrequencyResult) []float64 {
+		values := make([]float64, 0, 2*n)
+		for i := 0; i < n; i++ {
+			f := force(r).At(d, i)
+			values = append(values, cmplx.Abs(f), cmplx.Phase(f))
+		}
+		return values
+	})
+}
+
+// WriteNemohExcitationForce writes the excitation force as the ExcitationForce.tec file of Nemoh
+func WriteNemohExcitationForce(w io.Writer, body *FloatingBody, results []*FrequencyResult) error {
+	return writeNemohForce(w, "Diffraction force", body, results, func(r *FrequencyResult) *mat.CDense { return r.ExcitationForce })
+}
+
+// WriteNemohDiffractionForce writes the diffraction force as the DiffractionForce.tec file of Nemoh
+func WriteNemohDiffractionForce(w io.Writer, body *FloatingBody, results []*FrequencyResult) error {
+	return writeNemohForce(w, "Diffraction force", body, results, func(r *FrequencyResult) *mat.CDense { return r.DiffractionForce })
+}
+
+// WriteNemohFroudeKrylovForce writes the Froude-Krylov force as the FKForce.tec file of Nemoh
+func WriteNemohFroudeKrylovForce(w io.Writer, body *FloatingBody, results []*FrequencyResult) error {
+	return writeNemohForce(w, "FKforce", body, results, func(r *FrequencyResult) *mat.CDense { return r.FroudeKrylovForce })
+}
+
+// ExportNemoh writes the RadiationCoefficients.tec, ExcitationForce.tec, DiffractionForce.tec and FKForce.tec
+// files of the results in the directory, usually the results directory of the Nemoh case.
+// Results are written in the given order, which should be increasing frequencies as in Nemoh.
+func ExportNemoh(dir string, body *FloatingBody, results []*FrequencyResult) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for name, write := range map[string]func(io.Writer, *FloatingBody, []*FrequencyResult) error{
+		NemohRadiationCoefficientsFile: WriteNemohRadiationCoefficients,
+		NemohExcitationForceFile:       WriteNemohExcitationForce,
+		NemohDiffractionForceFile:      WriteNemohDiffractionForce,
+		NemohFroudeKrylovForceFile:     WriteNemohFroudeKrylovForce,
+	} {
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		if err := write(f, body, results); err != nil {
+			f.Close()
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package green_functions
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const testNemohCal = `--- Environment ------------------------------------------------------------------------------------------------------------------
+1025.0				! RHO 			! KG/M**3 	! Fluid specific volume
+9.81				! G			! M/S**2	! Gravity
+0.				! DEPTH			! M		! Water depth
+0.	0.			! XEFF YEFF		! M		! Wave measurement point
+--- Description of floating bodies -----------------------------------------------------------------------------------------------
+1				! Number of bodies
+--- Body 1 -----------------------------------------------------------------------------------------------------------------------
+mesh/hemisphere.dat		! Name of mesh file
+%d	%d			! Number of points and number of panels
+3				! Number of degrees of freedom
+1 1. 0.	0. 0. 0. 0.		! Surge
+1 0. 0. 1. 0. 0. 0.		! Heave
+2 0. 1. 0. 0. 0. -0.5		! Pitch about a point
+3				! Number of resulting generalised forces
+1 1. 0.	0. 0. 0. 0.		! Force in x direction
+1 0. 0. 1. 0. 0. 0.		! Force in z direction
+2 0. 1. 0. 0. 0. -0.5		! Moment force in y direction about a point
+1				! Number of lines of additional information
+0 ! comment
+--- Load cases to be solved -------------------------------------------------------------------------------------------------------
+%s		! Number of wave frequencies, Min, and Max (rad/s)
+2	0.0	90.0		! Number of wave directions, Min and Max (degrees)
+--- Post processing ---------------------------------------------------------------------------------------------------------------
+1	0.1	10.		! IRF 				! IRF calculation (0 for no calculation), time step and duration
+0				! Show pressure
+`
+
+// writeNemohCase writes a Nemoh case of a hemisphere and returns its directory
+func writeNemohCase(t *testing.T, frequencies string) string {
+	t.Helper()
+	mesh, err := NewHemisphereMesh(1, 4, 8)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var b strings.Builder
+	fmt.Fprintln(&b, "2 0")
+	for i, v := range mesh.Vertices {
+		fmt.Fprintf(&b, "%d %.17g %.17g %.17g\n", i+1, v[0], v[1], v[2])
+	}
+	fmt.Fprintln(&b, "0 0. 0. 0.")
+	for _, face := range mesh.Faces {
+		fmt.Fprintf(&b, "%d %d %d %d\n", face[0]+1, face[1]+1, face[2]+1, face[3]+1)
+	}
+	fmt.Fprintln(&b, "0 0 0 0")
+
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "mesh"), 0o755); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "mesh", "hemisphere.dat"), []byte(b.String()), 0o644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cal := fmt.Sprintf(testNemohCal, len(mesh.Vertices), mesh.GetNbFaces(), frequencies)
+	if err := os.WriteFile(filepath.Join(dir, "Nemoh.cal"), []byte(cal), 0o644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return dir
+}
+
+func TestReadNemohCal(t *testing.T) {
+	dir := writeNemohCase(t, "3	0.5	1.5")
+	cal, err := LoadNemohCal(filepath.Join(dir, "Nemoh.cal"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cal.Rho != 1025 || cal.Gravity != 9.81 || cal.WaterDepth != 0 || len(cal.Bodies) != 1 {
+		t.Fatalf("Unexpected environment %+v", cal)
+	}
+	body := cal.Bodies[0]
+	if body.MeshFile != "mesh/hemisphere.dat" || len(body.DOFs) != 3 || len(body.Forces) != 3 {
+		t.Fatalf("Unexpected body %+v", body)
+	}
+	if body.DOFs[1].Name != "Heave" || body.DOFs[2].Name != "Pitch" || !body.DOFs[2].Rotation || body.RotationCenter != [3]float64{0, 0, -0.5} {
+		t.Errorf("Unexpected degrees of freedom %+v around %v", body.DOFs, body.RotationCenter)
+	}
+	if cal.FrequencyType != AngularFrequency || len(cal.Frequencies) != 3 || cal.Frequencies[1] != 1 {
+		t.Errorf("Expected 3 angular frequencies from 0.5 to 1.5, got %v %v", cal.FrequencyType, cal.Frequencies)
+	}
+	if len(cal.WaveDirections) != 2 || math.Abs(cal.WaveDirections[1]-math.Pi/2) > 1e-12 {
+		t.Errorf("Expected wave directions 0 and 90 degrees, got %v", cal.WaveDirections)
+	}
+
+	// Nemoh 3 gives the type of frequencies first
+	for line, expected := range map[string][]float64{"2 2 0.1 0.2": {0.2 * math.Pi, 0.4 * math.Pi}, "3 2 5 10": {5, 10}} {
+		cal, err := ReadNemohCal(strings.NewReader(fmt.Sprintf(testNemohCal, 1, 1, line)))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if math.Abs(cal.Frequencies[0]-expected[0]) > 1e-12 || math.Abs(cal.Frequencies[1]-expected[1]) > 1e-12 {
+			t.Errorf("%s: expected %v, got %v", line, expected, cal.Frequencies)
+		}
+	}
+	if cal, _ := ReadNemohCal(strings.NewReader(fmt.Sprintf(testNemohCal, 1, 1, "3 2 5 10"))); cal.FrequencyType != WavePeriod {
+		t.Errorf("Expected periods, got %v", cal.FrequencyType)
+	}
+
+	for _, invalid := range []string{
+		fmt.Sprintf(testNemohCal, 1, 1, "4 2 5 10"),
+		strings.Replace(fmt.Sprintf(testNemohCal, 1, 1, "1 1 1"), "1 1. 0.	0. 0. 0. 0.		! Surge", "2 1. 0. 0. 0. 0. 0.		! Roll", 1),
+		strings.Replace(fmt.Sprintf(testNemohCal, 1, 1, "1 1 1"), "1 0. 0. 1. 0. 0. 0.		! Heave", "3 0. 0. 1. 0. 0. 0.", 1),
+		testNemohCal[:400],
+	} {
+		if _, err := ReadNemohCal(strings.NewReader(invalid)); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+}
+
+func TestNemohCal_Setup(t *testing.T) {
+	dir := writeNemohCase(t, "2	1	2")
+	cal, err := LoadNemohCal(filepath.Join(dir, "Nemoh.cal"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	solver, body, sweep, err := cal.Setup(dir, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if solver.Rho != 1025 || body.NbDOFs() != 3 || body.RotationCenter != [3]float64{0, 0, -0.5} || body.Mesh.GetNbFaces() != 32 {
+		t.Fatalf("Unexpected setup %+v %+v", solver, body)
+	}
+	if len(sweep.WaterDepths) != 1 || !math.IsInf(sweep.WaterDepths[0], 1) || len(sweep.WaveDirections) != 2 {
+		t.Errorf("Expected a deep water sweep with 2 directions, got %+v", sweep)
+	}
+
+	cal.Bodies[0].Forces = cal.Bodies[0].Forces[:2]
+	if _, _, _, err := cal.Setup(dir, nil); err == nil {
+		t.Error("Expected error for generalised forces different from the degrees of freedom")
+	}
+	cal.Bodies = append(cal.Bodies, cal.Bodies[0])
+	if _, _, _, err := cal.Setup(dir, nil); err == nil {
+		t.Error("Expected error for several bodies")
+	}
+}
+
+func TestWriteNemohTecplot(t *testing.T) {
+	dir := writeNemohCase(t, "2	1	2")
+	cal, err := LoadNemohCal(filepath.Join(dir, "Nemoh.cal"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	solver, body, sweep, err := cal.Setup(dir, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var results []*FrequencyResult
+	for _, omega := range sweep.Frequencies {
+		result, err := solver.SolveFrequency(body, omega, math.Inf(1), sweep.WaveDirections)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		results = append(results, result)
+	}
+	// Force on the third DOF due to the motion of the second one, distinct from the reverse coupling
+	results[1].AddedMass.Set(2, 1, 11)
+	results[1].AddedMass.Set(1, 2, 13)
+	results[1].RadiationDamping.Set(2, 1, 17)
+	results[1].RadiationDamping.Set(1, 2, 19)
+
+	var buf bytes.Buffer
+	if err := WriteNemohRadiationCoefficients(&buf, body, results); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	// Variables, one line of names per DOF, then a zone of 2 frequencies per DOF
+	if len(lines) != 1+3+3*3 {
+		t.Fatalf("Expected 13 lines, got %d:\n%s", len(lines), buf.String())
+	}
+	if lines[1] != `"A   1   1" "B   1   1"` || lines[7] != `Zone t="Motion of body    1 in DoF    2",I=     2,F=POINT` {
+		t.Errorf("Unexpected header lines %q and %q", lines[1], lines[7])
+	}
+	// Row of the motion in the second DOF at omega = 2: A and B of the second DOF are the 4th and 5th values,
+	// followed by those of the third DOF
+	fields := strings.Fields(lines[9])
+	if len(fields) != 1+2*3 {
+		t.Fatalf("Expected 7 values, got %q", lines[9])
+	}
+	for i, expected := range []float64{2, results[1].AddedMass.At(1, 1), results[1].RadiationDamping.At(1, 1), 11, 17} {
+		x, _ := strconv.ParseFloat(fields[[]int{0, 3, 4, 5, 6}[i]], 64)
+		if math.Abs(x-expected) > 1e-6*math.Abs(expected) {
+			t.Errorf("Expected %v, got %v in %q", expected, x, lines[9])
+		}
+	}
+
+	buf.Reset()
+	if err := WriteNemohExcitationForce(&buf, body, results); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), `Zone t="Diffraction force - beta =  90.000 deg",I=     2,F=POINT`) {
+		t.Errorf("Expected a zone for beam waves, got\n%s", buf.String())
+	}
+
+	if err := ExportNemoh(filepath.Join(dir, "results"), body, results); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, name := range []string{NemohRadiationCoefficientsFile, NemohExcitationForceFile, NemohDiffractionForceFile, NemohFroudeKrylovForceFile} {
+		if _, err := os.Stat(filepath.Join(dir, "results", name)); err != nil {
+			t.Errorf("Expected %s: %v", name, err)
+		}
+	}
+}
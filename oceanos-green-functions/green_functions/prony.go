@@ -0,0 +1,325 @@
+// Package green_functions - Fitting of Prony decompositions
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package green_functions
+
+import (
+	"errors"
+	"fmt"
+	"gonum.org/v1/gonum/mat"
+	"math"
+	"math/cmplx"
+	"sort"
+)
+
+// Interval on which the finite depth kernel is approximated, in terms of the dimensionless variable μh
+const (
+	finiteDepthPronyXMin = 0.0
+	finiteDepthPronyXMax = 20.0
+)
+
+// finiteDepthPythonTolerance is the mean squared error of the python strategy for the finite depth kernel.
+// The error of the Green function is about the square root of the tolerance, so the default tolerance is not enough.
+const finiteDepthPythonTolerance = 1e-7
+
+// ExponentialDecomposition approximates samples f of a real function at the uniformly spaced abscissae x
+// by a sum of nExp exponentials with Prony's method. The exponents are the roots of the linear prediction
+// polynomial and can be complex, in conjugate pairs; the coefficients are found by least squares.
+func ExponentialDecomposition(x, f []float64, nExp int) (*PronyDecomposition, error) {
+	step, err := uniformStep(x, f)
+	if err != nil {
+		return nil, err
+	}
+	roots, err := pronyRoots(f, nExp)
+	if err != nil {
+		return nil, err
+	}
+	exponents := make([]complex128, len(roots))
+	for i, z := range roots {
+		exponents[i] = cmplx.Log(z) / complex(step, 0)
+	}
+	coefficients, err := fitCoefficients(x, f, exponents)
+	if err != nil {
+		return nil, err
+	}
+	return NewPronyDecomposition(coefficients, exponents), nil
+}
+
+// FitPronyDecomposition approximates the real function f on [xMin, xMax] by a sum of decaying real exponentials,
+// increasing the number of exponentials until the error is below tolerance. The strategies are
+//
+//   - PythonMethod: the function is sampled on 4n+1 points to fit n exponentials, n = 4, 6, ..., 30,
+//     and the mean squared error is computed on 8n+1 points. Defaults to a tolerance of 1e-4.
+//   - FortranMethod: as in Nemoh, the function is sampled once on 81 points and n = 2, 3, ..., 40 exponentials
+//     are tried, keeping only the real roots of the prediction polynomial. The error is the largest
+//     absolute error on the samples relative to the largest value of f. Defaults to a tolerance of 1e-2.
+//
+// A zero tolerance selects the default tolerance of the strategy.
+func FitPronyDecomposition(f func(float64) float64, xMin, xMax, tolerance float64, method PronyDecompositionMethod) (*PronyDecomposition, error) {
+	if !(xMax > xMin) {
+		return nil, fmt.Errorf("invalid interval [%g, %g]", xMin, xMax)
+	}
+
+	switch method {
+	case PythonMethod, "":
+		if tolerance == 0 {
+			tolerance = 1e-4
+		}
+		for n := 4; n <= 30; n += 2 {
+			x, values := sampleFunction(f, xMin, xMax, 4*n+1)
+			prony, err := realExponentialDecomposition(x, values, n, false)
+			if err != nil {
+				continue
+			}
+			x, values = sampleFunction(f, xMin, xMax, 8*n+1)
+			if meanSquaredError(prony, x, values) < tolerance {
+				return prony, nil
+			}
+		}
+
+	case FortranMethod:
+		if tolerance == 0 {
+			tolerance = 1e-2
+		}
+		x, values := sampleFunction(f, xMin, xMax, 81)
+		var scale float64
+		for _, v := range values {
+			scale = math.Max(scale, math.Abs(v))
+		}
+		if scale == 0 {
+			return NewPronyDecomposition(nil, nil), nil
+		}
+		for n := 2; n <= 40; n++ {
+			prony, err := realExponentialDecomposition(x, values, n, true)
+			if err != nil {
+				continue
+			}
+			if maxAbsoluteError(prony, x, values)/scale < tolerance {
+				return prony, nil
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown Prony decomposition method %q", method)
+	}
+
+	return nil, fmt.Errorf("no Prony decomposition with an error below %g on [%g, %g]", tolerance, xMin, xMax)
+}
+
+// FiniteDepthPronyDecomposition approximates the part of the finite depth Green function kernel that is not
+// computed exactly by a sum of decaying exponentials of x = μh, for the dimensionless wavenumber kh.
+// See finiteDepthKernel for the approximated function.
+func FiniteDepthPronyDecomposition(dimensionlessWavenumber float64, method PronyDecompositionMethod) (*PronyDecomposition, error) {
+	if !(dimensionlessWavenumber > 0) || math.IsInf(dimensionlessWavenumber, 1) {
+		return nil, fmt.Errorf("dimensionless wavenumber must be positive and finite, got %g", dimensionlessWavenumber)
+	}
+	var tolerance float64
+	if method == PythonMethod || method == "" {
+		tolerance = finiteDepthPythonTolerance
+	}
+	prony, err := FitPronyDecomposition(finiteDepthKernel(dimensionlessWavenumber),
+		finiteDepthPronyXMin, finiteDepthPronyXMax, tolerance, method)
+	if err != nil {
+		return nil, fmt.Errorf("kh=%g: %w", dimensionlessWavenumber, err)
+	}
+	return prony, nil
+}
+
+// finiteDepthKernel returns the function of x = μh approximated by exponentials in the finite depth Green function.
+// With K = νh and m0 = kh, the kernel of the wave integral is exp(-2x) Q(x) with
+//
+//	Q(x) = (x + K) exp(x) / (x sinh(x) - K cosh(x))
+//
+// Its limit 2 at infinity gives Rankine images, and its real poles ±m0 give integrals computed exactly,
+// the pole at m0 being the propagating waves. The remainder Q(x) - 2 - r0/(x - m0) - r1/(x + m0),
+// due to the evanescent modes, is smooth for x >= 0.
+func finiteDepthKernel(dimensionlessWavenumber float64) func(float64) float64 {
+	m0 := dimensionlessWavenumber
+	K := m0 * math.Tanh(m0)
+	r0, r1 := finiteDepthPoleResidues(m0)
+	value := func(x float64) float64 {
+		// Q(x) - 2 = (2K exp(x) + (x + K) exp(-x)) / (x sinh(x) - K cosh(x)), written to avoid overflows
+		em2x := math.Exp(-2 * x)
+		numerator := 2*K + (x+K)*em2x
+		denominator := (x*(1-em2x) - K*(1+em2x)) / 2
+		return numerator/denominator - r0/(x-m0) - r1/(x+m0)
+	}
+	return func(x float64) float64 {
+		if math.Abs(x-m0) < 1e-6*math.Max(m0, 1) {
+			// Removable singularity, evaluated from the neighbouring values
+			h := 1e-4 * math.Max(m0, 1)
+			return (value(x-h) + value(x+h)) / 2
+		}
+		return value(x)
+	}
+}
+
+// finiteDepthPoleResidues returns the residues r0 and r1 of Q at its poles m0 and -m0, where m0 tanh(m0) = K
+//
+//	r0 = (m0 + K) exp(m0) / (sinh(m0) + m0/cosh(m0))
+//	r1 = (m0 - K) exp(-m0) / (sinh(m0) + m0/cosh(m0))
+func finiteDepthPoleResidues(m0 float64) (float64, float64) {
+	K := m0 * math.Tanh(m0)
+	// The denominator is multiplied by exp(-m0) to avoid overflows
+	e := math.Exp(-2 * m0)
+	denominator := (1-e)/2 + 2*m0*e/(1+e)
+	return (m0 + K) / denominator, (m0 - K) * e / denominator
+}
+
+// realExponentialDecomposition fits decaying real exponentials: the exponents are the real parts of the
+// logarithms of the roots of Prony's method, or only the real roots with onlyRealRoots. Duplicated and
+// non decaying exponents are discarded before the coefficients are fitted.
+func realExponentialDecomposition(x, f []float64, nExp int, onlyRealRoots bool) (*PronyDecomposition, error) {
+	step, err := uniformStep(x, f)
+	if err != nil {
+		return nil, err
+	}
+	roots, err := pronyRoots(f, nExp)
+	if err != nil {
+		return nil, err
+	}
+
+	var lambdas []float64
+	for _, z := range roots {
+		if onlyRealRoots && !(isRealExponent(z) && real(z) > 0) {
+			continue
+		}
+		lambda := math.Log(cmplx.Abs(z)) / step
+		if !(lambda < 0) || math.IsInf(lambda, -1) {
+			continue
+		}
+		lambdas = append(lambdas, lambda)
+	}
+	sort.Float64s(lambdas)
+
+	var exponents []complex128
+	for i, lambda := range lambdas {
+		if i > 0 && math.Abs(lambda-lambdas[i-1]) <= 1e-8*math.Abs(lambda) {
+			continue
+		}
+		exponents = append(exponents, complex(lambda, 0))
+	}
+	if len(exponents) == 0 {
+		return nil, errors.New("no decaying exponential")
+	}
+
+	coefficients, err := fitCoefficients(x, f, exponents)
+	if err != nil {
+		return nil, err
+	}
+	for i, c := range coefficients {
+		coefficients[i] = complex(real(c), 0)
+	}
+	return NewPronyDecomposition(coefficients, exponents), nil
+}
+
+// pronyRoots returns the roots of the linear prediction polynomial of order p of uniformly spaced samples,
+// values[k] = -Σ c_m values[k-m], as the eigenvalues of its companion matrix
+func pronyRoots(values []float64, order int) ([]complex128, error) {
+	n := len(values)
+	if order < 1 || n < 2*order {
+		return nil, fmt.Errorf("%d samples are not enough for order %d", n, order)
+	}
+
+	prediction := mat.NewDense(n-order, order, nil)
+	target := mat.NewVecDense(n-order, nil)
+	for k := order; k < n; k++ {
+		for m := 1; m <= order; m++ {
+			prediction.Set(k-order, m-1, values[k-m])
+		}
+		target.SetVec(k-order, -values[k])
+	}
+	var c mat.VecDense
+	if err := c.SolveVec(prediction, target); err != nil {
+		return nil, fmt.Errorf("linear prediction failed: %w", err)
+	}
+
+	// Roots of z^p + c_1 z^(p-1) + ... + c_p
+	companion := mat.NewDense(order, order, nil)
+	for m := 0; m < order; m++ {
+		companion.Set(0, m, -c.AtVec(m))
+		if m > 0 {
+			companion.Set(m, m-1, 1)
+		}
+	}
+	var eigen mat.Eigen
+	if !eigen.Factorize(companion, mat.EigenNone) {
+		return nil, errors.New("failed to find the roots of the prediction polynomial")
+	}
+	return eigen.Values(nil), nil
+}
+
+// fitCoefficients finds the coefficients of the exponentials by least squares, with the normal equations
+func fitCoefficients(x, values []float64, exponents []complex128) ([]complex128, error) {
+	p := len(exponents)
+	normal := mat.NewCDense(p, p, nil)
+	rhs := mat.NewCDense(p, 1, nil)
+	basis := make([]complex128, p)
+	for k, xk := range x {
+		for m, s := range exponents {
+			basis[m] = cmplx.Exp(s * complex(xk, 0))
+		}
+		for m := 0; m < p; m++ {
+			conj := cmplx.Conj(basis[m])
+			rhs.Set(m, 0, rhs.At(m, 0)+conj*complex(values[k], 0))
+			for l := 0; l < p; l++ {
+				normal.Set(m, l, normal.At(m, l)+conj*basis[l])
+			}
+		}
+	}
+	solution, err := solveComplexSystem(normal, rhs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fit the coefficients: %w", err)
+	}
+	coefficients := make([]complex128, p)
+	for m := range coefficients {
+		coefficients[m] = solution.At(m, 0)
+	}
+	return coefficients, nil
+}
+
+// uniformStep returns the step of uniformly spaced increasing abscissae
+func uniformStep(x, f []float64) (float64, error) {
+	if len(x) != len(f) {
+		return 0, errors.New("abscissae and values must have the same length")
+	}
+	if len(x) < 3 {
+		return 0, errors.New("at least three samples are required")
+	}
+	step := x[1] - x[0]
+	for i := 1; i < len(x); i++ {
+		if !(step > 0) || math.Abs(x[i]-x[i-1]-step) > 1e-9*step {
+			return 0, errors.New("abscissae must be increasing and uniformly spaced")
+		}
+	}
+	return step, nil
+}
+
+// sampleFunction evaluates f on n uniformly spaced points of [xMin, xMax]
+func sampleFunction(f func(float64) float64, xMin, xMax float64, n int) ([]float64, []float64) {
+	x := make([]float64, n)
+	values := make([]float64, n)
+	for i := range x {
+		x[i] = xMin + (xMax-xMin)*float64(i)/float64(n-1)
+		values[i] = f(x[i])
+	}
+	return x, values
+}
+
+func meanSquaredError(prony *PronyDecomposition, x, values []float64) float64 {
+	var sum float64
+	for i, xi := range x {
+		d := real(prony.Evaluate(xi)) - values[i]
+		sum += d * d
+	}
+	return sum / float64(len(x))
+}
+
+func maxAbsoluteError(prony *PronyDecomposition, x, values []float64) float64 {
+	var largest float64
+	for i, xi := range x {
+		largest = math.Max(largest, math.Abs(real(prony.Evaluate(xi))-values[i]))
+	}
+	return largest
+}
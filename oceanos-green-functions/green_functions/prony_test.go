@@ -0,0 +1,114 @@
+package green_functions
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestExponentialDecomposition(t *testing.T) {
+	f := func(x float64) float64 { return 2*math.Exp(-x) - 0.5*math.Exp(-3*x) + math.Exp(-0.2*x)*math.Cos(x) }
+	x, values := sampleFunction(f, 0, 5, 41)
+
+	prony, err := ExponentialDecomposition(x, values, 4)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, xi := range []float64{0.3, 2.7, 4.9} {
+		if got := prony.Evaluate(xi); cmplx.Abs(got-complex(f(xi), 0)) > 1e-8 {
+			t.Errorf("At x=%v: expected %v, got %v", xi, f(xi), got)
+		}
+	}
+
+	if _, err := ExponentialDecomposition([]float64{0, 1, 3}, []float64{1, 2, 3}, 1); err == nil {
+		t.Error("Expected error for non uniform abscissae")
+	}
+}
+
+func TestFitPronyDecomposition(t *testing.T) {
+	f := func(x float64) float64 { return 1 / (1 + x) }
+	for _, method := range []PronyDecompositionMethod{PythonMethod, FortranMethod} {
+		prony, err := FitPronyDecomposition(f, 0, 10, 0, method)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", method, err)
+		}
+		for i, s := range prony.Exponents {
+			if real(s) >= 0 || imag(s) != 0 || imag(prony.Coefficients[i]) != 0 {
+				t.Errorf("%s: expected decaying real exponentials, got %v exp(%v x)", method, prony.Coefficients[i], s)
+			}
+		}
+		for _, x := range []float64{0, 0.5, 3, 9} {
+			if got := real(prony.Evaluate(x)); math.Abs(got-f(x)) > 1e-2 {
+				t.Errorf("%s: at x=%v expected %v, got %v", method, x, f(x), got)
+			}
+		}
+	}
+
+	if _, err := FitPronyDecomposition(f, 0, 10, 0, "matlab"); err == nil {
+		t.Error("Expected error for unknown method")
+	}
+	if _, err := FitPronyDecomposition(f, 0, 10, 1e-30, PythonMethod); err == nil {
+		t.Error("Expected error for unreachable tolerance")
+	}
+}
+
+func TestFiniteDepthKernel(t *testing.T) {
+	for _, kh := range []float64{0.1, 1, 10} {
+		K := kh * math.Tanh(kh)
+		q := func(x float64) float64 { return (x + K) * math.Exp(x) / (x*math.Sinh(x) - K*math.Cosh(x)) }
+
+		// The residues remove the pole at x = kh
+		kernel := finiteDepthKernel(kh)
+		left, right := kernel(kh-1e-5), kernel(kh+1e-5)
+		if math.Abs(left-right) > 1e-3*math.Max(1, math.Abs(left)) {
+			t.Errorf("kh=%v: kernel is discontinuous at the pole, %v and %v", kh, left, right)
+		}
+
+		x := 0.5 * kh
+		r0, r1 := finiteDepthPoleResidues(kh)
+		expected := q(x) - 2 - r0/(x-kh) - r1/(x+kh)
+		if math.Abs(kernel(x)-expected) > 1e-9*math.Max(1, math.Abs(expected)) {
+			t.Errorf("kh=%v: kernel at %v expected %v, got %v", kh, x, expected, kernel(x))
+		}
+	}
+}
+
+func TestFiniteDepthPronyDecomposition(t *testing.T) {
+	for _, method := range []PronyDecompositionMethod{PythonMethod, FortranMethod} {
+		for _, kh := range []float64{0.1, 0.5, 1, 2, 5, 10} {
+			prony, err := FiniteDepthPronyDecomposition(kh, method)
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", method, err)
+				continue
+			}
+			kernel := finiteDepthKernel(kh)
+			x, values := sampleFunction(kernel, finiteDepthPronyXMin, finiteDepthPronyXMax, 201)
+			if mse := meanSquaredError(prony, x, values); mse > 1e-3 {
+				t.Errorf("%s: kh=%v: mean squared error %v with %d exponentials", method, kh, mse, len(prony.Exponents))
+			}
+		}
+	}
+
+	if _, err := FiniteDepthPronyDecomposition(0, PythonMethod); err == nil {
+		t.Error("Expected error for zero wavenumber")
+	}
+}
+
+func TestDelhommeau_FiniteDepthPronyDecomposition(t *testing.T) {
+	params := DefaultDelhommeauParameters()
+	params.FiniteDepthPronyDecompositionMethod = FortranMethod
+	gf := NewDelhommeau(params)
+
+	first, err := gf.FiniteDepthPronyDecomposition(1.5)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	second, _ := gf.FiniteDepthPronyDecomposition(1.5)
+	if first != second {
+		t.Error("Expected the decomposition to be cached")
+	}
+	expected, _ := FiniteDepthPronyDecomposition(1.5, FortranMethod)
+	if len(expected.Exponents) != len(first.Exponents) {
+		t.Errorf("Expected %d exponentials with the fortran method, got %d", len(expected.Exponents), len(first.Exponents))
+	}
+}
@@ -0,0 +1,282 @@
+// Package green_functions - Registry of the Green functions by name
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package green_functions
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// GreenFunctionFactory creates a Green function from its options. The options it does not read are an error.
+type GreenFunctionFactory func(options *GreenFunctionOptions) (AbstractGreenFunction, error)
+
+// ErrNotImplemented is returned by NewGreenFunction for the registered Green functions whose evaluation is not
+// implemented yet
+var ErrNotImplemented = errors.New("not implemented yet")
+
+// registeredGreenFunction is a Green function of the registry
+type registeredGreenFunction struct {
+	name    string
+	factory GreenFunctionFactory
+	// implemented is false for the Green functions whose Evaluate is a stub returning zero matrices
+	implemented bool
+}
+
+var (
+	registryMutex sync.RWMutex
+	// registry maps the lower case names and aliases of the Green functions to their registration
+	registry = map[string]*registeredGreenFunction{}
+)
+
+func init() {
+	RegisterGreenFunction("Delhommeau", newDelhommeauFromOptions)
+	RegisterGreenFunction("FinGreen3D", newFinGreen3DFromOptions)
+	// LiangWuNoblesseGF and HAMS are registered so that their settings are recognized, but
+	// NewGreenFunction refuses to create them until their evaluation is implemented
+	registerGreenFunction("LiangWuNoblesseGF", newLiangWuNoblesseGFFromOptions, false, "lwn")
+	registerGreenFunction("HAMS", func(*GreenFunctionOptions) (AbstractGreenFunction, error) { return NewHAMS(), nil }, false)
+}
+
+// RegisterGreenFunction makes a Green function available to NewGreenFunction under its name, the value of
+// the "green_function" key of its settings, and under the aliases. Names are not case sensitive.
+// Other packages register their Green functions in their init function; RegisterGreenFunction panics
+// if a name is already registered.
+func RegisterGreenFunction(name string, factory GreenFunctionFactory, aliases ...string) {
+	registerGreenFunction(name, factory, true, aliases...)
+}
+
+func registerGreenFunction(name string, factory GreenFunctionFactory, implemented bool, aliases ...string) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	if factory == nil {
+		panic("green_functions: nil factory for Green function " + name)
+	}
+	r := &registeredGreenFunction{name: name, factory: factory, implemented: implemented}
+	for _, n := range append([]string{name}, aliases...) {
+		key := strings.ToLower(n)
+		if _, ok := registry[key]; ok {
+			panic("green_functions: Green function " + n + " registered twice")
+		}
+		registry[key] = r
+	}
+}
+
+// GreenFunctionNames returns the sorted names of the registered Green functions that NewGreenFunction can
+// create, without their aliases
+func GreenFunctionNames() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	var names []string
+	for key, r := range registry {
+		if r.implemented && key == strings.ToLower(r.name) {
+			names = append(names, r.name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewGreenFunction creates the registered Green function with the given name or alias, configured by options
+// keyed as in its settings; absent options take their default value. The "green_function" key, if any,
+// is ignored so that settings can be given as options. waterDepths are the depths of the problems to solve,
+// when known: Green functions check that they support them, and FinGreen3D takes its depth from them.
+// The Green functions whose evaluation is not implemented yet check their options, then return an error
+// wrapping ErrNotImplemented.
+func NewGreenFunction(name string, options map[string]interface{}, waterDepths ...float64) (AbstractGreenFunction, error) {
+	registryMutex.RLock()
+	r, ok := registry[strings.ToLower(name)]
+	registryMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown Green function %q, expected one of %s", name, strings.Join(GreenFunctionNames(), ", "))
+	}
+	o := &GreenFunctionOptions{WaterDepths: waterDepths, values: options, used: map[string]bool{}}
+	gf, err := r.factory(o)
+	if optionsErr := o.finish(); optionsErr != nil {
+		err = optionsErr
+	}
+	if err == nil && !r.implemented {
+		err = ErrNotImplemented
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", r.name, err)
+	}
+	return gf, nil
+}
+
+// GreenFunctionOptions are the options given to a GreenFunctionFactory. Their values come from Go code or
+// are decoded from JSON or YAML, so numbers may be floats or integers and enumerations are plain strings.
+// The accessors leave the value unchanged when the option is absent and record the first invalid option,
+// which NewGreenFunction returns.
+type GreenFunctionOptions struct {
+	// WaterDepths are the water depths of the problems to solve, empty when unknown
+	WaterDepths []float64
+
+	values map[string]interface{}
+	used   map[string]bool
+	err    error
+}
+
+func (o *GreenFunctionOptions) fail(key string, format string, args ...interface{}) {
+	if o.err == nil {
+		o.err = fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...))
+	}
+}
+
+// lookup returns the value of an option and marks it as used
+func (o *GreenFunctionOptions) lookup(key string) (reflect.Value, bool) {
+	value, ok := o.values[key]
+	if !ok {
+		return reflect.Value{}, false
+	}
+	o.used[key] = true
+	return reflect.ValueOf(value), true
+}
+
+// Has tells whether the option is given
+func (o *GreenFunctionOptions) Has(key string) bool {
+	_, ok := o.values[key]
+	return ok
+}
+
+// number returns the value of a numerical option
+func (o *GreenFunctionOptions) number(key string) (float64, bool) {
+	v, ok := o.lookup(key)
+	if !ok {
+		return 0, false
+	}
+	switch {
+	case v.CanFloat():
+		return v.Float(), true
+	case v.CanInt():
+		return float64(v.Int()), true
+	case v.CanUint():
+		return float64(v.Uint()), true
+	}
+	o.fail(key, "expected a number, got %v", v)
+	return 0, false
+}
+
+// text returns the value of a string option
+func (o *GreenFunctionOptions) text(key string) (string, bool) {
+	v, ok := o.lookup(key)
+	if !ok {
+		return "", false
+	}
+	if v.Kind() != reflect.String {
+		o.fail(key, "expected a string, got %v", v)
+		return "", false
+	}
+	return v.String(), true
+}
+
+// Float reads a numerical option
+func (o *GreenFunctionOptions) Float(key string, value *float64) {
+	if x, ok := o.number(key); ok {
+		*value = x
+	}
+}
+
+// Int reads an integer option, which may be given as an integral float
+func (o *GreenFunctionOptions) Int(key string, value *int) {
+	x, ok := o.number(key)
+	if !ok {
+		return
+	}
+	if x != math.Trunc(x) || math.Abs(x) > math.MaxInt32 {
+		o.fail(key, "expected an integer, got %v", x)
+		return
+	}
+	*value = int(x)
+}
+
+// String reads a string option
+func (o *GreenFunctionOptions) String(key string, value *string) {
+	if x, ok := o.text(key); ok {
+		*value = x
+	}
+}
+
+// EnumOption reads an option that must be one of the allowed values
+func EnumOption[T ~string](o *GreenFunctionOptions, key string, value *T, allowed ...T) {
+	x, ok := o.text(key)
+	if !ok {
+		return
+	}
+	for _, a := range allowed {
+		if T(x) == a {
+			*value = a
+			return
+		}
+	}
+	o.fail(key, "unknown value %q, expected one of %v", x, allowed)
+}
+
+// finish returns the first invalid option, or an error for the options that the factory did not read
+func (o *GreenFunctionOptions) finish() error {
+	if o.err != nil {
+		return o.err
+	}
+	var unknown []string
+	for key := range o.values {
+		if key != SettingsKey && !o.used[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown options %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+func newDelhommeauFromOptions(o *GreenFunctionOptions) (AbstractGreenFunction, error) {
+	params := DefaultDelhommeauParameters()
+	o.Int("tabulation_nr", &params.TabulationNr)
+	o.Float("tabulation_rmax", &params.TabulationRmax)
+	o.Int("tabulation_nz", &params.TabulationNz)
+	o.Float("tabulation_zmin", &params.TabulationZmin)
+	o.Int("tabulation_nb_integration_points", &params.TabulationNbIntegrationPoints)
+	EnumOption(o, "tabulation_grid_shape", &params.TabulationGridShape, Legacy, ScaledNemoh3)
+	EnumOption(o, "finite_depth_method", &params.FiniteDepthMethod, LegacyMethod, NewerMethod)
+	EnumOption(o, "finite_depth_prony_decomposition_method", &params.FiniteDepthPronyDecompositionMethod, PythonMethod, FortranMethod)
+	EnumOption(o, "floating_point_precision", &params.FloatingPointPrecision, Float32, Float64)
+	EnumOption(o, "gf_singularities", &params.GfSingularities, HighFreq, LowFreq, LowFreqWithRankinePart)
+	// Not a setting: the tabulation gives the same results wherever it is kept
+	o.String("tabulation_cache_dir", &params.TabulationCacheDir)
+	return NewDelhommeau(params), nil
+}
+
+// newFinGreen3DFromOptions takes the depth from the water_depth option, or else from the single water depth
+// of the problems
+func newFinGreen3DFromOptions(o *GreenFunctionOptions) (AbstractGreenFunction, error) {
+	depth := math.NaN()
+	if len(o.WaterDepths) == 1 {
+		depth = o.WaterDepths[0]
+	}
+	o.Float("water_depth", &depth)
+	if !(depth > 0) || math.IsInf(depth, 1) {
+		return nil, fmt.Errorf("needs a finite positive water_depth option or a single finite water depth, got %g", depth)
+	}
+	for _, d := range o.WaterDepths {
+		if d != depth {
+			return nil, fmt.Errorf("water depth %g differs from the water_depth option %g", d, depth)
+		}
+	}
+	return NewFinGreen3D(depth), nil
+}
+
+func newLiangWuNoblesseGFFromOptions(o *GreenFunctionOptions) (AbstractGreenFunction, error) {
+	for _, depth := range o.WaterDepths {
+		if !math.IsInf(depth, 1) {
+			return nil, fmt.Errorf("only implemented in infinite depth, got %g", depth)
+		}
+	}
+	return NewLiangWuNoblesseGF(), nil
+}
@@ -0,0 +1,128 @@
+package green_functions
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testKernel is a Green function registered by the tests, as a third party package would
+type testKernel struct {
+	*Delhommeau
+	scale float64
+	label string
+}
+
+// registerTestKernel registers testKernel once, as the tests may run several times
+var registerTestKernel sync.Once
+
+func TestRegisterGreenFunction(t *testing.T) {
+	registerTestKernel.Do(func() {
+		RegisterGreenFunction("TestKernel", func(o *GreenFunctionOptions) (AbstractGreenFunction, error) {
+			k := &testKernel{Delhommeau: NewDefaultDelhommeau(), scale: 1}
+			o.Float("scale", &k.scale)
+			o.String("label", &k.label)
+			return k, nil
+		}, "test-kernel")
+	})
+
+	gf, err := NewGreenFunction("TEST-KERNEL", map[string]interface{}{"scale": 2, "label": "x"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if k := gf.(*testKernel); k.scale != 2 || k.label != "x" {
+		t.Errorf("Expected the options to be read, got %+v", k)
+	}
+	if names := GreenFunctionNames(); !reflect.DeepEqual(names, []string{"Delhommeau", "FinGreen3D", "TestKernel"}) {
+		t.Errorf("Unexpected names %v", names)
+	}
+	if _, err := NewGreenFunction("TestKernel", map[string]interface{}{"scale": "large"}); err == nil || !strings.Contains(err.Error(), "scale") {
+		t.Errorf("Expected error for a string scale, got %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic when registering a name twice")
+		}
+	}()
+	RegisterGreenFunction("testkernel", func(*GreenFunctionOptions) (AbstractGreenFunction, error) { return nil, nil })
+}
+
+func TestNewGreenFunction(t *testing.T) {
+	gf, err := NewGreenFunction("DELHOMMEAU", nil, math.Inf(1))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if settings, _ := SettingsOf(gf); settings[SettingsKey] != "Delhommeau" {
+		t.Errorf("Expected Delhommeau, got %v", settings)
+	}
+
+	// The tabulation cache directory is an option but not a setting
+	gf, err = NewGreenFunction("delhommeau", map[string]interface{}{"tabulation_cache_dir": "cache"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if dir := gf.(*Delhommeau).GetParameters().TabulationCacheDir; dir != "cache" {
+		t.Errorf("Expected the tabulation cache directory cache, got %q", dir)
+	}
+	if gf.(*Delhommeau).Hash() != NewDefaultDelhommeau().Hash() {
+		t.Error("Expected the tabulation cache directory to leave the hash unchanged")
+	}
+
+	// FinGreen3D takes its depth from the option or from the problems
+	for _, valid := range []struct {
+		options map[string]interface{}
+		depths  []float64
+	}{
+		{nil, []float64{12}},
+		{map[string]interface{}{"water_depth": 12}, []float64{12, 12}},
+	} {
+		gf, err := NewGreenFunction("fingreen3d", valid.options, valid.depths...)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if settings, _ := SettingsOf(gf); settings["water_depth"] != 12.0 {
+			t.Errorf("Expected FinGreen3D in 12 m of water, got %v", settings)
+		}
+	}
+
+	// The evaluation of LiangWuNoblesseGF and HAMS is not implemented: their valid options are not enough to create
+	// them, and the error names them even when they are given by an alias
+	for _, unimplemented := range []struct {
+		name     string
+		options  map[string]interface{}
+		depths   []float64
+		expected string
+	}{
+		{"lwn", nil, []float64{math.Inf(1)}, "LiangWuNoblesseGF"},
+		{"HAMS", nil, []float64{math.Inf(1)}, "HAMS"},
+	} {
+		_, err := NewGreenFunction(unimplemented.name, unimplemented.options, unimplemented.depths...)
+		if !errors.Is(err, ErrNotImplemented) {
+			t.Errorf("Expected ErrNotImplemented for %+v, got %v", unimplemented, err)
+		} else if !strings.HasPrefix(err.Error(), unimplemented.expected+": ") {
+			t.Errorf("Expected an error about %s, got %v", unimplemented.expected, err)
+		}
+	}
+
+	for _, invalid := range []struct {
+		name    string
+		options map[string]interface{}
+		depths  []float64
+	}{
+		{"rankine", nil, nil},
+		{"fingreen3d", nil, []float64{math.Inf(1)}},
+		{"fingreen3d", nil, []float64{10, 20}},
+		{"fingreen3d", map[string]interface{}{"water_depth": 10}, []float64{20}},
+		{"hams", map[string]interface{}{"water_depth": 10}, nil},
+		{"lwn", nil, []float64{math.Inf(1), 10}},
+		{"delhommeau", map[string]interface{}{"tabulation_nr": -0.5}, nil},
+	} {
+		if _, err := NewGreenFunction(invalid.name, invalid.options, invalid.depths...); err == nil || errors.Is(err, ErrNotImplemented) {
+			t.Errorf("Expected error for %+v", invalid)
+		}
+	}
+}
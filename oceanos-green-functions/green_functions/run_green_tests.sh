@@ -8,6 +8,13 @@
 #   perf     - Run performance analysis
 #   all      - Run all tests (default)
 #   report   - Generate detailed report
+#   compare  - Compare benchmarks against a baseline (default ../results.txt),
+#              failing on regressions; BENCH_COUNT sets the number of runs
+#
+# The baseline is machine-specific: timings recorded on another machine (such as the
+# committed results.txt) are not comparable. Regenerate it locally before compare, with
+#   go test . -run='^$' -bench=. -benchmem -count=10 > ../results.txt
+# on the commit to compare against.
 
 set -e
 
@@ -15,6 +22,7 @@ set -e
 GREEN='\033[0;32m'
 BLUE='\033[0;34m'
 CYAN='\033[0;36m'
+RED='\033[0;31m'
 NC='\033[0m'
 
 echo -e "${BLUE}==========================================${NC}"
@@ -57,6 +65,24 @@ run_performance() {
     echo ""
 }
 
+# Function to compare benchmarks against a baseline
+run_compare() {
+    local baseline="${1:-../results.txt}"
+    local count="${BENCH_COUNT:-5}"
+    # benchcmp refuses fewer than 5 runs per benchmark, which cannot show a significant regression
+    local runs
+    runs=$(awk '/^Benchmark/ { n[$1]++ } END { m = 0; for (b in n) if (m == 0 || n[b] < m) m = n[b]; print m }' "$baseline")
+    if [ "$runs" -lt 5 ] || [ "$count" -lt 5 ]; then
+        echo -e "${RED}The baseline has ${runs} runs of some benchmarks and BENCH_COUNT is ${count}, both need 5 or more${NC}"
+        echo "Regenerate the baseline with: go test . -run='^\$' -bench=. -benchmem -count=10 > ${baseline}"
+        exit 2
+    fi
+    echo -e "${CYAN}Comparing Benchmarks against ${baseline}...${NC}"
+    go test . -run='^$' -bench=. -benchmem -count="$count" > /tmp/green_benchmark_new.log
+    go run ../cmd/benchcmp "$baseline" /tmp/green_benchmark_new.log
+    echo ""
+}
+
 # Function to generate report
 generate_report() {
     echo -e "${CYAN}Generating Detailed Report...${NC}"
@@ -79,18 +105,22 @@ case "${1:-all}" in
     "report")
         generate_report
         ;;
+    "compare")
+        run_compare "$2"
+        ;;
     "all")
         run_unit_tests
         run_benchmarks
         run_performance
         ;;
     *)
-        echo "Usage: $0 [unit|bench|perf|all|report]"
+        echo "Usage: $0 [unit|bench|perf|all|report|compare [baseline]]"
         echo "  unit   - Run only unit tests"
         echo "  bench  - Run only benchmarks"
         echo "  perf   - Run performance analysis"
         echo "  all    - Run all tests (default)"
         echo "  report - Generate detailed report"
+        echo "  compare - Compare benchmarks against a baseline, failing on regressions"
         exit 1
         ;;
 esac
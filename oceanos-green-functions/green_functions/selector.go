@@ -0,0 +1,224 @@
+// Package green_functions - Automatic selection of the Green function
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package green_functions
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+)
+
+// AccuracyTarget is the accuracy that a problem requires from its Green function
+type AccuracyTarget string
+
+const (
+	// GoodAccuracy favours speed when a good accuracy is sufficient
+	GoodAccuracy AccuracyTarget = "good"
+	// BalancedAccuracy balances speed and accuracy
+	BalancedAccuracy AccuracyTarget = "balanced"
+	// MaximumAccuracy favours accuracy over speed
+	MaximumAccuracy AccuracyTarget = "maximum"
+)
+
+// SelectionCriteria describe the problems for which a Selector chooses a Green function
+type SelectionCriteria struct {
+	NbFaces int
+	// WaterDepth is math.Inf(1) in deep water
+	WaterDepth float64
+	// MinWavenumber and MaxWavenumber bound the wavenumbers of the problems, in rad/m
+	MinWavenumber float64
+	MaxWavenumber float64
+	// Length is the size of the body in meters, 0 when unknown
+	Length float64
+	// Accuracy is BalancedAccuracy when empty
+	Accuracy AccuracyTarget
+}
+
+// NewSelectionCriteria returns the criteria of the problems of a mesh, whose length is the diagonal of its bounding box
+func NewSelectionCriteria(mesh *Mesh, waterDepth, minWavenumber, maxWavenumber float64, accuracy AccuracyTarget) SelectionCriteria {
+	c := SelectionCriteria{
+		NbFaces:       mesh.GetNbFaces(),
+		WaterDepth:    waterDepth,
+		MinWavenumber: minWavenumber,
+		MaxWavenumber: maxWavenumber,
+		Accuracy:      accuracy,
+	}
+	if len(mesh.Vertices) > 0 {
+		lower, upper := mesh.Vertices[0], mesh.Vertices[0]
+		for _, v := range mesh.Vertices[1:] {
+			for i := range v {
+				lower[i] = math.Min(lower[i], v[i])
+				upper[i] = math.Max(upper[i], v[i])
+			}
+		}
+		c.Length = norm3(sub3(upper, lower))
+	}
+	return c
+}
+
+// Selection is the Green function chosen by a Selector, with the reasons of the choice
+type Selection struct {
+	GreenFunction AbstractGreenFunction
+	// Name and Options create the Green function with NewGreenFunction
+	Name    string
+	Options map[string]interface{}
+	Reasons []string
+}
+
+// Selector chooses and configures a Green function following the method selection guide of the README:
+// LiangWuNoblesseGF for small problems where a good accuracy is sufficient, FinGreen3D in finite depth,
+// HAMS for the other problems up to medium sizes and Delhommeau for large problems or when the maximum
+// accuracy is required.
+// A candidate that NewGreenFunction rejects falls back to the next one, Delhommeau being the last resort as it
+// supports every depth. As the evaluation of LiangWuNoblesseGF and HAMS is not implemented yet, they are
+// always rejected, the reasons recording the rejections.
+type Selector struct {
+	// Problems with fewer faces than SmallProblemFaces are small, those with more than LargeProblemFaces are large
+	SmallProblemFaces int
+	LargeProblemFaces int
+	// FinGreen3D is a candidate up to the dimensionless wavenumber k h = FinGreen3DMaxKH. Its number of
+	// evanescent modes grows with k h, beyond which Delhommeau is faster.
+	FinGreen3DMaxKH float64
+	// HighFrequencyKL is the dimensionless wavenumber k L of the body above which all the problems are at
+	// high frequency, where Delhommeau takes the singularities of its high_freq variant
+	HighFrequencyKL float64
+	// TabulationCacheDir is where Delhommeau tabulates its wave integrals, except when the maximum accuracy is
+	// required. They are integrated at each evaluation when empty.
+	TabulationCacheDir string
+	// Logger receives the reasoning of the selection, nothing is logged when nil
+	Logger *log.Logger
+}
+
+// NewSelector returns a Selector with the problem sizes of the README, 100 and 1000 faces. FinGreen3D is as fast as
+// Delhommeau around k h = 20, and the problems are at high frequency when the body is longer than three wavelengths.
+func NewSelector() *Selector {
+	return &Selector{SmallProblemFaces: 100, LargeProblemFaces: 1000, FinGreen3DMaxKH: 20, HighFrequencyKL: 6 * math.Pi}
+}
+
+// selectionCandidate is a Green function considered by the Selector
+type selectionCandidate struct {
+	name   string
+	reason string
+}
+
+// Select chooses the Green function of the problems
+func (s *Selector) Select(c SelectionCriteria) (*Selection, error) {
+	if c.Accuracy == "" {
+		c.Accuracy = BalancedAccuracy
+	}
+	switch c.Accuracy {
+	case GoodAccuracy, BalancedAccuracy, MaximumAccuracy:
+	default:
+		return nil, fmt.Errorf("unknown accuracy target %q, expected good, balanced or maximum", c.Accuracy)
+	}
+	if c.NbFaces <= 0 {
+		return nil, fmt.Errorf("number of faces must be positive, got %d", c.NbFaces)
+	}
+	if !(c.WaterDepth > 0) {
+		return nil, fmt.Errorf("water depth must be positive, got %g", c.WaterDepth)
+	}
+	if c.MinWavenumber < 0 || c.MaxWavenumber < c.MinWavenumber || math.IsNaN(c.MaxWavenumber) {
+		return nil, fmt.Errorf("invalid wavenumber range [%g, %g]", c.MinWavenumber, c.MaxWavenumber)
+	}
+
+	selection := &Selection{}
+	s.explain(selection, "%d faces, water depth %g m, wavenumbers from %g to %g rad/m, %s accuracy",
+		c.NbFaces, c.WaterDepth, c.MinWavenumber, c.MaxWavenumber, c.Accuracy)
+
+	var candidates []selectionCandidate
+	finiteDepth := !math.IsInf(c.WaterDepth, 1)
+	switch {
+	case c.Accuracy == MaximumAccuracy:
+		candidates = append(candidates, selectionCandidate{"Delhommeau", "the maximum accuracy is required"})
+	case c.NbFaces > s.LargeProblemFaces:
+		candidates = append(candidates, selectionCandidate{"Delhommeau", fmt.Sprintf("large problem of more than %d faces", s.LargeProblemFaces)})
+	case c.NbFaces < s.SmallProblemFaces && c.Accuracy == GoodAccuracy:
+		candidates = append(candidates, selectionCandidate{"LiangWuNoblesseGF", fmt.Sprintf("small problem of less than %d faces where a good accuracy is sufficient", s.SmallProblemFaces)})
+	case !finiteDepth:
+		candidates = append(candidates, selectionCandidate{"HAMS", fmt.Sprintf("problem of at most %d faces in deep water, balancing speed and accuracy", s.LargeProblemFaces)})
+	}
+	if finiteDepth {
+		if kh := c.MaxWavenumber * c.WaterDepth; kh <= s.FinGreen3DMaxKH {
+			candidates = append(candidates, selectionCandidate{"FinGreen3D", fmt.Sprintf("finite depth with k h up to %g", kh)})
+		} else {
+			s.explain(selection, "FinGreen3D skipped: k h up to %g, above %g where Delhommeau is faster", kh, s.FinGreen3DMaxKH)
+		}
+	}
+	candidates = append(candidates, selectionCandidate{"Delhommeau", "general purpose, supports every depth"})
+
+	var errs []error
+	tried := map[string]bool{}
+	for _, candidate := range candidates {
+		if tried[candidate.name] {
+			continue
+		}
+		tried[candidate.name] = true
+		options := s.options(selection, candidate.name, c)
+		gf, err := NewGreenFunction(candidate.name, options, c.WaterDepth)
+		if err != nil {
+			s.explain(selection, "%s rejected: %v, falling back", candidate.name, err)
+			errs = append(errs, err)
+			continue
+		}
+		s.explain(selection, "selected %s: %s", candidate.name, candidate.reason)
+		selection.GreenFunction, selection.Name, selection.Options = gf, candidate.name, options
+		return selection, nil
+	}
+	return nil, fmt.Errorf("no Green function supports the problems: %w", errors.Join(errs...))
+}
+
+// options configures a candidate for the problems. Only Delhommeau has options that depend on them.
+func (s *Selector) options(selection *Selection, name string, c SelectionCriteria) map[string]interface{} {
+	options := map[string]interface{}{}
+	if name != "Delhommeau" {
+		return options
+	}
+	params := DefaultDelhommeauParameters()
+
+	if kl := c.MinWavenumber * c.Length; kl > s.HighFrequencyKL {
+		options["gf_singularities"] = string(HighFreq)
+		s.explain(selection, "Delhommeau: high_freq singularities, k L from %g above %g", kl, s.HighFrequencyKL)
+	}
+
+	switch {
+	case c.Accuracy == MaximumAccuracy:
+		s.explain(selection, "Delhommeau: wave integrals integrated at each evaluation for the maximum accuracy")
+		return options
+	case s.TabulationCacheDir == "":
+		s.explain(selection, "Delhommeau: wave integrals integrated at each evaluation, no tabulation cache directory")
+		return options
+	}
+	options["tabulation_cache_dir"] = s.TabulationCacheDir
+
+	// The tabulation covers the dimensionless distances k r up to tabulation_rmax and depths k z down to
+	// tabulation_zmin, beyond which the integrals are computed at each evaluation
+	if kr := c.MaxWavenumber * c.Length; kr > params.TabulationRmax {
+		scale := math.Ceil(kr) / params.TabulationRmax
+		options["tabulation_rmax"] = math.Ceil(kr)
+		options["tabulation_zmin"] = math.Floor(params.TabulationZmin * scale)
+		options["tabulation_nr"] = int(math.Ceil(float64(params.TabulationNr) * scale))
+		s.explain(selection, "Delhommeau: wave integrals tabulated in %s up to k r = %g for a length of %g m",
+			s.TabulationCacheDir, math.Ceil(kr), c.Length)
+	} else {
+		s.explain(selection, "Delhommeau: wave integrals tabulated in %s", s.TabulationCacheDir)
+	}
+	return options
+}
+
+// explain records a reason of the selection and logs it
+func (s *Selector) explain(selection *Selection, format string, args ...interface{}) {
+	reason := fmt.Sprintf(format, args...)
+	selection.Reasons = append(selection.Reasons, reason)
+	if s.Logger != nil {
+		s.Logger.Printf("green function selection: %s", reason)
+	}
+}
+
+// String returns the selected Green function and the reasons of the choice
+func (sel *Selection) String() string {
+	return fmt.Sprintf("%s (%s)", sel.GreenFunction, strings.Join(sel.Reasons, "; "))
+}
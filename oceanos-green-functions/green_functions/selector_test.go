@@ -0,0 +1,138 @@
+package green_functions
+
+import (
+	"bytes"
+	"log"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestSelector_Select(t *testing.T) {
+	deep := math.Inf(1)
+	for _, tc := range []struct {
+		name     string
+		criteria SelectionCriteria
+		expected string
+	}{
+		{"small problem", SelectionCriteria{NbFaces: 50, WaterDepth: deep, MaxWavenumber: 1, Accuracy: GoodAccuracy}, "Delhommeau"},
+		{"small problem in finite depth", SelectionCriteria{NbFaces: 50, WaterDepth: 20, MaxWavenumber: 1, Accuracy: GoodAccuracy}, "FinGreen3D"},
+		{"medium problem", SelectionCriteria{NbFaces: 500, WaterDepth: deep, MaxWavenumber: 1}, "Delhommeau"},
+		{"medium problem in finite depth", SelectionCriteria{NbFaces: 500, WaterDepth: 20, MaxWavenumber: 1}, "FinGreen3D"},
+		{"large problem", SelectionCriteria{NbFaces: 5000, WaterDepth: 20, MaxWavenumber: 1}, "Delhommeau"},
+		{"maximum accuracy", SelectionCriteria{NbFaces: 50, WaterDepth: deep, MaxWavenumber: 1, Accuracy: MaximumAccuracy}, "Delhommeau"},
+	} {
+		selection, err := NewSelector().Select(tc.criteria)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if selection.Name != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.expected, selection)
+		}
+		if settings, _ := SettingsOf(selection.GreenFunction); settings[SettingsKey] != tc.expected {
+			t.Errorf("%s: expected a %s Green function, got %v", tc.name, tc.expected, settings)
+		}
+	}
+
+	for _, invalid := range []SelectionCriteria{
+		{NbFaces: 0, WaterDepth: 10, MaxWavenumber: 1},
+		{NbFaces: 10, WaterDepth: 0, MaxWavenumber: 1},
+		{NbFaces: 10, WaterDepth: 10, MinWavenumber: 2, MaxWavenumber: 1},
+		{NbFaces: 10, WaterDepth: 10, MaxWavenumber: 1, Accuracy: "perfect"},
+	} {
+		if _, err := NewSelector().Select(invalid); err == nil {
+			t.Errorf("Expected error for %+v", invalid)
+		}
+	}
+}
+
+func TestSelector_Fallback(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewSelector()
+	s.Logger = log.New(&buf, "", 0)
+	selection, err := s.Select(SelectionCriteria{NbFaces: 50, WaterDepth: 20, MaxWavenumber: 1, Accuracy: GoodAccuracy})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if selection.Name != "FinGreen3D" || len(selection.Reasons) != 3 {
+		t.Fatalf("Expected FinGreen3D after the rejection of LiangWuNoblesseGF, got %s", selection)
+	}
+	if !strings.Contains(selection.Reasons[1], "LiangWuNoblesseGF rejected") {
+		t.Errorf("Expected the rejection of LiangWuNoblesseGF, got %q", selection.Reasons[1])
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 3 || !strings.HasPrefix(lines[2], "green function selection: selected FinGreen3D") {
+		t.Errorf("Expected the reasons to be logged, got %q", buf.String())
+	}
+
+	// In deep water, the small problem falls back from LiangWuNoblesseGF, whose evaluation is not implemented, to
+	// Delhommeau
+	selection, err = NewSelector().Select(SelectionCriteria{NbFaces: 50, WaterDepth: math.Inf(1), MaxWavenumber: 1, Accuracy: GoodAccuracy})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if selection.Name != "Delhommeau" || !strings.Contains(strings.Join(selection.Reasons, "\n"), "LiangWuNoblesseGF rejected") {
+		t.Errorf("Expected Delhommeau after the rejection of LiangWuNoblesseGF, got %s %q", selection, selection.Reasons)
+	}
+}
+
+func TestNewSelectionCriteria(t *testing.T) {
+	mesh, err := NewHemisphereMesh(10, 4, 8)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	criteria := NewSelectionCriteria(mesh, math.Inf(1), 1, 10, BalancedAccuracy)
+	if criteria.NbFaces != 32 || criteria.MaxWavenumber != 10 || math.Abs(criteria.Length-30) > 1e-9 {
+		t.Fatalf("Unexpected criteria %+v", criteria)
+	}
+
+	// The body is 30 wavelengths long at most
+	selection, err := NewSelector().Select(criteria)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if selection.Options["gf_singularities"] != "high_freq" {
+		t.Errorf("Expected the high_freq singularities, got %v", selection.Options)
+	}
+	gf, err := NewGreenFunction(selection.Name, selection.Options)
+	if err != nil || gf.(*Delhommeau).Hash() != selection.GreenFunction.(*Delhommeau).Hash() {
+		t.Errorf("Expected the name and the options to recreate the selection, got %v", err)
+	}
+}
+
+func TestSelector_Options(t *testing.T) {
+	dir := t.TempDir()
+	s := NewSelector()
+	s.TabulationCacheDir = dir
+
+	// The tabulation covers the wavenumbers of the problems
+	large := SelectionCriteria{NbFaces: 5000, WaterDepth: math.Inf(1), MinWavenumber: 0.1, MaxWavenumber: 5, Length: 40}
+	selection, err := s.Select(large)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	params := selection.GreenFunction.(*Delhommeau).GetParameters()
+	if params.TabulationCacheDir != dir || params.TabulationRmax != 200 || params.TabulationZmin != -502 || params.TabulationNr != 1352 {
+		t.Errorf("Expected a tabulation in %s up to k r = 200, got %+v", dir, params)
+	}
+	if params.GfSingularities != LowFreq {
+		t.Errorf("Expected the low_freq singularities, got %s", params.GfSingularities)
+	}
+
+	// The maximum accuracy integrates the wave integrals at each evaluation
+	large.Accuracy = MaximumAccuracy
+	if selection, err = s.Select(large); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if params := selection.GreenFunction.(*Delhommeau).GetParameters(); params.TabulationCacheDir != "" || params.TabulationRmax != 100 {
+		t.Errorf("Expected no tabulation for the maximum accuracy, got %+v", params)
+	}
+
+	// FinGreen3D needs too many evanescent modes in deep water
+	deep := SelectionCriteria{NbFaces: 500, WaterDepth: 50, MaxWavenumber: 1}
+	if selection, err = s.Select(deep); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if selection.Name != "Delhommeau" || !strings.Contains(selection.Reasons[1], "FinGreen3D skipped") {
+		t.Errorf("Expected Delhommeau for k h = 50, got %s", selection)
+	}
+}
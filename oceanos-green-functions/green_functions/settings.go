@@ -0,0 +1,112 @@
+// Package green_functions - Green function settings and their serialization
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package green_functions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+)
+
+// SettingsKey is the key of the settings holding the name of the Green function
+const SettingsKey = "green_function"
+
+// ConfigurableGreenFunction is a Green function whose configuration is described by its settings,
+// from which NewGreenFunctionFromSettings creates an identical Green function
+type ConfigurableGreenFunction interface {
+	AbstractGreenFunction
+
+	// Settings returns the name of the Green function under the "green_function" key and its parameters
+	Settings() map[string]interface{}
+}
+
+func copySettings(settings map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(settings))
+	for k, v := range settings {
+		c[k] = v
+	}
+	return c
+}
+
+// SettingsOf returns the settings of a Green function, which must be a ConfigurableGreenFunction
+func SettingsOf(gf AbstractGreenFunction) (map[string]interface{}, error) {
+	configurable, ok := gf.(ConfigurableGreenFunction)
+	if !ok {
+		return nil, fmt.Errorf("%T has no settings", gf)
+	}
+	return configurable.Settings(), nil
+}
+
+// NewGreenFunctionFromSettings creates the Green function described by settings such as those returned
+// by Settings, or decoded from JSON or YAML. Parameters absent from the settings take their default value
+// and unknown parameters are an error, so that the settings of a result file reproduce its Green function.
+func NewGreenFunctionFromSettings(settings map[string]interface{}) (AbstractGreenFunction, error) {
+	name, ok := settings[SettingsKey].(string)
+	if !ok {
+		return nil, fmt.Errorf("settings have no %q name", SettingsKey)
+	}
+	return NewGreenFunction(name, settings)
+}
+
+// MarshalSettingsJSON returns the settings of a Green function as a JSON object
+func MarshalSettingsJSON(gf AbstractGreenFunction) ([]byte, error) {
+	settings, err := SettingsOf(gf)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(settings)
+}
+
+// MarshalSettingsYAML returns the settings of a Green function as a YAML mapping
+func MarshalSettingsYAML(gf AbstractGreenFunction) ([]byte, error) {
+	settings, err := SettingsOf(gf)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(settings)
+}
+
+// UnmarshalSettingsJSON creates the Green function described by a JSON object of settings
+func UnmarshalSettingsJSON(data []byte) (AbstractGreenFunction, error) {
+	var settings map[string]interface{}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		return nil, errors.New("settings must be a JSON object")
+	}
+	return NewGreenFunctionFromSettings(settings)
+}
+
+// UnmarshalSettingsYAML creates the Green function described by a YAML mapping of settings
+func UnmarshalSettingsYAML(data []byte) (AbstractGreenFunction, error) {
+	var settings map[string]interface{}
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		return nil, errors.New("settings must be a YAML mapping")
+	}
+	return NewGreenFunctionFromSettings(settings)
+}
+
+// LoadSettings reads the settings of a Green function from a JSON or YAML file, without checking them
+func LoadSettings(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// JSON objects are YAML mappings
+	var settings map[string]interface{}
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if settings == nil {
+		return nil, fmt.Errorf("%s: settings must be a mapping", path)
+	}
+	return settings, nil
+}
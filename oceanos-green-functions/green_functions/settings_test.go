@@ -0,0 +1,107 @@
+package green_functions
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSettings_RoundTrip(t *testing.T) {
+	params := DefaultDelhommeauParameters()
+	params.TabulationNr = 400
+	params.TabulationZmin = -100.5
+	params.TabulationGridShape = Legacy
+	params.FiniteDepthPronyDecompositionMethod = FortranMethod
+	params.GfSingularities = LowFreqWithRankinePart
+
+	for _, gf := range []ConfigurableGreenFunction{NewDelhommeau(params), NewFinGreen3D(12.5)} {
+		jsonData, err := MarshalSettingsJSON(gf)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		yamlData, err := MarshalSettingsYAML(gf)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		fromJSON, err := UnmarshalSettingsJSON(jsonData)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", jsonData, err)
+		}
+		fromYAML, err := UnmarshalSettingsYAML(yamlData)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", yamlData, err)
+		}
+		for _, read := range []AbstractGreenFunction{fromJSON, fromYAML} {
+			settings, err := SettingsOf(read)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(settings, gf.Settings()) {
+				t.Errorf("Expected %v, got %v", gf.Settings(), settings)
+			}
+		}
+	}
+
+	// The settings of the Green functions whose evaluation is not implemented are recognized, but do not
+	// recreate them
+	for _, gf := range []ConfigurableGreenFunction{NewLiangWuNoblesseGF(), NewHAMS()} {
+		data, err := MarshalSettingsJSON(gf)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := UnmarshalSettingsJSON(data); !errors.Is(err, ErrNotImplemented) {
+			t.Errorf("%s: expected ErrNotImplemented, got %v", data, err)
+		}
+		if _, err := NewGreenFunctionFromSettings(gf.Settings()); !errors.Is(err, ErrNotImplemented) {
+			t.Errorf("%s: expected ErrNotImplemented, got %v", gf, err)
+		}
+	}
+
+	// The recreated Delhommeau has the hash of the original one
+	read, err := NewGreenFunctionFromSettings(NewDelhommeau(params).Settings())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if read.(*Delhommeau).Hash() != NewDelhommeau(params).Hash() {
+		t.Error("Expected the same hash for the same settings")
+	}
+}
+
+func TestSettings_Copy(t *testing.T) {
+	gf := NewDefaultDelhommeau()
+	settings := gf.Settings()
+	settings["tabulation_nr"] = 1
+	if gf.Settings()["tabulation_nr"] == 1 {
+		t.Error("Expected Settings to return a copy")
+	}
+}
+
+func TestNewGreenFunctionFromSettings(t *testing.T) {
+	// Absent parameters take their default value
+	gf, err := UnmarshalSettingsYAML([]byte("green_function: Delhommeau\ntabulation_nr: 328\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := DefaultDelhommeauParameters()
+	expected.TabulationNr = 328
+	if !reflect.DeepEqual(gf.(*Delhommeau).parameters, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, gf.(*Delhommeau).parameters)
+	}
+
+	for settings, message := range map[string]string{
+		`{}`:                            "no",
+		`{"green_function": "Rankine"}`: "unknown Green function",
+		`{"green_function": "Delhommeau", "tabulation_nr": 1.5}`:           "expected an integer",
+		`{"green_function": "Delhommeau", "gf_singularities": "mid_freq"}`: "unknown value",
+		`{"green_function": "Delhommeau", "tabulation_rmax": "far"}`:       "expected a number",
+		`{"green_function": "HAMS", "water_depth": 10}`:                    "unknown options water_depth",
+		`{"green_function": "FinGreen3D"}`:                                 "water_depth",
+		`{"green_function": "FinGreen3D", "water_depth": -1}`:              "water_depth",
+		`[]`: "cannot unmarshal",
+	} {
+		if _, err := UnmarshalSettingsJSON([]byte(settings)); err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("%s: expected error containing %q, got %v", settings, message, err)
+		}
+	}
+}
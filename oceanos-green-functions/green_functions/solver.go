@@ -0,0 +1,291 @@
+// Package green_functions - Boundary element solver for the radiation and diffraction problems
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package green_functions
+
+import (
+	"errors"
+	"fmt"
+	"gonum.org/v1/gonum/mat"
+	"math"
+	"math/cmplx"
+)
+
+// BEMSolver solves the linear potential flow problems around a floating body with a source distribution.
+// Time dependency is exp(-iωt), incident waves have unit amplitude and the free surface is at z = 0.
+type BEMSolver struct {
+	GreenFunction AbstractGreenFunction
+	Rho           float64
+
+	// Cache optionally stores the frequency independent Rankine terms of the Green function between calls
+	Cache *RankineCache
+}
+
+// NewBEMSolver creates a new solver using the given Green function
+func NewBEMSolver(greenFunction AbstractGreenFunction) *BEMSolver {
+	return &BEMSolver{
+		GreenFunction: greenFunction,
+		Rho:           WaterDensity,
+	}
+}
+
+// ProblemResult holds the solution of a single boundary value problem
+type ProblemResult struct {
+	// Sources is the strength of the source distribution on each face
+	Sources []complex128
+	// Potentials is the potential at the center of each face
+	Potentials []complex128
+}
+
+// FrequencyResult holds the solutions of the radiation and diffraction problems at one frequency.
+// Radiation problems are solved for a unit velocity of each degree of freedom.
+type FrequencyResult struct {
+	Omega          float64
+	Wavenumber     float64
+	WaterDepth     float64
+	Rho            float64
+	WaveDirections []float64
+
+	// Radiation holds one result per degree of freedom, Diffraction one result per wave direction
+	Radiation   []ProblemResult
+	Diffraction []ProblemResult
+
+	// AddedMass and RadiationDamping are (nDOFs, nDOFs) matrices
+	AddedMass        *mat.Dense
+	RadiationDamping *mat.Dense
+
+	// FroudeKrylovForce, DiffractionForce and ExcitationForce are (nDirections, nDOFs) matrices
+	FroudeKrylovForce *mat.CDense
+	DiffractionForce  *mat.CDense
+	ExcitationForce   *mat.CDense
+}
+
+// SolveFrequency solves the radiation problems of all the degrees of freedom of the body
+// and the diffraction problems of all the wave directions at the angular frequency omega
+func (s *BEMSolver) SolveFrequency(body *FloatingBody, omega, waterDepth float64, waveDirections []float64) (*FrequencyResult, error) {
+	if omega <= 0 || math.IsInf(omega, 0) || math.IsNaN(omega) {
+		return nil, fmt.Errorf("angular frequency must be positive and finite, got %g", omega)
+	}
+	k := real(ComputeWaveNumber(omega, waterDepth))
+	return s.solve(body, omega, k, waterDepth, waveDirections)
+}
+
+// evaluateGreenFunction evaluates the Green function of the solver,
+// reusing the Rankine terms stored in the cache of the solver when there is one
+func (s *BEMSolver) evaluateGreenFunction(mesh1, mesh2 interface{}, freeSurface float64, waterDepth float64,
+	wavenumber complex128, adjointDoubleLayer bool, earlyDotProduct bool) (*mat.CDense, *mat.CDense, error) {
+	if s.Cache == nil {
+		return s.GreenFunction.Evaluate(mesh1, mesh2, freeSurface, waterDepth, wavenumber, adjointDoubleLayer, earlyDotProduct)
+	}
+	return s.Cache.Evaluate(s.GreenFunction, mesh1, mesh2, freeSurface, waterDepth, wavenumber, adjointDoubleLayer, earlyDotProduct)
+}
+
+// solve builds and solves the boundary integral equation for the given wavenumber
+func (s *BEMSolver) solve(body *FloatingBody, omega, k, waterDepth float64, waveDirections []float64) (*FrequencyResult, error) {
+	if body == nil || body.Mesh == nil {
+		return nil, errors.New("body must have a mesh")
+	}
+	if body.NbDOFs() == 0 {
+		return nil, errors.New("body must have at least one degree of freedom")
+	}
+	mesh := body.Mesh
+
+	S, K, err := s.evaluateGreenFunction(mesh, mesh, 0, waterDepth, complex(k, 0), true, true)
+	if err != nil {
+		return nil, err
+	}
+
+	nFaces := mesh.GetNbFaces()
+	for i := 0; i < nFaces; i++ {
+		K.Set(i, i, K.At(i, i)+0.5)
+	}
+
+	nDOFs := body.NbDOFs()
+	rhs := mat.NewCDense(nFaces, nDOFs+len(waveDirections), nil)
+	for j, velocities := range body.dofNormalVelocities() {
+		for i, v := range velocities {
+			rhs.Set(i, j, complex(v, 0))
+		}
+	}
+
+	centers := mesh.GetFacesCenters()
+	normals := mesh.GetFacesNormals()
+	for d, beta := range waveDirections {
+		for i := 0; i < nFaces; i++ {
+			_, velocity := airyWave(rowVector(centers, i), omega, k, waterDepth, beta)
+			var vn complex128
+			for c, n := range rowVector(normals, i) {
+				vn += velocity[c] * complex(n, 0)
+			}
+			rhs.Set(i, nDOFs+d, -vn)
+		}
+	}
+
+	sources, err := solveComplexSystem(K, rhs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to solve the boundary integral equation: %w", err)
+	}
+
+	result := &FrequencyResult{
+		Omega:            omega,
+		Wavenumber:       k,
+		WaterDepth:       waterDepth,
+		Rho:              s.Rho,
+		WaveDirections:   waveDirections,
+		Radiation:        make([]ProblemResult, nDOFs),
+		Diffraction:      make([]ProblemResult, len(waveDirections)),
+		AddedMass:        mat.NewDense(nDOFs, nDOFs, nil),
+		RadiationDamping: mat.NewDense(nDOFs, nDOFs, nil),
+	}
+	if len(waveDirections) > 0 {
+		result.FroudeKrylovForce = mat.NewCDense(len(waveDirections), nDOFs, nil)
+		result.DiffractionForce = mat.NewCDense(len(waveDirections), nDOFs, nil)
+		result.ExcitationForce = mat.NewCDense(len(waveDirections), nDOFs, nil)
+	}
+
+	for p := 0; p < nDOFs+len(waveDirections); p++ {
+		sigma := make([]complex128, nFaces)
+		for i := range sigma {
+			sigma[i] = sources.At(i, p)
+		}
+		problem := ProblemResult{Sources: sigma, Potentials: complexMatVec(S, sigma)}
+		if p < nDOFs {
+			result.Radiation[p] = problem
+		} else {
+			result.Diffraction[p-nDOFs] = problem
+		}
+	}
+
+	// Pressure is p = iωρφ and the generalized force on a degree of freedom is -∫ p n_i dS
+	dofNormals := body.dofNormalVelocities()
+	areas := mesh.GetFacesAreas()
+	pressureForce := func(potentials []complex128, dof int) complex128 {
+		var f complex128
+		for i, phi := range potentials {
+			f += phi * complex(dofNormals[dof][i]*areas[i], 0)
+		}
+		return -complex(0, omega*s.Rho) * f
+	}
+
+	for j, radiation := range result.Radiation {
+		for i := 0; i < nDOFs; i++ {
+			// For a unit velocity, the force is iω A - B
+			f := pressureForce(radiation.Potentials, i)
+			result.AddedMass.Set(i, j, imag(f)/omega)
+			result.RadiationDamping.Set(i, j, -real(f))
+		}
+	}
+
+	for d, beta := range waveDirections {
+		incident := make([]complex128, nFaces)
+		for i := range incident {
+			incident[i], _ = airyWave(rowVector(centers, i), omega, k, waterDepth, beta)
+		}
+		for i := 0; i < nDOFs; i++ {
+			fk := pressureForce(incident, i)
+			diffraction := pressureForce(result.Diffraction[d].Potentials, i)
+			result.FroudeKrylovForce.Set(d, i, fk)
+			result.DiffractionForce.Set(d, i, diffraction)
+			result.ExcitationForce.Set(d, i, fk+diffraction)
+		}
+	}
+
+	return result, nil
+}
+
+// ComputeRAO solves the equation of motion [-ω²(M + A) - iωB + C] ξ = F for each wave direction
+// and returns the complex motion amplitudes as a (nDirections, nDOFs) matrix.
+// The inertia matrix and the hydrostatic stiffness of the body must be set.
+func (r *FrequencyResult) ComputeRAO(body *FloatingBody) (*mat.CDense, error) {
+	if body.InertiaMatrix == nil || body.HydrostaticStiffness == nil {
+		return nil, errors.New("body inertia matrix and hydrostatic stiffness are required to compute motions")
+	}
+	n := body.NbDOFs()
+	if rows, cols := body.InertiaMatrix.Dims(); rows != n || cols != n {
+		return nil, fmt.Errorf("inertia matrix must be (%d, %d)", n, n)
+	}
+	if rows, cols := body.HydrostaticStiffness.Dims(); rows != n || cols != n {
+		return nil, fmt.Errorf("hydrostatic stiffness must be (%d, %d)", n, n)
+	}
+
+	w := r.Omega
+	system := mat.NewCDense(n, n, nil)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			m := body.InertiaMatrix.At(i, j) + r.AddedMass.At(i, j)
+			system.Set(i, j, complex(-w*w*m+body.HydrostaticStiffness.At(i, j), -w*r.RadiationDamping.At(i, j)))
+		}
+	}
+
+	nDirections := len(r.WaveDirections)
+	forces := mat.NewCDense(n, nDirections, nil)
+	for d := 0; d < nDirections; d++ {
+		for i := 0; i < n; i++ {
+			forces.Set(i, d, r.ExcitationForce.At(d, i))
+		}
+	}
+
+	motions, err := solveComplexSystem(system, forces)
+	if err != nil {
+		return nil, fmt.Errorf("failed to solve the equation of motion: %w", err)
+	}
+
+	rao := mat.NewCDense(nDirections, n, nil)
+	for d := 0; d < nDirections; d++ {
+		for i := 0; i < n; i++ {
+			rao.Set(d, i, motions.At(i, d))
+		}
+	}
+	return rao, nil
+}
+
+// TotalSources returns the sources of the total disturbance potential of the body moving with
+// the given complex motion amplitudes in the waves of direction index d: σ_D + Σ_j (-iω ξ_j) σ_j.
+// With nil motions, the body is fixed and only the diffraction sources are returned.
+func (r *FrequencyResult) TotalSources(d int, motions []complex128) []complex128 {
+	sigma := make([]complex128, len(r.Diffraction[d].Sources))
+	copy(sigma, r.Diffraction[d].Sources)
+	for j, xi := range motions {
+		velocity := complex(0, -r.Omega) * xi
+		for i, s := range r.Radiation[j].Sources {
+			sigma[i] += velocity * s
+		}
+	}
+	return sigma
+}
+
+// verticalProfile returns the vertical profile of a wave of wavenumber k at depth z, and its derivative
+//
+//	Z(z) = cosh(k(z + h)) / cosh(kh)
+//
+// which reduces to exp(kz) in infinite depth
+func verticalProfile(k, z, waterDepth float64) (float64, float64) {
+	ez := math.Exp(k * z)
+	if math.IsInf(waterDepth, 1) {
+		return ez, k * ez
+	}
+	// Written with decaying exponentials to avoid overflows for large kh
+	eb := math.Exp(-k * (z + 2*waterDepth))
+	norm := 1 + math.Exp(-2*k*waterDepth)
+	return (ez + eb) / norm, k * (ez - eb) / norm
+}
+
+// airyWave returns the potential and the velocity of a regular incident wave of unit amplitude
+// propagating in the direction beta
+//
+//	φ0 = -(ig/ω) Z(z) exp(ik(x cos β + y sin β))
+func airyWave(point [3]float64, omega, k, waterDepth, beta float64) (complex128, [3]complex128) {
+	cb, sb := math.Cos(beta), math.Sin(beta)
+	profile, dProfile := verticalProfile(k, point[2], waterDepth)
+	phase := cmplx.Exp(complex(0, k*(point[0]*cb+point[1]*sb)))
+	amplitude := complex(0, -Gravity/omega) * phase
+
+	potential := amplitude * complex(profile, 0)
+	velocity := [3]complex128{
+		potential * complex(0, k*cb),
+		potential * complex(0, k*sb),
+		amplitude * complex(dProfile, 0),
+	}
+	return potential, velocity
+}
@@ -0,0 +1,119 @@
+package green_functions
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBEMSolver_AddedMassLimits(t *testing.T) {
+	body := newTestHemisphere(t, 8, 16)
+	solver := NewBEMSolver(NewDefaultDelhommeau())
+	displacedMass := WaterDensity * 2 * math.Pi / 3
+
+	// With a rigid wall free surface, the hemisphere in surge is half of a sphere in an unbounded fluid
+	result, err := solver.solve(body, 1, 0, math.Inf(1), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if a11 := result.AddedMass.At(0, 0) / displacedMass; math.Abs(a11-0.5) > 0.05 {
+		t.Errorf("Expected zero frequency surge added mass close to 0.5, got %v", a11)
+	}
+
+	// With a zero potential free surface, the hemisphere in heave is half of a sphere in an unbounded fluid
+	result, err = solver.solve(body, 1, math.Inf(1), math.Inf(1), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if a33 := result.AddedMass.At(2, 2) / displacedMass; math.Abs(a33-0.5) > 0.05 {
+		t.Errorf("Expected infinite frequency heave added mass close to 0.5, got %v", a33)
+	}
+}
+
+func TestBEMSolver_SolveFrequency(t *testing.T) {
+	body := newTestHemisphere(t, 8, 16)
+	solver := NewBEMSolver(NewDefaultDelhommeau())
+	omega := math.Sqrt(Gravity)
+
+	result, err := solver.SolveFrequency(body, omega, math.Inf(1), []float64{0, math.Pi / 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(result.Radiation) != 6 || len(result.Diffraction) != 2 {
+		t.Fatalf("Expected 6 radiation and 2 diffraction results, got %d and %d", len(result.Radiation), len(result.Diffraction))
+	}
+	for i := 0; i < 3; i++ {
+		if result.RadiationDamping.At(i, i) <= 0 {
+			t.Errorf("Expected positive radiation damping for DOF %d, got %v", i, result.RadiationDamping.At(i, i))
+		}
+	}
+
+	// Axisymmetric body: surge in head waves is sway in beam waves
+	head, beam := result.ExcitationForce.At(0, 0), result.ExcitationForce.At(1, 1)
+	if math.Abs(real(head)-real(beam)) > 1e-6*math.Abs(real(head)) || math.Abs(imag(head)-imag(beam)) > 1e-6*math.Abs(imag(head)) {
+		t.Errorf("Expected equal surge and sway excitation, got %v and %v", head, beam)
+	}
+
+	// Haskind relation for the heave of an axisymmetric body: B33 = k |F3|² / (4 ρ g c_g)
+	k := result.Wavenumber
+	cg := omega / (2 * k)
+	f3 := result.ExcitationForce.At(0, 2)
+	expected := k * (real(f3)*real(f3) + imag(f3)*imag(f3)) / (4 * WaterDensity * Gravity * cg)
+	if b33 := result.RadiationDamping.At(2, 2); math.Abs(b33-expected) > 0.1*expected {
+		t.Errorf("Heave damping %v does not match Haskind relation %v", b33, expected)
+	}
+
+	if _, err := solver.SolveFrequency(body, 0, math.Inf(1), nil); err == nil {
+		t.Error("Expected error for zero frequency")
+	}
+}
+
+func TestFrequencyResult_ComputeRAO(t *testing.T) {
+	body := newTestHemisphere(t, 8, 16)
+	solver := NewBEMSolver(NewDefaultDelhommeau())
+
+	// In very long waves, the body follows the free surface
+	result, err := solver.SolveFrequency(body, 0.3, math.Inf(1), []float64{0})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	rao, err := result.ComputeRAO(body)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	heave := rao.At(0, 2)
+	if math.Abs(real(heave)-1) > 0.05 || math.Abs(imag(heave)) > 0.05 {
+		t.Errorf("Expected heave RAO close to 1 in long waves, got %v", heave)
+	}
+
+	body.InertiaMatrix = nil
+	if _, err := result.ComputeRAO(body); err == nil {
+		t.Error("Expected error without inertia matrix")
+	}
+}
+
+func TestAiryWave(t *testing.T) {
+	omega, depth := 1.2, 10.0
+	k := real(ComputeWaveNumber(omega, depth))
+	point := [3]float64{0.3, -0.4, -2}
+
+	potential, velocity := airyWave(point, omega, k, depth, 0.5)
+
+	h := 1e-6
+	for c := 0; c < 3; c++ {
+		shifted := point
+		shifted[c] += h
+		p, _ := airyWave(shifted, omega, k, depth, 0.5)
+		fd := (p - potential) / complex(h, 0)
+		if math.Abs(real(fd-velocity[c])) > 1e-4 || math.Abs(imag(fd-velocity[c])) > 1e-4 {
+			t.Errorf("Velocity component %d is %v, finite difference gives %v", c, velocity[c], fd)
+		}
+	}
+
+	// Unit amplitude at the free surface: η = iω/g φ
+	surface, _ := airyWave([3]float64{0, 0, 0}, omega, k, depth, 0)
+	if eta := complex(0, omega/Gravity) * surface; math.Abs(real(eta)-1) > 1e-12 || math.Abs(imag(eta)) > 1e-12 {
+		t.Errorf("Expected unit wave amplitude, got %v", eta)
+	}
+}
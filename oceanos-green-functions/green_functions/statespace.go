@@ -0,0 +1,282 @@
+// Package green_functions - State-space approximation of radiation impulse response functions
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package green_functions
+
+import (
+	"errors"
+	"fmt"
+	"gonum.org/v1/gonum/mat"
+	"math"
+	"math/cmplx"
+)
+
+// StateSpaceOptions configures the identification of state-space models
+type StateSpaceOptions struct {
+	// Tolerance is the largest relative root mean square error of the fitted impulse response, defaults to 1e-2
+	Tolerance float64
+	// MaxOrder is the largest number of exponentials of the fit, defaults to 20
+	MaxOrder int
+	// ZeroThreshold is the norm, relative to the largest kernel, below which a kernel is replaced by an empty model.
+	// Defaults to 1e-6.
+	ZeroThreshold float64
+}
+
+func (o StateSpaceOptions) withDefaults() StateSpaceOptions {
+	if o.Tolerance == 0 {
+		o.Tolerance = 1e-2
+	}
+	if o.MaxOrder == 0 {
+		o.MaxOrder = 20
+	}
+	if o.ZeroThreshold == 0 {
+		o.ZeroThreshold = 1e-6
+	}
+	return o
+}
+
+// StateSpaceModel is a linear system whose impulse response approximates a convolution kernel
+//
+//	x' = A x + B u
+//	y  = C x + D u
+//
+// so that the convolution ∫ K(t-τ) u(τ) dτ is replaced by the output y of the system for the input u
+type StateSpaceModel struct {
+	A, B, C, D *mat.Dense
+
+	// Prony is the sum of exponentials Σ a_m exp(s_m t) fitted to the impulse response
+	Prony *PronyDecomposition
+	// RelativeError is the relative root mean square error of the fit on the samples
+	RelativeError float64
+}
+
+// Order returns the number of states of the model
+func (m *StateSpaceModel) Order() int {
+	if m.A == nil {
+		return 0
+	}
+	n, _ := m.A.Dims()
+	return n
+}
+
+// ImpulseResponse evaluates the impulse response of the model at time t
+func (m *StateSpaceModel) ImpulseResponse(t float64) float64 {
+	if m.Prony == nil {
+		return 0
+	}
+	return real(m.Prony.Evaluate(t))
+}
+
+// FrequencyResponse evaluates the transfer function C (iωI - A)⁻¹ B + D of the model
+func (m *StateSpaceModel) FrequencyResponse(omega float64) complex128 {
+	var h complex128
+	if m.D != nil {
+		h = complex(m.D.At(0, 0), 0)
+	}
+	if m.Prony == nil {
+		return h
+	}
+	for i, a := range m.Prony.Coefficients {
+		h += a / (complex(0, omega) - m.Prony.Exponents[i])
+	}
+	return h
+}
+
+// FitStateSpaceModel identifies a state-space model of a kernel sampled at uniformly spaced times, with Prony's method.
+// The order is increased until the relative error is below the tolerance of the options.
+func FitStateSpaceModel(times, values []float64, options StateSpaceOptions) (*StateSpaceModel, error) {
+	options = options.withDefaults()
+	if _, err := uniformStep(times, values); err != nil {
+		return nil, err
+	}
+
+	norm := floats2Norm(values)
+	if norm == 0 {
+		return emptyStateSpaceModel(), nil
+	}
+
+	maxOrder := options.MaxOrder
+	if maxOrder > len(times)/2 {
+		maxOrder = len(times) / 2
+	}
+
+	var best *StateSpaceModel
+	for order := 1; order <= maxOrder; order++ {
+		prony, err := fitPronyDecomposition(times, values, order)
+		if err != nil {
+			continue
+		}
+		model, err := NewStateSpaceModel(prony)
+		if err != nil {
+			continue
+		}
+
+		residuals := make([]float64, len(times))
+		for i, t := range times {
+			residuals[i] = values[i] - model.ImpulseResponse(t)
+		}
+		model.RelativeError = floats2Norm(residuals) / norm
+
+		if best == nil || model.RelativeError < best.RelativeError {
+			best = model
+		}
+		if model.RelativeError <= options.Tolerance {
+			return model, nil
+		}
+	}
+
+	if best == nil {
+		return nil, errors.New("Prony's method failed for all the orders")
+	}
+	return nil, fmt.Errorf("best relative error %g with order %d is above the tolerance %g",
+		best.RelativeError, best.Order(), options.Tolerance)
+}
+
+// NewStateSpaceModel builds a real state-space realization of a sum of exponentials with stable exponents.
+// Complex exponents must come in conjugate pairs with conjugate coefficients; each pair gives a block of two states.
+func NewStateSpaceModel(prony *PronyDecomposition) (*StateSpaceModel, error) {
+	type mode struct {
+		exponent, coefficient complex128
+	}
+	var modes []mode
+	order := 0
+	for i, s := range prony.Exponents {
+		if real(s) >= 0 {
+			return nil, fmt.Errorf("exponent %v is not stable", s)
+		}
+		switch {
+		case isRealExponent(s):
+			modes = append(modes, mode{complex(real(s), 0), complex(real(prony.Coefficients[i]), 0)})
+			order++
+		case imag(s) > 0:
+			// The conjugate exponent is accounted for by the same block
+			modes = append(modes, mode{s, prony.Coefficients[i]})
+			order += 2
+		}
+	}
+	if order == 0 {
+		return emptyStateSpaceModel(), nil
+	}
+
+	A := mat.NewDense(order, order, nil)
+	B := mat.NewDense(order, 1, nil)
+	C := mat.NewDense(1, order, nil)
+	k := 0
+	for _, m := range modes {
+		sigma, omega := real(m.exponent), imag(m.exponent)
+		a := m.coefficient
+		if omega == 0 {
+			A.Set(k, k, sigma)
+			B.Set(k, 0, 1)
+			C.Set(0, k, real(a))
+			k++
+			continue
+		}
+		// z = x1 + i x2 with z' = s z + u and y = 2 Re(a z)
+		A.Set(k, k, sigma)
+		A.Set(k, k+1, -omega)
+		A.Set(k+1, k, omega)
+		A.Set(k+1, k+1, sigma)
+		B.Set(k, 0, 1)
+		C.Set(0, k, 2*real(a))
+		C.Set(0, k+1, -2*imag(a))
+		k += 2
+	}
+
+	// Keep the realized exponentials, so that the impulse response matches the matrices
+	var realized PronyDecomposition
+	for _, m := range modes {
+		realized.Exponents = append(realized.Exponents, m.exponent)
+		realized.Coefficients = append(realized.Coefficients, m.coefficient)
+		if imag(m.exponent) != 0 {
+			realized.Exponents = append(realized.Exponents, cmplx.Conj(m.exponent))
+			realized.Coefficients = append(realized.Coefficients, cmplx.Conj(m.coefficient))
+		}
+	}
+
+	return &StateSpaceModel{A: A, B: B, C: C, D: mat.NewDense(1, 1, nil), Prony: &realized}, nil
+}
+
+// StateSpace identifies a state-space model for each pair of degrees of freedom of the radiation impulse response.
+// The kernels that are negligible compared to the largest one are replaced by empty models.
+func (irf *RadiationIRF) StateSpace(options StateSpaceOptions) ([][]*StateSpaceModel, error) {
+	options = options.withDefaults()
+	nDOFs, _ := irf.Kernel[0].Dims()
+
+	var largest float64
+	for i := 0; i < nDOFs; i++ {
+		for j := 0; j < nDOFs; j++ {
+			largest = math.Max(largest, floats2Norm(irf.Component(i, j)))
+		}
+	}
+
+	models := make([][]*StateSpaceModel, nDOFs)
+	for i := range models {
+		models[i] = make([]*StateSpaceModel, nDOFs)
+		for j := range models[i] {
+			kernel := irf.Component(i, j)
+			if floats2Norm(kernel) <= options.ZeroThreshold*largest {
+				models[i][j] = emptyStateSpaceModel()
+				continue
+			}
+			model, err := FitStateSpaceModel(irf.Times, kernel, options)
+			if err != nil {
+				return nil, fmt.Errorf("degrees of freedom (%d, %d): %w", i, j, err)
+			}
+			models[i][j] = model
+		}
+	}
+	return models, nil
+}
+
+// fitPronyDecomposition fits a sum of order exponentials to uniformly spaced samples with Prony's method.
+// Unstable exponents are reflected into the left half plane and oscillations at the Nyquist frequency are discarded.
+func fitPronyDecomposition(times, values []float64, order int) (*PronyDecomposition, error) {
+	step := times[1] - times[0]
+	roots, err := pronyRoots(values, order)
+	if err != nil {
+		return nil, err
+	}
+
+	var exponents []complex128
+	for _, z := range roots {
+		if isRealExponent(z) && real(z) <= 0 {
+			continue
+		}
+		s := cmplx.Log(z) / complex(step, 0)
+		if real(s) >= 0 {
+			s = complex(-math.Abs(real(s))-1e-12/step, imag(s))
+		}
+		if isRealExponent(s) {
+			s = complex(real(s), 0)
+		}
+		exponents = append(exponents, s)
+	}
+	if len(exponents) == 0 {
+		return nil, errors.New("no valid exponent")
+	}
+
+	coefficients, err := fitCoefficients(times, values, exponents)
+	if err != nil {
+		return nil, err
+	}
+	return NewPronyDecomposition(coefficients, exponents), nil
+}
+
+// isRealExponent tells whether a root or exponent is real up to rounding errors
+func isRealExponent(s complex128) bool {
+	return math.Abs(imag(s)) <= 1e-10*cmplx.Abs(s)
+}
+
+func emptyStateSpaceModel() *StateSpaceModel {
+	return &StateSpaceModel{D: mat.NewDense(1, 1, nil)}
+}
+
+func floats2Norm(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v * v
+	}
+	return math.Sqrt(sum)
+}
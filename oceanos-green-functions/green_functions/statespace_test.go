@@ -0,0 +1,93 @@
+package green_functions
+
+import (
+	"gonum.org/v1/gonum/mat"
+	"math"
+	"testing"
+)
+
+func TestFitStateSpaceModel(t *testing.T) {
+	kernel := func(t float64) float64 { return math.Exp(-0.5*t)*math.Cos(2*t) + 0.5*math.Exp(-t) }
+	times := make([]float64, 200)
+	values := make([]float64, len(times))
+	for i := range times {
+		times[i] = 0.05 * float64(i)
+		values[i] = kernel(times[i])
+	}
+
+	model, err := FitStateSpaceModel(times, values, StateSpaceOptions{Tolerance: 1e-8})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if model.Order() != 3 {
+		t.Errorf("Expected order 3, got %d", model.Order())
+	}
+	if model.RelativeError > 1e-8 {
+		t.Errorf("Expected relative error below 1e-8, got %v", model.RelativeError)
+	}
+
+	// The impulse response of the realization C exp(At) B matches the kernel
+	for _, time := range []float64{0, 1.3, 4.2} {
+		var expAt mat.Dense
+		var scaled mat.Dense
+		scaled.Scale(time, model.A)
+		expAt.Exp(&scaled)
+		var response mat.Dense
+		response.Product(model.C, &expAt, model.B)
+		if math.Abs(response.At(0, 0)-kernel(time)) > 1e-6 {
+			t.Errorf("Impulse response at t=%v: expected %v, got %v", time, kernel(time), response.At(0, 0))
+		}
+		if math.Abs(model.ImpulseResponse(time)-kernel(time)) > 1e-6 {
+			t.Errorf("Prony impulse response at t=%v: expected %v, got %v", time, kernel(time), model.ImpulseResponse(time))
+		}
+	}
+
+	// The Laplace transform of the kernel on the imaginary axis
+	omega := 1.5
+	expected := complex(0.5, omega)/(complex(0.5, omega)*complex(0.5, omega)+4) + 0.5/complex(1, omega)
+	if h := model.FrequencyResponse(omega); math.Abs(real(h-expected)) > 1e-6 || math.Abs(imag(h-expected)) > 1e-6 {
+		t.Errorf("Frequency response: expected %v, got %v", expected, h)
+	}
+
+	if _, err := FitStateSpaceModel(times, values, StateSpaceOptions{Tolerance: 1e-8, MaxOrder: 1}); err == nil {
+		t.Error("Expected error when the tolerance cannot be reached")
+	}
+	if _, err := FitStateSpaceModel([]float64{0, 1, 3}, []float64{1, 2, 3}, StateSpaceOptions{}); err == nil {
+		t.Error("Expected error for non uniform times")
+	}
+
+	zero, err := FitStateSpaceModel(times, make([]float64, len(times)), StateSpaceOptions{})
+	if err != nil || zero.Order() != 0 || zero.ImpulseResponse(1) != 0 {
+		t.Errorf("Expected empty model for zero kernel, got order %d (%v)", zero.Order(), err)
+	}
+}
+
+func TestRadiationIRF_StateSpace(t *testing.T) {
+	omegas := make([]float64, 300)
+	for i := range omegas {
+		omegas[i] = 0.02 * float64(i+1)
+	}
+	results := syntheticResults(omegas, func(omega float64) float64 { return omega * omega * math.Exp(-omega*omega) },
+		func(float64) complex128 { return 0 })
+	irf, err := NewRadiationIRF(results, nil, IRFOptions{Duration: 15, TimeStep: 0.05})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	models, err := irf.StateSpace(StateSpaceOptions{Tolerance: 1e-2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	model := models[0][0]
+	if model.RelativeError > 1e-2 || model.Order() == 0 {
+		t.Fatalf("Unexpected model of order %d and error %v", model.Order(), model.RelativeError)
+	}
+
+	// The state-space model reproduces the damping, B(ω) = Re ∫ K(t) exp(-iωt) dt
+	for _, omega := range []float64{0.5, 1, 1.5} {
+		expected := omega * omega * math.Exp(-omega*omega)
+		if b := real(model.FrequencyResponse(omega)); math.Abs(b-expected) > 0.02 {
+			t.Errorf("Damping at ω=%v: expected %v, got %v", omega, expected, b)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+// Package green_functions - Frequency sweeps
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package green_functions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+)
+
+// FrequencyType represents the quantity used to define the frequencies of a sweep
+type FrequencyType string
+
+const (
+	AngularFrequency FrequencyType = "omega"
+	WavePeriod       FrequencyType = "period"
+	Wavenumber       FrequencyType = "wavenumber"
+)
+
+// Sweep defines a set of problems: every frequency is solved in every water depth, with all the wave directions
+type Sweep struct {
+	// Frequencies are angular frequencies, wave periods or wavenumbers depending on FrequencyType
+	Frequencies   []float64
+	FrequencyType FrequencyType
+
+	WaveDirections []float64
+
+	// WaterDepths defaults to a single infinite depth
+	WaterDepths []float64
+
+	// Workers is the number of frequencies solved in parallel, defaults to the number of CPUs
+	Workers int
+}
+
+// SweepResult is the result of one frequency and water depth of a sweep
+type SweepResult struct {
+	FrequencyIndex int
+	DepthIndex     int
+	Result         *FrequencyResult
+	Err            error
+}
+
+// sweepProblem is a single frequency and water depth of a sweep
+type sweepProblem struct {
+	frequencyIndex int
+	depthIndex     int
+	omega          float64
+	wavenumber     float64
+	waterDepth     float64
+}
+
+// waterDepths returns the water depths of the sweep, with the default value
+func (sw Sweep) waterDepths() []float64 {
+	if len(sw.WaterDepths) == 0 {
+		return []float64{math.Inf(1)}
+	}
+	return sw.WaterDepths
+}
+
+// problems converts the frequencies of the sweep into angular frequencies and wavenumbers for each water depth
+func (sw Sweep) problems() ([]sweepProblem, error) {
+	if len(sw.Frequencies) == 0 {
+		return nil, errors.New("sweep has no frequency")
+	}
+
+	var problems []sweepProblem
+	for d, depth := range sw.waterDepths() {
+		if !(depth > 0) {
+			return nil, fmt.Errorf("water depth must be positive, got %g", depth)
+		}
+		for f, value := range sw.Frequencies {
+			if !(value > 0) || math.IsInf(value, 1) {
+				return nil, fmt.Errorf("frequencies must be positive and finite, got %g", value)
+			}
+
+			var omega, k float64
+			switch sw.FrequencyType {
+			case AngularFrequency, "":
+				omega = value
+				k = real(ComputeWaveNumber(omega, depth))
+			case WavePeriod:
+				omega = 2 * math.Pi / value
+				k = real(ComputeWaveNumber(omega, depth))
+			case Wavenumber:
+				k = value
+				omega = math.Sqrt(Gravity * k * math.Tanh(k*depth))
+			default:
+				return nil, fmt.Errorf("unknown frequency type %q", sw.FrequencyType)
+			}
+
+			problems = append(problems, sweepProblem{
+				frequencyIndex: f,
+				depthIndex:     d,
+				omega:          omega,
+				wavenumber:     k,
+				waterDepth:     depth,
+			})
+		}
+	}
+	return problems, nil
+}
+
+// Sweep solves all the problems of a sweep in parallel and streams the results as they are computed.
+// The Rankine terms of the Green function, which do not depend on the frequency, are computed once per water depth,
+// in the cache of the solver if it has one, or in a cache local to the sweep.
+// The channel is closed when all the problems have been solved or the context is cancelled.
+func (s *BEMSolver) Sweep(ctx context.Context, body *FloatingBody, sweep Sweep) (<-chan SweepResult, error) {
+	if body == nil || body.Mesh == nil {
+		return nil, errors.New("body must have a mesh")
+	}
+	problems, err := sweep.problems()
+	if err != nil {
+		return nil, err
+	}
+
+	workers := sweep.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(problems) {
+		workers = len(problems)
+	}
+
+	// The Rankine terms are computed once per water depth and shared by the workers
+	solver := *s
+	if solver.Cache == nil {
+		solver.Cache = NewRankineCache()
+	}
+
+	jobs := make(chan sweepProblem)
+	results := make(chan SweepResult)
+
+	go func() {
+		defer close(jobs)
+		for _, problem := range problems {
+			select {
+			case jobs <- problem:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for problem := range jobs {
+				result, err := solver.solve(body, problem.omega, problem.wavenumber, problem.waterDepth, sweep.WaveDirections)
+				select {
+				case results <- SweepResult{
+					FrequencyIndex: problem.frequencyIndex,
+					DepthIndex:     problem.depthIndex,
+					Result:         result,
+					Err:            err,
+				}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results, nil
+}
+
+// SolveSweep solves all the problems of a sweep and returns the results indexed by water depth and frequency.
+// The first error stops the sweep.
+func (s *BEMSolver) SolveSweep(ctx context.Context, body *FloatingBody, sweep Sweep) ([][]*FrequencyResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := s.Sweep(ctx, body, sweep)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([][]*FrequencyResult, len(sweep.waterDepths()))
+	for d := range results {
+		results[d] = make([]*FrequencyResult, len(sweep.Frequencies))
+	}
+
+	for r := range stream {
+		if r.Err != nil {
+			cancel()
+			for range stream {
+				// Wait for the workers to stop
+			}
+			return nil, fmt.Errorf("frequency %d, water depth %d: %w", r.FrequencyIndex, r.DepthIndex, r.Err)
+		}
+		results[r.DepthIndex][r.FrequencyIndex] = r.Result
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
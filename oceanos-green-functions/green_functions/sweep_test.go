@@ -0,0 +1,114 @@
+package green_functions
+
+import (
+	"context"
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestSweep_Problems(t *testing.T) {
+	sweep := Sweep{Frequencies: []float64{2 * math.Pi}, FrequencyType: WavePeriod, WaterDepths: []float64{math.Inf(1), 10}}
+	problems, err := sweep.problems()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(problems) != 2 {
+		t.Fatalf("Expected 2 problems, got %d", len(problems))
+	}
+	if math.Abs(problems[0].omega-1) > 1e-12 || math.Abs(problems[0].wavenumber-1/Gravity) > 1e-12 {
+		t.Errorf("Unexpected deep water problem %+v", problems[0])
+	}
+	if problems[1].depthIndex != 1 || math.Abs(real(DispersionRelation(complex(problems[1].wavenumber, 0), 1, 10))) > 1e-9 {
+		t.Errorf("Unexpected finite depth problem %+v", problems[1])
+	}
+
+	sweep = Sweep{Frequencies: []float64{0.5}, FrequencyType: Wavenumber, WaterDepths: []float64{3}}
+	problems, _ = sweep.problems()
+	if math.Abs(real(DispersionRelation(complex(0.5, 0), problems[0].omega, 3))) > 1e-12 {
+		t.Errorf("Angular frequency %v does not match wavenumber 0.5", problems[0].omega)
+	}
+
+	invalid := []Sweep{
+		{},
+		{Frequencies: []float64{-1}},
+		{Frequencies: []float64{1}, WaterDepths: []float64{0}},
+		{Frequencies: []float64{1}, FrequencyType: "hertz"},
+	}
+	for i, sweep := range invalid {
+		if _, err := sweep.problems(); err == nil {
+			t.Errorf("Expected error for invalid sweep %d", i)
+		}
+	}
+}
+
+func TestBEMSolver_SolveSweep(t *testing.T) {
+	body := newTestHemisphere(t, 4, 8)
+	solver := NewBEMSolver(NewDefaultDelhommeau())
+	sweep := Sweep{
+		Frequencies:    []float64{1.5, 2.5, 3.5},
+		WaveDirections: []float64{0, math.Pi / 2},
+		Workers:        2,
+	}
+
+	results, err := solver.SolveSweep(context.Background(), body, sweep)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 1 || len(results[0]) != 3 {
+		t.Fatalf("Expected 1x3 results, got %dx%d", len(results), len(results[0]))
+	}
+
+	// Reusing the Rankine terms does not change the results
+	for f, omega := range sweep.Frequencies {
+		expected, err := solver.SolveFrequency(body, omega, math.Inf(1), sweep.WaveDirections)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		result := results[0][f]
+		if result.Omega != omega {
+			t.Errorf("Expected omega %v at index %d, got %v", omega, f, result.Omega)
+		}
+		if a, b := result.AddedMass.At(2, 2), expected.AddedMass.At(2, 2); math.Abs(a-b) > 1e-9*math.Abs(b) {
+			t.Errorf("Added mass %v differs from single frequency solve %v", a, b)
+		}
+		if a, b := result.ExcitationForce.At(1, 1), expected.ExcitationForce.At(1, 1); cmplx.Abs(a-b) > 1e-9*cmplx.Abs(b) {
+			t.Errorf("Excitation force %v differs from single frequency solve %v", a, b)
+		}
+	}
+}
+
+func TestBEMSolver_SweepStreaming(t *testing.T) {
+	body := newTestHemisphere(t, 3, 6)
+	solver := NewBEMSolver(NewDefaultDelhommeau())
+	sweep := Sweep{Frequencies: []float64{0.5, 1, 1.5, 2}, FrequencyType: Wavenumber}
+
+	stream, err := solver.Sweep(context.Background(), body, sweep)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	seen := make(map[int]bool)
+	for r := range stream {
+		if r.Err != nil {
+			t.Fatalf("Unexpected error: %v", r.Err)
+		}
+		if math.Abs(r.Result.Wavenumber-sweep.Frequencies[r.FrequencyIndex]) > 1e-12 {
+			t.Errorf("Result %d has wavenumber %v", r.FrequencyIndex, r.Result.Wavenumber)
+		}
+		seen[r.FrequencyIndex] = true
+	}
+	if len(seen) != len(sweep.Frequencies) {
+		t.Errorf("Expected %d results, got %d", len(sweep.Frequencies), len(seen))
+	}
+
+	// Cancelling the context stops the sweep and closes the stream
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, _ = solver.Sweep(ctx, body, sweep)
+	<-stream
+	cancel()
+	for range stream {
+	}
+	if _, err := solver.SolveSweep(ctx, body, sweep); err == nil {
+		t.Error("Expected error for cancelled context")
+	}
+}
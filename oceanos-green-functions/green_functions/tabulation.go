@@ -0,0 +1,758 @@
+// Package green_functions - Tabulation of the Green function integrals and its interpolation
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package green_functions
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"sync"
+)
+
+// InterpolationMethod represents the interpolation schemes of a tabulation
+type InterpolationMethod string
+
+const (
+	// BilinearInterpolation interpolates linearly in each direction
+	BilinearInterpolation InterpolationMethod = "bilinear"
+	// CubicInterpolation uses cubic Hermite polynomials in each direction, with slopes from finite differences,
+	// so that the interpolated function has continuous derivatives
+	CubicInterpolation InterpolationMethod = "cubic"
+	// LagrangeInterpolation uses Lagrange polynomials on LagrangePoints neighbouring nodes in each direction
+	LagrangeInterpolation InterpolationMethod = "lagrange"
+)
+
+// defaultLagrangePoints is the number of nodes of the Lagrange interpolation in each direction
+const defaultLagrangePoints = 4
+
+// AsymptoticFunction evaluates a tabulated function and its derivatives with respect to r and z
+type AsymptoticFunction func(r, z float64) (value, dr, dz complex128)
+
+// AsymptoticComponentsFunction evaluates all the components of a tabulation and their derivatives with respect to r and z
+type AsymptoticComponentsFunction func(r, z float64) (values, dr, dz []complex128)
+
+// defaultComponentName is the name of the single component of the tabulations created by NewTabulationCache
+const defaultComponentName = "value"
+
+// TabulationCache represents a cache for tabulated Green function values.
+// The ranges must be increasing and are not expected to change once the cache has been interpolated: they are
+// analysed on the first interpolation so that the nodes around a point are found in constant time when they are
+// uniformly spaced, or uniformly spaced in sign(x) log(1 + |x|/scale) as the scaled_nemoh3 grids.
+type TabulationCache struct {
+	RRange []float64
+	ZRange []float64
+	// Values holds the values of a single tabulated function at the nodes as Values[z][r].
+	// It is only used when Components is not set.
+	Values    [][]complex128
+	IsValid   bool
+	Precision FloatingPointPrecision
+
+	// ComponentNames are the names of the tabulated functions of Components
+	ComponentNames []string
+	// Components holds the values of each function at the nodes as Components[c][z][r],
+	// for tabulations of several functions instead of Values
+	Components [][][]complex128
+
+	// Method is the interpolation scheme, defaults to bilinear interpolation
+	Method InterpolationMethod
+	// LagrangePoints is the number of nodes of the Lagrange interpolation in each direction, defaults to 4
+	LagrangePoints int
+	// Asymptotic, when set, is evaluated outside of the tabulated range instead of returning an error
+	Asymptotic AsymptoticFunction
+	// AsymptoticComponents, when set, is evaluated by InterpolateComponents outside of the tabulated range
+	AsymptoticComponents AsymptoticComponentsFunction
+
+	setup        sync.Once
+	setupErr     error
+	rAxis, zAxis tabulationAxis
+}
+
+// NewTabulationCache creates a new tabulation cache of a single function, stored in Values
+func NewTabulationCache(rRange, zRange []float64, precision FloatingPointPrecision) *TabulationCache {
+	return &TabulationCache{
+		RRange:    rRange,
+		ZRange:    zRange,
+		Values:    newTabulationValues(rRange, zRange),
+		IsValid:   false,
+		Precision: precision,
+		Method:    BilinearInterpolation,
+	}
+}
+
+// NewTabulationCacheWithComponents creates a tabulation cache of several named functions on the same grid,
+// for instance a wave integral and its derivatives, interpolated together by InterpolateComponents
+func NewTabulationCacheWithComponents(rRange, zRange []float64, precision FloatingPointPrecision, names ...string) (*TabulationCache, error) {
+	if len(names) == 0 {
+		return nil, errors.New("a tabulation needs at least one component")
+	}
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if name == "" || seen[name] {
+			return nil, fmt.Errorf("invalid or duplicated component name %q", name)
+		}
+		seen[name] = true
+	}
+
+	components := make([][][]complex128, len(names))
+	for c := range components {
+		components[c] = newTabulationValues(rRange, zRange)
+	}
+
+	return &TabulationCache{
+		RRange:         rRange,
+		ZRange:         zRange,
+		IsValid:        false,
+		Precision:      precision,
+		ComponentNames: append([]string(nil), names...),
+		Components:     components,
+		Method:         BilinearInterpolation,
+	}, nil
+}
+
+// newTabulationValues allocates the values of a function at the nodes, as values[z][r]
+func newTabulationValues(rRange, zRange []float64) [][]complex128 {
+	values := make([][]complex128, len(zRange))
+	for i := range values {
+		values[i] = make([]complex128, len(rRange))
+	}
+	return values
+}
+
+// components returns the tabulated functions, Values being the single function of the tabulations without Components
+func (tc *TabulationCache) components() [][][]complex128 {
+	if tc.Components == nil {
+		return [][][]complex128{tc.Values}
+	}
+	return tc.Components
+}
+
+// componentNames returns the names of the functions returned by components
+func (tc *TabulationCache) componentNames() []string {
+	if tc.Components == nil {
+		return []string{defaultComponentName}
+	}
+	return tc.ComponentNames
+}
+
+// ComponentIndex returns the position of the named component in the results of InterpolateComponents, or -1.
+// The single function of Values is named "value".
+func (tc *TabulationCache) ComponentIndex(name string) int {
+	for c, n := range tc.componentNames() {
+		if n == name {
+			return c
+		}
+	}
+	return -1
+}
+
+// Component returns the values of the named component at the nodes, as Component(name)[z][r], or nil
+func (tc *TabulationCache) Component(name string) [][]complex128 {
+	if c := tc.ComponentIndex(name); c >= 0 {
+		return tc.components()[c]
+	}
+	return nil
+}
+
+// setUp checks the shape of the tabulated values and analyses the ranges, on the first interpolation
+func (tc *TabulationCache) setUp() error {
+	tc.setup.Do(func() {
+		components := tc.components()
+		if tc.Components != nil && (tc.Values != nil || len(tc.ComponentNames) != len(tc.Components)) {
+			tc.setupErr = errors.New("a tabulation holds either Values or Components with one name per component")
+			return
+		}
+		for _, component := range components {
+			if len(component) != len(tc.ZRange) {
+				tc.setupErr = fmt.Errorf("expected %d rows of tabulated values, got %d", len(tc.ZRange), len(component))
+				return
+			}
+			for _, row := range component {
+				if len(row) != len(tc.RRange) {
+					tc.setupErr = fmt.Errorf("expected %d tabulated values per row, got %d", len(tc.RRange), len(row))
+					return
+				}
+			}
+		}
+		tc.rAxis = newTabulationAxis(tc.RRange)
+		tc.zAxis = newTabulationAxis(tc.ZRange)
+	})
+	return tc.setupErr
+}
+
+// Interpolate interpolates the tabulated values at (r, z)
+func (tc *TabulationCache) Interpolate(r, z float64) (complex128, error) {
+	value, _, _, err := tc.InterpolateWithDerivatives(r, z)
+	return value, err
+}
+
+// InterpolateWithDerivatives interpolates the tabulated values at (r, z), together with the derivatives
+// of the interpolant with respect to r and z. Outside of the tabulated range, the asymptotic function is
+// evaluated if it is set.
+func (tc *TabulationCache) InterpolateWithDerivatives(r, z float64) (complex128, complex128, complex128, error) {
+	stencil, err := tc.stencil(r, z)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if stencil == nil {
+		if tc.Asymptotic != nil {
+			value, dr, dz := tc.Asymptotic(r, z)
+			return value, dr, dz, nil
+		}
+		return 0, 0, 0, &GreenFunctionEvaluationError{"Point outside tabulation range"}
+	}
+
+	value, dr, dz := stencil.apply(tc.components()[0])
+	return value, dr, dz, nil
+}
+
+// InterpolateComponents interpolates all the components at (r, z) with a single lookup of the neighbouring nodes,
+// together with their derivatives with respect to r and z, in the order of ComponentNames.
+// Outside of the tabulated range, AsymptoticComponents is evaluated if it is set.
+func (tc *TabulationCache) InterpolateComponents(r, z float64) ([]complex128, []complex128, []complex128, error) {
+	stencil, err := tc.stencil(r, z)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if stencil == nil {
+		if tc.AsymptoticComponents != nil {
+			values, dr, dz := tc.AsymptoticComponents(r, z)
+			return values, dr, dz, nil
+		}
+		return nil, nil, nil, &GreenFunctionEvaluationError{"Point outside tabulation range"}
+	}
+
+	components := tc.components()
+	n := len(components)
+	values, dr, dz := make([]complex128, n), make([]complex128, n), make([]complex128, n)
+	for c, component := range components {
+		values[c], dr[c], dz[c] = stencil.apply(component)
+	}
+	return values, dr, dz, nil
+}
+
+// interpolationStencil holds the weights of the nodes around a point, in r and z,
+// for the interpolated value and its derivative
+type interpolationStencil struct {
+	rStart, zStart int
+	wr, dwr        []float64
+	wz, dwz        []float64
+}
+
+// stencil returns the interpolation stencil at (r, z), or nil outside of the tabulated range
+func (tc *TabulationCache) stencil(r, z float64) (*interpolationStencil, error) {
+	if !tc.IsValid {
+		return nil, &GreenFunctionEvaluationError{"Tabulation cache is not valid"}
+	}
+	if err := tc.setUp(); err != nil {
+		return nil, err
+	}
+
+	rIdx := tc.rAxis.index(r)
+	zIdx := tc.zAxis.index(z)
+	if rIdx < 0 || zIdx < 0 {
+		return nil, nil
+	}
+
+	var s interpolationStencil
+	var err error
+	if s.rStart, s.wr, s.dwr, err = tc.weights(tc.RRange, rIdx, r); err != nil {
+		return nil, err
+	}
+	if s.zStart, s.wz, s.dwz, err = tc.weights(tc.ZRange, zIdx, z); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// apply interpolates the values at the nodes, values[z][r], and their derivatives with respect to r and z
+func (s *interpolationStencil) apply(values [][]complex128) (complex128, complex128, complex128) {
+	var value, dr, dz complex128
+	for j := range s.wz {
+		row := values[s.zStart+j]
+		var v, vr complex128
+		for i := range s.wr {
+			v += complex(s.wr[i], 0) * row[s.rStart+i]
+			vr += complex(s.dwr[i], 0) * row[s.rStart+i]
+		}
+		value += complex(s.wz[j], 0) * v
+		dr += complex(s.wz[j], 0) * vr
+		dz += complex(s.dwz[j], 0) * v
+	}
+	return value, dr, dz
+}
+
+// findIndex finds the index i such that arr[i] <= val <= arr[i+1] by bisection, or -1 outside of arr
+func (tc *TabulationCache) findIndex(arr []float64, val float64) int {
+	n := len(arr)
+	if n < 2 || !(val >= arr[0] && val <= arr[n-1]) {
+		return -1
+	}
+	return bisectIndex(arr, val)
+}
+
+// bisectIndex finds the index i such that nodes[i] <= x <= nodes[i+1] for x between the first and last nodes
+func bisectIndex(nodes []float64, x float64) int {
+	i := sort.SearchFloat64s(nodes, x) - 1
+	if i < 0 {
+		i = 0
+	}
+	return i
+}
+
+// weights returns the interpolation weights of the nodes nodes[start:start+len(w)] for the value at x,
+// and the weights of the derivative, where x is between nodes[i] and nodes[i+1]
+func (tc *TabulationCache) weights(nodes []float64, i int, x float64) (int, []float64, []float64, error) {
+	switch tc.Method {
+	case BilinearInterpolation, "":
+		h := nodes[i+1] - nodes[i]
+		t := (x - nodes[i]) / h
+		return i, []float64{1 - t, t}, []float64{-1 / h, 1 / h}, nil
+	case CubicInterpolation:
+		start, w, dw := cubicHermiteWeights(nodes, i, x)
+		return start, w, dw, nil
+	case LagrangeInterpolation:
+		points := tc.LagrangePoints
+		if points == 0 {
+			points = defaultLagrangePoints
+		}
+		if points < 2 {
+			return 0, nil, nil, fmt.Errorf("Lagrange interpolation needs at least 2 points, got %d", points)
+		}
+		if points > len(nodes) {
+			points = len(nodes)
+		}
+		// Stencil centred on the interval, shifted inside the grid near its ends
+		start := i - (points-2)/2
+		if start < 0 {
+			start = 0
+		}
+		if start+points > len(nodes) {
+			start = len(nodes) - points
+		}
+		w, dw := lagrangeWeights(nodes[start:start+points], x)
+		return start, w, dw, nil
+	default:
+		return 0, nil, nil, fmt.Errorf("unknown interpolation method %q", tc.Method)
+	}
+}
+
+// cubicHermiteWeights returns the weights of the cubic Hermite interpolation between nodes[i] and nodes[i+1],
+// with the slopes at the nodes from three points finite differences, or the secant at the ends of the grid
+func cubicHermiteWeights(nodes []float64, i int, x float64) (int, []float64, []float64) {
+	start := i - 1
+	if start < 0 {
+		start = 0
+	}
+	end := i + 2
+	if end > len(nodes)-1 {
+		end = len(nodes) - 1
+	}
+	n := end - start + 1
+	w := make([]float64, n)
+	dw := make([]float64, n)
+
+	// slope returns the weights of the finite difference slope at node k
+	slope := func(k int) []float64 {
+		s := make([]float64, n)
+		switch {
+		case k-1 >= start && k+1 <= end:
+			ha, hc := nodes[k]-nodes[k-1], nodes[k+1]-nodes[k]
+			s[k-1-start] = -hc / (ha * (ha + hc))
+			s[k-start] = (hc - ha) / (ha * hc)
+			s[k+1-start] = ha / (hc * (ha + hc))
+		case k+1 <= end:
+			h := nodes[k+1] - nodes[k]
+			s[k-start], s[k+1-start] = -1/h, 1/h
+		default:
+			h := nodes[k] - nodes[k-1]
+			s[k-1-start], s[k-start] = -1/h, 1/h
+		}
+		return s
+	}
+
+	h := nodes[i+1] - nodes[i]
+	t := (x - nodes[i]) / h
+	t2, t3 := t*t, t*t*t
+	h00, h10, h01, h11 := 2*t3-3*t2+1, t3-2*t2+t, -2*t3+3*t2, t3-t2
+	d00, d10, d01, d11 := 6*t2-6*t, 3*t2-4*t+1, -6*t2+6*t, 3*t2-2*t
+
+	left, right := slope(i), slope(i+1)
+	for k := range w {
+		w[k] = h*h10*left[k] + h*h11*right[k]
+		dw[k] = d10*left[k] + d11*right[k]
+	}
+	w[i-start] += h00
+	w[i+1-start] += h01
+	dw[i-start] += d00 / h
+	dw[i+1-start] += d01 / h
+	return start, w, dw
+}
+
+// lagrangeWeights returns the weights of the Lagrange polynomial through the nodes and of its derivative at x
+func lagrangeWeights(nodes []float64, x float64) ([]float64, []float64) {
+	n := len(nodes)
+	w := make([]float64, n)
+	dw := make([]float64, n)
+	for k := 0; k < n; k++ {
+		denominator := 1.0
+		for m := 0; m < n; m++ {
+			if m != k {
+				denominator *= nodes[k] - nodes[m]
+			}
+		}
+		product := 1.0
+		var derivative float64
+		for m := 0; m < n; m++ {
+			if m == k {
+				continue
+			}
+			// Derivative of the product, with the factor (x - nodes[m]) left out
+			partial := 1.0
+			for p := 0; p < n; p++ {
+				if p != k && p != m {
+					partial *= x - nodes[p]
+				}
+			}
+			derivative += partial
+			product *= x - nodes[m]
+		}
+		w[k] = product / denominator
+		dw[k] = derivative / denominator
+	}
+	return w, dw
+}
+
+// axisSpacing identifies how the nodes of a tabulation axis are spaced
+type axisSpacing int
+
+const (
+	irregularSpacing axisSpacing = iota
+	uniformSpacing
+	scaledSpacing
+)
+
+// tabulationAxis finds the interval containing a value among the nodes of an axis
+type tabulationAxis struct {
+	nodes   []float64
+	spacing axisSpacing
+	// The nodes are uniformly spaced by step in u(x) = x, or sign(x) log(1 + |x|/scale) with scaledSpacing
+	first, step, scale float64
+}
+
+// newTabulationAxis detects uniformly spaced and scaled nodes, for which the intervals are found in constant time
+func newTabulationAxis(nodes []float64) tabulationAxis {
+	axis := tabulationAxis{nodes: nodes}
+	n := len(nodes)
+	if n < 3 {
+		return axis
+	}
+
+	isUniform := func(u func(float64) float64) bool {
+		first, step := u(nodes[0]), (u(nodes[n-1])-u(nodes[0]))/float64(n-1)
+		if !(step > 0) {
+			return false
+		}
+		for i, x := range nodes {
+			if math.Abs(u(x)-first-float64(i)*step) > 1e-6*step {
+				return false
+			}
+		}
+		axis.first, axis.step = first, step
+		return true
+	}
+
+	if isUniform(func(x float64) float64 { return x }) {
+		axis.spacing = uniformSpacing
+		return axis
+	}
+
+	// Scaled grids start or end at zero: with u_k = k Δ, x_k = scale (exp(k Δ) - 1) so x_2/x_1 = exp(Δ) + 1
+	var x1, x2 float64
+	switch {
+	case nodes[0] == 0:
+		x1, x2 = nodes[1], nodes[2]
+	case nodes[n-1] == 0:
+		x1, x2 = nodes[n-2], nodes[n-3]
+	default:
+		return axis
+	}
+	if ratio := x2 / x1; ratio > 2 {
+		delta := math.Log(ratio - 1)
+		axis.scale = math.Abs(x1) / math.Expm1(delta)
+		if isUniform(axis.scaled) {
+			axis.spacing = scaledSpacing
+			return axis
+		}
+	}
+	axis.scale = 0
+	return axis
+}
+
+// scaled is the coordinate in which the nodes of scaled grids are uniformly spaced
+func (a tabulationAxis) scaled(x float64) float64 {
+	return math.Copysign(math.Log1p(math.Abs(x)/a.scale), x)
+}
+
+// index returns the index i such that nodes[i] <= x <= nodes[i+1], or -1 outside of the nodes
+func (a tabulationAxis) index(x float64) int {
+	n := len(a.nodes)
+	if n < 2 || !(x >= a.nodes[0] && x <= a.nodes[n-1]) {
+		return -1
+	}
+
+	var u float64
+	switch a.spacing {
+	case uniformSpacing:
+		u = x
+	case scaledSpacing:
+		u = a.scaled(x)
+	default:
+		return bisectIndex(a.nodes, x)
+	}
+
+	i := int(math.Floor((u - a.first) / a.step))
+	if i > n-2 {
+		i = n - 2
+	}
+	if i < 0 {
+		i = 0
+	}
+	// Correct the rounding errors of the transformation
+	for i > 0 && x < a.nodes[i] {
+		i--
+	}
+	for i < n-2 && x > a.nodes[i+1] {
+		i++
+	}
+	return i
+}
+
+// NewTabulationGrid returns the nodes in r and z of the tabulation of the Delhommeau integrals,
+// with r in [0, rmax] and z in [zmin, 0]. The legacy grid is uniformly spaced, while the scaled_nemoh3 grid
+// is refined close to zero, with nodes uniformly spaced in log(1 + |x|), so that both are found in constant time.
+func NewTabulationGrid(shape TabulationGridShape, nr int, rmax float64, nz int, zmin float64) ([]float64, []float64, error) {
+	if nr < 2 || nz < 2 || !(rmax > 0) || !(zmin < 0) {
+		return nil, nil, fmt.Errorf("invalid tabulation grid with %d points up to r=%g and %d points down to z=%g", nr, rmax, nz, zmin)
+	}
+
+	r := make([]float64, nr)
+	z := make([]float64, nz)
+	switch shape {
+	case Legacy:
+		for i := range r {
+			r[i] = rmax * float64(i) / float64(nr-1)
+		}
+		for j := range z {
+			z[j] = zmin * float64(nz-1-j) / float64(nz-1)
+		}
+	case ScaledNemoh3:
+		deltaR := math.Log1p(rmax) / float64(nr-1)
+		for i := range r {
+			r[i] = math.Expm1(float64(i) * deltaR)
+		}
+		deltaZ := math.Log1p(-zmin) / float64(nz-1)
+		for j := range z {
+			z[j] = -math.Expm1(float64(nz-1-j) * deltaZ)
+		}
+	default:
+		return nil, nil, fmt.Errorf("unknown tabulation grid shape %q", shape)
+	}
+	// Exact bounds despite rounding errors
+	r[nr-1], z[0] = rmax, zmin
+	return r, z, nil
+}
+
+// tabulationMagic starts the files of serialized tabulations, followed by tabulationFormatVersion
+const (
+	tabulationMagic         = "GFTB"
+	tabulationFormatVersion = 1
+)
+
+// maxTabulationSize bounds the number of values of a serialized tabulation, against corrupted headers
+const maxTabulationSize = 1 << 28
+
+// WriteTo serializes the tabulation with its grid, precision, interpolation settings and components, in little endian.
+// The values are stored in single precision for a Float32 tabulation. The asymptotic functions are not serialized.
+func (tc *TabulationCache) WriteTo(w io.Writer) (int64, error) {
+	if err := tc.setUp(); err != nil {
+		return 0, err
+	}
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	var err error
+	write := func(data interface{}) {
+		if err == nil {
+			err = binary.Write(bw, binary.LittleEndian, data)
+		}
+	}
+	writeString := func(s string) {
+		write(uint32(len(s)))
+		write([]byte(s))
+	}
+
+	write([]byte(tabulationMagic))
+	write(uint32(tabulationFormatVersion))
+	writeString(string(tc.Precision))
+	writeString(string(tc.Method))
+	write(uint32(tc.LagrangePoints))
+	write(tc.IsValid)
+	write(uint32(len(tc.RRange)))
+	write(uint32(len(tc.ZRange)))
+	components := tc.components()
+	write(uint32(len(components)))
+	for _, name := range tc.componentNames() {
+		writeString(name)
+	}
+	write(tc.RRange)
+	write(tc.ZRange)
+	for _, component := range components {
+		for _, row := range component {
+			if tc.Precision == Float32 {
+				data := make([]complex64, len(row))
+				for i, v := range row {
+					data[i] = complex64(v)
+				}
+				write(data)
+			} else {
+				write(row)
+			}
+		}
+	}
+
+	if err == nil {
+		err = bw.Flush()
+	}
+	return cw.n, err
+}
+
+// ReadTabulationCache deserializes a tabulation written by WriteTo
+func ReadTabulationCache(r io.Reader) (*TabulationCache, error) {
+	br := bufio.NewReader(r)
+	var err error
+	read := func(data interface{}) {
+		if err == nil {
+			err = binary.Read(br, binary.LittleEndian, data)
+		}
+	}
+	readString := func() string {
+		var n uint32
+		read(&n)
+		if err != nil || n > 1<<16 {
+			if err == nil {
+				err = fmt.Errorf("invalid string length %d", n)
+			}
+			return ""
+		}
+		b := make([]byte, n)
+		read(b)
+		return string(b)
+	}
+
+	magic := make([]byte, len(tabulationMagic))
+	var version uint32
+	read(magic)
+	read(&version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tabulation header: %w", err)
+	}
+	if string(magic) != tabulationMagic || version != tabulationFormatVersion {
+		return nil, fmt.Errorf("not a tabulation file of version %d", tabulationFormatVersion)
+	}
+
+	precision := FloatingPointPrecision(readString())
+	method := InterpolationMethod(readString())
+	var lagrangePoints, nr, nz, nc uint32
+	var valid bool
+	read(&lagrangePoints)
+	read(&valid)
+	read(&nr)
+	read(&nz)
+	read(&nc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tabulation header: %w", err)
+	}
+	if nc == 0 || uint64(nr)*uint64(nz)*uint64(nc) > maxTabulationSize {
+		return nil, fmt.Errorf("invalid tabulation of %d components on %dx%d nodes", nc, nz, nr)
+	}
+
+	names := make([]string, nc)
+	for c := range names {
+		names[c] = readString()
+	}
+	rRange, zRange := make([]float64, nr), make([]float64, nz)
+	read(rRange)
+	read(zRange)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tabulation grid: %w", err)
+	}
+
+	var tc *TabulationCache
+	if len(names) == 1 && names[0] == defaultComponentName {
+		tc = NewTabulationCache(rRange, zRange, precision)
+	} else if tc, err = NewTabulationCacheWithComponents(rRange, zRange, precision, names...); err != nil {
+		return nil, err
+	}
+	for _, component := range tc.components() {
+		for _, row := range component {
+			if precision == Float32 {
+				data := make([]complex64, len(row))
+				read(data)
+				for i, v := range data {
+					row[i] = complex128(v)
+				}
+			} else {
+				read(row)
+			}
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tabulation values: %w", err)
+	}
+
+	tc.Method = method
+	tc.LagrangePoints = int(lagrangePoints)
+	tc.IsValid = valid
+	return tc, nil
+}
+
+// Save writes the tabulation to a file
+func (tc *TabulationCache) Save(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := tc.WriteTo(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// LoadTabulationCache reads a tabulation saved to a file
+func LoadTabulationCache(path string) (*TabulationCache, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadTabulationCache(file)
+}
+
+// countingWriter counts the bytes written to w
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,349 @@
+package green_functions
+
+import (
+	"bytes"
+	"math"
+	"math/cmplx"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// tabulate fills a tabulation cache with the values of f at the nodes
+func tabulate(r, z []float64, f func(r, z float64) complex128) *TabulationCache {
+	tc := NewTabulationCache(r, z, Float64)
+	for j := range z {
+		for i := range r {
+			tc.Values[j][i] = f(r[i], z[j])
+		}
+	}
+	tc.IsValid = true
+	return tc
+}
+
+func TestNewTabulationGrid(t *testing.T) {
+	for _, shape := range []TabulationGridShape{Legacy, ScaledNemoh3} {
+		r, z, err := NewTabulationGrid(shape, 50, 100, 30, -251)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", shape, err)
+		}
+		if r[0] != 0 || r[49] != 100 || z[0] != -251 || z[29] != 0 {
+			t.Errorf("%s: unexpected bounds [%v, %v] and [%v, %v]", shape, r[0], r[49], z[0], z[29])
+		}
+		for i := 1; i < len(r); i++ {
+			if r[i] <= r[i-1] {
+				t.Fatalf("%s: r is not increasing at %d", shape, i)
+			}
+		}
+		for j := 1; j < len(z); j++ {
+			if z[j] <= z[j-1] {
+				t.Fatalf("%s: z is not increasing at %d", shape, j)
+			}
+		}
+
+		expected := uniformSpacing
+		if shape == ScaledNemoh3 {
+			expected = scaledSpacing
+			// Refined close to the free surface
+			if r[1]-r[0] > (r[49]-r[48])/10 || z[29]-z[28] > (z[1]-z[0])/10 {
+				t.Errorf("%s: the grid is not refined close to zero", shape)
+			}
+		}
+		tc := NewTabulationCache(r, z, Float64)
+		if err := tc.setUp(); err != nil {
+			t.Fatalf("%s: unexpected error: %v", shape, err)
+		}
+		if tc.rAxis.spacing != expected || tc.zAxis.spacing != expected {
+			t.Errorf("%s: expected spacing %v, got %v and %v", shape, expected, tc.rAxis.spacing, tc.zAxis.spacing)
+		}
+	}
+
+	if _, _, err := NewTabulationGrid("exotic", 50, 100, 30, -251); err == nil {
+		t.Error("Expected error for unknown grid shape")
+	}
+	if _, _, err := NewTabulationGrid(Legacy, 1, 100, 30, -251); err == nil {
+		t.Error("Expected error for a single node")
+	}
+}
+
+func TestTabulationAxis_Index(t *testing.T) {
+	r, z, _ := NewTabulationGrid(ScaledNemoh3, 200, 100, 100, -251)
+	irregular := []float64{-3, -2.5, -1, 0.2, 0.3, 4}
+	tc := NewTabulationCache(r, z, Float64)
+
+	for _, nodes := range [][]float64{r, z, irregular, {0, 0.5, 1, 1.5, 2}} {
+		axis := newTabulationAxis(nodes)
+		first, last := nodes[0], nodes[len(nodes)-1]
+		for k := -10; k <= 1010; k++ {
+			x := first + (last-first)*float64(k)/1000
+			i := axis.index(x)
+			if expected := tc.findIndex(nodes, x); expected < 0 {
+				if i != -1 {
+					t.Fatalf("Index of %v outside of the nodes: expected -1, got %d", x, i)
+				}
+			} else if i < 0 || i > len(nodes)-2 || x < nodes[i] || x > nodes[i+1] {
+				t.Fatalf("Index of %v: got %d", x, i)
+			}
+		}
+		// Nodes themselves
+		for i, x := range nodes {
+			if got := axis.index(x); got != i && got != i-1 {
+				t.Errorf("Index of node %d: got %d", i, got)
+			}
+		}
+	}
+}
+
+func TestTabulationCache_InterpolateWithDerivatives(t *testing.T) {
+	r, z, _ := NewTabulationGrid(ScaledNemoh3, 60, 10, 40, -10)
+	f := func(r, z float64) complex128 { return complex(math.Exp(z)*math.Cos(r), math.Exp(z)*math.Sin(r)) }
+	dfdr := func(r, z float64) complex128 { return complex(-math.Exp(z)*math.Sin(r), math.Exp(z)*math.Cos(r)) }
+	tc := tabulate(r, z, f)
+
+	testCases := []struct {
+		method                         InterpolationMethod
+		tolerance, derivativeTolerance float64
+	}{
+		{BilinearInterpolation, 1e-2, 2e-1},
+		{CubicInterpolation, 1e-3, 2e-2},
+		{LagrangeInterpolation, 3e-4, 1e-2},
+	}
+	for _, tc2 := range testCases {
+		tc.Method = tc2.method
+		var maxError, maxDerivativeError float64
+		for _, point := range [][2]float64{{0.05, -0.02}, {1.3, -0.7}, {4.2, -3.3}, {8.7, -0.1}} {
+			value, dr, dz, err := tc.InterpolateWithDerivatives(point[0], point[1])
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tc2.method, err)
+			}
+			expected := f(point[0], point[1])
+			maxError = math.Max(maxError, cmplx.Abs(value-expected))
+			maxDerivativeError = math.Max(maxDerivativeError, cmplx.Abs(dr-dfdr(point[0], point[1])))
+			maxDerivativeError = math.Max(maxDerivativeError, cmplx.Abs(dz-expected))
+		}
+		if maxError > tc2.tolerance || maxDerivativeError > tc2.derivativeTolerance {
+			t.Errorf("%s: errors %v on the values and %v on the derivatives", tc2.method, maxError, maxDerivativeError)
+		}
+	}
+
+	tc.Method = "spline"
+	if _, _, _, err := tc.InterpolateWithDerivatives(1, -1); err == nil {
+		t.Error("Expected error for unknown interpolation method")
+	}
+}
+
+func TestTabulationCache_InterpolatePolynomials(t *testing.T) {
+	r := []float64{0, 0.5, 1.5, 2, 3.5, 4}
+	z := []float64{-3, -2, -1.2, -0.5, 0}
+
+	// Lagrange polynomials of 4 points are exact for cubic polynomials on any grid
+	cubic := func(r, z float64) complex128 { return complex(r*r*r-2*r*z+z*z*z, r*z*z) }
+	tc := tabulate(r, z, cubic)
+	tc.Method = LagrangeInterpolation
+	for _, point := range [][2]float64{{0.2, -2.9}, {1.7, -1}, {3.9, -0.1}} {
+		if value, _ := tc.Interpolate(point[0], point[1]); cmplx.Abs(value-cubic(point[0], point[1])) > 1e-12 {
+			t.Errorf("Lagrange interpolation at %v: expected %v, got %v", point, cubic(point[0], point[1]), value)
+		}
+	}
+
+	// Cubic Hermite interpolation with finite difference slopes is exact for quadratic polynomials inside the grid
+	quadratic := func(r, z float64) complex128 { return complex(r*r-3*r*z+2*z*z, r) }
+	tc = tabulate(r, z, quadratic)
+	tc.Method = CubicInterpolation
+	for _, point := range [][2]float64{{1.7, -1}, {2.9, -1.9}} {
+		value, dr, dz, _ := tc.InterpolateWithDerivatives(point[0], point[1])
+		if cmplx.Abs(value-quadratic(point[0], point[1])) > 1e-12 {
+			t.Errorf("Cubic interpolation at %v: expected %v, got %v", point, quadratic(point[0], point[1]), value)
+		}
+		expectedDr := complex(2*point[0]-3*point[1], 1)
+		expectedDz := complex(-3*point[0]+4*point[1], 0)
+		if cmplx.Abs(dr-expectedDr) > 1e-12 || cmplx.Abs(dz-expectedDz) > 1e-12 {
+			t.Errorf("Cubic interpolation at %v: expected derivatives %v and %v, got %v and %v", point, expectedDr, expectedDz, dr, dz)
+		}
+	}
+}
+
+func TestTabulationCache_Asymptotic(t *testing.T) {
+	tc := tabulate([]float64{0, 1, 2}, []float64{-2, -1, 0}, func(r, z float64) complex128 { return 1 })
+	tc.Asymptotic = func(r, z float64) (complex128, complex128, complex128) {
+		return complex(1/r, 0), complex(-1/(r*r), 0), 0
+	}
+
+	value, dr, _, err := tc.InterpolateWithDerivatives(4, -1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if value != 0.25 || dr != -0.0625 {
+		t.Errorf("Expected the asymptotic function outside of the tabulation, got %v and %v", value, dr)
+	}
+	if value, _ := tc.Interpolate(1.5, -1.5); value != 1 {
+		t.Errorf("Expected the tabulated value inside the tabulation, got %v", value)
+	}
+}
+
+func TestNewTabulationCacheWithComponents(t *testing.T) {
+	r, z := []float64{0, 1, 2}, []float64{-2, -1, 0}
+	tc, err := NewTabulationCacheWithComponents(r, z, Float64, "wave", "wave_dr", "wave_dz")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(tc.Components) != 3 || tc.ComponentIndex("wave_dz") != 2 || tc.ComponentIndex("other") != -1 {
+		t.Errorf("Unexpected components %v", tc.ComponentNames)
+	}
+	tc.Component("wave_dr")[1][2] = 5
+	if tc.Components[1][1][2] != 5 || tc.Values != nil {
+		t.Error("Expected the components to be stored in Components only")
+	}
+	if tc.Component("other") != nil {
+		t.Error("Expected no values for an unknown component")
+	}
+
+	if _, err := NewTabulationCacheWithComponents(r, z, Float64); err == nil {
+		t.Error("Expected error without components")
+	}
+	if _, err := NewTabulationCacheWithComponents(r, z, Float64, "wave", "wave"); err == nil {
+		t.Error("Expected error for duplicated components")
+	}
+	single := NewTabulationCache(r, z, Float64)
+	if single.Components != nil || single.ComponentIndex(defaultComponentName) != 0 {
+		t.Errorf("Expected a single component in Values, got %v", single.ComponentNames)
+	}
+	single.Component(defaultComponentName)[1][2] = 5
+	if single.Values[1][2] != 5 {
+		t.Error("Expected Values to be the single component")
+	}
+}
+
+func TestTabulationCache_StructLiteral(t *testing.T) {
+	r, z := []float64{0, 1, 2}, []float64{-2, -1, 0}
+	values := tabulate(r, z, func(r, z float64) complex128 { return complex(r+z, 0) }).Values
+
+	tc := &TabulationCache{RRange: r, ZRange: z, Values: values, IsValid: true}
+	if value, err := tc.Interpolate(1.5, -0.5); err != nil || value != 1 {
+		t.Errorf("Expected 1, got %v (%v)", value, err)
+	}
+
+	both := &TabulationCache{RRange: r, ZRange: z, Values: values, IsValid: true,
+		ComponentNames: []string{"value"}, Components: [][][]complex128{values}}
+	if _, err := both.Interpolate(1.5, -0.5); err == nil {
+		t.Error("Expected error for a tabulation with both Values and Components")
+	}
+	misshapen := &TabulationCache{RRange: r, ZRange: z, Values: values[:2], IsValid: true}
+	if _, err := misshapen.Interpolate(1.5, -0.5); err == nil {
+		t.Error("Expected error for values not matching the grid")
+	}
+}
+
+func TestTabulationCache_InterpolateComponents(t *testing.T) {
+	r, z, _ := NewTabulationGrid(ScaledNemoh3, 40, 10, 30, -10)
+	functions := []func(r, z float64) complex128{
+		func(r, z float64) complex128 { return complex(math.Exp(z)*math.Cos(r), 0) },
+		func(r, z float64) complex128 { return complex(r*z, r+z) },
+	}
+	tc, _ := NewTabulationCacheWithComponents(r, z, Float64, "first", "second")
+	for c, f := range functions {
+		single := tabulate(r, z, f)
+		tc.Components[c] = single.Values
+	}
+	tc.IsValid = true
+	tc.Method = CubicInterpolation
+
+	values, dr, dz, err := tc.InterpolateComponents(2.3, -1.1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for c, f := range functions {
+		single := tabulate(r, z, f)
+		single.Method = CubicInterpolation
+		value, valueDr, valueDz, _ := single.InterpolateWithDerivatives(2.3, -1.1)
+		if values[c] != value || dr[c] != valueDr || dz[c] != valueDz {
+			t.Errorf("Component %d: expected (%v, %v, %v), got (%v, %v, %v)", c, value, valueDr, valueDz, values[c], dr[c], dz[c])
+		}
+	}
+
+	if _, _, _, err := tc.InterpolateComponents(20, -1); err == nil {
+		t.Error("Expected error outside of the tabulation without asymptotic function")
+	}
+	tc.AsymptoticComponents = func(r, z float64) ([]complex128, []complex128, []complex128) {
+		return []complex128{1, 2}, []complex128{0, 0}, []complex128{0, 0}
+	}
+	if values, _, _, err := tc.InterpolateComponents(20, -1); err != nil || values[1] != 2 {
+		t.Errorf("Expected the asymptotic components, got %v (%v)", values, err)
+	}
+}
+
+func TestTabulationCache_WriteTo(t *testing.T) {
+	r, z, _ := NewTabulationGrid(ScaledNemoh3, 20, 10, 15, -10)
+	f := func(r, z float64) complex128 { return complex(math.Exp(z)*math.Cos(r), math.Exp(z)*math.Sin(r)/3) }
+
+	for _, precision := range []FloatingPointPrecision{Float64, Float32} {
+		tc, _ := NewTabulationCacheWithComponents(r, z, precision, "value", "double")
+		for j := range z {
+			for i := range r {
+				tc.Components[0][j][i] = f(r[i], z[j])
+				tc.Components[1][j][i] = 2 * f(r[i], z[j])
+			}
+		}
+		tc.IsValid = true
+		tc.Method = LagrangeInterpolation
+		tc.LagrangePoints = 3
+
+		path := filepath.Join(t.TempDir(), "tabulation.bin")
+		if err := tc.Save(path); err != nil {
+			t.Fatalf("%s: unexpected error: %v", precision, err)
+		}
+		loaded, err := LoadTabulationCache(path)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", precision, err)
+		}
+
+		if loaded.Precision != precision || loaded.Method != LagrangeInterpolation || loaded.LagrangePoints != 3 || !loaded.IsValid {
+			t.Errorf("%s: settings not restored: %v, %v, %v, %v", precision, loaded.Precision, loaded.Method, loaded.LagrangePoints, loaded.IsValid)
+		}
+		if !reflect.DeepEqual(loaded.RRange, r) || !reflect.DeepEqual(loaded.ZRange, z) || !reflect.DeepEqual(loaded.ComponentNames, tc.ComponentNames) {
+			t.Errorf("%s: grid or component names not restored", precision)
+		}
+		if err := loaded.setUp(); err != nil || loaded.rAxis.spacing != scaledSpacing {
+			t.Errorf("%s: expected the scaled grid to be detected after loading", precision)
+		}
+
+		tolerance := 0.0
+		if precision == Float32 {
+			tolerance = 1e-6
+		}
+		for c := range tc.Components {
+			for j := range z {
+				for i := range r {
+					if cmplx.Abs(loaded.Components[c][j][i]-tc.Components[c][j][i]) > tolerance {
+						t.Fatalf("%s: value of component %d at (%d, %d) not restored", precision, c, j, i)
+					}
+				}
+			}
+		}
+		if loaded.Values != nil {
+			t.Errorf("%s: expected the components to be loaded in Components only", precision)
+		}
+	}
+
+	single := tabulate(r, z, f)
+	var buffer bytes.Buffer
+	if _, err := single.WriteTo(&buffer); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	loaded, err := ReadTabulationCache(&buffer)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if loaded.Components != nil || !reflect.DeepEqual(loaded.Values, single.Values) {
+		t.Error("Expected a single function to be loaded in Values")
+	}
+
+	if _, err := ReadTabulationCache(bytes.NewReader([]byte("not a tabulation"))); err == nil {
+		t.Error("Expected error for invalid data")
+	}
+	buffer.Reset()
+	NewTabulationCache([]float64{0, 1}, []float64{-1, 0}, Float64).WriteTo(&buffer)
+	if _, err := ReadTabulationCache(bytes.NewReader(buffer.Bytes()[:buffer.Len()-3])); err == nil {
+		t.Error("Expected error for truncated data")
+	}
+}
@@ -0,0 +1,442 @@
+// Package green_functions - Impulse response functions and Cummins equation coefficients
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package green_functions
+
+import (
+	"errors"
+	"fmt"
+	"gonum.org/v1/gonum/mat"
+	"math"
+	"sort"
+)
+
+// IntegrationMethod is the quadrature used to compute the Fourier transforms of sampled coefficients
+type IntegrationMethod string
+
+const (
+	// TrapezoidalIntegration applies the trapezoidal rule to the product of the coefficient and the oscillating term
+	TrapezoidalIntegration IntegrationMethod = "trapezoidal"
+	// FilonIntegration interpolates the coefficient linearly between samples and integrates the oscillating term exactly,
+	// which remains accurate for long times
+	FilonIntegration IntegrationMethod = "filon"
+)
+
+// WindowFunction is a taper applied to the coefficients before their Fourier transform,
+// to reduce the oscillations due to the truncation at the highest frequency
+type WindowFunction string
+
+const (
+	RectangularWindow WindowFunction = "rectangular"
+	HannWindow        WindowFunction = "hann"
+	LanczosWindow     WindowFunction = "lanczos"
+)
+
+// weight returns the window at x = ω/ω_max, between 0 and 1
+func (w WindowFunction) weight(x float64) (float64, error) {
+	switch w {
+	case RectangularWindow, "":
+		return 1, nil
+	case HannWindow:
+		return 0.5 * (1 + math.Cos(math.Pi*x)), nil
+	case LanczosWindow:
+		if x == 0 {
+			return 1, nil
+		}
+		return math.Sin(math.Pi*x) / (math.Pi * x), nil
+	default:
+		return 0, fmt.Errorf("unknown window function %q", w)
+	}
+}
+
+// IRFOptions configures the computation of impulse response functions
+type IRFOptions struct {
+	// Duration is the last time of the impulse response functions, defaults to π/Δω for the smallest frequency step Δω,
+	// beyond which the sampling of the frequencies aliases the response
+	Duration float64
+	// TimeStep defaults to π/(4 ω_max)
+	TimeStep float64
+
+	// Integration defaults to FilonIntegration
+	Integration IntegrationMethod
+	// Window defaults to RectangularWindow
+	Window WindowFunction
+}
+
+// times returns the time steps of the impulse response functions for the given sorted angular frequencies
+func (o IRFOptions) times(omegas []float64) ([]float64, error) {
+	duration, step := o.Duration, o.TimeStep
+	if duration == 0 {
+		minStep := math.Inf(1)
+		for i := 1; i < len(omegas); i++ {
+			minStep = math.Min(minStep, omegas[i]-omegas[i-1])
+		}
+		duration = math.Pi / minStep
+	}
+	if step == 0 {
+		step = math.Pi / (4 * omegas[len(omegas)-1])
+	}
+	if !(duration > 0) || math.IsInf(duration, 1) || !(step > 0) {
+		return nil, fmt.Errorf("duration and time step must be positive and finite, got %g and %g", duration, step)
+	}
+
+	n := int(math.Round(duration/step)) + 1
+	times := make([]float64, n)
+	for i := range times {
+		times[i] = float64(i) * step
+	}
+	return times, nil
+}
+
+// RadiationIRF is the radiation impulse response function, or retardation function, of the Cummins equation
+//
+//	K(t) = 2/π ∫ B(ω) cos(ωt) dω
+//
+// related to the frequency domain coefficients by Ogilvie's relations
+//
+//	A(ω) = A∞ - 1/ω ∫ K(t) sin(ωt) dt
+//	B(ω) = ∫ K(t) cos(ωt) dt
+type RadiationIRF struct {
+	Times []float64
+	// Kernel holds a (nDOFs, nDOFs) matrix for each time
+	Kernel []*mat.Dense
+	// InfiniteFrequencyAddedMass is A∞, used to reconstruct the added mass
+	InfiniteFrequencyAddedMass *mat.Dense
+
+	integration IntegrationMethod
+}
+
+// ExcitationIRF is the impulse response function of the excitation force to the wave elevation at the origin
+//
+//	K_e(t) = 1/π ∫ Re(F(ω) exp(-iωt)) dω
+//
+// It is not causal, and is computed for negative and positive times.
+type ExcitationIRF struct {
+	Times          []float64
+	WaveDirections []float64
+	// Kernel holds a (nDirections, nDOFs) matrix for each time
+	Kernel []*mat.Dense
+}
+
+// CumminsCoefficients holds the coefficients of the Cummins equation of the motion x of a body in waves of elevation η
+//
+//	(M + A∞) x'' + ∫ K(t-τ) x'(τ) dτ + C x = ∫ K_e(t-τ) η(τ) dτ
+type CumminsCoefficients struct {
+	Mass                       *mat.Dense
+	InfiniteFrequencyAddedMass *mat.Dense
+	HydrostaticStiffness       *mat.Dense
+	Radiation                  *RadiationIRF
+	// Excitation is nil when the results have no wave direction
+	Excitation *ExcitationIRF
+}
+
+// InfiniteFrequencyAddedMass computes the added mass of the body in the limit of an infinite wavenumber,
+// where the free surface is a surface of zero potential. In finite depth, the Green function must support this
+// limit, as Delhommeau does with the images of the source with respect to the free surface and the sea bottom.
+func (s *BEMSolver) InfiniteFrequencyAddedMass(body *FloatingBody, waterDepth float64) (*mat.Dense, error) {
+	// In this limit the potential is real and the added mass does not depend on the angular frequency
+	result, err := s.solve(body, 1, math.Inf(1), waterDepth, nil)
+	if err != nil {
+		return nil, err
+	}
+	return result.AddedMass, nil
+}
+
+// CumminsCoefficients computes the coefficients of the Cummins equation of a body from frequency domain results,
+// such as the results of a sweep at a single water depth
+func (s *BEMSolver) CumminsCoefficients(body *FloatingBody, results []*FrequencyResult, options IRFOptions) (*CumminsCoefficients, error) {
+	sorted, err := sortedResults(results)
+	if err != nil {
+		return nil, err
+	}
+
+	addedMass, err := s.InfiniteFrequencyAddedMass(body, sorted[0].WaterDepth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute the infinite frequency added mass: %w", err)
+	}
+
+	radiation, err := NewRadiationIRF(sorted, addedMass, options)
+	if err != nil {
+		return nil, err
+	}
+
+	coefficients := &CumminsCoefficients{
+		Mass:                       body.InertiaMatrix,
+		InfiniteFrequencyAddedMass: addedMass,
+		HydrostaticStiffness:       body.HydrostaticStiffness,
+		Radiation:                  radiation,
+	}
+	if len(sorted[0].WaveDirections) > 0 {
+		coefficients.Excitation, err = NewExcitationIRF(sorted, options)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return coefficients, nil
+}
+
+// NewRadiationIRF computes the radiation impulse response function from the radiation damping of frequency results.
+// The damping is extended to zero at zero frequency. infiniteFrequencyAddedMass may be nil.
+func NewRadiationIRF(results []*FrequencyResult, infiniteFrequencyAddedMass *mat.Dense, options IRFOptions) (*RadiationIRF, error) {
+	sorted, err := sortedResults(results)
+	if err != nil {
+		return nil, err
+	}
+	nDOFs, _ := sorted[0].RadiationDamping.Dims()
+
+	omegas := make([]float64, len(sorted)+1)
+	for i, result := range sorted {
+		omegas[i+1] = result.Omega
+	}
+	times, err := options.times(omegas)
+	if err != nil {
+		return nil, err
+	}
+	windows, err := windowWeights(omegas, options.Window)
+	if err != nil {
+		return nil, err
+	}
+
+	irf := &RadiationIRF{
+		Times:                      times,
+		Kernel:                     make([]*mat.Dense, len(times)),
+		InfiniteFrequencyAddedMass: infiniteFrequencyAddedMass,
+		integration:                options.Integration,
+	}
+	for t := range times {
+		irf.Kernel[t] = mat.NewDense(nDOFs, nDOFs, nil)
+	}
+
+	damping := make([]float64, len(omegas))
+	for i := 0; i < nDOFs; i++ {
+		for j := 0; j < nDOFs; j++ {
+			for f, result := range sorted {
+				damping[f+1] = result.RadiationDamping.At(i, j) * windows[f+1]
+			}
+			for t, time := range times {
+				value, err := fourierIntegral(omegas, damping, time, false, options.Integration)
+				if err != nil {
+					return nil, err
+				}
+				irf.Kernel[t].Set(i, j, 2/math.Pi*value)
+			}
+		}
+	}
+	return irf, nil
+}
+
+// Component returns the impulse response function of the force on the degree of freedom i
+// due to the velocity of the degree of freedom j
+func (irf *RadiationIRF) Component(i, j int) []float64 {
+	values := make([]float64, len(irf.Times))
+	for t, kernel := range irf.Kernel {
+		values[t] = kernel.At(i, j)
+	}
+	return values
+}
+
+// RadiationDamping reconstructs the radiation damping at the angular frequency omega with Ogilvie's relation
+func (irf *RadiationIRF) RadiationDamping(omega float64) (*mat.Dense, error) {
+	return irf.transform(omega, false, 1)
+}
+
+// AddedMass reconstructs the added mass at the angular frequency omega with Ogilvie's relation
+func (irf *RadiationIRF) AddedMass(omega float64) (*mat.Dense, error) {
+	if irf.InfiniteFrequencyAddedMass == nil {
+		return nil, errors.New("infinite frequency added mass is required to reconstruct the added mass")
+	}
+	if !(omega > 0) {
+		return nil, fmt.Errorf("angular frequency must be positive, got %g", omega)
+	}
+	addedMass, err := irf.transform(omega, true, -1/omega)
+	if err != nil {
+		return nil, err
+	}
+	addedMass.Add(addedMass, irf.InfiniteFrequencyAddedMass)
+	return addedMass, nil
+}
+
+// transform computes factor * ∫ K(t) cos(ωt) dt, or factor * ∫ K(t) sin(ωt) dt when sine is true,
+// for each degree of freedom pair
+func (irf *RadiationIRF) transform(omega float64, sine bool, factor float64) (*mat.Dense, error) {
+	nDOFs, _ := irf.Kernel[0].Dims()
+	result := mat.NewDense(nDOFs, nDOFs, nil)
+	for i := 0; i < nDOFs; i++ {
+		for j := 0; j < nDOFs; j++ {
+			value, err := fourierIntegral(irf.Times, irf.Component(i, j), omega, sine, irf.integration)
+			if err != nil {
+				return nil, err
+			}
+			result.Set(i, j, factor*value)
+		}
+	}
+	return result, nil
+}
+
+// NewExcitationIRF computes the excitation impulse response function from the excitation forces of frequency results,
+// on times symmetric around zero
+func NewExcitationIRF(results []*FrequencyResult, options IRFOptions) (*ExcitationIRF, error) {
+	sorted, err := sortedResults(results)
+	if err != nil {
+		return nil, err
+	}
+	directions := sorted[0].WaveDirections
+	if len(directions) == 0 {
+		return nil, errors.New("results have no wave direction")
+	}
+	for _, result := range sorted {
+		if len(result.WaveDirections) != len(directions) {
+			return nil, errors.New("all the results must have the same wave directions")
+		}
+		for d, beta := range result.WaveDirections {
+			if beta != directions[d] {
+				return nil, errors.New("all the results must have the same wave directions")
+			}
+		}
+	}
+	_, nDOFs := sorted[0].ExcitationForce.Dims()
+
+	omegas := make([]float64, len(sorted))
+	for i, result := range sorted {
+		omegas[i] = result.Omega
+	}
+	positiveTimes, err := options.times(omegas)
+	if err != nil {
+		return nil, err
+	}
+	windows, err := windowWeights(omegas, options.Window)
+	if err != nil {
+		return nil, err
+	}
+
+	nTimes := len(positiveTimes)
+	times := make([]float64, 2*nTimes-1)
+	for i, time := range positiveTimes {
+		times[nTimes-1-i] = -time
+		times[nTimes-1+i] = time
+	}
+
+	irf := &ExcitationIRF{
+		Times:          times,
+		WaveDirections: directions,
+		Kernel:         make([]*mat.Dense, len(times)),
+	}
+	for t := range times {
+		irf.Kernel[t] = mat.NewDense(len(directions), nDOFs, nil)
+	}
+
+	// Re(F exp(-iωt)) = Re(F) cos(ωt) + Im(F) sin(ωt)
+	realPart := make([]float64, len(omegas))
+	imagPart := make([]float64, len(omegas))
+	for d := range directions {
+		for j := 0; j < nDOFs; j++ {
+			for f, result := range sorted {
+				force := result.ExcitationForce.At(d, j)
+				realPart[f] = real(force) * windows[f]
+				imagPart[f] = imag(force) * windows[f]
+			}
+			for t, time := range times {
+				cosine, err := fourierIntegral(omegas, realPart, time, false, options.Integration)
+				if err != nil {
+					return nil, err
+				}
+				sine, err := fourierIntegral(omegas, imagPart, time, true, options.Integration)
+				if err != nil {
+					return nil, err
+				}
+				irf.Kernel[t].Set(d, j, (cosine+sine)/math.Pi)
+			}
+		}
+	}
+	return irf, nil
+}
+
+// Component returns the impulse response function of the force on the degree of freedom j
+// in waves of direction index d
+func (irf *ExcitationIRF) Component(d, j int) []float64 {
+	values := make([]float64, len(irf.Times))
+	for t, kernel := range irf.Kernel {
+		values[t] = kernel.At(d, j)
+	}
+	return values
+}
+
+// sortedResults checks that the results are consistent and sorts them by angular frequency
+func sortedResults(results []*FrequencyResult) ([]*FrequencyResult, error) {
+	if len(results) < 2 {
+		return nil, errors.New("at least two frequencies are required")
+	}
+	sorted := make([]*FrequencyResult, len(results))
+	copy(sorted, results)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Omega < sorted[j].Omega })
+
+	nDOFs, _ := sorted[0].RadiationDamping.Dims()
+	for i, result := range sorted {
+		if !(result.Omega > 0) || math.IsInf(result.Omega, 1) {
+			return nil, fmt.Errorf("angular frequencies must be positive and finite, got %g", result.Omega)
+		}
+		if i > 0 && result.Omega == sorted[i-1].Omega {
+			return nil, fmt.Errorf("angular frequency %g appears twice", result.Omega)
+		}
+		if result.WaterDepth != sorted[0].WaterDepth {
+			return nil, errors.New("all the results must have the same water depth")
+		}
+		if n, _ := result.RadiationDamping.Dims(); n != nDOFs {
+			return nil, errors.New("all the results must have the same degrees of freedom")
+		}
+	}
+	return sorted, nil
+}
+
+// windowWeights evaluates a window function at sorted angular frequencies
+func windowWeights(omegas []float64, window WindowFunction) ([]float64, error) {
+	omegaMax := omegas[len(omegas)-1]
+	weights := make([]float64, len(omegas))
+	for i, omega := range omegas {
+		w, err := window.weight(omega / omegaMax)
+		if err != nil {
+			return nil, err
+		}
+		weights[i] = w
+	}
+	return weights, nil
+}
+
+// fourierIntegral computes ∫ f(x) cos(xt) dx, or ∫ f(x) sin(xt) dx when sine is true,
+// for samples f of a function at sorted abscissae x
+func fourierIntegral(x, f []float64, t float64, sine bool, method IntegrationMethod) (float64, error) {
+	oscillation := math.Cos
+	if sine {
+		oscillation = math.Sin
+	}
+	var integral float64
+	for i := 1; i < len(x); i++ {
+		a, b := x[i-1], x[i]
+		fa, fb := f[i-1], f[i]
+		h := b - a
+
+		switch method {
+		case TrapezoidalIntegration:
+			integral += h / 2 * (fa*oscillation(a*t) + fb*oscillation(b*t))
+		case FilonIntegration, "":
+			if math.Abs(h*t) < 1e-3 {
+				// The exact integration cancels out for slow oscillations, for which the trapezoidal rule is accurate
+				integral += h / 2 * (fa*oscillation(a*t) + fb*oscillation(b*t))
+				continue
+			}
+			// f is linear on [a, b]: f(x) = fa + slope (x - a)
+			slope := (fb - fa) / h
+			sa, sb := math.Sin(a*t), math.Sin(b*t)
+			ca, cb := math.Cos(a*t), math.Cos(b*t)
+			if sine {
+				integral += (fa-slope*a)*(ca-cb)/t + slope*((sb-sa)/(t*t)-(b*cb-a*ca)/t)
+			} else {
+				integral += (fa-slope*a)*(sb-sa)/t + slope*((cb-ca)/(t*t)+(b*sb-a*sa)/t)
+			}
+		default:
+			return 0, fmt.Errorf("unknown integration method %q", method)
+		}
+	}
+	return integral, nil
+}
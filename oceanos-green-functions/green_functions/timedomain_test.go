@@ -0,0 +1,265 @@
+package green_functions
+
+import (
+	"context"
+	"gonum.org/v1/gonum/mat"
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+// syntheticResults builds single degree of freedom results with the given damping and excitation force
+func syntheticResults(omegas []float64, damping func(float64) float64, excitation func(float64) complex128) []*FrequencyResult {
+	results := make([]*FrequencyResult, len(omegas))
+	for i, omega := range omegas {
+		results[i] = &FrequencyResult{
+			Omega:            omega,
+			WaterDepth:       math.Inf(1),
+			WaveDirections:   []float64{0},
+			AddedMass:        mat.NewDense(1, 1, nil),
+			RadiationDamping: mat.NewDense(1, 1, []float64{damping(omega)}),
+			ExcitationForce:  mat.NewCDense(1, 1, []complex128{excitation(omega)}),
+		}
+	}
+	return results
+}
+
+func TestFourierIntegral(t *testing.T) {
+	// ∫ x cos(3x) dx and ∫ x sin(3x) dx over [0, 2]
+	expectedCos := (math.Cos(6)-1)/9 + 2*math.Sin(6)/3
+	expectedSin := math.Sin(6)/9 - 2*math.Cos(6)/3
+
+	coarse := []float64{0, 1, 2}
+	value, err := fourierIntegral(coarse, coarse, 3, false, FilonIntegration)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if math.Abs(value-expectedCos) > 1e-12 {
+		t.Errorf("Filon cosine integral: expected %v, got %v", expectedCos, value)
+	}
+	if value, _ := fourierIntegral(coarse, coarse, 3, true, FilonIntegration); math.Abs(value-expectedSin) > 1e-12 {
+		t.Errorf("Filon sine integral: expected %v, got %v", expectedSin, value)
+	}
+
+	fine := make([]float64, 2001)
+	for i := range fine {
+		fine[i] = float64(i) / 1000
+	}
+	if value, _ := fourierIntegral(fine, fine, 3, false, TrapezoidalIntegration); math.Abs(value-expectedCos) > 1e-5 {
+		t.Errorf("Trapezoidal cosine integral: expected %v, got %v", expectedCos, value)
+	}
+
+	if _, err := fourierIntegral(coarse, coarse, 3, false, "simpson"); err == nil {
+		t.Error("Expected error for unknown integration method")
+	}
+}
+
+func TestWindowFunction(t *testing.T) {
+	for _, window := range []WindowFunction{RectangularWindow, HannWindow, LanczosWindow} {
+		if w, err := window.weight(0); err != nil || w != 1 {
+			t.Errorf("Window %s at zero frequency: expected 1, got %v (%v)", window, w, err)
+		}
+	}
+	if w, _ := HannWindow.weight(1); math.Abs(w) > 1e-15 {
+		t.Errorf("Hann window at maximum frequency: expected 0, got %v", w)
+	}
+	if w, _ := LanczosWindow.weight(0.5); math.Abs(w-2/math.Pi) > 1e-15 {
+		t.Errorf("Lanczos window at half frequency: expected 2/π, got %v", w)
+	}
+	if _, err := WindowFunction("hamming").weight(0.5); err == nil {
+		t.Error("Expected error for unknown window")
+	}
+}
+
+func TestNewRadiationIRF(t *testing.T) {
+	omegas := make([]float64, 300)
+	for i := range omegas {
+		omegas[i] = 0.02 * float64(i+1)
+	}
+	// B(ω) = ω² exp(-ω²) has the impulse response K(t) = exp(-t²/4) (1/2 - t²/4) / √π
+	damping := func(omega float64) float64 { return omega * omega * math.Exp(-omega*omega) }
+	results := syntheticResults(omegas, damping, func(float64) complex128 { return 0 })
+
+	for _, integration := range []IntegrationMethod{FilonIntegration, TrapezoidalIntegration} {
+		irf, err := NewRadiationIRF(results, mat.NewDense(1, 1, []float64{2}), IRFOptions{Duration: 20, TimeStep: 0.05, Integration: integration})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(irf.Times) != 401 || irf.Times[400] != 20 {
+			t.Fatalf("Unexpected times from %v to %v", irf.Times[0], irf.Times[len(irf.Times)-1])
+		}
+
+		kernel := irf.Component(0, 0)
+		for _, i := range []int{0, 20, 40, 100} {
+			time := irf.Times[i]
+			expected := math.Exp(-time*time/4) * (0.5 - time*time/4) / math.Sqrt(math.Pi)
+			if math.Abs(kernel[i]-expected) > 1e-4 {
+				t.Errorf("%s: K(%v) expected %v, got %v", integration, time, expected, kernel[i])
+			}
+		}
+
+		// Ogilvie's relations give back the damping, and the added mass tends to A∞ - 2/(πω²) ∫ B dω
+		// with ∫ B dω = √π/4
+		b, err := irf.RadiationDamping(1)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if math.Abs(b.At(0, 0)-damping(1)) > 5e-4 {
+			t.Errorf("%s: reconstructed damping expected %v, got %v", integration, damping(1), b.At(0, 0))
+		}
+		a, err := irf.AddedMass(10)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expected := 2 - 2/(math.Pi*100)*math.Sqrt(math.Pi)/4
+		if math.Abs(a.At(0, 0)-expected) > 2e-4 {
+			t.Errorf("%s: added mass at high frequency expected %v, got %v", integration, expected, a.At(0, 0))
+		}
+	}
+
+	if _, err := NewRadiationIRF(results[:1], nil, IRFOptions{}); err == nil {
+		t.Error("Expected error for a single frequency")
+	}
+	if _, err := NewRadiationIRF(results, nil, IRFOptions{Window: "hamming"}); err == nil {
+		t.Error("Expected error for unknown window")
+	}
+	irf, _ := NewRadiationIRF(results, nil, IRFOptions{})
+	if _, err := irf.AddedMass(1); err == nil {
+		t.Error("Expected error without infinite frequency added mass")
+	}
+}
+
+func TestNewExcitationIRF(t *testing.T) {
+	omegas := make([]float64, 300)
+	for i := range omegas {
+		omegas[i] = 0.02 * float64(i)
+	}
+	omegas[0] = 1e-6
+	// F(ω) = exp(-ω²) exp(iωτ) has the impulse response K_e(t) = exp(-(t-τ)²/4) / (2√π)
+	delay := 2.0
+	excitation := func(omega float64) complex128 {
+		return complex(math.Exp(-omega*omega), 0) * cmplx.Exp(complex(0, omega*delay))
+	}
+	results := syntheticResults(omegas, func(float64) float64 { return 0 }, excitation)
+
+	irf, err := NewExcitationIRF(results, IRFOptions{Duration: 10, TimeStep: 0.1})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(irf.Times) != 201 || irf.Times[0] != -10 || irf.Times[100] != 0 {
+		t.Fatalf("Unexpected times from %v to %v", irf.Times[0], irf.Times[len(irf.Times)-1])
+	}
+
+	kernel := irf.Component(0, 0)
+	for _, i := range []int{80, 100, 120, 140} {
+		time := irf.Times[i]
+		expected := math.Exp(-(time-delay)*(time-delay)/4) / (2 * math.Sqrt(math.Pi))
+		if math.Abs(kernel[i]-expected) > 1e-3 {
+			t.Errorf("K_e(%v) expected %v, got %v", time, expected, kernel[i])
+		}
+	}
+
+	for _, result := range results {
+		result.WaveDirections = nil
+	}
+	if _, err := NewExcitationIRF(results, IRFOptions{}); err == nil {
+		t.Error("Expected error without wave directions")
+	}
+}
+
+func TestBEMSolver_InfiniteFrequencyAddedMass(t *testing.T) {
+	body := newTestHemisphere(t, 6, 12)
+	volume := 2 * math.Pi / 3
+
+	solver := NewBEMSolver(NewDefaultDelhommeau())
+	addedMass, err := solver.InfiniteFrequencyAddedMass(body, math.Inf(1))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Heave added mass of a hemisphere at infinite frequency is ρV/2
+	deep := addedMass.At(2, 2) / (WaterDensity * volume)
+	if math.Abs(deep-0.5) > 0.05 {
+		t.Errorf("Expected normalized heave added mass close to 0.5, got %v", deep)
+	}
+
+	// In finite depth, the sea bottom is negligible far from the body and increases the heave added mass close to it
+	for _, tc := range []struct {
+		depth float64
+		check func(a33 float64) bool
+	}{
+		{100, func(a33 float64) bool { return math.Abs(a33-deep) < 1e-3*deep }},
+		{1.5, func(a33 float64) bool { return a33 > 1.1*deep }},
+	} {
+		addedMass, err := solver.InfiniteFrequencyAddedMass(body, tc.depth)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if a33 := addedMass.At(2, 2) / (WaterDensity * volume); !tc.check(a33) {
+			t.Errorf("Unexpected normalized heave added mass %v in a depth of %g m, %v in deep water", a33, tc.depth, deep)
+		}
+	}
+}
+
+func TestBEMSolver_CumminsCoefficients(t *testing.T) {
+	body := newTestHemisphere(t, 3, 6)
+	solver := NewBEMSolver(NewDefaultDelhommeau())
+
+	frequencies := make([]float64, 12)
+	for i := range frequencies {
+		frequencies[i] = 0.5 * float64(i+1)
+	}
+	sweep := Sweep{Frequencies: frequencies, WaveDirections: []float64{0}}
+	results, err := solver.SolveSweep(context.Background(), body, sweep)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	coefficients, err := solver.CumminsCoefficients(body, results[0], IRFOptions{Duration: 10})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if coefficients.Excitation == nil || len(coefficients.Excitation.WaveDirections) != 1 {
+		t.Fatal("Expected excitation impulse response for one wave direction")
+	}
+	if n, _ := coefficients.InfiniteFrequencyAddedMass.Dims(); n != body.NbDOFs() {
+		t.Errorf("Expected %d degrees of freedom, got %d", body.NbDOFs(), n)
+	}
+
+	// The heave retardation function starts from a positive value and decays
+	heave := coefficients.Radiation.Component(2, 2)
+	if heave[0] <= 0 || math.Abs(heave[len(heave)-1]) > 0.1*heave[0] {
+		t.Errorf("Unexpected heave retardation function from %v to %v", heave[0], heave[len(heave)-1])
+	}
+}
+
+func TestBEMSolver_CumminsCoefficients_FiniteDepth(t *testing.T) {
+	body := newTestHemisphere(t, 3, 6)
+	solver := NewBEMSolver(NewDefaultDelhommeau())
+
+	frequencies := make([]float64, 12)
+	for i := range frequencies {
+		frequencies[i] = 0.5 * float64(i+1)
+	}
+	sweep := Sweep{Frequencies: frequencies, WaterDepths: []float64{3}}
+	results, err := solver.SolveSweep(context.Background(), body, sweep)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	coefficients, err := solver.CumminsCoefficients(body, results[0], IRFOptions{Duration: 10})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	deep, err := solver.InfiniteFrequencyAddedMass(body, math.Inf(1))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if a33 := coefficients.InfiniteFrequencyAddedMass.At(2, 2); !(a33 > deep.At(2, 2)) {
+		t.Errorf("Expected a heave added mass larger than %v in deep water, got %v", deep.At(2, 2), a33)
+	}
+
+	heave := coefficients.Radiation.Component(2, 2)
+	if heave[0] <= 0 || math.Abs(heave[len(heave)-1]) > 0.1*heave[0] {
+		t.Errorf("Unexpected heave retardation function from %v to %v", heave[0], heave[len(heave)-1])
+	}
+}
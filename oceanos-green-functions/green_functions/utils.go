@@ -133,79 +133,6 @@ const (
 	LowFrequencyWithRankine
 )
 
-// TabulationCache represents a cache for tabulated Green function values
-type TabulationCache struct {
-	RRange    []float64
-	ZRange    []float64
-	Values    [][]complex128
-	IsValid   bool
-	Precision FloatingPointPrecision
-}
-
-// NewTabulationCache creates a new tabulation cache
-func NewTabulationCache(rRange, zRange []float64, precision FloatingPointPrecision) *TabulationCache {
-	rows := len(zRange)
-	cols := len(rRange)
-	values := make([][]complex128, rows)
-	for i := range values {
-		values[i] = make([]complex128, cols)
-	}
-
-	return &TabulationCache{
-		RRange:    rRange,
-		ZRange:    zRange,
-		Values:    values,
-		IsValid:   false,
-		Precision: precision,
-	}
-}
-
-// Interpolate performs bilinear interpolation in the tabulation cache
-func (tc *TabulationCache) Interpolate(r, z float64) (complex128, error) {
-	if !tc.IsValid {
-		return 0, &GreenFunctionEvaluationError{"Tabulation cache is not valid"}
-	}
-
-	// Find indices for interpolation
-	rIdx := tc.findIndex(tc.RRange, r)
-	zIdx := tc.findIndex(tc.ZRange, z)
-
-	if rIdx < 0 || rIdx >= len(tc.RRange)-1 || zIdx < 0 || zIdx >= len(tc.ZRange)-1 {
-		return 0, &GreenFunctionEvaluationError{"Point outside tabulation range"}
-	}
-
-	// Bilinear interpolation
-	r1, r2 := tc.RRange[rIdx], tc.RRange[rIdx+1]
-	z1, z2 := tc.ZRange[zIdx], tc.ZRange[zIdx+1]
-
-	fr := (r - r1) / (r2 - r1)
-	fz := (z - z1) / (z2 - z1)
-
-	v11 := tc.Values[zIdx][rIdx]
-	v12 := tc.Values[zIdx+1][rIdx]
-	v21 := tc.Values[zIdx][rIdx+1]
-	v22 := tc.Values[zIdx+1][rIdx+1]
-
-	// Interpolate in r direction with proper type conversion
-	v1 := v11*complex(1-fr, 0) + v21*complex(fr, 0)
-	v2 := v12*complex(1-fr, 0) + v22*complex(fr, 0)
-
-	// Interpolate in z direction
-	result := v1*complex(1-fz, 0) + v2*complex(fz, 0)
-
-	return result, nil
-}
-
-// findIndex finds the appropriate index for interpolation
-func (tc *TabulationCache) findIndex(arr []float64, val float64) int {
-	for i := 0; i < len(arr)-1; i++ {
-		if val >= arr[i] && val <= arr[i+1] {
-			return i
-		}
-	}
-	return -1
-}
-
 // ValidateMatrixDimensions checks if matrix dimensions are compatible
 func ValidateMatrixDimensions(rows, cols int) error {
 	if rows <= 0 || cols <= 0 {
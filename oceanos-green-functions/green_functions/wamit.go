@@ -0,0 +1,346 @@
+// Package green_functions - WAMIT output files
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package green_functions
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"gonum.org/v1/gonum/mat"
+	"io"
+	"math"
+	"math/cmplx"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// WAMITConventions holds the reference values of the nondimensionalization of the WAMIT output files:
+// the length scale ULEN, the water density and the gravity acceleration
+type WAMITConventions struct {
+	Length  float64
+	Rho     float64
+	Gravity float64
+}
+
+// DefaultWAMITConventions returns a unit length scale, with the water density and the gravity of the solver
+func DefaultWAMITConventions() WAMITConventions {
+	return WAMITConventions{Length: 1, Rho: WaterDensity, Gravity: Gravity}
+}
+
+// WAMITRadiation is a line of a .1 file with the dimensional added mass and radiation damping between modes I and J.
+// A period of 0 stands for the zero frequency limit and a period of -1 for the infinite frequency limit,
+// where WAMIT only gives the added mass.
+type WAMITRadiation struct {
+	Period    float64
+	I, J      int
+	AddedMass float64
+	Damping   float64
+}
+
+// WAMITWave is a line of a .2, .3 or .4 file with the dimensional excitation force or motion of mode I
+// in waves of the given heading in degrees. Values follow the exp(-iωt) convention of the solver.
+type WAMITWave struct {
+	Period  float64
+	Heading float64
+	I       int
+	Value   complex128
+}
+
+// WAMITHydrostatics is a line of a .hst file with the dimensional hydrostatic stiffness between modes I and J
+type WAMITHydrostatics struct {
+	I, J      int
+	Stiffness float64
+}
+
+// wamitRotation returns 1 for the rotation modes, whose nondimensionalization has one more power of the length scale.
+// Modes 1 to 3 are the translations and 4 to 6 the rotations; generalized modes are scaled like translations.
+func wamitRotation(mode int) int {
+	if mode >= 4 && mode <= 6 {
+		return 1
+	}
+	return 0
+}
+
+// WAMITModes returns the WAMIT mode numbers of the degrees of freedom of the body. The rigid body motions
+// along and around the axes are modes 1 to 6 and the other degrees of freedom are generalized modes from 7.
+func WAMITModes(body *FloatingBody) []int {
+	modes := make([]int, len(body.DOFs))
+	next := 7
+	for i, dof := range body.DOFs {
+		for axis := 0; axis < 3; axis++ {
+			var unit [3]float64
+			unit[axis] = 1
+			if dof.Axis == unit {
+				modes[i] = axis + 1
+				if dof.Rotation {
+					modes[i] += 3
+				}
+			}
+		}
+		if modes[i] == 0 {
+			modes[i] = next
+			next++
+		}
+	}
+	return modes
+}
+
+// radiationScale returns ρ L^k, with k = 3, 4 or 5 depending on the rotations of the modes
+func (c WAMITConventions) radiationScale(i, j int) float64 {
+	return c.Rho * math.Pow(c.Length, float64(3+wamitRotation(i)+wamitRotation(j)))
+}
+
+// excitationScale returns ρ g L^m for a unit wave amplitude, with m = 2 or 3 depending on the rotation of the mode
+func (c WAMITConventions) excitationScale(i int) float64 {
+	return c.Rho * c.Gravity * math.Pow(c.Length, float64(2+wamitRotation(i)))
+}
+
+// motionScale returns 1 / L^n for a unit wave amplitude, with n = 1 for rotations
+func (c WAMITConventions) motionScale(i int) float64 {
+	return math.Pow(c.Length, -float64(wamitRotation(i)))
+}
+
+// hydrostaticScale returns ρ g L^k, with k = 2, 3 or 4 depending on the rotations of the modes
+func (c WAMITConventions) hydrostaticScale(i, j int) float64 {
+	return c.Rho * c.Gravity * math.Pow(c.Length, float64(2+wamitRotation(i)+wamitRotation(j)))
+}
+
+// WriteWAMIT1 writes the added mass and radiation damping of the results as a WAMIT .1 file:
+// one "PER I J A B" line per pair of modes, with A = A_ij / (ρ L^k) and B = B_ij / (ρ L^k ω)
+func WriteWAMIT1(w io.Writer, body *FloatingBody, results []*FrequencyResult, c WAMITConventions) error {
+	modes := WAMITModes(body)
+	bw := bufio.NewWriter(w)
+	for _, r := range results {
+		period := 2 * math.Pi / r.Omega
+		for i, mi := range modes {
+			for j, mj := range modes {
+				scale := c.radiationScale(mi, mj)
+				fmt.Fprintf(bw, "%14.6E%6d%6d%14.6E%14.6E\n", period, mi, mj,
+					r.AddedMass.At(i, j)/scale, r.RadiationDamping.At(i, j)/(scale*r.Omega))
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// writeWAMITWaves writes "PER BETA I |X| phase Re Im" lines of the nondimensional values of each mode and heading.
+// WAMIT uses the exp(iωt) time dependence, so values are conjugated and phases are in degrees.
+func writeWAMITWaves(w io.Writer, body *FloatingBody, results []*FrequencyResult, value func(r, d, i int) complex128,
+	scale func(mode int) float64) error {
+	modes := WAMITModes(body)
+	bw := bufio.NewWriter(w)
+	for k, r := range results {
+		period := 2 * math.Pi / r.Omega
+		for d, beta := range r.WaveDirections {
+			for i, mode := range modes {
+				x := cmplx.Conj(value(k, d, i)) / complex(scale(mode), 0)
+				fmt.Fprintf(bw, "%14.6E%14.6E%6d%14.6E%14.6E%14.6E%14.6E\n", period, beta*180/math.Pi, mode,
+					cmplx.Abs(x), cmplx.Phase(x)*180/math.Pi, real(x), imag(x))
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// WriteWAMITExcitation writes the excitation forces of the results as a WAMIT .2 or .3 file, with X = X_i / (ρ g A L^m).
+// WAMIT writes the forces from the Haskind relations in .2 files and from the diffraction potential in .3 files;
+// the solver only computes the latter, which is written in both cases.
+func WriteWAMITExcitation(w io.Writer, body *FloatingBody, results []*FrequencyResult, c WAMITConventions) error {
+	return writeWAMITWaves(w, body, results,
+		func(r, d, i int) complex128 { return results[r].ExcitationForce.At(d, i) },
+		c.excitationScale)
+}
+
+// WriteWAMIT4 writes the motions of the body in the waves of the results as a WAMIT .4 file, with ξ = ξ_i L^n / A.
+// The inertia matrix and the hydrostatic stiffness of the body must be set.
+func WriteWAMIT4(w io.Writer, body *FloatingBody, results []*FrequencyResult, c WAMITConventions) error {
+	motions := make([]*mat.CDense, len(results))
+	for k, r := range results {
+		rao, err := r.ComputeRAO(body)
+		if err != nil {
+			return fmt.Errorf("omega %g: %w", r.Omega, err)
+		}
+		motions[k] = rao
+	}
+	return writeWAMITWaves(w, body, results,
+		func(r, d, i int) complex128 { return motions[r].At(d, i) },
+		c.motionScale)
+}
+
+// WriteWAMITHydrostatics writes the hydrostatic stiffness of the body as a WAMIT .hst file,
+// with one "I J C" line per pair of modes and C = C_ij / (ρ g L^k)
+func WriteWAMITHydrostatics(w io.Writer, body *FloatingBody, c WAMITConventions) error {
+	if body.HydrostaticStiffness == nil {
+		return errors.New("body hydrostatic stiffness is required")
+	}
+	modes := WAMITModes(body)
+	bw := bufio.NewWriter(w)
+	for i, mi := range modes {
+		for j, mj := range modes {
+			fmt.Fprintf(bw, "%6d%6d%14.6E\n", mi, mj, body.HydrostaticStiffness.At(i, j)/c.hydrostaticScale(mi, mj))
+		}
+	}
+	return bw.Flush()
+}
+
+// ExportWAMIT writes the .1, .3, .hst and .4 files of the results with the given path prefix, such as out/body
+// for out/body.1. The results must share the water depth, as in a WAMIT run. The .hst file is only written
+// when the body has a hydrostatic stiffness, and the .4 file when it also has an inertia matrix.
+// It returns the paths of the written files.
+func ExportWAMIT(prefix string, body *FloatingBody, results []*FrequencyResult, c WAMITConventions) ([]string, error) {
+	for _, r := range results {
+		if r.WaterDepth != results[0].WaterDepth {
+			return nil, errors.New("results must share the water depth")
+		}
+	}
+
+	type output struct {
+		extension string
+		write     func(w io.Writer) error
+	}
+	outputs := []output{
+		{".1", func(w io.Writer) error { return WriteWAMIT1(w, body, results, c) }},
+		{".3", func(w io.Writer) error { return WriteWAMITExcitation(w, body, results, c) }},
+	}
+	if body.HydrostaticStiffness != nil {
+		outputs = append(outputs, output{".hst", func(w io.Writer) error { return WriteWAMITHydrostatics(w, body, c) }})
+		if body.InertiaMatrix != nil {
+			outputs = append(outputs, output{".4", func(w io.Writer) error { return WriteWAMIT4(w, body, results, c) }})
+		}
+	}
+
+	var paths []string
+	for _, o := range outputs {
+		path := prefix + o.extension
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := o.write(f); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if err := f.Close(); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// readWAMITLines parses the numbers of the non empty lines of a WAMIT output file,
+// checking that each line has one of the expected numbers of fields
+func readWAMITLines(r io.Reader, fields ...int) ([][]float64, error) {
+	var lines [][]float64
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		tokens := strings.Fields(scanner.Text())
+		if len(tokens) == 0 {
+			continue
+		}
+		valid := false
+		for _, count := range fields {
+			valid = valid || len(tokens) == count
+		}
+		if !valid {
+			return nil, fmt.Errorf("wamit: line %d: expected %v fields, got %d", n, fields, len(tokens))
+		}
+		numbers := make([]float64, len(tokens))
+		for i, token := range tokens {
+			x, err := strconv.ParseFloat(strings.Replace(strings.Replace(token, "D", "E", 1), "d", "e", 1), 64)
+			if err != nil {
+				return nil, fmt.Errorf("wamit: line %d: invalid number %q", n, token)
+			}
+			numbers[i] = x
+		}
+		lines = append(lines, numbers)
+	}
+	return lines, scanner.Err()
+}
+
+// wamitMode converts a field of a line to a mode number
+func wamitMode(x float64) (int, error) {
+	if x < 1 || x != math.Trunc(x) {
+		return 0, fmt.Errorf("wamit: invalid mode %g", x)
+	}
+	return int(x), nil
+}
+
+// ReadWAMIT1 reads a WAMIT .1 file and returns the dimensional added mass and radiation damping.
+// The lines of the zero and infinite frequency limits only have the added mass.
+func ReadWAMIT1(r io.Reader, c WAMITConventions) ([]WAMITRadiation, error) {
+	lines, err := readWAMITLines(r, 4, 5)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]WAMITRadiation, len(lines))
+	for k, line := range lines {
+		rec := WAMITRadiation{Period: line[0]}
+		if rec.I, err = wamitMode(line[1]); err != nil {
+			return nil, err
+		}
+		if rec.J, err = wamitMode(line[2]); err != nil {
+			return nil, err
+		}
+		scale := c.radiationScale(rec.I, rec.J)
+		rec.AddedMass = line[3] * scale
+		if len(line) == 5 && rec.Period > 0 {
+			rec.Damping = line[4] * scale * 2 * math.Pi / rec.Period
+		}
+		records[k] = rec
+	}
+	return records, nil
+}
+
+// readWAMITWaves reads the "PER BETA I |X| phase Re Im" lines of a .2, .3 or .4 file, conjugating the values
+// back to the exp(-iωt) convention
+func readWAMITWaves(r io.Reader, scale func(mode int) float64) ([]WAMITWave, error) {
+	lines, err := readWAMITLines(r, 7)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]WAMITWave, len(lines))
+	for k, line := range lines {
+		rec := WAMITWave{Period: line[0], Heading: line[1]}
+		if rec.I, err = wamitMode(line[2]); err != nil {
+			return nil, err
+		}
+		rec.Value = cmplx.Conj(complex(line[5], line[6])) * complex(scale(rec.I), 0)
+		records[k] = rec
+	}
+	return records, nil
+}
+
+// ReadWAMITExcitation reads a WAMIT .2 or .3 file and returns the dimensional excitation forces
+func ReadWAMITExcitation(r io.Reader, c WAMITConventions) ([]WAMITWave, error) {
+	return readWAMITWaves(r, c.excitationScale)
+}
+
+// ReadWAMIT4 reads a WAMIT .4 file and returns the dimensional motions for a unit wave amplitude
+func ReadWAMIT4(r io.Reader, c WAMITConventions) ([]WAMITWave, error) {
+	return readWAMITWaves(r, c.motionScale)
+}
+
+// ReadWAMITHydrostatics reads a WAMIT .hst file and returns the dimensional hydrostatic stiffness
+func ReadWAMITHydrostatics(r io.Reader, c WAMITConventions) ([]WAMITHydrostatics, error) {
+	lines, err := readWAMITLines(r, 3)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]WAMITHydrostatics, len(lines))
+	for k, line := range lines {
+		var rec WAMITHydrostatics
+		if rec.I, err = wamitMode(line[0]); err != nil {
+			return nil, err
+		}
+		if rec.J, err = wamitMode(line[1]); err != nil {
+			return nil, err
+		}
+		rec.Stiffness = line[2] * c.hydrostaticScale(rec.I, rec.J)
+		records[k] = rec
+	}
+	return records, nil
+}
@@ -0,0 +1,167 @@
+package green_functions
+
+import (
+	"bytes"
+	"math"
+	"math/cmplx"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWAMITModes(t *testing.T) {
+	body := &FloatingBody{DOFs: []DOF{
+		{Name: "Heave", Axis: [3]float64{0, 0, 1}},
+		{Name: "Pitch", Axis: [3]float64{0, 1, 0}, Rotation: true},
+		{Name: "Diagonal", Axis: [3]float64{1, 1, 0}},
+	}}
+	modes := WAMITModes(body)
+	if len(modes) != 3 || modes[0] != 3 || modes[1] != 5 || modes[2] != 7 {
+		t.Errorf("Expected modes [3 5 7], got %v", modes)
+	}
+}
+
+func TestWAMIT_RoundTrip(t *testing.T) {
+	body := newTestHemisphere(t, 4, 8)
+	solver := NewBEMSolver(NewDefaultDelhommeau())
+	result, err := solver.SolveFrequency(body, 1.5, math.Inf(1), []float64{0, math.Pi / 4})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	results := []*FrequencyResult{result}
+	c := WAMITConventions{Length: 2, Rho: WaterDensity, Gravity: Gravity}
+	near := func(a, b, scale float64) bool {
+		return math.Abs(a-b) <= 1e-6*scale
+	}
+
+	var buf bytes.Buffer
+	if err := WriteWAMIT1(&buf, body, results, c); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Heave line: A33 / (ρ L^3), B33 / (ρ L^3 ω)
+	line := strings.Split(buf.String(), "\n")[2*6+2]
+	expected := []float64{2 * math.Pi / 1.5, 3, 3, result.AddedMass.At(2, 2) / (WaterDensity * 8), result.RadiationDamping.At(2, 2) / (WaterDensity * 8 * 1.5)}
+	for i, field := range strings.Fields(line) {
+		if x, err := strconv.ParseFloat(field, 64); err != nil || !near(x, expected[i], math.Abs(expected[i])) {
+			t.Errorf("Expected %v in %q, got field %d = %s", expected, line, i, field)
+		}
+	}
+
+	radiation, err := ReadWAMIT1(&buf, c)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(radiation) != 36 {
+		t.Fatalf("Expected 36 lines, got %d", len(radiation))
+	}
+	for _, rec := range radiation {
+		a, b := result.AddedMass.At(rec.I-1, rec.J-1), result.RadiationDamping.At(rec.I-1, rec.J-1)
+		if !near(rec.AddedMass, a, math.Abs(result.AddedMass.At(2, 2))+math.Abs(a)) ||
+			!near(rec.Damping, b, math.Abs(result.RadiationDamping.At(2, 2))+math.Abs(b)) {
+			t.Errorf("Modes %d %d: expected %v and %v, got %+v", rec.I, rec.J, a, b, rec)
+		}
+	}
+
+	buf.Reset()
+	if err := WriteWAMITExcitation(&buf, body, results, c); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// WAMIT phases are those of the exp(iωt) convention
+	fields := strings.Fields(strings.Split(buf.String(), "\n")[2])
+	phase, _ := strconv.ParseFloat(fields[4], 64)
+	if expected := -cmplx.Phase(result.ExcitationForce.At(0, 2)) * 180 / math.Pi; math.Abs(phase-expected) > 1e-4 {
+		t.Errorf("Expected heave excitation phase %v, got %v", expected, phase)
+	}
+	excitation, err := ReadWAMITExcitation(&buf, c)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(excitation) != 12 || excitation[6].Heading != 45 {
+		t.Fatalf("Expected 12 lines with the second heading from line 7, got %d: %+v", len(excitation), excitation[6])
+	}
+	for k, rec := range excitation {
+		x := result.ExcitationForce.At(k/6, rec.I-1)
+		if cmplx.Abs(rec.Value-x) > 1e-6*cmplx.Abs(result.ExcitationForce.At(0, 2)) {
+			t.Errorf("Mode %d in heading %v: expected %v, got %v", rec.I, rec.Heading, x, rec.Value)
+		}
+	}
+
+	buf.Reset()
+	if err := WriteWAMIT4(&buf, body, results, c); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	motions, err := ReadWAMIT4(&buf, c)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	rao, _ := result.ComputeRAO(body)
+	if heave := motions[2].Value; cmplx.Abs(heave-rao.At(0, 2)) > 1e-6 {
+		t.Errorf("Expected heave motion %v, got %v", rao.At(0, 2), heave)
+	}
+
+	buf.Reset()
+	if err := WriteWAMITHydrostatics(&buf, body, c); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	hydrostatics, err := ReadWAMITHydrostatics(&buf, c)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	c33 := body.HydrostaticStiffness.At(2, 2)
+	for _, rec := range hydrostatics {
+		if expected := body.HydrostaticStiffness.At(rec.I-1, rec.J-1); !near(rec.Stiffness, expected, c33) {
+			t.Errorf("Modes %d %d: expected %v, got %v", rec.I, rec.J, expected, rec.Stiffness)
+		}
+	}
+}
+
+func TestReadWAMIT1_Limits(t *testing.T) {
+	data := "  -1.000000E+00     3     3  5.000000E-01\n\n   0.000000E+00     3     3  9.000000E-01\n" +
+		"   6.283185E+00     3     3  7.000000E-01  2.000000D-01\n"
+	records, err := ReadWAMIT1(strings.NewReader(data), DefaultWAMITConventions())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(records) != 3 || records[0].AddedMass != 500 || records[0].Damping != 0 || records[1].Period != 0 {
+		t.Errorf("Unexpected records of the frequency limits %+v", records)
+	}
+	if d := records[2].Damping; math.Abs(d-200) > 1e-3 {
+		t.Errorf("Expected a damping of 200 at omega 1, got %v", d)
+	}
+
+	for _, invalid := range []string{"1 3 3", "1 0 3 0.5 0.1", "1 x 3 0.5 0.1"} {
+		if _, err := ReadWAMIT1(strings.NewReader(invalid), DefaultWAMITConventions()); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+}
+
+func TestExportWAMIT(t *testing.T) {
+	body := newTestHemisphere(t, 4, 8)
+	solver := NewBEMSolver(NewDefaultDelhommeau())
+	result, err := solver.SolveFrequency(body, 1, math.Inf(1), []float64{0})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	prefix := filepath.Join(t.TempDir(), "hemisphere")
+	paths, err := ExportWAMIT(prefix, body, []*FrequencyResult{result}, DefaultWAMITConventions())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(paths) != 4 || paths[3] != prefix+".4" {
+		t.Errorf("Expected the .1, .3, .hst and .4 files, got %v", paths)
+	}
+	for _, path := range paths {
+		if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+			t.Errorf("Expected a non empty %s, got %v", path, err)
+		}
+	}
+
+	body.InertiaMatrix, body.HydrostaticStiffness = nil, nil
+	if paths, err = ExportWAMIT(prefix, body, []*FrequencyResult{result}, DefaultWAMITConventions()); err != nil || len(paths) != 2 {
+		t.Errorf("Expected only the .1 and .3 files without inertia and stiffness, got %v (%v)", paths, err)
+	}
+}
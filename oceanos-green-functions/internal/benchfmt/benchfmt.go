@@ -0,0 +1,108 @@
+// Package benchfmt - Parsing and comparison of `go test -bench` output
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package benchfmt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Result is a line of `go test -bench` output
+type Result struct {
+	// Name is the name of the benchmark without the Benchmark prefix and the GOMAXPROCS suffix
+	Name       string
+	Procs      int
+	Iterations int64
+	// Metrics are the value and unit pairs of the line, such as ns/op, B/op, allocs/op or custom metrics
+	Metrics []Metric
+	// Config holds the configuration lines preceding the benchmark, such as goos, goarch, pkg and cpu
+	Config map[string]string
+}
+
+// Metric is a value and unit pair of a benchmark result
+type Metric struct {
+	Value float64
+	Unit  string
+}
+
+// Parse parses the output of `go test -bench`, ignoring the lines that are not benchmark results
+func Parse(r io.Reader) ([]Result, error) {
+	config := map[string]string{}
+	var results []Result
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+
+		if key, value, ok := parseConfigLine(text); ok {
+			// Results already parsed keep the configuration they were run with
+			config = copyConfig(config)
+			config[key] = value
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) < 2 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		iterations, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			// Benchmark names printed alone, as with -v, are not results
+			continue
+		}
+		if len(fields)%2 != 0 {
+			return nil, fmt.Errorf("line %d: expected value and unit pairs after the number of iterations", line)
+		}
+
+		result := Result{Iterations: iterations, Config: config}
+		result.Name, result.Procs = splitProcs(strings.TrimPrefix(fields[0], "Benchmark"))
+		for i := 2; i < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid value %q", line, fields[i])
+			}
+			result.Metrics = append(result.Metrics, Metric{Value: value, Unit: fields[i+1]})
+		}
+		results = append(results, result)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// parseConfigLine parses a "key: value" configuration line, whose key starts with a lower case letter and has no space
+func parseConfigLine(text string) (string, string, bool) {
+	key, value, ok := strings.Cut(text, ":")
+	if !ok || key == "" || !unicode.IsLower(rune(key[0])) || strings.ContainsAny(key, " \t") {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(value), true
+}
+
+func copyConfig(config map[string]string) map[string]string {
+	c := make(map[string]string, len(config)+1)
+	for k, v := range config {
+		c[k] = v
+	}
+	return c
+}
+
+// splitProcs splits the -N GOMAXPROCS suffix from a benchmark name
+func splitProcs(name string) (string, int) {
+	i := strings.LastIndexByte(name, '-')
+	if i < 0 {
+		return name, 1
+	}
+	procs, err := strconv.Atoi(name[i+1:])
+	if err != nil || procs <= 0 {
+		return name, 1
+	}
+	return name[:i], procs
+}
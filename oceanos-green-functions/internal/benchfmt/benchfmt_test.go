@@ -0,0 +1,169 @@
+package benchfmt
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+const benchOutput = `goos: windows
+goarch: amd64
+pkg: github.com/capytaine/capytaine/go-capytaine/green_functions
+cpu: 12th Gen Intel(R) Core(TM) i5-12450HX
+BenchmarkDelhommeau_Evaluate_Small-12                	 8042275	       139.5 ns/op	     256 B/op	       4 allocs/op
+BenchmarkFinGreen3D_InfiniteVsFiniteDepth/InfiniteDepth-12         	40520553	        29.82 ns/op	       0 B/op	       0 allocs/op
+BenchmarkDelhommeau_Evaluate_Small-12                	 8000000	       140.5 ns/op	     256 B/op	       4 allocs/op
+BenchmarkSolver_Sweep
+BenchmarkSolver_Sweep-12    	      10	 104857600 ns/op	        12.50 frequencies/s
+--- BENCH: BenchmarkSolver_Sweep-12
+    solver_test.go:42: mesh with 128 faces
+PASS
+ok  	github.com/capytaine/capytaine/go-capytaine/green_functions	35.195s
+`
+
+func TestParse(t *testing.T) {
+	results, err := Parse(strings.NewReader(benchOutput))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 results, got %d", len(results))
+	}
+
+	r := results[1]
+	if r.Name != "FinGreen3D_InfiniteVsFiniteDepth/InfiniteDepth" || r.Procs != 12 || r.Iterations != 40520553 {
+		t.Errorf("Unexpected result %+v", r)
+	}
+	if len(r.Metrics) != 3 || r.Metrics[0] != (Metric{29.82, "ns/op"}) || r.Metrics[1] != (Metric{0, "B/op"}) {
+		t.Errorf("Unexpected metrics %+v", r.Metrics)
+	}
+	if r.Config["cpu"] != "12th Gen Intel(R) Core(TM) i5-12450HX" || r.Config["goos"] != "windows" {
+		t.Errorf("Unexpected configuration %v", r.Config)
+	}
+
+	custom := results[3].Metrics[1]
+	if custom.Unit != "frequencies/s" || custom.Value != 12.5 {
+		t.Errorf("Expected the custom metric, got %+v", custom)
+	}
+
+	if _, err := Parse(strings.NewReader("BenchmarkX-4 100 12 ns/op 3\n")); err == nil {
+		t.Error("Expected error for a value without unit")
+	}
+	if _, err := Parse(strings.NewReader("BenchmarkX-4 100 fast ns/op\n")); err == nil {
+		t.Error("Expected error for an invalid value")
+	}
+}
+
+func TestMannWhitneyU(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		x, y     []float64
+		expected float64
+	}{
+		// Fully separated samples are the two most extreme of the C(6, 3) = 20 arrangements
+		{"separated 3 vs 3", []float64{1, 2, 3}, []float64{4, 5, 6}, 2.0 / 20},
+		{"separated 5 vs 5", []float64{1, 2, 3, 4, 5}, []float64{6, 7, 8, 9, 10}, 2.0 / 252},
+		{"single runs", []float64{1}, []float64{2}, 1},
+		{"identical", []float64{4, 4, 4}, []float64{4, 4, 4}, 1},
+		{"interleaved", []float64{1, 3, 5}, []float64{2, 4, 6}, 0.7},
+	} {
+		if p := MannWhitneyU(tc.x, tc.y); math.Abs(p-tc.expected) > 1e-12 {
+			t.Errorf("%s: expected p = %v, got %v", tc.name, tc.expected, p)
+		}
+		if p, q := MannWhitneyU(tc.x, tc.y), MannWhitneyU(tc.y, tc.x); math.Abs(p-q) > 1e-12 {
+			t.Errorf("%s: expected a symmetric test, got %v and %v", tc.name, p, q)
+		}
+	}
+
+	// Ties use the normal approximation
+	p := MannWhitneyU([]float64{1, 1, 2, 2, 3, 3, 4, 4}, []float64{5, 5, 6, 6, 7, 7, 8, 8})
+	if p > 0.01 {
+		t.Errorf("Expected a significant difference, got p = %v", p)
+	}
+}
+
+func TestMinPValue(t *testing.T) {
+	for _, tc := range []struct {
+		m, n     int
+		expected float64
+	}{
+		{1, 1, 1},
+		{1, 5, 2.0 / 6},
+		{3, 3, 2.0 / 20},
+		{5, 5, 2.0 / 252},
+	} {
+		if p := MinPValue(tc.m, tc.n); math.Abs(p-tc.expected) > 1e-12 {
+			t.Errorf("%d+%d runs: expected %v, got %v", tc.m, tc.n, tc.expected, p)
+		}
+	}
+	// The fully separated samples reach the smallest p-value
+	if p := MannWhitneyU([]float64{1, 2, 3}, []float64{4, 5, 6}); p != MinPValue(3, 3) {
+		t.Errorf("Expected %v for separated samples, got %v", MinPValue(3, 3), p)
+	}
+	if p := MinPValue(60, 60); p <= 0 || p > 1e-10 {
+		t.Errorf("Expected a tiny p-value for large samples, got %v", p)
+	}
+
+	for alpha, expected := range map[float64]int{0.05: 4, 0.01: 5, 1: 1} {
+		if n := MinRuns(alpha); n != expected {
+			t.Errorf("alpha %v: expected %d runs, got %d", alpha, expected, n)
+		}
+	}
+}
+
+func TestMannWhitneyCounts(t *testing.T) {
+	counts := mannWhitneyCounts(4, 6)
+	var total float64
+	for u, c := range counts {
+		total += c
+		if c != counts[len(counts)-1-u] {
+			t.Errorf("Expected a symmetric distribution, got %v", counts)
+			break
+		}
+	}
+	if total != 210 || len(counts) != 25 {
+		t.Errorf("Expected C(10, 4) = 210 arrangements and 25 values of U, got %v and %d", total, len(counts))
+	}
+}
+
+func TestCompare(t *testing.T) {
+	run := func(name string, nsPerOp ...float64) []Result {
+		var results []Result
+		for _, v := range nsPerOp {
+			results = append(results, Result{Name: name, Procs: 8, Iterations: 1000,
+				Metrics: []Metric{{v, "ns/op"}, {256, "B/op"}, {v / 10, "MB/s"}}})
+		}
+		return results
+	}
+	old := append(run("Evaluate", 100, 101, 99, 98, 102), run("Removed", 5)...)
+	new := append(run("Evaluate", 120, 119, 121, 122, 118), run("Added", 5)...)
+
+	comparisons := Compare(old, new)
+	if len(comparisons) != 3 {
+		t.Fatalf("Expected 3 comparisons for the common benchmark, got %d", len(comparisons))
+	}
+	bytes, rate, ns := comparisons[0], comparisons[1], comparisons[2]
+	if bytes.Unit != "B/op" || rate.Unit != "MB/s" || ns.Unit != "ns/op" {
+		t.Fatalf("Expected comparisons sorted by unit, got %s %s %s", bytes.Unit, rate.Unit, ns.Unit)
+	}
+
+	if math.Abs(ns.Delta-0.2) > 1e-12 || ns.Old.N != 5 || ns.New.N != 5 {
+		t.Errorf("Expected a 20%% increase over 5 runs, got %+v", ns)
+	}
+	if !ns.Regression(0.1, 0.05) || ns.Regression(0.25, 0.05) {
+		t.Errorf("Expected a regression above 10%% and below 25%%, got %+v", ns)
+	}
+	// A larger rate is an improvement
+	if rate.Worsening() >= 0 || rate.Regression(0, 0.05) {
+		t.Errorf("Expected an improvement of the rate, got %+v", rate)
+	}
+	if bytes.Delta != 0 || bytes.Significant(0.05) {
+		t.Errorf("Expected no change of B/op, got %+v", bytes)
+	}
+
+	// Single runs are never significant
+	single := Compare(run("Evaluate", 100), run("Evaluate", 200))
+	if single[2].Regression(0.1, 0.05) {
+		t.Errorf("Expected no significant regression from single runs, got %+v", single[2])
+	}
+}
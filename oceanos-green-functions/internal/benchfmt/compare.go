@@ -0,0 +1,252 @@
+// Package benchfmt - Comparison of two sets of benchmark runs
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package benchfmt
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// Summary describes the runs of a benchmark metric
+type Summary struct {
+	N      int
+	Mean   float64
+	StdDev float64
+	Values []float64
+}
+
+// Summarize computes the mean and the sample standard deviation of the values
+func Summarize(values []float64) Summary {
+	s := Summary{N: len(values), Values: values}
+	if s.N == 0 {
+		return s
+	}
+	for _, v := range values {
+		s.Mean += v
+	}
+	s.Mean /= float64(s.N)
+	if s.N > 1 {
+		for _, v := range values {
+			s.StdDev += (v - s.Mean) * (v - s.Mean)
+		}
+		s.StdDev = math.Sqrt(s.StdDev / float64(s.N-1))
+	}
+	return s
+}
+
+// Comparison is the change of a metric of a benchmark between an old and a new set of runs
+type Comparison struct {
+	Name string
+	Unit string
+	Old  Summary
+	New  Summary
+
+	// Delta is the relative change of the mean, (new - old) / old
+	Delta float64
+	// PValue is the two-sided p-value of the Mann-Whitney U test, 1 when there are too few runs
+	PValue float64
+}
+
+// HigherIsBetter reports whether an increase of a metric is an improvement, as for rates such as MB/s
+func HigherIsBetter(unit string) bool {
+	return strings.HasSuffix(unit, "/s")
+}
+
+// Worsening returns the relative change of the metric in the direction of a regression,
+// positive when the benchmark got slower or allocates more
+func (c Comparison) Worsening() float64 {
+	if HigherIsBetter(c.Unit) {
+		return -c.Delta
+	}
+	return c.Delta
+}
+
+// Significant reports whether the change is statistically significant at the given level.
+// Every change is significant at the level 1.
+func (c Comparison) Significant(alpha float64) bool {
+	return c.PValue <= alpha
+}
+
+// Detectable reports whether the numbers of runs allow a change to be significant at the given level
+func (c Comparison) Detectable(alpha float64) bool {
+	return MinPValue(c.Old.N, c.New.N) <= alpha
+}
+
+// Regression reports whether the metric got worse by more than the threshold, a relative change,
+// with a significant difference between the runs
+func (c Comparison) Regression(threshold, alpha float64) bool {
+	return c.Worsening() > threshold && c.Significant(alpha)
+}
+
+// Compare compares the metrics of the benchmarks present in both sets of results.
+// Runs are grouped by benchmark name, regardless of GOMAXPROCS, and comparisons are sorted by name and unit.
+func Compare(old, new []Result) []Comparison {
+	type key struct {
+		name, unit string
+	}
+	group := func(results []Result) map[key][]float64 {
+		values := map[key][]float64{}
+		for _, r := range results {
+			for _, m := range r.Metrics {
+				k := key{r.Name, m.Unit}
+				values[k] = append(values[k], m.Value)
+			}
+		}
+		return values
+	}
+	oldValues, newValues := group(old), group(new)
+
+	var comparisons []Comparison
+	for k, o := range oldValues {
+		n, ok := newValues[k]
+		if !ok {
+			continue
+		}
+		c := Comparison{Name: k.name, Unit: k.unit, Old: Summarize(o), New: Summarize(n), PValue: MannWhitneyU(o, n)}
+		switch {
+		case c.Old.Mean != 0:
+			c.Delta = (c.New.Mean - c.Old.Mean) / math.Abs(c.Old.Mean)
+		case c.New.Mean != 0:
+			c.Delta = math.Copysign(math.Inf(1), c.New.Mean)
+		}
+		comparisons = append(comparisons, c)
+	}
+
+	sort.Slice(comparisons, func(i, j int) bool {
+		if comparisons[i].Name != comparisons[j].Name {
+			return comparisons[i].Name < comparisons[j].Name
+		}
+		return comparisons[i].Unit < comparisons[j].Unit
+	})
+	return comparisons
+}
+
+// mannWhitneyExactLimit is the largest product of the sample sizes for which the exact distribution is used
+const mannWhitneyExactLimit = 2500
+
+// MannWhitneyU returns the two-sided p-value of the Mann-Whitney U test of the hypothesis that
+// the two samples come from the same distribution. The exact distribution of U is used for small samples
+// without ties, and the normal approximation with tie and continuity corrections otherwise.
+func MannWhitneyU(x, y []float64) float64 {
+	m, n := len(x), len(y)
+	if m == 0 || n == 0 {
+		return 1
+	}
+
+	// Ranks of the pooled samples, ties get the mean of their ranks
+	type observation struct {
+		value float64
+		first bool
+	}
+	pooled := make([]observation, 0, m+n)
+	for _, v := range x {
+		pooled = append(pooled, observation{v, true})
+	}
+	for _, v := range y {
+		pooled = append(pooled, observation{v, false})
+	}
+	sort.Slice(pooled, func(i, j int) bool { return pooled[i].value < pooled[j].value })
+
+	var rankSum, tieCorrection float64
+	ties := false
+	for i := 0; i < len(pooled); {
+		j := i
+		for j < len(pooled) && pooled[j].value == pooled[i].value {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if pooled[k].first {
+				rankSum += rank
+			}
+		}
+		if t := float64(j - i); t > 1 {
+			ties = true
+			tieCorrection += t*t*t - t
+		}
+		i = j
+	}
+	u := rankSum - float64(m*(m+1))/2
+
+	if !ties && m*n <= mannWhitneyExactLimit {
+		// P(U <= u) and P(U >= u) from the number of arrangements giving each value of U
+		counts := mannWhitneyCounts(m, n)
+		var total, lower, upper float64
+		for v, c := range counts {
+			total += c
+			if float64(v) <= u {
+				lower += c
+			}
+			if float64(v) >= u {
+				upper += c
+			}
+		}
+		return math.Min(1, 2*math.Min(lower, upper)/total)
+	}
+
+	fm, fn := float64(m), float64(n)
+	mean := fm * fn / 2
+	variance := fm * fn / 12 * ((fm + fn + 1) - tieCorrection/((fm+fn)*(fm+fn-1)))
+	if variance <= 0 {
+		return 1
+	}
+	z := math.Max(0, math.Abs(u-mean)-0.5) / math.Sqrt(variance)
+	return math.Min(1, math.Erfc(z/math.Sqrt2))
+}
+
+// MinPValue returns the smallest p-value of MannWhitneyU for samples of sizes m and n, reached when they are
+// fully separated. No difference between the samples is significant at a lower level: with single runs, it is 1.
+func MinPValue(m, n int) float64 {
+	if m == 0 || n == 0 {
+		return 1
+	}
+	if m*n <= mannWhitneyExactLimit {
+		// 2 of the C(m+n, m) arrangements are fully separated
+		arrangements := 1.0
+		for i := 1; i <= m; i++ {
+			arrangements = arrangements * float64(n+i) / float64(i)
+		}
+		return math.Min(1, 2/arrangements)
+	}
+	fm, fn := float64(m), float64(n)
+	z := (fm*fn/2 - 0.5) / math.Sqrt(fm*fn*(fm+fn+1)/12)
+	return math.Min(1, math.Erfc(z/math.Sqrt2))
+}
+
+// MinRuns returns the smallest number of runs on each side for which MinPValue reaches the given level
+func MinRuns(alpha float64) int {
+	n := 1
+	for MinPValue(n, n) > alpha {
+		n++
+	}
+	return n
+}
+
+// mannWhitneyCounts returns the number of arrangements of m and n observations giving each value of U,
+// from the recurrence c(u; m, n) = c(u - n; m - 1, n) + c(u; m, n - 1)
+func mannWhitneyCounts(m, n int) []float64 {
+	// counts[j] holds the distribution for m' observations in the first sample and j in the second
+	counts := make([][]float64, n+1)
+	for j := range counts {
+		counts[j] = []float64{1}
+	}
+	for i := 1; i <= m; i++ {
+		next := make([][]float64, n+1)
+		next[0] = []float64{1}
+		for j := 1; j <= n; j++ {
+			c := make([]float64, i*j+1)
+			for u, v := range counts[j] {
+				c[u+j] += v
+			}
+			for u, v := range next[j-1] {
+				c[u] += v
+			}
+			next[j] = c
+		}
+		counts = next
+	}
+	return counts[n]
+}
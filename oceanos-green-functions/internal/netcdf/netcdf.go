@@ -0,0 +1,520 @@
+// Package netcdf - Reader and writer of the NetCDF classic format
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package netcdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Type is the external type of an attribute or a variable
+type Type int32
+
+// Types of the classic format
+const (
+	Byte   Type = 1
+	Char   Type = 2
+	Short  Type = 3
+	Int    Type = 4
+	Float  Type = 5
+	Double Type = 6
+)
+
+// size returns the size of an element in bytes
+func (t Type) size() int {
+	switch t {
+	case Byte, Char:
+		return 1
+	case Short:
+		return 2
+	case Int, Float:
+		return 4
+	case Double:
+		return 8
+	}
+	return 0
+}
+
+// Tags of the lists of the header
+const (
+	tagDimension = 0x0A
+	tagVariable  = 0x0B
+	tagAttribute = 0x0C
+)
+
+var magic = []byte{'C', 'D', 'F', 1}
+
+// Dimension is a named dimension of fixed length
+type Dimension struct {
+	Name   string
+	Length int
+}
+
+// Attribute is a named value attached to the file or to a variable.
+// Values are strings, int32 or []int32, and float64 or []float64.
+// Numeric attributes are read back as scalars when they hold a single value.
+type Attribute struct {
+	Name  string
+	Value interface{}
+}
+
+// Variable is an array over dimensions of the file, stored in row major order.
+// Values are []float64, []int32, or strings for character arrays.
+type Variable struct {
+	Name       string
+	Dims       []string
+	Attributes []Attribute
+	Value      interface{}
+}
+
+// Attribute returns the value of the attribute of the variable with the given name
+func (v *Variable) Attribute(name string) (interface{}, bool) {
+	return findAttribute(v.Attributes, name)
+}
+
+// File is a NetCDF dataset held in memory.
+// It is written in the classic format (CDF-1), the format of xarray with format="NETCDF3_CLASSIC",
+// which scipy.io.netcdf_file reads without any native library. Only fixed size dimensions and the
+// char, int and double types are supported.
+type File struct {
+	Dimensions []Dimension
+	Attributes []Attribute
+	Variables  []Variable
+}
+
+// AddDimension adds a dimension, or checks the length of an existing dimension with the same name
+func (f *File) AddDimension(name string, length int) error {
+	if d := f.Dimension(name); d != nil {
+		if d.Length != length {
+			return fmt.Errorf("dimension %q already has length %d, got %d", name, d.Length, length)
+		}
+		return nil
+	}
+	f.Dimensions = append(f.Dimensions, Dimension{Name: name, Length: length})
+	return nil
+}
+
+// AddAttribute adds a global attribute
+func (f *File) AddAttribute(name string, value interface{}) {
+	f.Attributes = append(f.Attributes, Attribute{Name: name, Value: value})
+}
+
+// AddVariable adds a variable over the given dimensions, which must have been added to the file
+func (f *File) AddVariable(name string, dims []string, value interface{}, attributes ...Attribute) {
+	f.Variables = append(f.Variables, Variable{Name: name, Dims: dims, Attributes: attributes, Value: value})
+}
+
+// AddStrings adds a coordinate variable of strings along the dimension dim, also added to the file.
+// Strings are stored as a character array padded with zeros, with a trailing dimension named after
+// their maximum length like xarray does, which turns them back into strings when reading the file.
+func (f *File) AddStrings(name, dim string, values []string, attributes ...Attribute) error {
+	width := 1
+	for _, s := range values {
+		if len(s) > width {
+			width = len(s)
+		}
+	}
+	lengthDim := fmt.Sprintf("string%d", width)
+	if err := f.AddDimension(dim, len(values)); err != nil {
+		return err
+	}
+	if err := f.AddDimension(lengthDim, width); err != nil {
+		return err
+	}
+	chars := make([]byte, len(values)*width)
+	for i, s := range values {
+		copy(chars[i*width:], s)
+	}
+	f.AddVariable(name, []string{dim, lengthDim}, string(chars), attributes...)
+	return nil
+}
+
+// Dimension returns the dimension with the given name, or nil
+func (f *File) Dimension(name string) *Dimension {
+	for i := range f.Dimensions {
+		if f.Dimensions[i].Name == name {
+			return &f.Dimensions[i]
+		}
+	}
+	return nil
+}
+
+// Variable returns the variable with the given name, or nil
+func (f *File) Variable(name string) *Variable {
+	for i := range f.Variables {
+		if f.Variables[i].Name == name {
+			return &f.Variables[i]
+		}
+	}
+	return nil
+}
+
+// Attribute returns the value of the global attribute with the given name
+func (f *File) Attribute(name string) (interface{}, bool) {
+	return findAttribute(f.Attributes, name)
+}
+
+// Strings splits a character array of shape (n, width) into its n strings, without the padding
+func Strings(chars string, n int) []string {
+	if n == 0 {
+		return nil
+	}
+	width := len(chars) / n
+	values := make([]string, n)
+	for i := range values {
+		values[i] = string(bytes.TrimRight([]byte(chars[i*width:(i+1)*width]), "\x00"))
+	}
+	return values
+}
+
+func findAttribute(attributes []Attribute, name string) (interface{}, bool) {
+	for _, a := range attributes {
+		if a.Name == name {
+			return a.Value, true
+		}
+	}
+	return nil, false
+}
+
+// encodeValues returns the type, the number of elements and the big endian encoding of a value
+func encodeValues(value interface{}) (Type, int, []byte, error) {
+	switch v := value.(type) {
+	case string:
+		return Char, len(v), []byte(v), nil
+	case int32:
+		return encodeValues([]int32{v})
+	case []int32:
+		b := make([]byte, 4*len(v))
+		for i, x := range v {
+			binary.BigEndian.PutUint32(b[4*i:], uint32(x))
+		}
+		return Int, len(v), b, nil
+	case float64:
+		return encodeValues([]float64{v})
+	case []float64:
+		b := make([]byte, 8*len(v))
+		for i, x := range v {
+			binary.BigEndian.PutUint64(b[8*i:], math.Float64bits(x))
+		}
+		return Double, len(v), b, nil
+	}
+	return 0, 0, nil, fmt.Errorf("unsupported value type %T", value)
+}
+
+// padding returns the number of zero bytes aligning n bytes on 4 bytes
+func padding(n int) int {
+	return (4 - n%4) % 4
+}
+
+// headerWriter writes the big endian fields of the header
+type headerWriter struct {
+	bytes.Buffer
+}
+
+func (h *headerWriter) int32(x int) {
+	binary.Write(&h.Buffer, binary.BigEndian, int32(x))
+}
+
+func (h *headerWriter) name(s string) {
+	h.int32(len(s))
+	h.WriteString(s)
+	h.Write(make([]byte, padding(len(s))))
+}
+
+func (h *headerWriter) attributes(attributes []Attribute) error {
+	if len(attributes) == 0 {
+		h.int32(0)
+		h.int32(0)
+		return nil
+	}
+	h.int32(tagAttribute)
+	h.int32(len(attributes))
+	for _, a := range attributes {
+		t, n, data, err := encodeValues(a.Value)
+		if err != nil {
+			return fmt.Errorf("attribute %q: %w", a.Name, err)
+		}
+		h.name(a.Name)
+		h.int32(int(t))
+		h.int32(n)
+		h.Write(data)
+		h.Write(make([]byte, padding(len(data))))
+	}
+	return nil
+}
+
+// Write writes the file in the classic format
+func (f *File) Write(w io.Writer) error {
+	dimIDs := map[string]int{}
+	for i, d := range f.Dimensions {
+		if d.Name == "" {
+			return errors.New("dimension without a name")
+		}
+		if d.Length <= 0 {
+			return fmt.Errorf("dimension %q must have a positive length, got %d", d.Name, d.Length)
+		}
+		if _, ok := dimIDs[d.Name]; ok {
+			return fmt.Errorf("duplicate dimension %q", d.Name)
+		}
+		dimIDs[d.Name] = i
+	}
+
+	// Variables are validated and encoded first: the header depends on their sizes
+	types := make([]Type, len(f.Variables))
+	data := make([][]byte, len(f.Variables))
+	names := map[string]bool{}
+	for i, v := range f.Variables {
+		if v.Name == "" || names[v.Name] {
+			return fmt.Errorf("missing or duplicate variable name %q", v.Name)
+		}
+		names[v.Name] = true
+		size := 1
+		for _, dim := range v.Dims {
+			id, ok := dimIDs[dim]
+			if !ok {
+				return fmt.Errorf("variable %q: unknown dimension %q", v.Name, dim)
+			}
+			size *= f.Dimensions[id].Length
+		}
+		t, n, b, err := encodeValues(v.Value)
+		if err != nil {
+			return fmt.Errorf("variable %q: %w", v.Name, err)
+		}
+		if n != size {
+			return fmt.Errorf("variable %q: expected %d values, got %d", v.Name, size, n)
+		}
+		types[i], data[i] = t, b
+	}
+
+	// The offsets of the data have a fixed size, so the size of the header does not depend on them
+	header := func(begins []int64) (*headerWriter, error) {
+		h := &headerWriter{}
+		h.Write(magic)
+		h.int32(0) // no record
+		if len(f.Dimensions) == 0 {
+			h.int32(0)
+			h.int32(0)
+		} else {
+			h.int32(tagDimension)
+			h.int32(len(f.Dimensions))
+			for _, d := range f.Dimensions {
+				h.name(d.Name)
+				h.int32(d.Length)
+			}
+		}
+		if err := h.attributes(f.Attributes); err != nil {
+			return nil, err
+		}
+		if len(f.Variables) == 0 {
+			h.int32(0)
+			h.int32(0)
+			return h, nil
+		}
+		h.int32(tagVariable)
+		h.int32(len(f.Variables))
+		for i, v := range f.Variables {
+			h.name(v.Name)
+			h.int32(len(v.Dims))
+			for _, dim := range v.Dims {
+				h.int32(dimIDs[dim])
+			}
+			if err := h.attributes(v.Attributes); err != nil {
+				return nil, fmt.Errorf("variable %q: %w", v.Name, err)
+			}
+			h.int32(int(types[i]))
+			h.int32(len(data[i]) + padding(len(data[i])))
+			h.int32(int(begins[i]))
+		}
+		return h, nil
+	}
+
+	begins := make([]int64, len(f.Variables))
+	h, err := header(begins)
+	if err != nil {
+		return err
+	}
+	offset := int64(h.Len())
+	for i, b := range data {
+		begins[i] = offset
+		offset += int64(len(b) + padding(len(b)))
+	}
+	if offset > math.MaxInt32 {
+		return fmt.Errorf("%d bytes is too large for the classic format", offset)
+	}
+	if h, err = header(begins); err != nil {
+		return err
+	}
+
+	if _, err := w.Write(h.Bytes()); err != nil {
+		return err
+	}
+	for _, b := range data {
+		if _, err := w.Write(append(b, make([]byte, padding(len(b)))...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// headerReader reads the big endian fields of the header, keeping the first error
+type headerReader struct {
+	data   []byte
+	offset int
+	err    error
+}
+
+func (h *headerReader) next(n int) []byte {
+	if h.err != nil {
+		return nil
+	}
+	if n < 0 || h.offset+n > len(h.data) {
+		h.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	b := h.data[h.offset : h.offset+n]
+	h.offset += n
+	return b
+}
+
+func (h *headerReader) int32() int {
+	b := h.next(4)
+	if b == nil {
+		return 0
+	}
+	return int(int32(binary.BigEndian.Uint32(b)))
+}
+
+func (h *headerReader) name() string {
+	n := h.int32()
+	b := h.next(n + padding(n))
+	if b == nil {
+		return ""
+	}
+	return string(b[:n])
+}
+
+// list reads the tag and the number of elements of a list, which are both zero for an absent list
+func (h *headerReader) list(tag int) int {
+	t, n := h.int32(), h.int32()
+	if h.err == nil && t != tag && (t != 0 || n != 0) {
+		h.err = fmt.Errorf("expected tag %#x at offset %d, got %#x", tag, h.offset-8, t)
+	}
+	return n
+}
+
+// decodeValues decodes n values of type t, numbers with a single value being returned as scalars
+func decodeValues(t Type, n int, b []byte) (interface{}, error) {
+	switch t {
+	case Char:
+		return string(b[:n]), nil
+	case Int:
+		values := make([]int32, n)
+		for i := range values {
+			values[i] = int32(binary.BigEndian.Uint32(b[4*i:]))
+		}
+		return values, nil
+	case Double:
+		values := make([]float64, n)
+		for i := range values {
+			values[i] = math.Float64frombits(binary.BigEndian.Uint64(b[8*i:]))
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("unsupported type %d", t)
+}
+
+func (h *headerReader) attributes() []Attribute {
+	n := h.list(tagAttribute)
+	var attributes []Attribute
+	for i := 0; i < n && h.err == nil; i++ {
+		name := h.name()
+		t := Type(h.int32())
+		count := h.int32()
+		b := h.next(count*t.size() + padding(count*t.size()))
+		if h.err != nil {
+			break
+		}
+		value, err := decodeValues(t, count, b)
+		if err != nil {
+			h.err = fmt.Errorf("attribute %q: %w", name, err)
+			break
+		}
+		switch v := value.(type) {
+		case []int32:
+			if len(v) == 1 {
+				value = v[0]
+			}
+		case []float64:
+			if len(v) == 1 {
+				value = v[0]
+			}
+		}
+		attributes = append(attributes, Attribute{Name: name, Value: value})
+	}
+	return attributes
+}
+
+// Read reads a file in the classic format written with fixed size dimensions of the char, int and double types
+func Read(r io.Reader) (*File, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 4 || !bytes.Equal(data[:4], magic) {
+		return nil, errors.New("not a NetCDF classic file")
+	}
+	h := &headerReader{data: data, offset: 4}
+	if records := h.int32(); records != 0 {
+		return nil, errors.New("record variables are not supported")
+	}
+
+	f := &File{}
+	n := h.list(tagDimension)
+	for i := 0; i < n && h.err == nil; i++ {
+		name := h.name()
+		length := h.int32()
+		if length == 0 {
+			return nil, fmt.Errorf("dimension %q: record dimensions are not supported", name)
+		}
+		f.Dimensions = append(f.Dimensions, Dimension{Name: name, Length: length})
+	}
+	f.Attributes = h.attributes()
+
+	n = h.list(tagVariable)
+	for i := 0; i < n && h.err == nil; i++ {
+		v := Variable{Name: h.name()}
+		size := 1
+		for rank, j := h.int32(), 0; j < rank && h.err == nil; j++ {
+			id := h.int32()
+			if id < 0 || id >= len(f.Dimensions) {
+				return nil, fmt.Errorf("variable %q: invalid dimension %d", v.Name, id)
+			}
+			v.Dims = append(v.Dims, f.Dimensions[id].Name)
+			size *= f.Dimensions[id].Length
+		}
+		v.Attributes = h.attributes()
+		t := Type(h.int32())
+		h.int32() // vsize, recomputed from the dimensions
+		begin := h.int32()
+		if h.err != nil {
+			break
+		}
+		if begin < 0 || begin+size*t.size() > len(data) {
+			return nil, fmt.Errorf("variable %q: data out of the file", v.Name)
+		}
+		if v.Value, err = decodeValues(t, size, data[begin:begin+size*t.size()]); err != nil {
+			return nil, fmt.Errorf("variable %q: %w", v.Name, err)
+		}
+		f.Variables = append(f.Variables, v)
+	}
+	if h.err != nil {
+		return nil, fmt.Errorf("invalid NetCDF header: %w", h.err)
+	}
+	return f, nil
+}
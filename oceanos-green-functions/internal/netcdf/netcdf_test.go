@@ -0,0 +1,145 @@
+package netcdf
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFile_Write(t *testing.T) {
+	f := &File{}
+	f.AddDimension("x", 2)
+	f.AddVariable("x", []string{"x"}, []float64{1, 2}, Attribute{Name: "units", Value: "m"})
+
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []byte{
+		'C', 'D', 'F', 1,
+		0, 0, 0, 0, // numrecs
+		0, 0, 0, 0x0A, 0, 0, 0, 1, // one dimension
+		0, 0, 0, 1, 'x', 0, 0, 0, 0, 0, 0, 2,
+		0, 0, 0, 0, 0, 0, 0, 0, // no global attribute
+		0, 0, 0, 0x0B, 0, 0, 0, 1, // one variable
+		0, 0, 0, 1, 'x', 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, // x(x)
+		0, 0, 0, 0x0C, 0, 0, 0, 1, 0, 0, 0, 5, 'u', 'n', 'i', 't', 's', 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 1, 'm', 0, 0, 0,
+		0, 0, 0, 6, 0, 0, 0, 16, 0, 0, 0, 104, // double, vsize, begin
+		0x3f, 0xf0, 0, 0, 0, 0, 0, 0, 0x40, 0, 0, 0, 0, 0, 0, 0,
+	}
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Errorf("Expected\n%v\ngot\n%v", expected, buf.Bytes())
+	}
+}
+
+func TestFile_RoundTrip(t *testing.T) {
+	f := &File{}
+	f.AddAttribute("title", "hemisphere")
+	f.AddAttribute("rho", 1025.0)
+	f.AddAttribute("tabulation_nr", int32(676))
+	f.AddAttribute("range", []float64{0, math.Inf(1)})
+	if err := f.AddStrings("dof", "dof", []string{"Heave", "Pitch", "Yaw"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	f.AddDimension("omega", 2)
+	f.AddVariable("omega", []string{"omega"}, []float64{0.5, 1})
+	f.AddVariable("added_mass", []string{"omega", "dof"}, []float64{1, 2, 3, 4, 5, 6},
+		Attribute{Name: "long_name", Value: "Added mass"})
+	f.AddVariable("count", nil, []int32{7})
+
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if buf.Len()%4 != 0 {
+		t.Errorf("Expected a file aligned on 4 bytes, got %d bytes", buf.Len())
+	}
+	read, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(read.Dimensions, f.Dimensions) {
+		t.Errorf("Expected dimensions %v, got %v", f.Dimensions, read.Dimensions)
+	}
+	if !reflect.DeepEqual(read.Attributes, f.Attributes) {
+		t.Errorf("Expected attributes %v, got %v", f.Attributes, read.Attributes)
+	}
+	if !reflect.DeepEqual(read.Variables, f.Variables) {
+		t.Errorf("Expected variables %+v, got %+v", f.Variables, read.Variables)
+	}
+
+	dof := read.Variable("dof")
+	if names := Strings(dof.Value.(string), read.Dimension("dof").Length); !reflect.DeepEqual(names, []string{"Heave", "Pitch", "Yaw"}) {
+		t.Errorf("Expected the DOF names, got %q", names)
+	}
+	if dims := dof.Dims; dims[1] != "string5" {
+		t.Errorf("Expected a string5 dimension, got %v", dims)
+	}
+	if name, _ := read.Variable("added_mass").Attribute("long_name"); name != "Added mass" {
+		t.Errorf("Expected the long name of the added mass, got %v", name)
+	}
+}
+
+func TestFile_WriteErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		file func() *File
+		msg  string
+	}{
+		{"unknown dimension", func() *File {
+			f := &File{}
+			f.AddVariable("x", []string{"x"}, []float64{1})
+			return f
+		}, "unknown dimension"},
+		{"wrong size", func() *File {
+			f := &File{}
+			f.AddDimension("x", 2)
+			f.AddVariable("x", []string{"x"}, []float64{1})
+			return f
+		}, "expected 2 values"},
+		{"zero length", func() *File {
+			f := &File{}
+			f.AddDimension("x", 0)
+			return f
+		}, "positive length"},
+		{"unsupported type", func() *File {
+			f := &File{}
+			f.AddAttribute("flag", true)
+			return f
+		}, "unsupported value type bool"},
+	} {
+		var buf bytes.Buffer
+		if err := tc.file().Write(&buf); err == nil || !strings.Contains(err.Error(), tc.msg) {
+			t.Errorf("%s: expected an error containing %q, got %v", tc.name, tc.msg, err)
+		}
+	}
+
+	f := &File{}
+	f.AddDimension("x", 2)
+	if err := f.AddDimension("x", 3); err == nil {
+		t.Error("Expected error for a dimension added with another length")
+	}
+}
+
+func TestRead_Errors(t *testing.T) {
+	f := &File{}
+	f.AddDimension("x", 3)
+	f.AddVariable("x", []string{"x"}, []float64{1, 2, 3})
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data := buf.Bytes()
+
+	if _, err := Read(bytes.NewReader([]byte("CDF\x02"))); err == nil {
+		t.Error("Expected error for the 64-bit offset format")
+	}
+	if _, err := Read(bytes.NewReader(data[:40])); err == nil {
+		t.Error("Expected error for a truncated header")
+	}
+	if _, err := Read(bytes.NewReader(data[:len(data)-8])); err == nil {
+		t.Error("Expected error for truncated data")
+	}
+}
@@ -0,0 +1,155 @@
+// Package statsd - Client of the StatsD line protocol
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package statsd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxPacketSize keeps the StatsD packets below the usual Ethernet MTU once the IP and UDP headers are added
+const DefaultMaxPacketSize = 1432
+
+// Client writes metrics in the StatsD line protocol, with DogStatsD style tags.
+// Metrics are buffered and packed into packets of at most maxPacketSize bytes; each Write to w sends one packet.
+// A maxPacketSize of zero or less sends every metric in its own packet.
+type Client struct {
+	w             io.Writer
+	prefix        string
+	tags          []string
+	maxPacketSize int
+
+	buf     bytes.Buffer
+	metrics int
+	packets int
+}
+
+// NewClient creates a client sending the metrics to w, with the prefix prepended to their names and the tags appended to each of them
+func NewClient(w io.Writer, prefix string, tags []string, maxPacketSize int) *Client {
+	return &Client{
+		w:             w,
+		prefix:        strings.TrimSuffix(prefix, "."),
+		tags:          tags,
+		maxPacketSize: maxPacketSize,
+	}
+}
+
+// Gauge sends a gauge, with the tags of the client followed by the given tags
+func (c *Client) Gauge(name string, value float64, tags ...string) error {
+	return c.send(name, value, "g", tags)
+}
+
+// Count sends a counter increment
+func (c *Client) Count(name string, value float64, tags ...string) error {
+	return c.send(name, value, "c", tags)
+}
+
+// Timing sends a duration in milliseconds
+func (c *Client) Timing(name string, milliseconds float64, tags ...string) error {
+	return c.send(name, milliseconds, "ms", tags)
+}
+
+func (c *Client) send(name string, value float64, kind string, tags []string) error {
+	var line strings.Builder
+	if c.prefix != "" {
+		line.WriteString(SanitizeMetricName(c.prefix))
+		line.WriteByte('.')
+	}
+	line.WriteString(SanitizeMetricName(name))
+	line.WriteByte(':')
+	line.WriteString(strconv.FormatFloat(value, 'f', -1, 64))
+	line.WriteByte('|')
+	line.WriteString(kind)
+	if len(c.tags)+len(tags) > 0 {
+		line.WriteString("|#")
+		line.WriteString(strings.Join(append(append([]string(nil), c.tags...), tags...), ","))
+	}
+
+	// Metrics are separated by newlines inside a packet
+	if c.buf.Len() > 0 && c.buf.Len()+1+line.Len() > c.maxPacketSize {
+		if err := c.Flush(); err != nil {
+			return err
+		}
+	}
+	if c.buf.Len() > 0 {
+		c.buf.WriteByte('\n')
+	}
+	c.buf.WriteString(line.String())
+	c.metrics++
+
+	if c.maxPacketSize <= 0 {
+		return c.Flush()
+	}
+	return nil
+}
+
+// Flush sends the buffered metrics
+func (c *Client) Flush() error {
+	if c.buf.Len() == 0 {
+		return nil
+	}
+	defer c.buf.Reset()
+	if _, err := c.w.Write(c.buf.Bytes()); err != nil {
+		return fmt.Errorf("sending statsd packet: %w", err)
+	}
+	c.packets++
+	return nil
+}
+
+// Metrics returns the number of metrics sent or buffered
+func (c *Client) Metrics() int {
+	return c.metrics
+}
+
+// Packets returns the number of packets sent
+func (c *Client) Packets() int {
+	return c.packets
+}
+
+// SanitizeMetricName replaces the characters reserved by the StatsD protocol and the whitespace in a metric name
+func SanitizeMetricName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '|', '@', '#', ',', ' ', '\t', '\n', '\r':
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// SanitizeTagValue replaces the characters reserved by the DogStatsD tag syntax and the whitespace in a tag value
+func SanitizeTagValue(value string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '|', '@', '#', ',', ' ', '\t', '\n', '\r':
+			return '_'
+		}
+		return r
+	}, value)
+}
+
+// Tag formats a name:value tag, replacing the reserved characters
+func Tag(name, value string) string {
+	return SanitizeMetricName(name) + ":" + SanitizeTagValue(value)
+}
+
+// ParseTags parses a comma separated list of key:value tags
+func ParseTags(s string) ([]string, error) {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		if strings.ContainsAny(tag, "|#@ ") {
+			return nil, fmt.Errorf("invalid tag %q", tag)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
@@ -0,0 +1,74 @@
+package statsd
+
+import (
+	"strings"
+	"testing"
+)
+
+// packetRecorder records each Write as a packet
+type packetRecorder struct {
+	packets []string
+}
+
+func (p *packetRecorder) Write(b []byte) (int, error) {
+	p.packets = append(p.packets, string(b))
+	return len(b), nil
+}
+
+func TestClient_Batching(t *testing.T) {
+	var rec packetRecorder
+	c := NewClient(&rec, "gf.", []string{"env:ci"}, 100)
+	for i := 0; i < 5; i++ {
+		if err := c.Gauge("latency mean", 1.5, "method:delhommeau"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := c.Flush(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	line := "gf.latency_mean:1.5|g|#env:ci,method:delhommeau"
+	var lines []string
+	for _, packet := range rec.packets {
+		if len(packet) > 100 {
+			t.Errorf("Packet of %d bytes exceeds the maximum size", len(packet))
+		}
+		lines = append(lines, strings.Split(packet, "\n")...)
+	}
+	if len(lines) != 5 || c.Metrics() != 5 {
+		t.Fatalf("Expected 5 metrics, got %v", lines)
+	}
+	for _, l := range lines {
+		if l != line {
+			t.Errorf("Expected %q, got %q", line, l)
+		}
+	}
+	// Two metrics of 47 bytes fit in a packet
+	if len(rec.packets) != 3 {
+		t.Errorf("Expected 3 packets, got %d", len(rec.packets))
+	}
+
+	rec.packets = nil
+	c = NewClient(&rec, "", nil, 0)
+	c.Count("requests", 3)
+	c.Timing("latency", 2)
+	if len(rec.packets) != 2 || rec.packets[0] != "requests:3|c" || rec.packets[1] != "latency:2|ms" {
+		t.Errorf("Expected one packet per metric, got %q", rec.packets)
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	tags, err := ParseTags(" env:ci, ,host:runner-1")
+	if err != nil || len(tags) != 2 || tags[0] != "env:ci" || tags[1] != "host:runner-1" {
+		t.Errorf("Expected [env:ci host:runner-1], got %v (%v)", tags, err)
+	}
+	if _, err := ParseTags("env|ci"); err == nil {
+		t.Error("Expected error for reserved character")
+	}
+}
+
+func TestTag(t *testing.T) {
+	if tag := Tag("mesh size", "16 faces|x"); tag != "mesh_size:16_faces_x" {
+		t.Errorf("Expected mesh_size:16_faces_x, got %q", tag)
+	}
+}
@@ -0,0 +1,247 @@
+// Package server - JSON types of the HTTP API
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package server
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/capytaine/capytaine/go-capytaine/green_functions/green_functions"
+	"gonum.org/v1/gonum/mat"
+)
+
+// MeshData is a panel mesh. Faces have three or four zero-based vertex indices,
+// ordered counterclockwise when seen from the fluid.
+type MeshData struct {
+	Vertices [][3]float64 `json:"vertices"`
+	Faces    [][]int      `json:"faces"`
+}
+
+// Matrix is a real matrix stored in row-major order
+type Matrix struct {
+	Rows int       `json:"rows"`
+	Cols int       `json:"cols"`
+	Data []float64 `json:"data"`
+}
+
+// ComplexMatrix is a complex matrix with its real and imaginary parts stored in row-major order
+type ComplexMatrix struct {
+	Rows int       `json:"rows"`
+	Cols int       `json:"cols"`
+	Real []float64 `json:"real"`
+	Imag []float64 `json:"imag"`
+}
+
+// EvaluateRequest asks for the S and K matrices of a Green function between collocation points and the faces of a mesh
+type EvaluateRequest struct {
+	// GreenFunction is the name of a registered Green function, delhommeau (default) or fingreen3d, configured
+	// by GreenFunctionOptions keyed as in its settings. lwn and hams are recognized but not implemented yet.
+	GreenFunction        string                 `json:"green_function,omitempty"`
+	GreenFunctionOptions map[string]interface{} `json:"green_function_options,omitempty"`
+	Mesh                 MeshData               `json:"mesh"`
+	// Points are the collocation points, the centers of the faces of the mesh when empty
+	Points [][3]float64 `json:"points,omitempty"`
+
+	FreeSurface float64 `json:"free_surface"`
+	// WaterDepth is infinite when omitted
+	WaterDepth *float64 `json:"water_depth,omitempty"`
+	// Wavenumber, or Omega from which the wavenumber is computed with the dispersion relation
+	Wavenumber *float64 `json:"wavenumber,omitempty"`
+	Omega      *float64 `json:"omega,omitempty"`
+
+	// AdjointDoubleLayer and EarlyDotProduct default to true, as in the solver
+	AdjointDoubleLayer *bool `json:"adjoint_double_layer,omitempty"`
+	EarlyDotProduct    *bool `json:"early_dot_product,omitempty"`
+}
+
+// EvaluateResponse holds the single layer matrix S and the double layer matrix K.
+// Without early dot product, K has three columns per face, the components of the gradient.
+type EvaluateResponse struct {
+	S ComplexMatrix `json:"S"`
+	K ComplexMatrix `json:"K"`
+}
+
+// SolveRequest asks for the radiation and diffraction problems of a rigid body at several frequencies
+type SolveRequest struct {
+	GreenFunction        string                 `json:"green_function,omitempty"`
+	GreenFunctionOptions map[string]interface{} `json:"green_function_options,omitempty"`
+	Mesh                 MeshData               `json:"mesh"`
+
+	// Frequencies are angular frequencies (default), periods or wavenumbers depending on FrequencyType
+	Frequencies   []float64 `json:"frequencies"`
+	FrequencyType string    `json:"frequency_type,omitempty"`
+	// WaveDirections are in radians, a single direction 0 when omitted
+	WaveDirections []float64 `json:"wave_directions,omitempty"`
+	WaterDepth     *float64  `json:"water_depth,omitempty"`
+	// Rho defaults to the density of water
+	Rho            float64    `json:"rho,omitempty"`
+	RotationCenter [3]float64 `json:"rotation_center"`
+}
+
+// SolveResponse holds the hydrodynamic coefficients at each frequency, in the order of the request
+type SolveResponse struct {
+	DOFs    []string          `json:"dofs"`
+	Results []FrequencyResult `json:"results"`
+}
+
+// FrequencyResult holds the (nDOFs, nDOFs) added mass and radiation damping matrices
+// and the (nDirections, nDOFs) excitation force matrix
+type FrequencyResult struct {
+	Omega            float64       `json:"omega"`
+	Wavenumber       float64       `json:"wavenumber"`
+	WaterDepth       *float64      `json:"water_depth,omitempty"`
+	WaveDirections   []float64     `json:"wave_directions"`
+	AddedMass        Matrix        `json:"added_mass"`
+	RadiationDamping Matrix        `json:"radiation_damping"`
+	ExcitationForce  ComplexMatrix `json:"excitation_force"`
+}
+
+// SweepEvent is a message of a streaming sweep. A stream starts with a start event, then has a result
+// or an error event for each frequency, in the order of completion, progress events at regular intervals,
+// and ends with a done event. Completed, Failed and Total count the frequencies.
+type SweepEvent struct {
+	// Type is start, result, error, progress or done
+	Type string `json:"type"`
+	// Index is the index in the request of the frequency of a result or error event
+	Index          *int    `json:"index,omitempty"`
+	Completed      int     `json:"completed"`
+	Failed         int     `json:"failed"`
+	Total          int     `json:"total"`
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+	// DOFs are sent in the start event
+	DOFs   []string         `json:"dofs,omitempty"`
+	Result *FrequencyResult `json:"result,omitempty"`
+	// Error is the failure of a frequency, or of the whole sweep in the done event
+	Error string `json:"error,omitempty"`
+}
+
+// JobStatus is the state of an asynchronous job
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+	JobCanceled  JobStatus = "canceled"
+)
+
+// Job is an asynchronous solve. Completed and Total count the frequencies of a running job.
+// The job and its result are deleted at ExpiresAt, some time after it finished.
+type Job struct {
+	ID         string     `json:"id"`
+	Status     JobStatus  `json:"status"`
+	Completed  int        `json:"completed"`
+	Total      int        `json:"total"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// Finished tells whether the job has succeeded, failed or been canceled
+func (j *Job) Finished() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed || j.Status == JobCanceled
+}
+
+// JobsResponse lists the jobs from the oldest to the newest
+type JobsResponse struct {
+	Jobs []Job `json:"jobs"`
+}
+
+// ErrorResponse is the body of the responses with an error status
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// HealthResponse is the body of the health check responses
+type HealthResponse struct {
+	Status        string  `json:"status"`
+	UptimeSeconds float64 `json:"uptime_seconds"`
+}
+
+// NewMatrix copies a gonum matrix
+func NewMatrix(m mat.Matrix) Matrix {
+	rows, cols := m.Dims()
+	data := make([]float64, 0, rows*cols)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			data = append(data, m.At(i, j))
+		}
+	}
+	return Matrix{Rows: rows, Cols: cols, Data: data}
+}
+
+// NewComplexMatrix copies a gonum complex matrix
+func NewComplexMatrix(m mat.CMatrix) ComplexMatrix {
+	rows, cols := m.Dims()
+	c := ComplexMatrix{Rows: rows, Cols: cols, Real: make([]float64, 0, rows*cols), Imag: make([]float64, 0, rows*cols)}
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			z := m.At(i, j)
+			c.Real = append(c.Real, real(z))
+			c.Imag = append(c.Imag, imag(z))
+		}
+	}
+	return c
+}
+
+// Dense converts the matrix to a gonum matrix
+func (m Matrix) Dense() (*mat.Dense, error) {
+	if m.Rows <= 0 || m.Cols <= 0 || len(m.Data) != m.Rows*m.Cols {
+		return nil, fmt.Errorf("matrix of shape (%d, %d) has %d values", m.Rows, m.Cols, len(m.Data))
+	}
+	return mat.NewDense(m.Rows, m.Cols, m.Data), nil
+}
+
+// CDense converts the matrix to a gonum complex matrix
+func (m ComplexMatrix) CDense() (*mat.CDense, error) {
+	if m.Rows <= 0 || m.Cols <= 0 || len(m.Real) != m.Rows*m.Cols || len(m.Imag) != m.Rows*m.Cols {
+		return nil, fmt.Errorf("complex matrix of shape (%d, %d) has %d real and %d imaginary values",
+			m.Rows, m.Cols, len(m.Real), len(m.Imag))
+	}
+	data := make([]complex128, len(m.Real))
+	for i := range data {
+		data[i] = complex(m.Real[i], m.Imag[i])
+	}
+	return mat.NewCDense(m.Rows, m.Cols, data), nil
+}
+
+// Mesh builds the mesh, storing triangles as quadrilaterals whose last vertex repeats the third one
+func (m MeshData) Mesh() (*green_functions.Mesh, error) {
+	faces := make([][4]int, len(m.Faces))
+	for i, face := range m.Faces {
+		switch len(face) {
+		case 3:
+			faces[i] = [4]int{face[0], face[1], face[2], face[2]}
+		case 4:
+			faces[i] = [4]int{face[0], face[1], face[2], face[3]}
+		default:
+			return nil, fmt.Errorf("face %d has %d vertices, expected 3 or 4", i, len(face))
+		}
+	}
+	return green_functions.NewMesh(m.Vertices, faces)
+}
+
+// waterDepth returns the water depth of a request, infinite when omitted
+func waterDepth(depth *float64) (float64, error) {
+	if depth == nil {
+		return math.Inf(1), nil
+	}
+	if !(*depth > 0) {
+		return 0, fmt.Errorf("water depth must be positive, got %g", *depth)
+	}
+	return *depth, nil
+}
+
+// newGreenFunction creates the registered Green function with the given name and options, delhommeau when empty
+func newGreenFunction(name string, options map[string]interface{}, waterDepth float64) (green_functions.AbstractGreenFunction, error) {
+	if name == "" {
+		name = "delhommeau"
+	}
+	return green_functions.NewGreenFunction(name, options, waterDepth)
+}
@@ -0,0 +1,228 @@
+// Package server - Binary encoding of complex matrices
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package server
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// MatrixMediaType is the content type of the binary responses of /v1/evaluate.
+//
+// A response holds the matrix S then the matrix K. Each matrix starts with a 24 bytes header:
+//
+//	offset  size  field
+//	0       4     magic "GBMX"
+//	4       1     version, 1
+//	5       1     name, 'S' or 'K'
+//	6       1     element size in bytes, 8 for complex64 or 16 for complex128
+//	7       1     layout, 0 for one column per face or 1 for the x, y and z gradient columns of each face
+//	8       4     number of rows
+//	12      4     number of columns, three times the number of faces in the gradient layout
+//	16      4     number of rows per block, the number of rows when the matrix is not chunked
+//	20      4     reserved, zero
+//
+// followed by the elements in row-major order, each one as its real then imaginary part.
+// Integers and floating point numbers are little-endian. The rows are sent by blocks, and the
+// response is flushed after each block so that the client can decode a matrix as it arrives.
+const MatrixMediaType = "application/vnd.greenbem.matrix"
+
+const (
+	matrixMagic      = "GBMX"
+	matrixVersion    = 1
+	matrixHeaderSize = 24
+)
+
+// Precision is the size in bytes of the elements of a binary matrix
+type Precision uint8
+
+const (
+	Complex64  Precision = 8
+	Complex128 Precision = 16
+)
+
+// ParsePrecision parses complex64 or complex128
+func ParsePrecision(s string) (Precision, error) {
+	switch s {
+	case "complex64":
+		return Complex64, nil
+	case "complex128":
+		return Complex128, nil
+	}
+	return 0, fmt.Errorf("unknown precision %q, expected complex64 or complex128", s)
+}
+
+func (p Precision) String() string {
+	switch p {
+	case Complex64:
+		return "complex64"
+	case Complex128:
+		return "complex128"
+	}
+	return fmt.Sprintf("Precision(%d)", uint8(p))
+}
+
+// Layout tells how the columns of a binary matrix map to the faces of the mesh
+type Layout uint8
+
+const (
+	// DenseLayout has one column per face, as S and K with early dot product
+	DenseLayout Layout = 0
+	// GradientLayout has three columns per face, the components of the gradient, as K without early dot product
+	GradientLayout Layout = 1
+)
+
+// MatrixHeader describes a binary matrix
+type MatrixHeader struct {
+	Name      byte
+	Precision Precision
+	Layout    Layout
+	Rows      int
+	Cols      int
+	BlockRows int
+}
+
+// WriteMatrix writes a matrix in the binary format. When w has a Flush method, it is called after each block of rows.
+// A blockRows of zero or less writes the matrix as a single block.
+func WriteMatrix(w io.Writer, name byte, m mat.CMatrix, layout Layout, precision Precision, blockRows int) error {
+	rows, cols := m.Dims()
+	if precision != Complex64 && precision != Complex128 {
+		return fmt.Errorf("invalid precision %d", precision)
+	}
+	if rows > math.MaxUint32 || cols > math.MaxUint32 {
+		return fmt.Errorf("matrix of shape (%d, %d) is too large", rows, cols)
+	}
+	if blockRows <= 0 || blockRows > rows {
+		blockRows = rows
+	}
+
+	header := make([]byte, matrixHeaderSize)
+	copy(header, matrixMagic)
+	header[4] = matrixVersion
+	header[5] = name
+	header[6] = byte(precision)
+	header[7] = byte(layout)
+	binary.LittleEndian.PutUint32(header[8:], uint32(rows))
+	binary.LittleEndian.PutUint32(header[12:], uint32(cols))
+	binary.LittleEndian.PutUint32(header[16:], uint32(blockRows))
+
+	flusher, _ := w.(interface{ Flush() error })
+	bw := bufio.NewWriterSize(w, 64<<10)
+	if _, err := bw.Write(header); err != nil {
+		return err
+	}
+
+	row := make([]byte, cols*int(precision))
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			z := m.At(i, j)
+			if precision == Complex64 {
+				binary.LittleEndian.PutUint32(row[8*j:], math.Float32bits(float32(real(z))))
+				binary.LittleEndian.PutUint32(row[8*j+4:], math.Float32bits(float32(imag(z))))
+			} else {
+				binary.LittleEndian.PutUint64(row[16*j:], math.Float64bits(real(z)))
+				binary.LittleEndian.PutUint64(row[16*j+8:], math.Float64bits(imag(z)))
+			}
+		}
+		if _, err := bw.Write(row); err != nil {
+			return err
+		}
+		if (i+1)%blockRows == 0 || i == rows-1 {
+			if err := bw.Flush(); err != nil {
+				return err
+			}
+			if flusher != nil {
+				if err := flusher.Flush(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadMatrixHeader reads and checks the header of a binary matrix
+func ReadMatrixHeader(r io.Reader) (MatrixHeader, error) {
+	header := make([]byte, matrixHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return MatrixHeader{}, err
+	}
+	if string(header[:4]) != matrixMagic {
+		return MatrixHeader{}, errors.New("not a binary matrix")
+	}
+	if header[4] != matrixVersion {
+		return MatrixHeader{}, fmt.Errorf("unsupported binary matrix version %d", header[4])
+	}
+	h := MatrixHeader{
+		Name:      header[5],
+		Precision: Precision(header[6]),
+		Layout:    Layout(header[7]),
+		Rows:      int(binary.LittleEndian.Uint32(header[8:])),
+		Cols:      int(binary.LittleEndian.Uint32(header[12:])),
+		BlockRows: int(binary.LittleEndian.Uint32(header[16:])),
+	}
+	if h.Precision != Complex64 && h.Precision != Complex128 {
+		return MatrixHeader{}, fmt.Errorf("invalid element size %d", header[6])
+	}
+	if h.Layout != DenseLayout && h.Layout != GradientLayout {
+		return MatrixHeader{}, fmt.Errorf("invalid layout %d", header[7])
+	}
+	if h.Rows == 0 || h.Cols == 0 {
+		return MatrixHeader{}, fmt.Errorf("invalid shape (%d, %d)", h.Rows, h.Cols)
+	}
+	if h.Layout == GradientLayout && h.Cols%3 != 0 {
+		return MatrixHeader{}, fmt.Errorf("gradient layout with %d columns, expected a multiple of 3", h.Cols)
+	}
+	return h, nil
+}
+
+// ReadMatrix reads a binary matrix, decoding the elements directly into the returned matrix.
+// Matrices whose memory would exceed maxBytes are rejected before being allocated; zero or a negative
+// maxBytes sets no limit other than the size the platform can address.
+func ReadMatrix(r io.Reader, maxBytes int64) (MatrixHeader, *mat.CDense, error) {
+	h, err := ReadMatrixHeader(r)
+	if err != nil {
+		return MatrixHeader{}, nil, err
+	}
+	const elementBytes = 16 // the elements are decoded as complex128
+	if h.Cols > math.MaxInt/elementBytes/h.Rows {
+		return MatrixHeader{}, nil, fmt.Errorf("matrix %c of shape (%d, %d) is too large", h.Name, h.Rows, h.Cols)
+	}
+	if bytes := int64(h.Rows) * int64(h.Cols) * elementBytes; maxBytes > 0 && bytes > maxBytes {
+		return MatrixHeader{}, nil, fmt.Errorf("matrix %c of shape (%d, %d) needs %d bytes, more than the limit of %d",
+			h.Name, h.Rows, h.Cols, bytes, maxBytes)
+	}
+	m := mat.NewCDense(h.Rows, h.Cols, nil)
+	raw := m.RawCMatrix()
+
+	row := make([]byte, h.Cols*int(h.Precision))
+	for i := 0; i < h.Rows; i++ {
+		if _, err := io.ReadFull(r, row); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return MatrixHeader{}, nil, fmt.Errorf("matrix %c, row %d: %w", h.Name, i, err)
+		}
+		data := raw.Data[i*raw.Stride : i*raw.Stride+h.Cols]
+		for j := range data {
+			if h.Precision == Complex64 {
+				re := math.Float32frombits(binary.LittleEndian.Uint32(row[8*j:]))
+				im := math.Float32frombits(binary.LittleEndian.Uint32(row[8*j+4:]))
+				data[j] = complex(float64(re), float64(im))
+			} else {
+				re := math.Float64frombits(binary.LittleEndian.Uint64(row[16*j:]))
+				im := math.Float64frombits(binary.LittleEndian.Uint64(row[16*j+8:]))
+				data[j] = complex(re, im)
+			}
+		}
+	}
+	return h, m, nil
+}
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"math/cmplx"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// flushCounter counts the flushes of a buffer
+type flushCounter struct {
+	bytes.Buffer
+	flushes int
+}
+
+func (f *flushCounter) Flush() error {
+	f.flushes++
+	return nil
+}
+
+func testMatrix(rows, cols int) *mat.CDense {
+	m := mat.NewCDense(rows, cols, nil)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			m.Set(i, j, complex(float64(i)+1/3.0, -float64(j)*math.Pi))
+		}
+	}
+	return m
+}
+
+func TestWriteMatrix_RoundTrip(t *testing.T) {
+	m := testMatrix(7, 6)
+
+	var buf flushCounter
+	if err := WriteMatrix(&buf, 'K', m, GradientLayout, Complex128, 3); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if buf.Len() != matrixHeaderSize+7*6*16 {
+		t.Errorf("Expected %d bytes, got %d", matrixHeaderSize+7*6*16, buf.Len())
+	}
+	// Blocks of rows 0-2, 3-5 and 6
+	if buf.flushes != 3 {
+		t.Errorf("Expected 3 flushes, got %d", buf.flushes)
+	}
+
+	h, decoded, err := ReadMatrix(&buf, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := MatrixHeader{Name: 'K', Precision: Complex128, Layout: GradientLayout, Rows: 7, Cols: 6, BlockRows: 3}
+	if h != expected {
+		t.Errorf("Expected header %+v, got %+v", expected, h)
+	}
+	if !mat.CEqual(m, decoded) {
+		t.Error("Decoded matrix differs from the encoded one")
+	}
+
+	buf.Reset()
+	if err := WriteMatrix(&buf, 'S', m, DenseLayout, Complex64, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	h, decoded, err = ReadMatrix(&buf, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if h.Precision != Complex64 || h.BlockRows != 7 {
+		t.Errorf("Expected complex64 in a single block, got %+v", h)
+	}
+	for i := 0; i < 7; i++ {
+		for j := 0; j < 6; j++ {
+			if cmplx.Abs(decoded.At(i, j)-m.At(i, j)) > 1e-6*cmplx.Abs(m.At(i, j)) {
+				t.Fatalf("Expected %v at (%d, %d), got %v", m.At(i, j), i, j, decoded.At(i, j))
+			}
+		}
+	}
+}
+
+func TestReadMatrix_Errors(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMatrix(&buf, 'S', testMatrix(2, 3), GradientLayout, Complex128, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data := buf.Bytes()
+
+	if _, _, err := ReadMatrix(bytes.NewReader(data[:len(data)-1]), 0); err == nil || !strings.Contains(err.Error(), "row 1") {
+		t.Errorf("Expected an error for a truncated matrix, got %v", err)
+	}
+	corrupted := append([]byte("JSON"), data[4:]...)
+	if _, _, err := ReadMatrix(bytes.NewReader(corrupted), 0); err == nil {
+		t.Error("Expected error for an invalid magic")
+	}
+	corrupted = append([]byte(nil), data...)
+	corrupted[6] = 4
+	if _, _, err := ReadMatrix(bytes.NewReader(corrupted), 0); err == nil {
+		t.Error("Expected error for an invalid element size")
+	}
+	if _, _, err := ReadMatrix(bytes.NewReader(nil), 0); err != io.EOF {
+		t.Errorf("Expected io.EOF for an empty input, got %v", err)
+	}
+
+	// The shape is checked against the limit before allocating the matrix
+	if _, _, err := ReadMatrix(bytes.NewReader(data), 2*3*16); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, _, err := ReadMatrix(bytes.NewReader(data), 2*3*16-1); err == nil || !strings.Contains(err.Error(), "limit") {
+		t.Errorf("Expected an error for a matrix exceeding the limit, got %v", err)
+	}
+	for _, shape := range [][2]uint32{{1 << 20, 3 << 20}, {math.MaxUint32, math.MaxUint32}} {
+		corrupted = append([]byte(nil), data...)
+		binary.LittleEndian.PutUint32(corrupted[8:], shape[0])
+		binary.LittleEndian.PutUint32(corrupted[12:], shape[1])
+		if _, _, err := ReadMatrix(bytes.NewReader(corrupted), 1<<30); err == nil {
+			t.Errorf("Expected error for a matrix of shape %v", shape)
+		}
+	}
+	if _, _, err := ReadMatrix(bytes.NewReader(corrupted), 0); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("Expected an error for a matrix whose size overflows, got %v", err)
+	}
+}
+
+func TestServer_EvaluateBinary(t *testing.T) {
+	_, data := hemisphereData(t)
+	s := New(DefaultConfig())
+
+	evaluate := func(req EvaluateRequest, accept string) *httptest.ResponseRecorder {
+		body, err := json.Marshal(req)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		r := httptest.NewRequest(http.MethodPost, "/v1/evaluate", bytes.NewReader(body))
+		r.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, r)
+		return rec
+	}
+
+	req := EvaluateRequest{Mesh: data, Wavenumber: float(1), EarlyDotProduct: new(bool)}
+	var expected EvaluateResponse
+	if status := post(t, s, "/v1/evaluate", req, &expected); status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", status)
+	}
+	expectedS, _ := expected.S.CDense()
+	expectedK, _ := expected.K.CDense()
+
+	rec := evaluate(req, "application/json, "+MatrixMediaType+"; block-rows=4")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != MatrixMediaType+"; precision=complex128" {
+		t.Fatalf("Expected a binary response, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	hS, S, err := ReadMatrix(rec.Body, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	hK, K, err := ReadMatrix(rec.Body, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if hS.Name != 'S' || hS.Layout != DenseLayout || hK.Name != 'K' || hK.Layout != GradientLayout || hK.BlockRows != 4 {
+		t.Errorf("Unexpected headers %+v and %+v", hS, hK)
+	}
+	if !mat.CEqual(S, expectedS) || !mat.CEqual(K, expectedK) {
+		t.Error("Binary matrices differ from the JSON ones")
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("Expected the end of the response, got %d more bytes", rec.Body.Len())
+	}
+
+	if rec := evaluate(req, MatrixMediaType+"; precision=complex32"); rec.Code != http.StatusNotAcceptable {
+		t.Errorf("Expected status 406 for an unknown precision, got %d", rec.Code)
+	}
+	// Errors are reported in JSON
+	rec = evaluate(EvaluateRequest{Mesh: data}, MatrixMediaType)
+	if rec.Code != http.StatusBadRequest || !strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json") {
+		t.Errorf("Expected a JSON error with status 400, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
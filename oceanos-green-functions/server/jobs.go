@@ -0,0 +1,518 @@
+// Package server - Asynchronous solve jobs
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cleanupInterval is the period of the deletion of the expired jobs
+const cleanupInterval = time.Minute
+
+var (
+	errJobNotFound  = &httpError{http.StatusNotFound, errors.New("job not found")}
+	errJobQueueFull = &httpError{http.StatusServiceUnavailable, errors.New("job queue is full")}
+)
+
+// jobRecord is the persisted state of a job
+type jobRecord struct {
+	Job
+	Request *SolveRequest `json:"request"`
+}
+
+// jobEntry is a job known to the queue
+type jobEntry struct {
+	record jobRecord
+	// result is kept in memory when the jobs are not persisted
+	result *SolveResponse
+	// cancel stops a running job, cancelRequested tells it apart from a shutdown
+	cancel          context.CancelFunc
+	cancelRequested bool
+}
+
+// jobQueue runs the solve jobs in a bounded number of workers. With a directory, every job is saved
+// in <id>.json and its result in <id>.result.json, so that the jobs survive a restart: the finished ones
+// are served again and the others are run from the start.
+type jobQueue struct {
+	dir       string
+	maxQueued int
+	ttl       time.Duration
+	logger    *log.Logger
+	solve     func(ctx context.Context, req *SolveRequest, progress func(completed int)) (*SolveResponse, error)
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	jobs    map[string]*jobEntry
+	pending []string
+	closed  bool
+
+	ctx  context.Context
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+}
+
+func newJobQueue(config Config, solve func(context.Context, *SolveRequest, func(int)) (*SolveResponse, error)) *jobQueue {
+	q := &jobQueue{
+		dir:       config.JobDir,
+		maxQueued: config.MaxQueuedJobs,
+		ttl:       config.JobTTL,
+		logger:    config.Logger,
+		solve:     solve,
+		jobs:      map[string]*jobEntry{},
+	}
+	q.cond = sync.NewCond(&q.mu)
+	q.ctx, q.stop = context.WithCancel(context.Background())
+	return q
+}
+
+// start restores the persisted jobs and starts the workers and the cleanup of the expired jobs
+func (q *jobQueue) start(workers int) {
+	if q.dir != "" {
+		if err := q.load(); err != nil {
+			q.logf("loading the jobs: %v", err)
+		}
+	}
+	if workers <= 0 {
+		workers = 1
+	}
+	q.wg.Add(workers + 1)
+	for i := 0; i < workers; i++ {
+		go q.work()
+	}
+	go func() {
+		defer q.wg.Done()
+		ticker := time.NewTicker(cleanupInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				q.cleanup(now)
+			case <-q.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// close stops the workers. The running jobs are saved as queued, to be run again after a restart.
+func (q *jobQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.cond.Broadcast()
+	q.mu.Unlock()
+	q.stop()
+	q.wg.Wait()
+}
+
+func (q *jobQueue) logf(format string, args ...interface{}) {
+	if q.logger != nil {
+		q.logger.Printf(format, args...)
+	}
+}
+
+// load reads the persisted jobs, skipping the unreadable ones, and queues the unfinished ones by creation date
+func (q *jobQueue) load() error {
+	if err := os.MkdirAll(q.dir, 0o755); err != nil {
+		return err
+	}
+	paths, err := filepath.Glob(filepath.Join(q.dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	var unfinished []*jobEntry
+	for _, path := range paths {
+		if strings.HasSuffix(path, ".result.json") {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			q.logf("skipping job %s: %v", path, err)
+			continue
+		}
+		e := &jobEntry{}
+		if err := json.Unmarshal(data, &e.record); err != nil || e.record.ID == "" || e.record.Request == nil {
+			q.logf("skipping job %s: invalid job file", path)
+			continue
+		}
+		if !e.record.Finished() {
+			e.record.Status = JobQueued
+			e.record.Completed = 0
+			e.record.StartedAt = nil
+			unfinished = append(unfinished, e)
+		}
+		q.jobs[e.record.ID] = e
+	}
+
+	sort.Slice(unfinished, func(i, j int) bool {
+		return unfinished[i].record.CreatedAt.Before(unfinished[j].record.CreatedAt)
+	})
+	for _, e := range unfinished {
+		q.pending = append(q.pending, e.record.ID)
+	}
+	q.cleanup(time.Now())
+	return nil
+}
+
+// save persists a job, atomically so that a crash never leaves a partial file. The lock must be held.
+func (q *jobQueue) save(e *jobEntry) error {
+	if q.dir == "" {
+		return nil
+	}
+	data, err := json.Marshal(&e.record)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(q.dir, e.record.ID+".json"), data)
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// submit queues a job for a validated request
+func (q *jobQueue) submit(req *SolveRequest) (Job, error) {
+	id, err := newJobID()
+	if err != nil {
+		return Job{}, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return Job{}, &httpError{http.StatusServiceUnavailable, errors.New("server is shutting down")}
+	}
+	if q.maxQueued > 0 && len(q.pending) >= q.maxQueued {
+		return Job{}, errJobQueueFull
+	}
+	e := &jobEntry{record: jobRecord{
+		Job:     Job{ID: id, Status: JobQueued, Total: len(req.Frequencies), CreatedAt: time.Now().UTC()},
+		Request: req,
+	}}
+	if err := q.save(e); err != nil {
+		return Job{}, &httpError{http.StatusInternalServerError, fmt.Errorf("saving the job: %w", err)}
+	}
+	q.jobs[id] = e
+	q.pending = append(q.pending, id)
+	q.cond.Signal()
+	return e.record.Job, nil
+}
+
+// get returns the state of a job
+func (q *jobQueue) get(id string) (Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	e, ok := q.jobs[id]
+	if !ok {
+		return Job{}, errJobNotFound
+	}
+	return e.record.Job, nil
+}
+
+// list returns the jobs from the oldest to the newest
+func (q *jobQueue) list() []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	jobs := make([]Job, 0, len(q.jobs))
+	for _, e := range q.jobs {
+		jobs = append(jobs, e.record.Job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+	return jobs
+}
+
+// result returns the result of a succeeded job
+func (q *jobQueue) result(id string) (*SolveResponse, Job, error) {
+	q.mu.Lock()
+	e, ok := q.jobs[id]
+	if !ok {
+		q.mu.Unlock()
+		return nil, Job{}, errJobNotFound
+	}
+	job, result := e.record.Job, e.result
+	q.mu.Unlock()
+
+	if job.Status != JobSucceeded || result != nil {
+		return result, job, nil
+	}
+	data, err := os.ReadFile(filepath.Join(q.dir, id+".result.json"))
+	if err != nil {
+		return nil, job, &httpError{http.StatusInternalServerError, fmt.Errorf("reading the result: %w", err)}
+	}
+	var resp SolveResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, job, &httpError{http.StatusInternalServerError, fmt.Errorf("reading the result: %w", err)}
+	}
+	return &resp, job, nil
+}
+
+// cancel cancels a queued job at once, or asks a running job to stop
+func (q *jobQueue) cancel(id string) (Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	e, ok := q.jobs[id]
+	if !ok {
+		return Job{}, errJobNotFound
+	}
+	switch e.record.Status {
+	case JobSucceeded, JobFailed, JobCanceled:
+		return e.record.Job, &httpError{http.StatusConflict, fmt.Errorf("job already %s", e.record.Status)}
+	case JobQueued:
+		for i, pending := range q.pending {
+			if pending == id {
+				q.pending = append(q.pending[:i], q.pending[i+1:]...)
+				break
+			}
+		}
+		q.finish(e, JobCanceled, "")
+	case JobRunning:
+		e.cancelRequested = true
+		e.cancel()
+	}
+	return e.record.Job, nil
+}
+
+// finish records the end of a job. The lock must be held.
+func (q *jobQueue) finish(e *jobEntry, status JobStatus, message string) {
+	now := time.Now().UTC()
+	expires := now.Add(q.ttl)
+	e.record.Status = status
+	e.record.Error = message
+	e.record.FinishedAt = &now
+	e.record.ExpiresAt = &expires
+	if err := q.save(e); err != nil {
+		q.logf("saving job %s: %v", e.record.ID, err)
+	}
+}
+
+// cleanup deletes the jobs that expired before now, with their results
+func (q *jobQueue) cleanup(now time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for id, e := range q.jobs {
+		if e.record.ExpiresAt == nil || e.record.ExpiresAt.After(now) {
+			continue
+		}
+		delete(q.jobs, id)
+		if q.dir != "" {
+			for _, path := range []string{filepath.Join(q.dir, id+".json"), filepath.Join(q.dir, id+".result.json")} {
+				if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+					q.logf("deleting job %s: %v", id, err)
+				}
+			}
+		}
+	}
+}
+
+// next waits for a queued job and marks it as running, it returns nil once the queue is closed
+func (q *jobQueue) next() (*jobEntry, context.Context) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.pending) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if q.closed {
+		return nil, nil
+	}
+	e := q.jobs[q.pending[0]]
+	q.pending = q.pending[1:]
+
+	var ctx context.Context
+	ctx, e.cancel = context.WithCancel(q.ctx)
+	now := time.Now().UTC()
+	e.record.Status = JobRunning
+	e.record.StartedAt = &now
+	if err := q.save(e); err != nil {
+		q.logf("saving job %s: %v", e.record.ID, err)
+	}
+	return e, ctx
+}
+
+func (q *jobQueue) work() {
+	defer q.wg.Done()
+	for {
+		e, ctx := q.next()
+		if e == nil {
+			return
+		}
+		q.run(ctx, e)
+	}
+}
+
+// run solves a job and records its outcome
+func (q *jobQueue) run(ctx context.Context, e *jobEntry) {
+	defer e.cancel()
+	result, err := q.solve(ctx, e.record.Request, func(completed int) {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		e.record.Completed = completed
+		if err := q.save(e); err != nil {
+			q.logf("saving job %s: %v", e.record.ID, err)
+		}
+	})
+
+	if err == nil && q.dir != "" {
+		var data []byte
+		if data, err = json.Marshal(result); err == nil {
+			err = writeFileAtomic(filepath.Join(q.dir, e.record.ID+".result.json"), data)
+		}
+		if err != nil {
+			err = fmt.Errorf("saving the result: %w", err)
+		}
+		result = nil
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	switch {
+	case err == nil:
+		e.result = result
+		q.finish(e, JobSucceeded, "")
+	case ctx.Err() != nil && e.cancelRequested:
+		q.finish(e, JobCanceled, "")
+	case ctx.Err() != nil:
+		// The server is shutting down, the job is run again after a restart
+		e.record.Status = JobQueued
+		e.record.Completed = 0
+		e.record.StartedAt = nil
+		if err := q.save(e); err != nil {
+			q.logf("saving job %s: %v", e.record.ID, err)
+		}
+	default:
+		q.finish(e, JobFailed, err.Error())
+	}
+}
+
+// solveJob solves the frequencies of a job, reporting the number of frequencies solved. The first error stops it.
+func (s *Server) solveJob(ctx context.Context, req *SolveRequest, progress func(completed int)) (*SolveResponse, error) {
+	solver, body, sweep, err := s.sweepProblem(req)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := solver.Sweep(ctx, body, sweep)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &SolveResponse{DOFs: dofNames(body), Results: make([]FrequencyResult, len(sweep.Frequencies))}
+	completed := 0
+	for r := range stream {
+		if r.Err != nil {
+			cancel()
+			for range stream {
+				// Wait for the workers to stop
+			}
+			return nil, fmt.Errorf("frequency %d: %w", r.FrequencyIndex, r.Err)
+		}
+		resp.Results[r.FrequencyIndex] = newFrequencyResult(r.Result)
+		completed++
+		progress(completed)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *Server) handleSubmitJob(w http.ResponseWriter, r *http.Request) {
+	var req SolveRequest
+	if err := s.decodeRequest(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	// Invalid requests are rejected at once rather than failing in the queue
+	if _, _, _, err := s.sweepProblem(&req); err != nil {
+		writeError(w, err)
+		return
+	}
+	job, err := s.jobs.submit(&req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Location", "/v1/jobs/"+job.ID)
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+func (s *Server) handleListJobs(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, JobsResponse{Jobs: s.jobs.list()})
+}
+
+func (s *Server) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	job, err := s.jobs.get(r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+func (s *Server) handleJobResult(w http.ResponseWriter, r *http.Request) {
+	result, job, err := s.jobs.result(r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if job.Status != JobSucceeded {
+		message := fmt.Sprintf("job is %s", job.Status)
+		if job.Error != "" {
+			message += ": " + job.Error
+		}
+		writeError(w, &httpError{http.StatusConflict, errors.New(message)})
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleCancelJob(w http.ResponseWriter, r *http.Request) {
+	job, err := s.jobs.cancel(r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	// A running job stops once its current frequencies are solved
+	status := http.StatusOK
+	if job.Status == JobRunning {
+		status = http.StatusAccepted
+	}
+	writeJSON(w, status, job)
+}
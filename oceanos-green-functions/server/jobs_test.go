@@ -0,0 +1,196 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// waitForJob polls a job until it has the given status
+func waitForJob(t *testing.T, q *jobQueue, id string, status JobStatus) Job {
+	t.Helper()
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		job, err := q.get(id)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if job.Status == status {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Job %s is %s, expected %s", id, job.Status, status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// blockingSolve runs until its context is cancelled, after reporting one solved frequency
+func blockingSolve(ctx context.Context, req *SolveRequest, progress func(int)) (*SolveResponse, error) {
+	progress(1)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// instantSolve returns one result per frequency
+func instantSolve(ctx context.Context, req *SolveRequest, progress func(int)) (*SolveResponse, error) {
+	resp := &SolveResponse{DOFs: []string{"Heave"}}
+	for i, f := range req.Frequencies {
+		resp.Results = append(resp.Results, FrequencyResult{Omega: f})
+		progress(i + 1)
+	}
+	return resp, nil
+}
+
+func TestServer_Jobs(t *testing.T) {
+	_, data := hemisphereData(t)
+	s := New(DefaultConfig())
+	defer s.Close()
+
+	body, err := json.Marshal(SolveRequest{Mesh: data, Frequencies: []float64{1, 2}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/jobs", bytes.NewReader(body)))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var job Job
+	if err := json.Unmarshal(rec.Body.Bytes(), &job); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if rec.Header().Get("Location") != "/v1/jobs/"+job.ID {
+		t.Errorf("Expected the location of the job, got %q", rec.Header().Get("Location"))
+	}
+	if job.ID == "" || job.Total != 2 {
+		t.Fatalf("Unexpected job %+v", job)
+	}
+	job = waitForJob(t, s.jobs, job.ID, JobSucceeded)
+	if job.Completed != 2 || job.FinishedAt == nil || job.ExpiresAt == nil {
+		t.Errorf("Expected a finished job with 2 frequencies, got %+v", job)
+	}
+
+	get := func(path string, v interface{}) int {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if v != nil && rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		}
+		return rec.Code
+	}
+	var result, expected SolveResponse
+	if status := get("/v1/jobs/"+job.ID+"/result", &result); status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", status)
+	}
+	post(t, s, "/v1/solve", SolveRequest{Mesh: data, Frequencies: []float64{1, 2}}, &expected)
+	if len(result.Results) != 2 || result.Results[1].Omega != 2 ||
+		result.Results[1].AddedMass.Data[14] != expected.Results[1].AddedMass.Data[14] {
+		t.Errorf("Expected the result of /v1/solve, got %+v", result.Results)
+	}
+
+	var list JobsResponse
+	if status := get("/v1/jobs", &list); status != http.StatusOK || len(list.Jobs) != 1 || list.Jobs[0].ID != job.ID {
+		t.Errorf("Expected the job in the list, got %d %+v", status, list)
+	}
+	if status := get("/v1/jobs/unknown", nil); status != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown job, got %d", status)
+	}
+	if status := post(t, s, "/v1/jobs/"+job.ID+"/cancel", nil, nil); status != http.StatusConflict {
+		t.Errorf("Expected status 409 when cancelling a finished job, got %d", status)
+	}
+	if status := post(t, s, "/v1/jobs", SolveRequest{Mesh: data}, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid request, got %d", status)
+	}
+}
+
+func TestJobQueue_Cancel(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxQueuedJobs = 1
+	q := newJobQueue(config, blockingSolve)
+	q.start(1)
+	defer q.close()
+
+	running, err := q.submit(&SolveRequest{Frequencies: []float64{1, 2}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	waitForJob(t, q, running.ID, JobRunning)
+	queued, err := q.submit(&SolveRequest{Frequencies: []float64{1}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := q.submit(&SolveRequest{Frequencies: []float64{1}}); err != errJobQueueFull {
+		t.Errorf("Expected a full queue, got %v", err)
+	}
+
+	if job, err := q.cancel(queued.ID); err != nil || job.Status != JobCanceled {
+		t.Errorf("Expected the queued job to be cancelled at once, got %+v (%v)", job, err)
+	}
+	if job, err := q.cancel(running.ID); err != nil || job.Status != JobRunning || job.Completed != 1 {
+		t.Errorf("Expected the running job to be stopping, got %+v (%v)", job, err)
+	}
+	waitForJob(t, q, running.ID, JobCanceled)
+
+	if _, _, err := q.result(running.ID); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestJobQueue_Persistence(t *testing.T) {
+	config := DefaultConfig()
+	config.JobDir = filepath.Join(t.TempDir(), "jobs")
+
+	// The first server is stopped while a job runs and another one waits
+	q := newJobQueue(config, blockingSolve)
+	q.start(1)
+	first, err := q.submit(&SolveRequest{Frequencies: []float64{1, 2}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	waitForJob(t, q, first.ID, JobRunning)
+	second, err := q.submit(&SolveRequest{Frequencies: []float64{3}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	q.close()
+
+	// After the restart, both jobs run again
+	q = newJobQueue(config, instantSolve)
+	q.start(1)
+	waitForJob(t, q, first.ID, JobSucceeded)
+	job := waitForJob(t, q, second.ID, JobSucceeded)
+	q.close()
+
+	// Finished jobs and their results survive another restart
+	q = newJobQueue(config, instantSolve)
+	q.start(1)
+	defer q.close()
+	result, restored, err := q.result(second.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if restored.Status != JobSucceeded || !restored.FinishedAt.Equal(*job.FinishedAt) || len(result.Results) != 1 || result.Results[0].Omega != 3 {
+		t.Errorf("Unexpected restored job %+v with result %+v", restored, result)
+	}
+	if jobs := q.list(); len(jobs) != 2 || jobs[0].ID != first.ID {
+		t.Errorf("Expected the two jobs by creation date, got %+v", jobs)
+	}
+
+	// Expired jobs are deleted with their files
+	q.cleanup(restored.ExpiresAt.Add(time.Second))
+	if jobs := q.list(); len(jobs) != 0 {
+		t.Errorf("Expected the expired jobs to be deleted, got %+v", jobs)
+	}
+	if files, _ := os.ReadDir(config.JobDir); len(files) != 0 {
+		t.Errorf("Expected an empty job directory, got %d files", len(files))
+	}
+}
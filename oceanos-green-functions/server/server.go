@@ -0,0 +1,663 @@
+// Package server - HTTP service for Green function evaluations and BEM solves
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"mime"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/capytaine/capytaine/go-capytaine/green_functions/green_functions"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Config holds the limits of the service
+type Config struct {
+	// MaxRequestBytes is the largest accepted request body
+	MaxRequestBytes int64
+	// MaxFaces limits the size of the meshes
+	MaxFaces int
+	// MaxMatrixBytes limits the memory of the S and K matrices of an evaluation, or of a frequency of a solve,
+	// which grows with the number of collocation points times the number of faces; there is no limit when zero
+	MaxMatrixBytes int64
+	// MaxFrequencies limits the number of frequencies of a solve request
+	MaxFrequencies int
+	// MaxComputations limits the number of evaluations, solves and streaming sweeps computed at the same time,
+	// the others waiting for their turn within their timeout; there is no limit when zero
+	MaxComputations int
+	// RequestTimeout bounds the computation time of a request
+	RequestTimeout time.Duration
+	// StreamTimeout bounds the duration of a streaming sweep, which is not limited when zero
+	StreamTimeout time.Duration
+	// ProgressInterval is the period of the progress events of the streaming sweeps,
+	// which also keep the idle connections open; no progress event is sent when zero
+	ProgressInterval time.Duration
+	// Workers is the number of frequencies of a solve request computed in parallel, the number of CPUs when zero
+	Workers int
+	// JobDir is the directory where the jobs and their results are saved, they are only kept in memory when empty
+	JobDir string
+	// JobWorkers is the number of jobs run at the same time, at least one
+	JobWorkers int
+	// MaxQueuedJobs limits the number of jobs waiting for a worker, there is no limit when zero
+	MaxQueuedJobs int
+	// JobTTL is how long a finished job and its result are kept
+	JobTTL time.Duration
+	// Logger receives one line per request, nothing is logged when nil
+	Logger *log.Logger
+}
+
+// DefaultConfig returns limits suited to interactive use with meshes of a few thousand faces
+func DefaultConfig() Config {
+	return Config{
+		MaxRequestBytes:  8 << 20,
+		MaxFaces:         5000,
+		MaxMatrixBytes:   1 << 30,
+		MaxFrequencies:   200,
+		MaxComputations:  runtime.NumCPU(),
+		RequestTimeout:   60 * time.Second,
+		StreamTimeout:    time.Hour,
+		ProgressInterval: 10 * time.Second,
+		JobWorkers:       1,
+		MaxQueuedJobs:    100,
+		JobTTL:           24 * time.Hour,
+	}
+}
+
+// Server serves the JSON API:
+//
+//	GET  /healthz      liveness check
+//	GET  /readyz       readiness check, failing once the server is draining
+//	POST /v1/evaluate  S and K matrices of a Green function, in JSON or in the binary format of MatrixMediaType
+//	POST /v1/solve     added mass, radiation damping and excitation force of a rigid body
+//	POST /v1/solve/stream  the same results pushed as each frequency completes, as NDJSON or Server-Sent Events
+//
+// and the asynchronous solves of large meshes, which are not bound by the request timeout:
+//
+//	POST /v1/jobs              queue a solve request, the job is returned with status 202
+//	GET  /v1/jobs              list the jobs
+//	GET  /v1/jobs/{id}         status and progress of a job
+//	GET  /v1/jobs/{id}/result  result of a succeeded job, as returned by /v1/solve
+//	POST /v1/jobs/{id}/cancel  cancel a queued or running job
+type Server struct {
+	config   Config
+	mux      *http.ServeMux
+	started  time.Time
+	draining atomic.Bool
+	jobs     *jobQueue
+	// slots holds a token per computation in progress, nil without limit
+	slots chan struct{}
+}
+
+// New creates a server with the given limits and starts the workers of its jobs.
+// The persisted jobs of Config.JobDir are restored, the unfinished ones are run again.
+func New(config Config) *Server {
+	s := &Server{config: config, mux: http.NewServeMux(), started: time.Now()}
+	if config.MaxComputations > 0 {
+		s.slots = make(chan struct{}, config.MaxComputations)
+	}
+	s.jobs = newJobQueue(config, s.solveJob)
+	s.mux.HandleFunc("/healthz", s.handleHealth)
+	s.mux.HandleFunc("/readyz", s.handleReady)
+	s.mux.HandleFunc("/v1/evaluate", s.handleEvaluate)
+	s.mux.HandleFunc("/v1/solve", s.handleSolve)
+	s.mux.HandleFunc("/v1/solve/stream", s.handleSolveStream)
+	s.mux.HandleFunc("POST /v1/jobs", s.handleSubmitJob)
+	s.mux.HandleFunc("GET /v1/jobs", s.handleListJobs)
+	s.mux.HandleFunc("GET /v1/jobs/{id}", s.handleGetJob)
+	s.mux.HandleFunc("GET /v1/jobs/{id}/result", s.handleJobResult)
+	s.mux.HandleFunc("POST /v1/jobs/{id}/cancel", s.handleCancelJob)
+	s.jobs.start(config.JobWorkers)
+	return s
+}
+
+// Close stops the workers of the jobs. The running jobs are saved as queued and run again after a restart.
+func (s *Server) Close() {
+	s.jobs.close()
+}
+
+// Drain makes the readiness check fail, so that load balancers stop sending requests before a shutdown
+func (s *Server) Drain() {
+	s.draining.Store(true)
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.config.Logger == nil {
+		s.mux.ServeHTTP(w, r)
+		return
+	}
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	s.mux.ServeHTTP(rec, r)
+	s.config.Logger.Printf("%s %s %d %s", r.Method, r.URL.Path, rec.status, time.Since(start).Round(time.Microsecond))
+}
+
+// statusRecorder records the status of a response for the request log
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap gives access to the flushing of the response to http.ResponseController
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// httpError is an error with the status of the response reporting it
+type httpError struct {
+	status int
+	err    error
+}
+
+func (e *httpError) Error() string {
+	return e.err.Error()
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return &httpError{http.StatusBadRequest, fmt.Errorf(format, args...)}
+}
+
+func tooLarge(format string, args ...interface{}) error {
+	return &httpError{http.StatusRequestEntityTooLarge, fmt.Errorf(format, args...)}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error response. Errors of the computations, such as a singular system
+// or a Green function not supporting the parameters, are reported as unprocessable requests.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusUnprocessableEntity
+	var he *httpError
+	switch {
+	case errors.As(err, &he):
+		status = he.status
+	case errors.Is(err, context.DeadlineExceeded):
+		status = http.StatusServiceUnavailable
+		err = errors.New("request timed out")
+	case errors.Is(err, context.Canceled):
+		// The client went away, the status is only logged
+		status = 499
+	}
+	writeJSON(w, status, ErrorResponse{Error: err.Error()})
+}
+
+// decodeRequest decodes the JSON body of a POST request, rejecting unknown fields and bodies above the size limit
+func (s *Server) decodeRequest(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		return &httpError{http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method)}
+	}
+	if s.config.MaxRequestBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxRequestBytes)
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		var maxBytes *http.MaxBytesError
+		if errors.As(err, &maxBytes) {
+			return tooLarge("request body larger than %d bytes", maxBytes.Limit)
+		}
+		return badRequest("invalid JSON request: %v", err)
+	}
+	if dec.More() {
+		return badRequest("invalid JSON request: unexpected data after the request")
+	}
+	return nil
+}
+
+// acquire waits for a computation slot within ctx and returns the function releasing it
+func (s *Server) acquire(ctx context.Context) (func(), error) {
+	if s.slots == nil {
+		return func() {}, nil
+	}
+	release := func() { <-s.slots }
+	// A free slot is taken even when ctx is done, so that the computation reports its own cancellation
+	select {
+	case s.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+	select {
+	case s.slots <- struct{}{}:
+		return release, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// compute runs f in a computation slot within the request timeout. f stops at its next cancellation check when
+// the request times out or the client goes away, but the Green function evaluation in progress cannot be
+// interrupted: f keeps its slot until it returns, so that abandoned computations still count in the limit.
+func (s *Server) compute(ctx context.Context, f func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	if s.config.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.RequestTimeout)
+		defer cancel()
+	}
+	release, err := s.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	type outcome struct {
+		v   interface{}
+		err error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		defer release()
+		v, err := f(ctx)
+		done <- outcome{v, err}
+	}()
+	select {
+	case o := <-done:
+		return o.v, o.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// mesh checks the size of a mesh and builds it
+func (s *Server) mesh(data MeshData) (*green_functions.Mesh, error) {
+	if len(data.Faces) == 0 {
+		return nil, badRequest("mesh has no face")
+	}
+	if s.config.MaxFaces > 0 && len(data.Faces) > s.config.MaxFaces {
+		return nil, tooLarge("mesh has %d faces, the limit is %d", len(data.Faces), s.config.MaxFaces)
+	}
+	mesh, err := data.Mesh()
+	if err != nil {
+		return nil, badRequest("invalid mesh: %v", err)
+	}
+	return mesh, nil
+}
+
+// checkMatrixBytes checks the memory of the S and K matrices between rows collocation points and cols faces,
+// K holding the gradients of the Green function unless early
+func (s *Server) checkMatrixBytes(rows, cols int, early bool) error {
+	kColumns := int64(3)
+	if early {
+		kColumns = 1
+	}
+	bytes := int64(rows) * int64(cols) * (1 + kColumns) * 16
+	if s.config.MaxMatrixBytes > 0 && bytes > s.config.MaxMatrixBytes {
+		return tooLarge("S and K matrices of %d collocation points and %d faces take %d bytes, the limit is %d",
+			rows, cols, bytes, s.config.MaxMatrixBytes)
+	}
+	return nil
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, HealthResponse{Status: "ok", UptimeSeconds: time.Since(s.started).Seconds()})
+}
+
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	if s.draining.Load() {
+		writeJSON(w, http.StatusServiceUnavailable, HealthResponse{Status: "draining", UptimeSeconds: time.Since(s.started).Seconds()})
+		return
+	}
+	writeJSON(w, http.StatusOK, HealthResponse{Status: "ready", UptimeSeconds: time.Since(s.started).Seconds()})
+}
+
+func (s *Server) handleEvaluate(w http.ResponseWriter, r *http.Request) {
+	format, err := negotiateMatrixFormat(r.Header.Values("Accept"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req EvaluateRequest
+	if err := s.decodeRequest(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	S, K, early, err := s.evaluate(r.Context(), &req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if format == nil {
+		writeJSON(w, http.StatusOK, &EvaluateResponse{S: NewComplexMatrix(S), K: NewComplexMatrix(K)})
+		return
+	}
+
+	layout := DenseLayout
+	if !early {
+		layout = GradientLayout
+	}
+	w.Header().Set("Content-Type", mime.FormatMediaType(MatrixMediaType, map[string]string{"precision": format.precision.String()}))
+	w.WriteHeader(http.StatusOK)
+	// The status is sent, a failure of the client connection can only end the response early
+	out := &responseFlusher{w, http.NewResponseController(w)}
+	if err := WriteMatrix(out, 'S', S, DenseLayout, format.precision, format.blockRows); err != nil {
+		return
+	}
+	WriteMatrix(out, 'K', K, layout, format.precision, format.blockRows)
+}
+
+// matrixFormat holds the parameters of the binary format requested in the Accept header
+type matrixFormat struct {
+	precision Precision
+	blockRows int
+}
+
+// negotiateMatrixFormat returns the binary format when the Accept header asks for it, as in
+// "application/vnd.greenbem.matrix; precision=complex64; block-rows=256", and nil for JSON
+func negotiateMatrixFormat(accept []string) (*matrixFormat, error) {
+	for _, value := range accept {
+		for _, item := range strings.Split(value, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(item))
+			if err != nil || mediaType != MatrixMediaType {
+				continue
+			}
+			format := &matrixFormat{precision: Complex128}
+			if p, ok := params["precision"]; ok {
+				if format.precision, err = ParsePrecision(p); err != nil {
+					return nil, &httpError{http.StatusNotAcceptable, err}
+				}
+			}
+			if b, ok := params["block-rows"]; ok {
+				if format.blockRows, err = strconv.Atoi(b); err != nil || format.blockRows < 0 {
+					return nil, &httpError{http.StatusNotAcceptable, fmt.Errorf("invalid block-rows %q", b)}
+				}
+			}
+			return format, nil
+		}
+	}
+	return nil, nil
+}
+
+// responseFlusher sends the buffered part of a response when a block of a binary matrix is complete
+type responseFlusher struct {
+	http.ResponseWriter
+	rc *http.ResponseController
+}
+
+func (f *responseFlusher) Flush() error {
+	return f.rc.Flush()
+}
+
+// evaluate validates an evaluation request and computes the matrices.
+// It also returns whether K holds the normal derivative rather than the gradient.
+func (s *Server) evaluate(ctx context.Context, req *EvaluateRequest) (S, K *mat.CDense, early bool, err error) {
+	mesh, err := s.mesh(req.Mesh)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	depth, err := waterDepth(req.WaterDepth)
+	if err != nil {
+		return nil, nil, false, badRequest("%v", err)
+	}
+
+	var k float64
+	switch {
+	case req.Wavenumber != nil && req.Omega != nil:
+		return nil, nil, false, badRequest("give either wavenumber or omega")
+	case req.Wavenumber != nil:
+		k = *req.Wavenumber
+	case req.Omega != nil:
+		if !(*req.Omega > 0) {
+			return nil, nil, false, badRequest("omega must be positive, got %g", *req.Omega)
+		}
+		k = real(green_functions.ComputeWaveNumber(*req.Omega, depth))
+	default:
+		return nil, nil, false, badRequest("wavenumber or omega is required")
+	}
+	if k < 0 {
+		return nil, nil, false, badRequest("wavenumber must be non-negative, got %g", k)
+	}
+
+	gf, err := newGreenFunction(req.GreenFunction, req.GreenFunctionOptions, depth)
+	if err != nil {
+		return nil, nil, false, badRequest("%v", err)
+	}
+	adjoint := true
+	early = true
+	if req.AdjointDoubleLayer != nil {
+		adjoint = *req.AdjointDoubleLayer
+	}
+	if req.EarlyDotProduct != nil {
+		early = *req.EarlyDotProduct
+	}
+
+	var points green_functions.MeshLike = mesh
+	if len(req.Points) > 0 {
+		data := make([]float64, 0, 3*len(req.Points))
+		for _, p := range req.Points {
+			data = append(data, p[:]...)
+		}
+		points = pointSet{mat.NewDense(len(req.Points), 3, data)}
+	}
+	if err := s.checkMatrixBytes(points.GetNbFaces(), mesh.GetNbFaces(), early); err != nil {
+		return nil, nil, false, err
+	}
+
+	v, err := s.compute(ctx, func(ctx context.Context) (interface{}, error) {
+		S, K, err := evaluateBlocks(ctx, gf, points, mesh, req.FreeSurface, depth, complex(k, 0), adjoint, early)
+		if err != nil {
+			return nil, err
+		}
+		return [2]*mat.CDense{S, K}, nil
+	})
+	if err != nil {
+		return nil, nil, false, err
+	}
+	matrices := v.([2]*mat.CDense)
+	return matrices[0], matrices[1], early, nil
+}
+
+// evaluationBlockRows is the number of collocation points evaluated between two cancellation checks
+const evaluationBlockRows = 64
+
+// pointSet gives the collocation points of an evaluation request to evaluateBlocks
+type pointSet struct {
+	points *mat.Dense
+}
+
+func (p pointSet) GetNbFaces() int {
+	rows, _ := p.points.Dims()
+	return rows
+}
+
+func (p pointSet) GetFacesCenters() *mat.Dense {
+	return p.points
+}
+
+func (p pointSet) GetFacesNormals() *mat.Dense {
+	return nil
+}
+
+// faceBlock is the collocation points and normals of the faces start to start+n of a mesh
+type faceBlock struct {
+	mesh     green_functions.MeshLike
+	start, n int
+}
+
+func (b faceBlock) GetNbFaces() int {
+	return b.n
+}
+
+func (b faceBlock) GetFacesCenters() *mat.Dense {
+	return b.mesh.GetFacesCenters().Slice(b.start, b.start+b.n, 0, 3).(*mat.Dense)
+}
+
+func (b faceBlock) GetFacesNormals() *mat.Dense {
+	return b.mesh.GetFacesNormals().Slice(b.start, b.start+b.n, 0, 3).(*mat.Dense)
+}
+
+// evaluateBlocks evaluates the Green function between the collocation points and the faces of mesh by blocks of
+// evaluationBlockRows points, returning when ctx is done at the end of the block in progress
+func evaluateBlocks(ctx context.Context, gf green_functions.AbstractGreenFunction, points green_functions.MeshLike,
+	mesh *green_functions.Mesh, freeSurface, depth float64, k complex128, adjoint, early bool) (S, K *mat.CDense, err error) {
+
+	rows := points.GetNbFaces()
+	for start := 0; start < rows; start += evaluationBlockRows {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		n := min(evaluationBlockRows, rows-start)
+		var block interface{} = faceBlock{points, start, n}
+		if p, ok := points.(pointSet); ok {
+			block = p.points.Slice(start, start+n, 0, 3).(*mat.Dense)
+		}
+		blockS, blockK, err := gf.Evaluate(block, mesh, freeSurface, depth, k, adjoint, early)
+		if err != nil {
+			return nil, nil, err
+		}
+		if S == nil {
+			_, sCols := blockS.Dims()
+			_, kCols := blockK.Dims()
+			S, K = mat.NewCDense(rows, sCols, nil), mat.NewCDense(rows, kCols, nil)
+		}
+		copyRows(S, blockS, start)
+		copyRows(K, blockK, start)
+	}
+	return S, K, nil
+}
+
+// copyRows copies the rows of block into m from the row start
+func copyRows(m, block *mat.CDense, start int) {
+	rows, cols := block.Dims()
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			m.Set(start+i, j, block.At(i, j))
+		}
+	}
+}
+
+func (s *Server) handleSolve(w http.ResponseWriter, r *http.Request) {
+	var req SolveRequest
+	if err := s.decodeRequest(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	resp, err := s.solve(r.Context(), &req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// sweepProblem validates a solve request and returns the solver, the body and the sweep solving it
+func (s *Server) sweepProblem(req *SolveRequest) (*green_functions.BEMSolver, *green_functions.FloatingBody, green_functions.Sweep, error) {
+	var sweep green_functions.Sweep
+	mesh, err := s.mesh(req.Mesh)
+	if err != nil {
+		return nil, nil, sweep, err
+	}
+	if len(req.Frequencies) == 0 {
+		return nil, nil, sweep, badRequest("frequencies are required")
+	}
+	if s.config.MaxFrequencies > 0 && len(req.Frequencies) > s.config.MaxFrequencies {
+		return nil, nil, sweep, tooLarge("%d frequencies, the limit is %d", len(req.Frequencies), s.config.MaxFrequencies)
+	}
+	for _, f := range req.Frequencies {
+		if !(f > 0) || math.IsInf(f, 1) {
+			return nil, nil, sweep, badRequest("frequencies must be positive and finite, got %g", f)
+		}
+	}
+	switch green_functions.FrequencyType(req.FrequencyType) {
+	case "", green_functions.AngularFrequency, green_functions.WavePeriod, green_functions.Wavenumber:
+	default:
+		return nil, nil, sweep, badRequest("unknown frequency type %q, expected omega, period or wavenumber", req.FrequencyType)
+	}
+	if err := s.checkMatrixBytes(mesh.GetNbFaces(), mesh.GetNbFaces(), true); err != nil {
+		return nil, nil, sweep, err
+	}
+	depth, err := waterDepth(req.WaterDepth)
+	if err != nil {
+		return nil, nil, sweep, badRequest("%v", err)
+	}
+	gf, err := newGreenFunction(req.GreenFunction, req.GreenFunctionOptions, depth)
+	if err != nil {
+		return nil, nil, sweep, badRequest("%v", err)
+	}
+
+	directions := req.WaveDirections
+	if len(directions) == 0 {
+		directions = []float64{0}
+	}
+	sweep = green_functions.Sweep{
+		Frequencies:    req.Frequencies,
+		FrequencyType:  green_functions.FrequencyType(req.FrequencyType),
+		WaveDirections: directions,
+		WaterDepths:    []float64{depth},
+		Workers:        s.config.Workers,
+	}
+
+	body := green_functions.NewRigidBody("body", mesh, req.RotationCenter)
+	solver := green_functions.NewBEMSolver(gf)
+	if req.Rho != 0 {
+		if !(req.Rho > 0) {
+			return nil, nil, sweep, badRequest("rho must be positive, got %g", req.Rho)
+		}
+		solver.Rho = req.Rho
+	}
+	return solver, body, sweep, nil
+}
+
+// solve validates a solve request and solves the problems of all the frequencies
+func (s *Server) solve(ctx context.Context, req *SolveRequest) (*SolveResponse, error) {
+	solver, body, sweep, err := s.sweepProblem(req)
+	if err != nil {
+		return nil, err
+	}
+	v, err := s.compute(ctx, func(ctx context.Context) (interface{}, error) {
+		return solver.SolveSweep(ctx, body, sweep)
+	})
+	if err != nil {
+		return nil, err
+	}
+	results := v.([][]*green_functions.FrequencyResult)[0]
+
+	resp := &SolveResponse{DOFs: dofNames(body), Results: make([]FrequencyResult, len(results))}
+	for i, r := range results {
+		resp.Results[i] = newFrequencyResult(r)
+	}
+	return resp, nil
+}
+
+func dofNames(body *green_functions.FloatingBody) []string {
+	names := make([]string, len(body.DOFs))
+	for i, dof := range body.DOFs {
+		names[i] = dof.Name
+	}
+	return names
+}
+
+func newFrequencyResult(r *green_functions.FrequencyResult) FrequencyResult {
+	result := FrequencyResult{
+		Omega:            r.Omega,
+		Wavenumber:       r.Wavenumber,
+		WaveDirections:   r.WaveDirections,
+		AddedMass:        NewMatrix(r.AddedMass),
+		RadiationDamping: NewMatrix(r.RadiationDamping),
+		ExcitationForce:  NewComplexMatrix(r.ExcitationForce),
+	}
+	if !math.IsInf(r.WaterDepth, 1) {
+		depth := r.WaterDepth
+		result.WaterDepth = &depth
+	}
+	return result
+}
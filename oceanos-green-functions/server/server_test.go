@@ -0,0 +1,343 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/cmplx"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/capytaine/capytaine/go-capytaine/green_functions/green_functions"
+	vegeta "github.com/tsenart/vegeta/v12/lib"
+	"gonum.org/v1/gonum/mat"
+)
+
+// hemisphereData returns a coarse hemisphere mesh and its JSON representation
+func hemisphereData(t *testing.T) (*green_functions.Mesh, MeshData) {
+	t.Helper()
+	mesh, err := green_functions.NewHemisphereMesh(1, 2, 6)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data := MeshData{Vertices: mesh.Vertices}
+	for _, f := range mesh.Faces {
+		data.Faces = append(data.Faces, []int{f[0], f[1], f[2], f[3]})
+	}
+	return mesh, data
+}
+
+// post sends a JSON request to the server and decodes the response into v
+func post(t *testing.T, h http.Handler, path string, req interface{}, v interface{}) int {
+	t.Helper()
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body)))
+	if v != nil && rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	return rec.Code
+}
+
+func float(x float64) *float64 {
+	return &x
+}
+
+func TestServer_Health(t *testing.T) {
+	s := New(DefaultConfig())
+	for _, path := range []string{"/healthz", "/readyz"} {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: expected status 200, got %d", path, rec.Code)
+		}
+	}
+
+	s.Drain()
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 when draining, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected the liveness check to pass when draining, got %d", rec.Code)
+	}
+}
+
+func TestServer_Evaluate(t *testing.T) {
+	mesh, data := hemisphereData(t)
+	s := New(DefaultConfig())
+
+	var resp EvaluateResponse
+	if status := post(t, s, "/v1/evaluate", EvaluateRequest{Mesh: data, Wavenumber: float(1)}, &resp); status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", status)
+	}
+	S, err := resp.S.CDense()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	K, err := resp.K.CDense()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedS, expectedK, err := green_functions.NewDefaultDelhommeau().Evaluate(mesh, mesh, 0, math.Inf(1), 1, true, true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	n := mesh.GetNbFaces()
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if cmplx.Abs(S.At(i, j)-expectedS.At(i, j)) > 1e-12 || cmplx.Abs(K.At(i, j)-expectedK.At(i, j)) > 1e-12 {
+				t.Fatalf("Matrices differ from the library at (%d, %d)", i, j)
+			}
+		}
+	}
+
+	// Collocation points and gradients without early dot product
+	req := EvaluateRequest{Mesh: data, Omega: float(1), Points: [][3]float64{{0, 0, -2}, {3, 0, -1}}, EarlyDotProduct: new(bool)}
+	if status := post(t, s, "/v1/evaluate", req, &resp); status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", status)
+	}
+	if resp.S.Rows != 2 || resp.S.Cols != n || resp.K.Rows != 2 || resp.K.Cols != 3*n {
+		t.Errorf("Expected S of shape (2, %d) and K of shape (2, %d), got (%d, %d) and (%d, %d)",
+			n, 3*n, resp.S.Rows, resp.S.Cols, resp.K.Rows, resp.K.Cols)
+	}
+}
+
+func TestServer_Solve(t *testing.T) {
+	mesh, data := hemisphereData(t)
+	s := New(DefaultConfig())
+
+	var resp SolveResponse
+	req := SolveRequest{Mesh: data, Frequencies: []float64{1, 2}, WaveDirections: []float64{0, math.Pi / 2}, WaterDepth: float(5)}
+	if status := post(t, s, "/v1/solve", req, &resp); status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", status)
+	}
+	if len(resp.Results) != 2 || len(resp.DOFs) != 6 {
+		t.Fatalf("Expected 2 results with 6 DOFs, got %d and %v", len(resp.Results), resp.DOFs)
+	}
+
+	expected, err := green_functions.NewBEMSolver(green_functions.NewDefaultDelhommeau()).SolveFrequency(
+		green_functions.NewRigidBody("body", mesh, [3]float64{}), 2, 5, []float64{0, math.Pi / 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	r := resp.Results[1]
+	if r.Omega != 2 || r.WaterDepth == nil || *r.WaterDepth != 5 {
+		t.Errorf("Unexpected frequency and depth %v %v", r.Omega, r.WaterDepth)
+	}
+	addedMass, err := r.AddedMass.Dense()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	force, err := r.ExcitationForce.CDense()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for i := 0; i < 6; i++ {
+		if math.Abs(addedMass.At(i, i)-expected.AddedMass.At(i, i)) > 1e-9*(1+math.Abs(expected.AddedMass.At(i, i))) {
+			t.Errorf("Added mass %d: expected %v, got %v", i, expected.AddedMass.At(i, i), addedMass.At(i, i))
+		}
+		if cmplx.Abs(force.At(1, i)-expected.ExcitationForce.At(1, i)) > 1e-9*(1+cmplx.Abs(expected.ExcitationForce.At(1, i))) {
+			t.Errorf("Excitation force %d: expected %v, got %v", i, expected.ExcitationForce.At(1, i), force.At(1, i))
+		}
+	}
+}
+
+func TestServer_Errors(t *testing.T) {
+	_, data := hemisphereData(t)
+	config := DefaultConfig()
+	config.MaxRequestBytes = 64 << 10
+	config.MaxFaces = 20
+	config.MaxMatrixBytes = 10000
+	s := New(config)
+	points := make([][3]float64, 40)
+	for i := range points {
+		points[i] = [3]float64{float64(i), 0, -1}
+	}
+
+	for _, tc := range []struct {
+		name   string
+		path   string
+		req    interface{}
+		status int
+	}{
+		{"missing wavenumber", "/v1/evaluate", EvaluateRequest{Mesh: data}, http.StatusBadRequest},
+		{"empty mesh", "/v1/evaluate", EvaluateRequest{Wavenumber: float(1)}, http.StatusBadRequest},
+		{"invalid face", "/v1/evaluate", EvaluateRequest{Mesh: MeshData{Vertices: data.Vertices, Faces: [][]int{{0, 1}}}, Wavenumber: float(1)},
+			http.StatusBadRequest},
+		{"unknown green function", "/v1/evaluate", EvaluateRequest{Mesh: data, Wavenumber: float(1), GreenFunction: "rankine"},
+			http.StatusBadRequest},
+		{"lwn not implemented", "/v1/evaluate", EvaluateRequest{Mesh: data, Wavenumber: float(1), GreenFunction: "lwn"},
+			http.StatusBadRequest},
+		{"lwn in finite depth", "/v1/solve", SolveRequest{Mesh: data, Frequencies: []float64{1}, GreenFunction: "lwn", WaterDepth: float(10)},
+			http.StatusBadRequest},
+		{"invalid green function option", "/v1/solve", SolveRequest{Mesh: data, Frequencies: []float64{1},
+			GreenFunctionOptions: map[string]interface{}{"tabulation_nr": "many"}}, http.StatusBadRequest},
+		{"negative depth", "/v1/solve", SolveRequest{Mesh: data, Frequencies: []float64{1}, WaterDepth: float(-1)}, http.StatusBadRequest},
+		{"no frequency", "/v1/solve", SolveRequest{Mesh: data}, http.StatusBadRequest},
+		{"negative frequency", "/v1/solve", SolveRequest{Mesh: data, Frequencies: []float64{-1}}, http.StatusBadRequest},
+		{"unknown frequency type", "/v1/solve", SolveRequest{Mesh: data, Frequencies: []float64{1}, FrequencyType: "hertz"},
+			http.StatusBadRequest},
+		{"unknown field", "/v1/solve", map[string]interface{}{"mesh": data, "frequencies": []float64{1}, "omega": 1}, http.StatusBadRequest},
+		{"too many faces", "/v1/solve", SolveRequest{Mesh: MeshData{Vertices: data.Vertices, Faces: append(data.Faces, data.Faces...)},
+			Frequencies: []float64{1}}, http.StatusRequestEntityTooLarge},
+		{"too large matrices", "/v1/evaluate", EvaluateRequest{Mesh: data, Wavenumber: float(1), Points: points}, http.StatusRequestEntityTooLarge},
+		{"too large body", "/v1/evaluate", map[string]interface{}{"padding": strings.Repeat("x", 100<<10)}, http.StatusRequestEntityTooLarge},
+	} {
+		rec := httptest.NewRecorder()
+		body, _ := json.Marshal(tc.req)
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, tc.path, bytes.NewReader(body)))
+		if rec.Code != tc.status {
+			t.Errorf("%s: expected status %d, got %d: %s", tc.name, tc.status, rec.Code, rec.Body.String())
+		}
+		var e ErrorResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &e); err != nil || e.Error == "" {
+			t.Errorf("%s: expected an error message, got %q", tc.name, rec.Body.String())
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/solve", nil))
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != http.MethodPost {
+		t.Errorf("Expected status 405 with the allowed method, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/evaluate", strings.NewReader("{")))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid JSON, got %d", rec.Code)
+	}
+}
+
+func TestServer_Timeout(t *testing.T) {
+	_, data := hemisphereData(t)
+	config := DefaultConfig()
+	config.RequestTimeout = time.Nanosecond
+	s := New(config)
+
+	frequencies := make([]float64, 50)
+	for i := range frequencies {
+		frequencies[i] = 0.1 * float64(i+1)
+	}
+	if status := post(t, s, "/v1/solve", SolveRequest{Mesh: data, Frequencies: frequencies}, nil); status != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 on timeout, got %d", status)
+	}
+}
+
+func TestServer_MaxComputations(t *testing.T) {
+	_, data := hemisphereData(t)
+	config := DefaultConfig()
+	config.MaxComputations = 1
+	config.RequestTimeout = 50 * time.Millisecond
+	s := New(config)
+
+	// While the only slot is taken, the requests wait for it until their timeout
+	release, err := s.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	req := EvaluateRequest{Mesh: data, Wavenumber: float(1)}
+	if status := post(t, s, "/v1/evaluate", req, nil); status != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 while the computations are busy, got %d", status)
+	}
+	release()
+	if status := post(t, s, "/v1/evaluate", req, nil); status != http.StatusOK {
+		t.Errorf("Expected status 200 once the slot is released, got %d", status)
+	}
+}
+
+func TestEvaluateBlocks(t *testing.T) {
+	mesh, err := green_functions.NewHemisphereMesh(1, 8, 12)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	gf := green_functions.NewDefaultDelhommeau()
+	points := pointSet{mat.DenseCopyOf(mesh.GetFacesCenters())}
+	points.points.Apply(func(i, j int, x float64) float64 { return x - 0.1 }, points.points)
+
+	// The meshes and the point sets of several blocks give the matrices of a single evaluation
+	for _, tc := range []struct {
+		points   green_functions.MeshLike
+		mesh1    interface{}
+		adjoint  bool
+		early    bool
+		expected string
+	}{
+		{mesh, mesh, true, true, "mesh"},
+		{mesh, mesh, false, false, "mesh without early dot product"},
+		{points, points.points, true, false, "points"},
+	} {
+		S, K, err := evaluateBlocks(context.Background(), gf, tc.points, mesh, 0, math.Inf(1), 1, tc.adjoint, tc.early)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expectedS, expectedK, err := gf.Evaluate(tc.mesh1, mesh, 0, math.Inf(1), 1, tc.adjoint, tc.early)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !mat.CEqual(S, expectedS) || !mat.CEqual(K, expectedK) {
+			t.Errorf("%s: the blocks differ from a single evaluation", tc.expected)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := evaluateBlocks(ctx, gf, mesh, mesh, 0, math.Inf(1), 1, true, true); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the evaluation to stop when cancelled, got %v", err)
+	}
+}
+
+// TestLoadtestTargets sends the requests of the vegeta targets of the repository to the server
+func TestLoadtestTargets(t *testing.T) {
+	// The bodies of the targets are relative to the module root
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := os.Chdir(".."); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer os.Chdir(wd)
+
+	src, err := os.ReadFile("loadtest/targets.txt")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	targets, err := vegeta.ReadAllTargets(vegeta.NewHTTPTargeter(bytes.NewReader(src), nil, nil))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(targets) != 3 {
+		t.Fatalf("Expected 3 targets, got %d", len(targets))
+	}
+
+	s := New(DefaultConfig())
+	for _, target := range targets {
+		u, err := url.Parse(target.URL)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		req := httptest.NewRequest(target.Method, u.Path, bytes.NewReader(target.Body))
+		req.Header = target.Header
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s %s: expected status 200, got %d: %s", target.Method, target.URL, rec.Code, rec.Body.String())
+		}
+	}
+}
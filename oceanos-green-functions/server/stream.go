@@ -0,0 +1,166 @@
+// Package server - Streaming of frequency sweeps
+// Copyright (C) 2025 Capytaine Contributors
+// See LICENSE file at <https://github.com/capytaine/capytaine>
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Media types of the streaming sweeps: one JSON event per line, or Server-Sent Events when the client accepts them
+const (
+	NDJSONMediaType = "application/x-ndjson"
+	SSEMediaType    = "text/event-stream"
+)
+
+// eventWriter writes the events of a stream and flushes each of them
+type eventWriter struct {
+	w   io.Writer
+	rc  *http.ResponseController
+	sse bool
+}
+
+func (e *eventWriter) write(event *SweepEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if e.sse {
+		_, err = io.WriteString(e.w, "event: "+event.Type+"\ndata: "+string(data)+"\n\n")
+	} else {
+		_, err = e.w.Write(append(data, '\n'))
+	}
+	if err != nil {
+		return err
+	}
+	return e.rc.Flush()
+}
+
+// acceptsSSE tells whether the Accept header asks for Server-Sent Events
+func acceptsSSE(accept []string) bool {
+	for _, value := range accept {
+		for _, item := range strings.Split(value, ",") {
+			if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(item)); err == nil && mediaType == SSEMediaType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// handleSolveStream solves the frequencies of a solve request in parallel and sends each result as soon as it is computed.
+// A failed frequency is reported and the others are still solved. The sweep stops when the client goes away.
+func (s *Server) handleSolveStream(w http.ResponseWriter, r *http.Request) {
+	var req SolveRequest
+	if err := s.decodeRequest(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	solver, body, sweep, err := s.sweepProblem(&req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if s.config.StreamTimeout > 0 {
+		ctx, cancel = context.WithTimeout(r.Context(), s.config.StreamTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(r.Context())
+	}
+	defer cancel()
+	release, err := s.acquire(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+	results, err := solver.Sweep(ctx, body, sweep)
+	if err != nil {
+		writeError(w, badRequest("%v", err))
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// The write timeout of the server is meant for the other requests, a sweep can take much longer
+	rc.SetWriteDeadline(time.Time{})
+	events := &eventWriter{w: w, rc: rc, sse: acceptsSSE(r.Header.Values("Accept"))}
+	if events.sse {
+		w.Header().Set("Content-Type", SSEMediaType)
+		w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		w.Header().Set("Content-Type", NDJSONMediaType)
+	}
+	w.WriteHeader(http.StatusOK)
+
+	start := time.Now()
+	progress := SweepEvent{Total: len(sweep.Frequencies)}
+	send := func(event SweepEvent) error {
+		event.Completed, event.Failed, event.Total = progress.Completed, progress.Failed, progress.Total
+		event.ElapsedSeconds = time.Since(start).Seconds()
+		return events.write(&event)
+	}
+	// A failed write means that the client went away: the sweep is cancelled and its workers are waited for
+	abort := func() {
+		cancel()
+		for range results {
+		}
+	}
+
+	if err := send(SweepEvent{Type: "start", DOFs: dofNames(body)}); err != nil {
+		abort()
+		return
+	}
+
+	var ticks <-chan time.Time
+	if s.config.ProgressInterval > 0 {
+		ticker := time.NewTicker(s.config.ProgressInterval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	for results != nil {
+		var event SweepEvent
+		select {
+		case res, ok := <-results:
+			if !ok {
+				results = nil
+				continue
+			}
+			index := res.FrequencyIndex
+			if res.Err != nil {
+				progress.Failed++
+				event = SweepEvent{Type: "error", Index: &index, Error: res.Err.Error()}
+			} else {
+				progress.Completed++
+				result := newFrequencyResult(res.Result)
+				event = SweepEvent{Type: "result", Index: &index, Result: &result}
+			}
+		case <-ticks:
+			event = SweepEvent{Type: "progress"}
+		}
+		if err := send(event); err != nil {
+			abort()
+			return
+		}
+	}
+
+	done := SweepEvent{Type: "done"}
+	switch err := ctx.Err(); {
+	case errors.Is(err, context.DeadlineExceeded):
+		done.Error = "sweep timed out"
+	case err != nil:
+		// The client went away
+		return
+	}
+	send(done)
+}
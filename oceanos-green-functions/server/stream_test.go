@@ -0,0 +1,150 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// streamRequest posts a solve request to the streaming endpoint
+func streamRequest(t *testing.T, h http.Handler, req SolveRequest, accept string) *httptest.ResponseRecorder {
+	t.Helper()
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/v1/solve/stream", bytes.NewReader(body))
+	if accept != "" {
+		r.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	return rec
+}
+
+func decodeEvents(t *testing.T, data []byte) []SweepEvent {
+	t.Helper()
+	var events []SweepEvent
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var event SweepEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestServer_SolveStream(t *testing.T) {
+	_, data := hemisphereData(t)
+	s := New(DefaultConfig())
+
+	rec := streamRequest(t, s, SolveRequest{Mesh: data, Frequencies: []float64{0.5, 1, 2}, WaveDirections: []float64{0, 1}}, "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != NDJSONMediaType {
+		t.Fatalf("Expected an NDJSON stream, got %d %q: %s", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+	events := decodeEvents(t, rec.Body.Bytes())
+	if len(events) != 5 || events[0].Type != "start" || events[4].Type != "done" {
+		t.Fatalf("Expected start, 3 results and done, got %+v", events)
+	}
+	if len(events[0].DOFs) != 6 || events[0].Total != 3 {
+		t.Errorf("Expected the DOFs and the number of frequencies in the start event, got %+v", events[0])
+	}
+
+	seen := map[int]bool{}
+	for i, event := range events[1:4] {
+		if event.Type != "result" || event.Index == nil || event.Result == nil || event.Completed != i+1 {
+			t.Fatalf("Unexpected event %+v", event)
+		}
+		expected := []float64{0.5, 1, 2}[*event.Index]
+		if event.Result.Omega != expected || len(event.Result.WaveDirections) != 2 || event.Result.ExcitationForce.Rows != 2 {
+			t.Errorf("Expected the result of omega %g, got %+v", expected, event.Result)
+		}
+		seen[*event.Index] = true
+	}
+	if len(seen) != 3 || events[4].Completed != 3 || events[4].Failed != 0 || events[4].Error != "" {
+		t.Errorf("Expected every frequency once and a clean done event, got %v and %+v", seen, events[4])
+	}
+
+	rec = streamRequest(t, s, SolveRequest{Mesh: data, Frequencies: []float64{1}}, "text/event-stream")
+	if rec.Header().Get("Content-Type") != SSEMediaType {
+		t.Fatalf("Expected Server-Sent Events, got %q", rec.Header().Get("Content-Type"))
+	}
+	body := rec.Body.String()
+	for _, event := range []string{"start", "result", "done"} {
+		if !strings.Contains(body, "event: "+event+"\ndata: {\"type\":\""+event+"\"") {
+			t.Errorf("Expected a %s event in %q", event, body)
+		}
+	}
+
+	// Invalid requests are rejected before the stream starts
+	rec = streamRequest(t, s, SolveRequest{Mesh: data}, "")
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rec.Code)
+	}
+}
+
+func TestServer_SolveStreamTimeout(t *testing.T) {
+	_, data := hemisphereData(t)
+	config := DefaultConfig()
+	config.StreamTimeout = time.Nanosecond
+	rec := streamRequest(t, New(config), SolveRequest{Mesh: data, Frequencies: []float64{1, 2, 3, 4}}, "")
+	events := decodeEvents(t, rec.Body.Bytes())
+	if last := events[len(events)-1]; last.Type != "done" || last.Error != "sweep timed out" {
+		t.Errorf("Expected a done event reporting the timeout, got %+v", last)
+	}
+}
+
+// brokenWriter is a response whose client goes away after the first event
+type brokenWriter struct {
+	header http.Header
+	writes int
+}
+
+func (w *brokenWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *brokenWriter) WriteHeader(int) {}
+
+func (w *brokenWriter) Write(b []byte) (int, error) {
+	w.writes++
+	if w.writes > 1 {
+		return 0, errors.New("connection reset by peer")
+	}
+	return len(b), nil
+}
+
+func (w *brokenWriter) Flush() {}
+
+func TestServer_SolveStreamDisconnect(t *testing.T) {
+	_, data := hemisphereData(t)
+	s := New(DefaultConfig())
+	body, err := json.Marshal(SolveRequest{Mesh: data, Frequencies: []float64{0.5, 1, 1.5, 2, 2.5, 3}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	w := &brokenWriter{header: http.Header{}}
+
+	done := make(chan struct{})
+	go func() {
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/solve/stream", bytes.NewReader(body)))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(30 * time.Second):
+		t.Fatal("Stream did not stop after the client went away")
+	}
+	if w.writes != 2 {
+		t.Errorf("Expected the stream to stop at the first failed write, got %d writes", w.writes)
+	}
+}